@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxAPIErrorBody is how much of an error response is read for its message
+const maxAPIErrorBody = 64 << 10
+
+// requestIDHeaders are the headers APIs commonly identify a request with, in
+// order of preference
+var requestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"Request-Id",
+	"X-Amzn-RequestId",
+	"X-Amz-Request-Id",
+	"X-GitHub-Request-Id",
+}
+
+// APIError is an error response from an HTTP API. Returned from a command, it
+// is printed with the server's explanation, the ID of the request for the
+// API's maintainers, and a hint of what to do about it, and Main exits with
+// the status given by ExitStatus.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+
+	// Message is the server's explanation of the error, if any
+	Message string
+
+	// RequestID identifies the request to the API's maintainers, if the
+	// response included one
+	RequestID string
+
+	// RetryAfter is how long the server asked the client to wait before
+	// trying again, if it did
+	RetryAfter time.Duration
+
+	sys System
+}
+
+// NewAPIError reads the error response resp, closing its body. The message
+// is found in JSON bodies in the fields APIs commonly use, such as `message`,
+// `error` and `detail`, or is the first line of a plain text body.
+func NewAPIError(sys System, resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, sys: sys}
+	for _, h := range requestIDHeaders {
+		if id := resp.Header.Get(h); len(id) > 0 {
+			e.RequestID = id
+			break
+		}
+	}
+	e.RetryAfter = retryAfter(resp.Header.Get("Retry-After"))
+
+	if resp.Body != nil {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxAPIErrorBody))
+		resp.Body.Close()
+		e.Message = apiErrorMessage(resp.Header.Get("Content-Type"), body)
+	}
+	return e
+}
+
+// retryAfter parses a Retry-After header, which is a number of seconds or a
+// date
+func retryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t).Round(time.Second); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// apiErrorMessage finds the server's explanation within an error response
+func apiErrorMessage(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil {
+			return jsonErrorMessage(v)
+		}
+		return ""
+	}
+	if len(mediaType) > 0 && mediaType != "text/plain" {
+		return ""
+	}
+	line, _ := bufio.NewReader(strings.NewReader(string(body))).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// jsonErrorMessage finds the message within a decoded JSON error body
+func jsonErrorMessage(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []interface{}:
+		if len(v) > 0 {
+			return jsonErrorMessage(v[0])
+		}
+	case map[string]interface{}:
+		for _, key := range []string{"message", "error_description", "detail", "error", "errors", "title"} {
+			if m := jsonErrorMessage(v[key]); len(m) > 0 {
+				return m
+			}
+		}
+	}
+	return ""
+}
+
+// ExitStatus returns the status Main exits with for the error:
+// ExitNoPermission when the request wasn't authorized, ExitTemporaryFailure
+// when it may succeed if retried later, ExitUnavailable when the server
+// failed and ExitFailure otherwise
+func (e *APIError) ExitStatus() int {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ExitNoPermission
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests,
+		e.StatusCode == http.StatusServiceUnavailable, e.RetryAfter > 0:
+		return ExitTemporaryFailure
+	case e.StatusCode >= 500:
+		return ExitUnavailable
+	}
+	return ExitFailure
+}
+
+// Hint suggests what the user might do about the error, or returns an empty
+// string
+func (e *APIError) Hint() string {
+	switch e.ExitStatus() {
+	case ExitNoPermission:
+		if e.StatusCode == http.StatusUnauthorized {
+			return e.localize("Your credentials were refused; try logging in again")
+		}
+		return e.localize("You don't have permission to do this")
+	case ExitTemporaryFailure:
+		if e.RetryAfter > 0 {
+			return e.localize("Try again in %s", e.RetryAfter)
+		}
+		return e.localize("Try again later")
+	case ExitUnavailable:
+		return e.localize("The service failed; if this persists, report it with the request ID")
+	}
+	if e.StatusCode == http.StatusNotFound {
+		return e.localize("Check that the name or ID is correct")
+	}
+	return ""
+}
+
+func (e *APIError) Error() string {
+	status := strconv.Itoa(e.StatusCode)
+	if text := http.StatusText(e.StatusCode); len(text) > 0 {
+		status += " " + text
+	}
+	lines := []string{status}
+	if len(e.Message) > 0 {
+		lines[0] = fmt.Sprintf("%s: %s", status, e.Message)
+	}
+	if len(e.RequestID) > 0 {
+		lines = append(lines, e.localize("Request ID: %s", e.RequestID))
+	}
+	if hint := e.Hint(); len(hint) > 0 {
+		lines = append(lines, hint)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// localize translates format for the System the error was read with, if any
+func (e *APIError) localize(format string, a ...interface{}) string {
+	if e.sys == nil {
+		return fmt.Sprintf(format, a...)
+	}
+	return Localize(e.sys, format, a...)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func testResponse(status int, contentType, body string, header ...string) *http.Response {
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+	if len(contentType) > 0 {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		resp.Header.Set(header[i], header[i+1])
+	}
+	return resp
+}
+
+func TestAPIError(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{})
+	for _, c := range []struct {
+		resp     *http.Response
+		expected string
+		status   int
+	}{
+		{
+			testResponse(404, "application/json", `{"message": "widget not found"}`, "X-Request-Id", "req-123"),
+			"404 Not Found: widget not found\nRequest ID: req-123\nCheck that the name or ID is correct",
+			ExitFailure,
+		},
+		{
+			testResponse(401, "application/problem+json", `{"title": "Unauthorized", "detail": "token expired"}`),
+			"401 Unauthorized: token expired\nYour credentials were refused; try logging in again",
+			ExitNoPermission,
+		},
+		{
+			testResponse(422, "application/json; charset=utf-8", `{"errors": [{"message": "name is taken"}]}`),
+			"422 Unprocessable Entity: name is taken",
+			ExitFailure,
+		},
+		{
+			testResponse(429, "text/plain", "slow down\nsecond line", "Retry-After", "30", "X-Correlation-Id", "corr-9"),
+			"429 Too Many Requests: slow down\nRequest ID: corr-9\nTry again in 30s",
+			ExitTemporaryFailure,
+		},
+		{
+			testResponse(502, "text/html", "<html>Bad Gateway</html>"),
+			"502 Bad Gateway\nThe service failed; if this persists, report it with the request ID",
+			ExitUnavailable,
+		},
+	} {
+		e := NewAPIError(system, c.resp)
+		if e.Error() != c.expected {
+			t.Errorf("expected:\n%s\nreceived:\n%s\n", c.expected, e.Error())
+		}
+		if e.ExitStatus() != c.status {
+			t.Errorf("expected status %d for %d, received %d\n", c.status, c.resp.StatusCode, e.ExitStatus())
+		}
+	}
+}
+
+func TestRetryAfterDate(t *testing.T) {
+	at := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	if d := retryAfter(at); d < 100*time.Second || d > 2*time.Minute {
+		t.Errorf("expected about 2m, received %s\n", d)
+	}
+	if d := retryAfter("Mon, 02 Jan 2006 15:04:05 GMT"); d != 0 {
+		t.Errorf("expected no wait for a date in the past, received %s\n", d)
+	}
+}
+
+type testAPIErrorCommand struct {
+	DefaultHelp
+}
+
+func (c *testAPIErrorCommand) Command(ctx context.Context, args []string, s System) error {
+	resp := testResponse(403, "application/json", `{"error": {"message": "not an admin"}}`)
+	return errors.Wrap(NewAPIError(s, resp), "unable to delete widget")
+}
+
+func TestAPIErrorExitStatus(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Arguments:   []string{"widgets"},
+		Environment: map[string]string{"LANG": "de_DE.UTF-8"},
+	})
+	ExpectExitCode(t, Main(context.Background(), &testAPIErrorCommand{}, system), ExitNoPermission)
+	ExpectMatch(t, *output.Stderr, `unable to delete widget: 403 Forbidden: not an admin\nSie haben keine Berechtigung dafür`)
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Assets are files embedded in a program, such as the templates of files it
+// generates, which users may override without rebuilding it by placing a
+// file of the same name in the program's configuration directory. Files may
+// be embedded with go:embed and given as http.FS(files), or read from disk
+// with http.Dir.
+type Assets struct {
+	// App is the name of the application, whose configuration directory is
+	// searched for overrides
+	App string
+
+	// Files are the embedded files
+	Files http.FileSystem
+
+	// Dir is the directory within the application's configuration directory
+	// containing overrides, `templates` if empty
+	Dir string
+}
+
+// OverridePath returns the path of the file which overrides the asset name,
+// whether or not it exists, so that users can be told where to put it
+func (a *Assets) OverridePath(sys System, name string) (string, error) {
+	dir, err := sys.ConfigDir(a.App)
+	if err != nil {
+		return "", err
+	}
+	overrides := a.Dir
+	if len(overrides) == 0 {
+		overrides = "templates"
+	}
+	return filepath.Join(dir, overrides, filepath.FromSlash(cleanAsset(name))), nil
+}
+
+// Open opens the asset name, a slash-separated path, preferring the user's
+// override to the embedded file
+func (a *Assets) Open(sys System, name string) (io.ReadCloser, error) {
+	b, _, err := a.read(sys, name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// ReadFile returns the contents of the asset name, preferring the user's
+// override to the embedded file
+func (a *Assets) ReadFile(sys System, name string) ([]byte, error) {
+	b, _, err := a.read(sys, name)
+	return b, err
+}
+
+// Template parses the asset name as a text/template with the given
+// functions, preferring the user's override to the embedded file. Errors
+// in an override name its path, so that users can find their mistake.
+func (a *Assets) Template(sys System, name string, funcs template.FuncMap) (*template.Template, error) {
+	b, source, err := a.read(sys, name)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(source).Funcs(funcs).Parse(string(b))
+}
+
+// read returns the contents of the asset name and where they were read from:
+// the path of the user's override, read through the System, or else name
+func (a *Assets) read(sys System, name string) ([]byte, string, error) {
+	if override, err := a.OverridePath(sys, name); err == nil {
+		b, err := sys.ReadFile(override)
+		if err == nil {
+			return b, override, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", err
+		}
+	}
+
+	f, err := a.Files.Open("/" + cleanAsset(name))
+	if err != nil {
+		return nil, "", &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+	return b, name, nil
+}
+
+// cleanAsset returns name as a relative slash-separated path which can't
+// refer outside of the directory it's resolved in
+func cleanAsset(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+strings.Replace(name, `\`, "/", -1)), "/")
+}
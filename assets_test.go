@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssets(t *testing.T) {
+	sandbox := Sandbox(t)
+	embedded := filepath.Join(sandbox.Root, "embedded")
+	if err := os.MkdirAll(filepath.Join(embedded, "init"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"init/main.go.tmpl": "package {{.}}\n",
+		"init/README.md":    "# Widgets\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(embedded, filepath.FromSlash(name)), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	override := sandbox.WriteFile(".config/widgets/templates/init/main.go.tmpl", []byte("package {{.}} // customized\n"))
+
+	system, _ := sandbox.System([]string{"widgets"})
+	assets := &Assets{App: "widgets", Files: http.Dir(embedded)}
+
+	if path, err := assets.OverridePath(system, "init/../init/main.go.tmpl"); err != nil || path != override {
+		t.Errorf("expected override path %s, received %s (%v)\n", override, path, err)
+	}
+
+	tmpl, err := assets.Template(system, "init/main.go.tmpl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, "widgets"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "package widgets // customized\n" {
+		t.Errorf("expected the override to be used, received %q\n", b.String())
+	}
+
+	if readme, err := assets.ReadFile(system, "init/README.md"); err != nil || string(readme) != "# Widgets\n" {
+		t.Errorf("expected the embedded file, received %q (%v)\n", readme, err)
+	}
+
+	if _, err := assets.ReadFile(system, "../../etc/passwd"); !os.IsNotExist(err) {
+		t.Errorf("expected a missing asset, received %v\n", err)
+	}
+
+	// overrides are read through the System, relative to its directory
+	sandbox.WriteFile("conf/templates/init/README.md", []byte("# Custom\n"))
+	memory, _ := NewMemorySystem(MemorySystemOptions{Environment: map[string]string{}})
+	base, _ := baseOf(memory)
+	base.Dir = sandbox.Home
+	base.ConfigDirOverride = "conf"
+	if readme, err := assets.ReadFile(memory, "init/README.md"); err != nil || string(readme) != "# Custom\n" {
+		t.Errorf("expected the override within the System's directory, received %q (%v)\n", readme, err)
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// OpenBrowser opens a web page in the user's browser, using the command
+// named by $BROWSER if it is set, and otherwise `open` on macOS, rundll32 on
+// Windows and xdg-open elsewhere. Only http and https URLs are opened, so
+// that a URL received from elsewhere can't run a local program.
+func (s *BaseSystem) OpenBrowser(address string) error {
+	if err := checkBrowserURL(address); err != nil {
+		return err
+	}
+	name, args := browserCommand(s.Getenv("BROWSER"), runtime.GOOS, address)
+	_, err := s.Exec(context.Background(), name, args)
+	return err
+}
+
+// checkBrowserURL returns an error unless address is an http or https URL
+func checkBrowserURL(address string) error {
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("Not a web address: %q", address)
+	}
+	return nil
+}
+
+// browserCommand returns the program and arguments which open address on
+// goos. A $BROWSER may list several commands separated by colons, of which
+// the first is used, and may place the address with %s.
+func browserCommand(browser, goos, address string) (string, []string) {
+	if command := strings.Fields(strings.Split(browser, ":")[0]); len(command) > 0 {
+		args := command[1:]
+		placed := false
+		for i, arg := range args {
+			if strings.Contains(arg, "%s") {
+				args[i] = strings.Replace(arg, "%s", address, -1)
+				placed = true
+			}
+		}
+		if !placed {
+			args = append(args, address)
+		}
+		return command[0], args
+	}
+
+	switch goos {
+	case "darwin":
+		return "open", []string{address}
+	case "windows":
+		return "rundll32", []string{"url.dll,FileProtocolHandler", address}
+	default:
+		return "xdg-open", []string{address}
+	}
+}
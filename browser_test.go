@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	for _, c := range []struct {
+		browser, goos string
+		expected      []string
+	}{
+		{"", "linux", []string{"xdg-open", "https://example.com"}},
+		{"", "darwin", []string{"open", "https://example.com"}},
+		{"", "windows", []string{"rundll32", "url.dll,FileProtocolHandler", "https://example.com"}},
+		{"firefox --new-tab:chromium", "linux", []string{"firefox", "--new-tab", "https://example.com"}},
+		{"w3m -o url=%s", "linux", []string{"w3m", "-o", "url=https://example.com"}},
+	} {
+		name, args := browserCommand(c.browser, c.goos, "https://example.com")
+		if actual := append([]string{name}, args...); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("expected %q for %q on %s, received %q\n", c.expected, c.browser, c.goos, actual)
+		}
+	}
+}
+
+func TestOpenBrowser(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, nil)
+	if err := system.OpenBrowser("https://example.com/device?code=ABCD"); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.OpenBrowser("file:///etc/passwd"); err == nil {
+		t.Error("expected a URL which isn't a web address to be refused")
+	}
+	if opened := system.OpenedURLs(); len(opened) != 1 || opened[0] != "https://example.com/device?code=ABCD" {
+		t.Errorf("expected the URL to be recorded, received %q\n", opened)
+	}
+}
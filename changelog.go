@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const lastVersionFile = "last-version"
+
+// Changelog returns the notes, in markdown, describing what changed in app
+// since the previous version which was run. It may return notes embedded in
+// the binary or fetch them, e.g. from a release page.
+type Changelog func(ctx context.Context, previous string) (string, error)
+
+// changelogOffer is the application whose changelog is offered after an
+// upgrade
+type changelogOffer struct {
+	app       string
+	changelog Changelog
+}
+
+// WithChangelog records the version of app which last ran in its state
+// directory and, the first time a different version runs, offers to show
+// what's new, rendered with RenderMarkdown. The offer is made once per
+// upgrade, and only to a user at a terminal: it is skipped when input isn't
+// interactive, when `--yes` is given, when output is captured by `--output`
+// or `--pipe`, and when CI is set. Requires WithVersion.
+func WithChangelog(app string, changelog Changelog) Option {
+	return func(c *config) {
+		c.changelog = &changelogOffer{app: app, changelog: changelog}
+	}
+}
+
+// lastVersionPath returns the path of the file recording the version of app
+// which last ran
+func lastVersionPath(sys System, app string) (string, error) {
+	dir, err := stateDir(sys, app)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, lastVersionFile), nil
+}
+
+// offer records version as the last which ran and, if it differs
+// from the one which ran before and quiet is false, asks whether to show the
+// changelog. Nothing is offered on the first run, since there is no previous
+// version to compare with.
+func (o *changelogOffer) offer(ctx context.Context, sys System, version string, quiet bool) error {
+	if len(version) == 0 || version == "(devel)" {
+		return nil
+	}
+
+	path, err := lastVersionPath(sys, o.app)
+	if err != nil {
+		return err
+	}
+
+	b, err := sys.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	previous := strings.TrimSpace(string(b))
+	if previous == version {
+		return nil
+	}
+
+	if err := sys.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := sys.WriteFile(path, []byte(version+"\n"), 0600); err != nil {
+		return err
+	}
+
+	if len(previous) == 0 || quiet || sys.AssumesYes() || !sys.Interactive() || len(sys.Getenv("CI")) > 0 {
+		return nil
+	}
+
+	show, err := Confirm(sys, Localize(sys, "%s was updated to %s. Show what's new?", o.app, version))
+	if err != nil || !show {
+		return err
+	}
+
+	notes, err := o.changelog(ctx, previous)
+	if err != nil {
+		return err
+	}
+	_, err = sys.Println(sys.RenderMarkdown(notes))
+	return err
+}
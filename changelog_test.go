@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testChangelogCommand struct{}
+
+func (c *testChangelogCommand) Help() {}
+
+func (c *testChangelogCommand) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestChangelog(t *testing.T) {
+	sandbox := Sandbox(t)
+
+	var previous []string
+	changelog := WithChangelog("testapp", func(ctx context.Context, since string) (string, error) {
+		previous = append(previous, since)
+		return "# What's new\n\nWidgets sync **faster**.", nil
+	})
+	run := func(version string, answer string) {
+		system, output := sandbox.System([]string{"testapp"})
+		if len(answer) > 0 {
+			shown := make(chan error, 1)
+			defer func() {
+				if err := <-shown; err != nil {
+					t.Errorf("expected the rendered changelog: %s\n", err)
+				}
+			}()
+			go func() {
+				system.Console.ExpectString("Show what's new?")
+				system.Console.SendLine(answer)
+				_, err := system.Console.ExpectString("What's new")
+				shown <- err
+			}()
+		}
+		if result := Main(context.Background(), &testChangelogCommand{}, system,
+			WithVersion(VersionInfo{Version: version}), changelog,
+		); result != 0 {
+			t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+		}
+	}
+
+	run("1.0.0", "")
+	run("2.0.0", "y")
+	run("2.0.0", "")
+
+	if len(previous) != 1 || previous[0] != "1.0.0" {
+		t.Errorf("expected the changelog to be shown once since 1.0.0, received %q\n", previous)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(sandbox.StateHome, "testapp", lastVersionFile))
+	if err != nil || strings.TrimSpace(string(b)) != "2.0.0" {
+		t.Errorf("expected 2.0.0 to be recorded, received %q (%v)\n", b, err)
+	}
+}
+
+func TestChangelogAutomation(t *testing.T) {
+	sandbox := Sandbox(t)
+	sandbox.Setenv("CI", "true")
+	sandbox.WriteFile(".local/state/testapp/"+lastVersionFile, []byte("1.0.0\n"))
+
+	system, output := sandbox.System([]string{"testapp"})
+	shown := false
+	result := Main(context.Background(), &testChangelogCommand{}, system,
+		WithVersion(VersionInfo{Version: "2.0.0"}),
+		WithChangelog("testapp", func(ctx context.Context, since string) (string, error) {
+			shown = true
+			return "", nil
+		}),
+	)
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if shown {
+		t.Error("expected the changelog not to be offered in CI")
+	}
+}
@@ -1,12 +1,16 @@
 package cli
 
 import (
+	"bytes"
 	"context"
-	"crypto/sha256"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 )
@@ -22,6 +26,43 @@ func (e *ExitError) Error() string {
 	return e.Message
 }
 
+// Exit statuses returned by Main, by category. Commands may return other
+// statuses using an ExitError.
+const (
+	// ExitOK indicates that the command succeeded
+	ExitOK = 0
+
+	// ExitFailure indicates that the command failed
+	ExitFailure = 1
+
+	// ExitUsage indicates that the command line was invalid, e.g. it named
+	// an unknown command or flag. It matches the status the flag package
+	// exits with.
+	ExitUsage = 2
+
+	// ExitUnavailable indicates that a service the command relies on failed,
+	// as EX_UNAVAILABLE in sysexits.h
+	ExitUnavailable = 69
+
+	// ExitTemporaryFailure indicates that the command may succeed if it is
+	// run again later, as EX_TEMPFAIL in sysexits.h
+	ExitTemporaryFailure = 75
+
+	// ExitNoPermission indicates that the user isn't permitted to do what
+	// the command attempted, as EX_NOPERM in sysexits.h
+	ExitNoPermission = 77
+
+	// ExitCancelled indicates that the command was interrupted by the user,
+	// following the shell convention of 128 plus the signal number
+	ExitCancelled = 130
+)
+
+// unwrapExitError finds the ExitError which caused err, if any
+func unwrapExitError(err error) (*ExitError, bool) {
+	e, ok := errors.Cause(err).(*ExitError)
+	return e, ok
+}
+
 // Command is an interface used to represent a CLI component. Both primary
 // commands and subcommands implement Command
 type Command interface {
@@ -50,6 +91,71 @@ type HasSubcommands interface {
 	Subcommands() CLI
 }
 
+// HasSynopsis is an interface for commands that provide a one-line summary
+type HasSynopsis interface {
+	// Synopsis should return a short, single-line description of the command
+	Synopsis() string
+}
+
+// HasDescription is an interface for commands that provide a long
+// description. If a command has a description but no synopsis, the first
+// line of the description is used as its synopsis.
+type HasDescription interface {
+	// Description should return one or more paragraphs describing the command
+	Description() string
+}
+
+// Example is an annotated invocation of a command
+type Example struct {
+	// Description explains what the example does
+	Description string
+
+	// Command is the command line, including the program's name
+	Command string
+}
+
+// HasExamples is an interface for commands that illustrate their use with
+// example invocations, which are shown in help and documentation
+type HasExamples interface {
+	// Examples should return example invocations in the order they should be
+	// shown
+	Examples() []Example
+}
+
+// CompletesArgs is an interface for commands that complete their positional
+// arguments at runtime, e.g. with the names of remote resources. Generated
+// completion scripts ask the program for these candidates through the hidden
+// `__complete` command.
+type CompletesArgs interface {
+	// Complete should return candidates for the argument being completed,
+	// which begins with toComplete. The command's flags have been parsed from
+	// the command line before it is called. Candidates which don't begin with
+	// toComplete are discarded. A candidate may be followed by a tab and a
+	// description, which shells that support them display alongside it.
+	Complete(ctx context.Context, toComplete string, sys System) []string
+}
+
+// HasAliases is an interface for commands that may be invoked by more than
+// one name
+type HasAliases interface {
+	// Aliases should return alternate names for the command
+	Aliases() []string
+}
+
+// HasVisibility is an interface for commands that may be hidden from help,
+// completion and documentation while remaining runnable
+type HasVisibility interface {
+	// Hidden should return true if the command should not be listed
+	Hidden() bool
+}
+
+// HasDeprecation is an interface for commands that are deprecated
+type HasDeprecation interface {
+	// Deprecated should return a message explaining what to use instead, or an
+	// empty string if the command is not deprecated
+	Deprecated() string
+}
+
 // NoOpCommand is a command that does nothing.
 type NoOpCommand struct{}
 
@@ -62,29 +168,196 @@ func (NoOpCommand) Command(c context.Context, args []string, s *System) error {
 
 // CLI is a map of names to Command implementations. It is used to represent a
 // set of subcommands for a given Command
+//
+// A command registered under the Fallback name is dispatched to when none of
+// the other names match. It receives the unmatched name followed by the
+// remaining command-line arguments, unparsed, which is useful for proxying to
+// plugins or external commands.
 type CLI map[string]Command
 
-// ListSubcommands returns a slice of names of the subcommands within a CLI
+// Fallback is the name under which a catch-all command is registered in a CLI
+const Fallback = "*"
+
+// Lazy is a Command factory which may be used as an entry in a CLI to defer
+// constructing a subcommand until it is dispatched to, or until help or
+// completion needs it
+//
+//	CLI{"deploy": Lazy(func() Command { return newDeployCommand() })}
+type Lazy func() Command
+
+// Help constructs the command and calls its Help method
+func (l Lazy) Help() {
+	unwrap(l).Help()
+}
+
+// WithAliases declares aliases for a lazily constructed command. Aliases are
+// resolved without constructing commands, so the Aliases method of a command
+// behind a Lazy factory isn't consulted when dispatching; they must be
+// declared here instead.
+//
+//	CLI{"deploy": Lazy(newDeployCommand).WithAliases("ship")}
+func (l Lazy) WithAliases(aliases ...string) Command {
+	return &aliasedLazy{l, aliases}
+}
+
+type aliasedLazy struct {
+	Lazy
+
+	aliases []string
+}
+
+func (a *aliasedLazy) Aliases() []string {
+	return a.aliases
+}
+
+// unwrap constructs the command behind any Lazy factories
+func unwrap(cmd Command) Command {
+	for {
+		if a, ok := cmd.(*aliasedLazy); ok {
+			cmd = a.Lazy
+		}
+		l, ok := cmd.(Lazy)
+		if !ok || l == nil {
+			return cmd
+		}
+		cmd = l()
+	}
+}
+
+// Entry describes a command within a CLI
+type Entry struct {
+	// Path is the space-separated list of subcommand names leading to the
+	// command, including its own name
+	Path string
+
+	// Name is the command's own name
+	Name string
+
+	Command     Command
+	Synopsis    string
+	Description string
+	Aliases     []string
+	Deprecated  string
+	Examples    []Example
+
+	// Hidden is true if the command, or any of its ancestors, is hidden
+	Hidden bool
+
+	// Runnable is true if the command implements Action
+	Runnable bool
+}
+
+// Entries returns a description of every command within a CLI, including
+// nested subcommands, sorted by path. Prefix is prepended to each path.
+func (c CLI) Entries(prefix string) []Entry {
+	return c.entries(prefix, false, true)
+}
+
+func (c CLI) entries(prefix string, hidden, recursive bool) []Entry {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []Entry
+	for _, name := range names {
+		if name == Fallback {
+			continue
+		}
+
+		cmd := unwrap(c[name])
+		e := Entry{Path: name, Name: name, Command: cmd, Hidden: hidden}
+		if len(prefix) > 0 {
+			e.Path = fmt.Sprintf("%s %s", prefix, name)
+		}
+
+		e.Synopsis, e.Description = describe(cmd)
+		if b, ok := c[name].(HasAliases); ok {
+			e.Aliases = b.Aliases()
+		} else if b, ok := cmd.(HasAliases); ok {
+			e.Aliases = b.Aliases()
+		}
+		if b, ok := cmd.(HasDeprecation); ok {
+			e.Deprecated = b.Deprecated()
+		}
+		if b, ok := cmd.(HasExamples); ok {
+			e.Examples = b.Examples()
+		}
+		if b, ok := cmd.(HasVisibility); ok && b.Hidden() {
+			e.Hidden = true
+		}
+		_, e.Runnable = cmd.(Action)
+
+		entries = append(entries, e)
+
+		if b, ok := cmd.(HasSubcommands); ok && recursive {
+			entries = append(entries, b.Subcommands().entries(e.Path, e.Hidden, true)...)
+		}
+	}
+	return entries
+}
+
+// describe returns the synopsis and description of a command
+func describe(cmd Command) (synopsis, description string) {
+	_, hasSynopsis := cmd.(HasSynopsis)
+	_, hasDescription := cmd.(HasDescription)
+	if !hasSynopsis && !hasDescription {
+		if synopsis, description, ok := registeredDoc(cmd); ok {
+			return synopsis, description
+		}
+	}
+	if b, ok := cmd.(HasDescription); ok {
+		description = strings.TrimSpace(b.Description())
+	}
+	if b, ok := cmd.(HasSynopsis); ok {
+		synopsis = b.Synopsis()
+	} else if len(description) > 0 {
+		synopsis = strings.SplitN(description, "\n", 2)[0]
+	}
+	return synopsis, description
+}
+
+// ListSubcommands returns a slice of names of the runnable subcommands within
+// a CLI, including nested subcommands
 func (c CLI) ListSubcommands(prefix string) []string {
 	var subcommands []string
-	for name, cmd := range c {
-		if len(prefix) > 0 {
-			name = fmt.Sprintf("%s %s", prefix, name)
+	for _, e := range c.Entries(prefix) {
+		if e.Runnable {
+			subcommands = append(subcommands, e.Path)
 		}
+	}
+	return subcommands
+}
+
+// lookup finds the named command, falling back to searching the aliases of
+// each command. No commands are constructed; the aliases of Lazy commands are
+// those declared with WithAliases.
+func (c CLI) lookup(name string) (Command, bool) {
+	if cmd, ok := c[name]; ok {
+		return cmd, true
+	}
 
-		if _, ok := (interface{})(cmd).(Action); ok {
-			subcommands = append(subcommands, name)
+	names := make([]string, 0, len(c))
+	for n := range c {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		if n == Fallback {
+			continue
 		}
 
-		if sc, ok := cmd.(HasSubcommands); ok {
-			for _, sck := range sc.Subcommands().ListSubcommands(name) {
-				if _, ok := (interface{})(cmd).(Action); ok {
-					subcommands = append(subcommands, sck)
+		if b, ok := c[n].(HasAliases); ok {
+			for _, alias := range b.Aliases() {
+				if alias == name {
+					return c[n], true
 				}
 			}
 		}
 	}
-	return subcommands
+	return nil, false
 }
 
 // Main should be called from a CLI application's `main` function. It should be
@@ -94,71 +367,490 @@ func (c CLI) ListSubcommands(prefix string) []string {
 // subcommand is found, or if flag parsing fails, it will call the Help method
 // from the most-recently visited subcommand. Main returns the Unix status code
 // which should be returned to the underlying OS
-func Main(ctx context.Context, mainCmd Command, sys System) (status int) {
-	var cmd Command = mainCmd
-	var args, flags []string
-	var head, name string
-	var tail []string = sys.Args()
-	for {
-		var subcommands CLI
-		if b, ok := (interface{})(cmd).(HasSubcommands); ok {
-			subcommands = b.Subcommands()
+//
+// A `help [command]` subcommand is provided for any root command with
+// subcommands that doesn't define its own, and `-h`/`--help` is recognized at
+// every level. Both call the Help method of the named command and return 0.
+// Additional built-in behavior may be enabled by passing Options.
+func Main(ctx context.Context, mainCmd Command, sys System, opts ...Option) (status int) {
+	cfg := newConfig(opts)
+
+	var tokens []string
+	if arguments := sys.Args(); len(arguments) > 1 {
+		tokens = arguments[1:]
+	}
+
+	if len(tokens) > 0 && tokens[0] == CompletionCommandName {
+		return complete(ctx, mainCmd, tokens[1:], cfg, sys)
+	}
+
+	if cfg.responseFiles {
+		expanded, err := expandResponseFiles(sys, tokens)
+		if err != nil {
+			sys.Log(err.Error())
+			return ExitFailure
 		}
+		tokens = expanded
+	}
 
-		head = tail[0]
-		if head[0] == '-' {
-			flags = append(flags, head)
-		} else if subcommands == nil {
-			args = append(args, head)
-		} else {
-			if subcommand, ok := subcommands[head]; ok {
-				cmd = subcommand
+	cmd, path, rest, passthrough := resolve(mainCmd, tokens, cfg.subcommands)
+	name := strings.Join(path, " ")
 
-				if len(name) == 0 {
-					name = head
-				} else {
-					name = strings.Join([]string{name, head}, " ")
-				}
-			} else if head != sys.Args()[0] {
-				args = append(args, head)
+	if len(path) == 0 && len(rest) > 0 && rest[0] == "help" {
+		if _, ok := mainCmd.(HasSubcommands); ok {
+			return help(mainCmd, rest[1:], cfg, sys)
+		}
+	}
+
+	f, framework := newFlagSet(cmd, name, cfg)
+	usage := func(w io.Writer) { showHelp(sys, w, cmd, path, f, cfg) }
+	// help is shown once Parse returns, on Stdout if it was asked for and
+	// Stderr if the arguments were wrong
+	f.Usage = func() {}
+	if b, ok := cmd.(interface{ bindHelp(func()) }); ok {
+		b.bindHelp(func() { usage(sys.Stdout()) })
+	}
+	if b, ok := cmd.(interface{ bindTree(Command, *config) }); ok {
+		b.bindTree(mainCmd, cfg)
+	}
+	flags, args := splitFlags(f, rest)
+	if cfg.expandEnv {
+		for i, arg := range args {
+			args[i] = os.Expand(arg, sys.Getenv)
+		}
+	}
+	args = append(args, passthrough...)
+	if err := f.Parse(flags); err != nil {
+		if err == flag.ErrHelp {
+			usage(sys.Stdout())
+			return ExitOK
+		}
+		usage(sys.Stderr())
+		sys.Logf(tr(sys, "Failed to parse command-line arguments:\n%s\n"), err)
+		return ExitUsage
+	}
+
+	var render OutputRenderer
+	format := framework.value("output")
+	_, outputs := cmd.(Outputs)
+	if _, data := dataFormats[format]; len(format) > 0 && format != "text" && !(outputs && data) {
+		var ok bool
+		if render, ok = cfg.outputs[format]; !ok {
+			sys.Logf(tr(sys, "Unknown output format: %s\n"), format)
+			return ExitUsage
+		}
+	}
+
+	var report OutputRenderer
+	var reportPath string
+	if value := framework.value("report"); len(cfg.reports) > 0 && len(value) > 0 {
+		var err error
+		if report, reportPath, err = cfg.parseReport(sys, value); err != nil {
+			sys.Log(err.Error())
+			return ExitUsage
+		}
+	}
+
+	if cfg.version != nil && framework.isSet("version") {
+		if err := printVersion(sys, *cfg.version, false); err != nil {
+			sys.Log(err.Error())
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	if cfg.assumeYes && framework.isSet("yes") {
+		if s, ok := baseOf(sys); ok {
+			s.AssumeYes = true
+		}
+	}
+
+	if cfg.maskPasswords {
+		if s, ok := baseOf(sys); ok {
+			s.MaskPasswords = true
+		}
+	}
+
+	if cfg.curl && framework.isSet("curl") {
+		if s, ok := baseOf(sys); ok {
+			s.EchoCurl = true
+		}
+	}
+
+	if cfg.debugHTTP && framework.isSet("debug-http") {
+		if s, ok := baseOf(sys); ok {
+			s.DebugHTTP = true
+		}
+	}
+
+	if cfg.noColor && framework.isSet("no-color") {
+		if s, ok := baseOf(sys); ok {
+			s.NoColor = true
+		}
+	}
+
+	if len(cfg.dirs) > 0 {
+		if s, ok := baseOf(sys); ok {
+			if err := s.overrideDirs(cfg.dirs, framework); err != nil {
+				sys.Log(err.Error())
+				return ExitFailure
 			}
 		}
+	}
 
-		if len(tail) == 1 {
-			break
+	if cfg.strictInput && framework.isSet("strict-input") {
+		if s, ok := baseOf(sys); ok {
+			s.StrictInput = true
 		}
+	}
 
-		tail = tail[1:]
+	if cfg.conflictFlags && (framework.isSet("force") || framework.isSet("skip")) {
+		if framework.isSet("force") && framework.isSet("skip") {
+			sys.Log(tr(sys, "--force and --skip can't be used together"))
+			return ExitUsage
+		}
+		if s, ok := baseOf(sys); ok {
+			s.Conflicts = ConflictSkip
+			if framework.isSet("force") {
+				s.Conflicts = ConflictOverwrite
+			}
+		}
 	}
 
-	if b, ok := (interface{})(cmd).(HasFlags); ok {
-		f := flag.NewFlagSet(name, flag.ExitOnError)
-		f.Usage = cmd.Help
-		b.Flags(f)
-		if err := f.Parse(flags); err != nil {
-			sys.Logf("Failed to parse command-line arguments:\n%s\n", err)
-			return 1
+	if locale := framework.value("sort-locale"); cfg.sortLocale && len(locale) > 0 {
+		if s, ok := baseOf(sys); ok {
+			s.SortLocale = locale
+		}
+	}
+
+	if cfg.changelog != nil && cfg.version != nil {
+		quiet := render != nil || (cfg.pipe && len(framework.value("pipe")) > 0)
+		if err := cfg.changelog.offer(ctx, sys, cfg.version.Version, quiet); err != nil {
+			sys.Logf(tr(sys, "Unable to show what's new: %s\n"), err)
+		}
+	}
+
+	if pipeline := framework.value("pipe"); cfg.pipe && len(pipeline) > 0 {
+		var wait func() error
+		sys, wait = startPipe(ctx, sys, pipeline)
+		defer func() {
+			if err := wait(); err != nil {
+				sys.Logf(tr(sys, "Pipeline failed: %s\n"), err)
+			}
+		}()
+	} else if cfg.pager && render == nil && !framework.isSet("no-pager") {
+		stop := startPager(ctx, sys)
+		defer func() {
+			if err := stop(); err != nil {
+				sys.Logf(tr(sys, "Pager failed: %s\n"), err)
+			}
+		}()
+	}
+
+	action, ok := cmd.(Action)
+	if o, isOutputs := cmd.(Outputs); isOutputs {
+		action, ok = &outputAction{o, format, framework.value("format")}, true
+	}
+	if !ok {
+		if _, ok := cmd.(interface{ generatedHelp() }); !ok {
+			return ExitOK
+		}
+		if len(args) > 0 {
+			usage(sys.Stderr())
+			sys.Logf(tr(sys, "Unknown command: %s\n"), args[0])
+			return ExitUsage
+		}
+		usage(sys.Stdout())
+		return ExitOK
+	}
+
+	if _, isReport := cmd.(*usageReportCommand); len(cfg.usage) > 0 && !isReport {
+		if err := recordUsage(sys, cfg.usage, name, f, framework); err != nil {
+			sys.Logf(tr(sys, "Unable to record usage: %s\n"), err)
 		}
 	}
 
-	if b, ok := (interface{})(cmd).(Action); ok {
-		ctx = context.WithValue(ctx, "origin", name)
-		ctx = context.WithValue(ctx, "trace-id", traceID())
-		if err := b.Command(ctx, args, sys); err != nil {
+	ctx = context.WithValue(ctx, "origin", name)
+	trace := sys.RandomID()
+	ctx = context.WithValue(ctx, "trace-id", trace)
+
+	if target := framework.value("log-target"); len(cfg.logTarget) > 0 {
+		restore, err := setLogTarget(sys, target, cfg.logTarget, name, trace)
+		if err != nil {
 			sys.Log(err.Error())
-			switch err := errors.Cause(err).(type) {
-			case *ExitError:
-				return err.Status
-			default:
-				return 1
+			return exitStatus(err, false)
+		}
+		defer restore()
+	}
+
+	if _, isLogs := cmd.(*LogsCommand); len(cfg.debugLog) > 0 && !isLogs {
+		if stop, err := startDebugLog(sys, cfg.debugLog, name, trace); err != nil {
+			sys.Logf(tr(sys, "Unable to open debug log: %s\n"), err)
+		} else {
+			defer stop()
+		}
+	}
+
+	if len(cfg.cache) > 0 {
+		if dir, err := sys.CacheDir(cfg.cache); err == nil {
+			ctx = context.WithValue(ctx, "cache", &memoCache{
+				sys:     sys,
+				dir:     filepath.Join(dir, "memo"),
+				noCache: framework.isSet("no-cache"),
+				refresh: framework.isSet("refresh"),
+			})
+		}
+	}
+
+	ctx, e := newExits(ctx)
+	defer e.run()
+	program := name
+	if arguments := sys.Args(); len(arguments) > 0 {
+		program = programName(arguments[0])
+	}
+	ctx = context.WithValue(ctx, "temp-dir", &scratchDir{
+		sys:    sys,
+		prefix: program,
+		keep:   cfg.keepTemp && framework.isSet("keep-temp"),
+	})
+
+	var captured bytes.Buffer
+	var restoreOutput func()
+	if render != nil {
+		restoreOutput = captureOutput(sys, &captured)
+	}
+
+	var finish func(int, error) RunRecord
+	_, isLast := cmd.(*LastCommand)
+	saveRun := len(cfg.runLog) > 0 && !isLast
+	github := cfg.githubActions && inGitHubActions(sys) && !framework.isSet("no-github-actions")
+	if saveRun || render != nil || report != nil || github || cfg.metrics != nil {
+		ctx, finish = startRecording(ctx, sys, name, args)
+	}
+
+	ctx, r := newRollbacks(ctx, sys)
+
+	err := runAction(ctx, action, args, sys)
+	if cfg.reauth != nil && cfg.reauth.isExpired(err) {
+		r.run(ctx, sys)
+		err = cfg.reauth.retry(ctx, sys, err, func() error {
+			return runAction(ctx, action, args, sys)
+		})
+	}
+	interrupted := r.release()
+	if interrupted && errors.Cause(err) == context.Canceled {
+		sys.Log(tr(sys, "Interrupted"))
+	} else if err != nil && len(err.Error()) > 0 {
+		sys.Log(err.Error())
+	}
+	if err != nil || interrupted {
+		r.run(ctx, sys)
+	}
+
+	status = exitStatus(err, interrupted)
+	if finish != nil {
+		record := finish(status, err)
+		if saveRun {
+			if err := saveRunRecord(sys, cfg.runLog, record); err != nil {
+				sys.Logf(tr(sys, "Unable to save run record: %s\n"), err)
+			}
+		}
+		if arguments := sys.Args(); len(arguments) > 0 {
+			record.Command = strings.TrimSpace(programName(arguments[0]) + " " + record.Command)
+		}
+		if render != nil {
+			if restoreOutput != nil {
+				restoreOutput()
+				record.Output = captured.String()
+			}
+			var b bytes.Buffer
+			if err := render(&b, record); err != nil {
+				sys.Logf(tr(sys, "Unable to render output: %s\n"), err)
+			}
+			sys.Print(b.String())
+		}
+		if report != nil {
+			if err := writeReport(sys, report, reportPath, record); err != nil {
+				sys.Logf(tr(sys, "Unable to write report: %s\n"), err)
+			}
+		}
+		if github {
+			if err := reportToGitHubActions(sys, record); err != nil {
+				sys.Logf(tr(sys, "Unable to report to GitHub Actions: %s\n"), err)
+			}
+		}
+		if cfg.metrics != nil {
+			if err := cfg.metrics.update(sys, record); err != nil {
+				sys.Logf(tr(sys, "Unable to write metrics: %s\n"), err)
+			}
+		}
+	}
+	return status
+}
+
+// runAction runs a command, converting a call to System.Exit into the error
+// it returns
+func runAction(ctx context.Context, action Action, args []string, sys System) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(exitRequest)
+			if !ok {
+				panic(r)
+			}
+			err = nil
+			if exit.status != ExitOK {
+				err = &ExitError{Status: exit.status}
+			}
+		}
+	}()
+	return action.Command(ctx, args, sys)
+}
+
+// exitStatus returns the status Main should return for a command which
+// returned err
+func exitStatus(err error, interrupted bool) int {
+	if interrupted {
+		return ExitCancelled
+	}
+	if err != nil {
+		if e, ok := unwrapExitError(err); ok {
+			return e.Status
+		}
+		if e, ok := errors.Cause(err).(*APIError); ok {
+			return e.ExitStatus()
+		}
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// resolve walks the subcommand tree from root following the leading
+// positional tokens. It returns the deepest command found, the names of the
+// subcommands leading to it, and the remaining tokens in their original
+// order. Built-in subcommands are consulted beneath the root when the root
+// doesn't define a subcommand of the same name. If a catch-all command is
+// reached, the unmatched token and every token after it are returned
+// unparsed as passthrough.
+func resolve(root Command, tokens []string, builtins CLI) (cmd Command, path, rest, passthrough []string) {
+	cmd = root
+
+	var subcommands CLI
+	if b, ok := root.(HasSubcommands); ok {
+		subcommands = b.Subcommands()
+	}
+	fetched := true
+	descending := true
+
+	for i, token := range tokens {
+		if token == "--" {
+			rest = append(rest, tokens[i:]...)
+			break
+		}
+
+		if len(token) > 1 && token[0] == '-' {
+			rest = append(rest, token)
+			continue
+		}
+
+		if descending {
+			if !fetched {
+				if b, ok := cmd.(HasSubcommands); ok {
+					subcommands = b.Subcommands()
+				}
+				fetched = true
+			}
+
+			subcommand, ok := subcommands.lookup(token)
+			if !ok && len(path) == 0 && subcommands != nil {
+				subcommand, ok = builtins.lookup(token)
+			}
+			if ok {
+				cmd = unwrap(subcommand)
+				path = append(path, token)
+				subcommands, fetched = nil, false
+				continue
 			}
+			// the built-in help subcommand is handled by Main, and must
+			// not be passed to a fallback
+			builtinHelp := token == "help" && len(path) == 0 && subcommands != nil
+			if fallback, ok := subcommands[Fallback]; ok && !builtinHelp {
+				cmd = unwrap(fallback)
+				passthrough = tokens[i:]
+				break
+			}
+			descending = false
 		}
+
+		rest = append(rest, token)
 	}
 
-	return 0
+	return cmd, path, rest, passthrough
 }
 
-func traceID() string {
-	stamp := []byte(string(time.Now().UnixNano()))
-	return fmt.Sprintf("%x", sha256.Sum256(stamp))[:45]
+// splitFlags separates tokens into flags and positional arguments. A token
+// following a flag defined in f which takes a value, and which wasn't given
+// with `=`, is treated as that flag's value. Everything after a `--` token is
+// treated as a positional argument.
+func splitFlags(f *flag.FlagSet, tokens []string) (flags, args []string) {
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "--" {
+			args = append(args, tokens[i+1:]...)
+			break
+		}
+
+		if len(token) < 2 || token[0] != '-' {
+			args = append(args, token)
+			continue
+		}
+
+		flags = append(flags, token)
+
+		name := strings.TrimLeft(token, "-")
+		if strings.Contains(name, "=") || f == nil {
+			continue
+		}
+		if fl := f.Lookup(name); fl != nil && !isBoolFlag(fl) && i+1 < len(tokens) {
+			i++
+			flags = append(flags, tokens[i])
+		}
+	}
+	return flags, args
+}
+
+// isBoolFlag reports whether the flag can be given without a value
+func isBoolFlag(fl *flag.Flag) bool {
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// newFlagSet creates the FlagSet for cmd, including the framework-level flags,
+// and returns it along with the framework-level flags it defined
+func newFlagSet(cmd Command, name string, cfg *config) (*flag.FlagSet, frameworkFlags) {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	// parse errors are reported by Main through the System
+	f.SetOutput(ioutil.Discard)
+	if b, ok := cmd.(HasFlags); ok {
+		b.Flags(f)
+	}
+	framework := cfg.defineFlags(f)
+	if _, ok := cmd.(Outputs); ok {
+		cfg.defineOutputFlags(f, framework)
+	}
+	return f, framework
+}
+
+// help displays help for the command named by path. If path names a command
+// which doesn't exist, help for the closest ancestor is displayed and a
+// nonzero status is returned.
+func help(root Command, path []string, cfg *config, sys System) int {
+	cmd, found, rest, _ := resolve(root, path, cfg.subcommands)
+	f, _ := newFlagSet(cmd, strings.Join(found, " "), cfg)
+	if _, rest = splitFlags(nil, rest); len(rest) > 0 {
+		showHelp(sys, sys.Stderr(), cmd, found, f, cfg)
+		sys.Logf(tr(sys, "Unknown command: %s\n"), strings.Join(rest, " "))
+		return ExitUsage
+	}
+	showHelp(sys, sys.Stdout(), cmd, found, f, cfg)
+	return ExitOK
 }
@@ -1,8 +1,13 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -144,3 +149,541 @@ func TestSubcommand(t *testing.T) {
 		t.Errorf("subc.Subcommands method ran but should not have\n")
 	}
 }
+
+func TestHelpSubcommand(t *testing.T) {
+	subc := &testSubcommand{&testCommand{}}
+	cmd := &testMainCommand{&testCommand{}, subc}
+	system, _ := NewTestSystem(t, []string{"testmain", "help", "testsub"}, nil)
+	result := Main(context.Background(), cmd, system)
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if cmd.helpDidRun {
+		t.Errorf("cmd.Help ran but should not have\n")
+	}
+
+	if !subc.helpDidRun {
+		t.Errorf("subc.Help did not run\n")
+	}
+
+	if subc.commandDidRun {
+		t.Errorf("subc.Command ran but should not have\n")
+	}
+}
+
+func TestHelpFlag(t *testing.T) {
+	for _, f := range []string{"-h", "--help"} {
+		subc := &testSubcommand{&testCommand{}}
+		cmd := &testMainCommand{&testCommand{}, subc}
+		system, _ := NewTestSystem(t, []string{"testmain", "testsub", f}, nil)
+		result := Main(context.Background(), cmd, system)
+
+		if result != 0 {
+			t.Errorf("command did not return a 0 status for %s\n", f)
+		}
+
+		if !subc.helpDidRun {
+			t.Errorf("subc.Help did not run for %s\n", f)
+		}
+
+		if subc.commandDidRun {
+			t.Errorf("subc.Command ran but should not have for %s\n", f)
+		}
+	}
+}
+
+// runMain runs Main against a TestSystem and returns the exit status along
+// with everything written to the console
+func runMain(t *testing.T, cmd Command, arguments []string, opts ...Option) (int, *TestOutput) {
+	return runMainWithEnv(t, cmd, arguments, nil, opts...)
+}
+
+func runMainWithEnv(t *testing.T, cmd Command, arguments []string, environment map[string]string, opts ...Option) (int, *TestOutput) {
+	system, output := NewTestSystem(t, arguments, environment)
+
+	wait := system.Capture()
+	result := Main(context.Background(), cmd, system, opts...)
+	wait()
+
+	return result, output
+}
+
+func TestUsageExitCode(t *testing.T) {
+	subc := &testSubcommand{&testCommand{}}
+	cmd := &testMainCommand{&testCommand{}, subc}
+	f, _ := newFlagSet(cmd, "testmain", newConfig(nil))
+	if f.Output() != ioutil.Discard {
+		t.Errorf("expected the flag package not to write parse errors itself\n")
+	}
+
+	result, output := runMain(t, cmd, []string{"testmain", "--bogus"})
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.STDERR, `flag provided but not defined: -bogus`)
+	if cmd.commandDidRun {
+		t.Errorf("cmd.Command ran but should not have\n")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	info := VersionInfo{Version: "1.2.3", Commit: "abc123", Date: "2020-10-02"}
+
+	subc := &testSubcommand{&testCommand{}}
+	cmd := &testMainCommand{&testCommand{}, subc}
+	result, output := runMain(t, cmd, []string{"testmain", "--version"}, WithVersion(info))
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	if cmd.commandDidRun {
+		t.Errorf("cmd.Command ran but should not have\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `testmain version 1\.2\.3 \(commit abc123, built 2020-10-02\)`)
+
+	result, output = runMain(t, cmd, []string{"testmain", "version", "--json"}, WithVersion(info))
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `{"version":"1\.2\.3","commit":"abc123","date":"2020-10-02"}`)
+}
+
+type testVersionFlagCommand struct {
+	version bool
+	ran     bool
+}
+
+func (c *testVersionFlagCommand) Help() {}
+
+func (c *testVersionFlagCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.version, "version", false, "the command's own version flag")
+}
+
+func (c *testVersionFlagCommand) Command(ctx context.Context, args []string, s System) error {
+	c.ran = true
+	return nil
+}
+
+func TestVersionFlagDefinedByCommand(t *testing.T) {
+	cmd := &testVersionFlagCommand{}
+	result, output := runMain(t, cmd, []string{"testversion", "--version"},
+		WithVersion(VersionInfo{Version: "1.2.3"}))
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	if !cmd.ran || !cmd.version {
+		t.Errorf("command's own --version flag was not passed to it\n")
+	}
+	if strings.Contains(output.STDOUT.String(), "1.2.3") {
+		t.Errorf("framework version was printed\n")
+	}
+}
+
+type testPrintCommand struct {
+	lines []string
+}
+
+func (c *testPrintCommand) Help() {}
+
+func (c *testPrintCommand) Command(ctx context.Context, args []string, s System) error {
+	for _, line := range c.lines {
+		s.Println(line)
+	}
+	return &ExitError{Status: 3, Message: "printed"}
+}
+
+func TestPipe(t *testing.T) {
+	cmd := &testPrintCommand{[]string{"b 2", "a 1", "c 3"}}
+	result, output := runMain(t, cmd,
+		[]string{"testprint", "--pipe", "sort -k2 -r"}, WithPipe())
+
+	if result != 3 {
+		t.Errorf("expected the command's exit status, received %d\n", result)
+	}
+	ExpectMatch(t, *output.STDOUT, `c 3\r?\nb 2\r?\na 1`)
+}
+
+func TestPipeThroughExec(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"testprint", "--pipe", "tr a-z A-Z"}, nil, 5, 40)
+	system.FakeExec("*", func(ctx context.Context, call ExecCall) int {
+		input, _ := ioutil.ReadAll(call.Stdin)
+		call.Stdout.Write(bytes.ToUpper(input))
+		call.Stderr.Write([]byte("pipeline done\n"))
+		return 0
+	})
+
+	// a System implemented outside the package, which doesn't embed a
+	// BaseSystem
+	sys := struct{ System }{system}
+	cmd := &testPrintCommand{[]string{"b 2", "a 1"}}
+	if result := Main(context.Background(), cmd, sys, WithPipe()); result != 3 {
+		t.Errorf("expected the command's exit status, received %d\n", result)
+	}
+	ExpectMatch(t, *output.STDOUT, `B 2\r?\nA 1`)
+	ExpectMatch(t, *output.STDERR, `pipeline done`)
+
+	if calls := system.ExecCalls(); len(calls) != 1 || calls[0].Args[len(calls[0].Args)-1] != "tr a-z A-Z" {
+		t.Errorf("expected the pipeline to be run in a shell, received %+v\n", calls)
+	}
+}
+
+type testLazyCommand struct {
+	*testCommand
+
+	constructed map[string]int
+}
+
+func (c *testLazyCommand) Help() {}
+
+func (c *testLazyCommand) Subcommands() CLI {
+	subcommands := CLI{}
+	for _, name := range []string{"first", "second"} {
+		name := name
+		subcommands[name] = Lazy(func() Command {
+			c.constructed[name]++
+			return &testSubcommand{c.testCommand}
+		})
+	}
+	subcommands["second"] = subcommands["second"].(Lazy).WithAliases("2nd")
+	return subcommands
+}
+
+type testLazyRoot struct {
+	*testLazyCommand
+
+	args []string
+}
+
+func (c *testLazyRoot) Command(ctx context.Context, args []string, s System) error {
+	c.args = args
+	return nil
+}
+
+func TestLazyUnmatchedArgument(t *testing.T) {
+	cmd := &testLazyRoot{&testLazyCommand{&testCommand{}, map[string]int{}}, nil}
+	if result, _ := runMain(t, cmd, []string{"testlazy", "unmatched"}); result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if len(cmd.args) != 1 || cmd.args[0] != "unmatched" {
+		t.Errorf("root command did not receive the unmatched argument: %v\n", cmd.args)
+	}
+
+	if len(cmd.constructed) != 0 {
+		t.Errorf("subcommands were constructed but should not have been: %v\n", cmd.constructed)
+	}
+}
+
+func TestLazyAlias(t *testing.T) {
+	cmd := &testLazyCommand{&testCommand{}, map[string]int{}}
+	if result, _ := runMain(t, cmd, []string{"testlazy", "2nd"}); result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if !cmd.commandDidRun {
+		t.Errorf("lazy subcommand did not run when invoked by alias\n")
+	}
+
+	if cmd.constructed["first"] != 0 || cmd.constructed["second"] != 1 {
+		t.Errorf("unexpected subcommands constructed: %v\n", cmd.constructed)
+	}
+}
+
+func TestLazySubcommand(t *testing.T) {
+	cmd := &testLazyCommand{&testCommand{}, map[string]int{}}
+	system, _ := NewTestSystem(t, []string{"testlazy", "second"}, nil)
+	result := Main(context.Background(), cmd, system)
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if !cmd.commandDidRun {
+		t.Errorf("lazy subcommand did not run\n")
+	}
+
+	if cmd.constructed["first"] != 0 {
+		t.Errorf("first subcommand was constructed but should not have been\n")
+	}
+
+	if cmd.constructed["second"] != 1 {
+		t.Errorf("second subcommand was constructed %d times\n", cmd.constructed["second"])
+	}
+}
+
+type testMetadataCommand struct {
+	*testSubcommand
+
+	aliases []string
+	hidden  bool
+}
+
+func (c *testMetadataCommand) Synopsis() string   { return "does a thing" }
+func (c *testMetadataCommand) Aliases() []string  { return c.aliases }
+func (c *testMetadataCommand) Hidden() bool       { return c.hidden }
+func (c *testMetadataCommand) Deprecated() string { return "" }
+
+func TestEntries(t *testing.T) {
+	visible := &testMetadataCommand{&testSubcommand{&testCommand{}}, []string{"ls"}, false}
+	hidden := &testMetadataCommand{&testSubcommand{&testCommand{}}, nil, true}
+	parent := &testMainCommand{&testCommand{}, hidden}
+	entries := CLI{"list": visible, "internal": parent}.Entries("app")
+
+	expected := []Entry{
+		{Path: "app internal", Name: "internal", Runnable: true},
+		{Path: "app internal testsub", Name: "testsub", Synopsis: "does a thing",
+			Hidden: true, Runnable: true},
+		{Path: "app list", Name: "list", Synopsis: "does a thing",
+			Aliases: []string{"ls"}, Runnable: true},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, received %d\n", len(expected), len(entries))
+	}
+
+	for i, e := range expected {
+		entry := entries[i]
+		if entry.Path != e.Path || entry.Name != e.Name ||
+			entry.Synopsis != e.Synopsis || entry.Hidden != e.Hidden ||
+			entry.Runnable != e.Runnable || len(entry.Aliases) != len(e.Aliases) {
+			t.Errorf("expected entry %+v, received %+v\n", e, entry)
+		}
+	}
+}
+
+func TestAlias(t *testing.T) {
+	subc := &testMetadataCommand{&testSubcommand{&testCommand{}}, []string{"ts"}, false}
+	cmd := &testMainCommand{&testCommand{}, subc}
+	system, _ := NewTestSystem(t, []string{"testmain", "ts"}, nil)
+	result := Main(context.Background(), cmd, system)
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if cmd.commandDidRun {
+		t.Errorf("cmd.Command ran but should not have\n")
+	}
+
+	if !subc.commandDidRun {
+		t.Errorf("subc.Command did not run when invoked by alias\n")
+	}
+}
+
+type testFallbackCommand struct {
+	*testCommand
+
+	args []string
+}
+
+func (c *testFallbackCommand) Help() {}
+
+func (c *testFallbackCommand) Command(ctx context.Context, args []string, s System) error {
+	c.commandDidRun = true
+	c.args = args
+	return nil
+}
+
+func TestFallback(t *testing.T) {
+	fallback := &testFallbackCommand{&testCommand{}, nil}
+	cmd := &testMainCommand{&testCommand{}, nil}
+	root := &testFallbackRoot{cmd, CLI{"testsub": cmd.subc, Fallback: fallback}}
+	system, _ := NewTestSystem(t,
+		[]string{"testmain", "plugin", "--verbose", "arg"}, nil)
+	result := Main(context.Background(), root, system)
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if cmd.commandDidRun {
+		t.Errorf("cmd.Command ran but should not have\n")
+	}
+
+	if !fallback.commandDidRun {
+		t.Fatalf("fallback.Command did not run\n")
+	}
+
+	expected := []string{"plugin", "--verbose", "arg"}
+	if strings.Join(fallback.args, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected fallback args %v, received %v\n", expected, fallback.args)
+	}
+}
+
+func TestFallbackHelp(t *testing.T) {
+	fallback := &testFallbackCommand{&testCommand{}, nil}
+	subc := &testSubcommand{&testCommand{}}
+	cmd := &testMainCommand{&testCommand{}, subc}
+	root := &testFallbackRoot{cmd, CLI{"testsub": subc, Fallback: fallback}}
+	result, _ := runMain(t, root, []string{"testmain", "help", "testsub"})
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if fallback.commandDidRun {
+		t.Errorf("fallback.Command ran but should not have\n")
+	}
+
+	if !subc.helpDidRun {
+		t.Errorf("subc.Help did not run\n")
+	}
+}
+
+type testFallbackRoot struct {
+	*testMainCommand
+
+	subcommands CLI
+}
+
+func (c *testFallbackRoot) Subcommands() CLI {
+	return c.subcommands
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	responseFile := filepath.Join(dir, "args.txt")
+	content := "first \"second arg\"\n'third'\n"
+	if err := ioutil.WriteFile(responseFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fallback := &testFallbackCommand{&testCommand{}, nil}
+	system, _ := NewTestSystem(t, []string{"testfallback", "@" + responseFile, "last"}, nil)
+	result := Main(context.Background(), fallback, system, WithResponseFiles())
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	expected := []string{"first", "second arg", "third", "last"}
+	if strings.Join(fallback.args, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected args %q, received %q\n", expected, fallback.args)
+	}
+}
+
+type testRollbackCommand struct {
+	rolledBack []int
+}
+
+func (c *testRollbackCommand) Help() {}
+
+func (c *testRollbackCommand) Command(ctx context.Context, args []string, s System) error {
+	for i := 1; i <= 3; i++ {
+		i := i
+		OnRollback(ctx, func(ctx context.Context) error {
+			c.rolledBack = append(c.rolledBack, i)
+			return nil
+		})
+	}
+	return &ExitError{Status: 5, Message: "step 4 failed"}
+}
+
+func TestRollback(t *testing.T) {
+	cmd := &testRollbackCommand{}
+	result, output := runMain(t, cmd, []string{"testrollback"})
+
+	if result != 5 {
+		t.Errorf("expected the command's exit status, received %d\n", result)
+	}
+
+	if len(cmd.rolledBack) != 3 || cmd.rolledBack[0] != 3 || cmd.rolledBack[2] != 1 {
+		t.Errorf("expected rollbacks to run in reverse order, received %v\n", cmd.rolledBack)
+	}
+	ExpectMatch(t, *output.STDERR, `Rolling back 3 change\(s\)`)
+}
+
+type testExitCommand struct {
+	status     int
+	deferred   bool
+	rolledBack bool
+}
+
+func (c *testExitCommand) Help() {}
+
+func (c *testExitCommand) Command(ctx context.Context, args []string, s System) error {
+	defer func() { c.deferred = true }()
+	OnRollback(ctx, func(ctx context.Context) error {
+		c.rolledBack = true
+		return nil
+	})
+
+	s.Exit(c.status)
+	s.Println("unreachable")
+	return nil
+}
+
+func TestExit(t *testing.T) {
+	cmd := &testExitCommand{status: 3}
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"testexit"}})
+	result := Main(context.Background(), cmd, system)
+
+	ExpectExitCode(t, result, 3)
+	if !cmd.deferred || !cmd.rolledBack {
+		t.Errorf("expected deferred functions and rollbacks to run on exit\n")
+	}
+	if output.Stdout.Len() > 0 {
+		t.Errorf("expected the command to end, received output %q\n", output.Stdout)
+	}
+	if expected := "Rolling back 1 change(s)\nRollback 1 of 1 complete\n"; output.Stderr.String() != expected {
+		t.Errorf("expected only rollback messages, received %q\n", output.Stderr)
+	}
+
+	cmd = &testExitCommand{status: ExitOK}
+	result, _ = runMain(t, cmd, []string{"testexit"})
+
+	ExpectExitCode(t, result, ExitOK)
+	if !cmd.deferred || cmd.rolledBack {
+		t.Errorf("expected deferred functions to run, but not rollbacks, on a successful exit\n")
+	}
+}
+
+type testInterruptCommand struct {
+	rolledBack bool
+}
+
+func (c *testInterruptCommand) Help() {}
+
+func (c *testInterruptCommand) Command(ctx context.Context, args []string, s System) error {
+	OnRollback(ctx, func(ctx context.Context) error {
+		c.rolledBack = true
+		return nil
+	})
+
+	// simulate the user pressing Ctrl-C
+	s.(*TestSystem).Signal(os.Interrupt)
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRollbackInterrupted(t *testing.T) {
+	cmd := &testInterruptCommand{}
+	result, output := runMain(t, cmd, []string{"testinterrupt"})
+
+	ExpectCancelled(t, result)
+
+	if !cmd.rolledBack {
+		t.Errorf("rollback did not run after interrupt\n")
+	}
+	ExpectMatch(t, *output.STDERR, `Interrupted`)
+}
+
+func TestEnvExpansion(t *testing.T) {
+	fallback := &testFallbackCommand{&testCommand{}, nil}
+	system, _ := NewTestSystem(t, []string{"testfallback", "$GREETING", "${NAME}!"},
+		map[string]string{"GREETING": "hello", "NAME": "world"})
+	result := Main(context.Background(), fallback, system, WithEnvExpansion())
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	if strings.Join(fallback.args, " ") != "hello world!" {
+		t.Errorf("expected expanded args, received %q\n", fallback.args)
+	}
+}
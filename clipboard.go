@@ -0,0 +1,33 @@
+package cli
+
+// ReadClipboard returns the text on the clipboard, read with pbpaste on
+// macOS, the clipboard API on Windows, wl-paste under Wayland and xclip
+// elsewhere
+func (s *BaseSystem) ReadClipboard() (string, error) {
+	return s.readClipboard()
+}
+
+// WriteClipboard puts text on the clipboard, with pbcopy on macOS, the
+// clipboard API on Windows, wl-copy under Wayland and xclip elsewhere
+func (s *BaseSystem) WriteClipboard(text string) error {
+	return s.writeClipboard(text)
+}
+
+// clipboardCommand returns the program and arguments which read, or write,
+// the clipboard on goos
+func clipboardCommand(goos string, wayland, write bool) (string, []string) {
+	switch {
+	case goos == "darwin" && write:
+		return "pbcopy", nil
+	case goos == "darwin":
+		return "pbpaste", nil
+	case wayland && write:
+		return "wl-copy", nil
+	case wayland:
+		return "wl-paste", []string{"--no-newline"}
+	case write:
+		return "xclip", []string{"-selection", "clipboard", "-in"}
+	default:
+		return "xclip", []string{"-selection", "clipboard", "-out"}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"context"
+	"runtime"
+	"strings"
+)
+
+func (s *BaseSystem) readClipboard() (string, error) {
+	name, args := clipboardCommand(runtime.GOOS, len(s.Getenv("WAYLAND_DISPLAY")) > 0, false)
+	result, err := s.Exec(context.Background(), name, args)
+	return string(result.Stdout), err
+}
+
+func (s *BaseSystem) writeClipboard(text string) error {
+	name, args := clipboardCommand(runtime.GOOS, len(s.Getenv("WAYLAND_DISPLAY")) > 0, true)
+	_, err := s.Exec(context.Background(), name, args, ExecStdin(strings.NewReader(text)))
+	return err
+}
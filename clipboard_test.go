@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestClipboardCommand(t *testing.T) {
+	for _, c := range []struct {
+		goos           string
+		wayland, write bool
+		expected       []string
+	}{
+		{"darwin", false, true, []string{"pbcopy"}},
+		{"darwin", false, false, []string{"pbpaste"}},
+		{"linux", true, true, []string{"wl-copy"}},
+		{"linux", true, false, []string{"wl-paste", "--no-newline"}},
+		{"linux", false, true, []string{"xclip", "-selection", "clipboard", "-in"}},
+		{"freebsd", false, false, []string{"xclip", "-selection", "clipboard", "-out"}},
+	} {
+		name, args := clipboardCommand(c.goos, c.wayland, c.write)
+		if actual := append([]string{name}, args...); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("expected %q on %s (wayland %t, write %t), received %q\n", c.expected, c.goos, c.wayland, c.write, actual)
+		}
+	}
+}
+
+type testClipboardCommand struct {
+	DefaultHelp
+}
+
+func (c *testClipboardCommand) Command(ctx context.Context, args []string, s System) error {
+	if err := s.WriteClipboard("token-" + args[0]); err != nil {
+		return err
+	}
+	_, err := s.Println("Copied the token to the clipboard")
+	return err
+}
+
+func TestTestSystemClipboard(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"copy", "abc"}, nil)
+	ExpectExitCode(t, Main(context.Background(), &testClipboardCommand{}, system), ExitOK)
+	if text, err := system.ReadClipboard(); err != nil || text != "token-abc" {
+		t.Errorf("expected the token on the clipboard, received %q (%v)\n", text, err)
+	}
+	if calls := system.ExecCalls(); len(calls) > 0 {
+		t.Errorf("expected no programs to be run, received %v\n", calls)
+	}
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// cfUnicodeText is the clipboard format of UTF-16 text, and gmemMoveable the
+// allocation the clipboard takes ownership of
+const (
+	cfUnicodeText = 13
+	gmemMoveable  = 0x2
+)
+
+var (
+	user32           = syscall.NewLazyDLL("user32.dll")
+	openClipboard    = user32.NewProc("OpenClipboard")
+	closeClipboard   = user32.NewProc("CloseClipboard")
+	emptyClipboard   = user32.NewProc("EmptyClipboard")
+	getClipboardData = user32.NewProc("GetClipboardData")
+	setClipboardData = user32.NewProc("SetClipboardData")
+
+	kernel32     = syscall.NewLazyDLL("kernel32.dll")
+	globalAlloc  = kernel32.NewProc("GlobalAlloc")
+	globalFree   = kernel32.NewProc("GlobalFree")
+	globalLock   = kernel32.NewProc("GlobalLock")
+	globalUnlock = kernel32.NewProc("GlobalUnlock")
+)
+
+// lockClipboard opens the clipboard, returning a function which closes it.
+// The clipboard is opened by a thread, to which the caller is locked until
+// it is closed.
+func lockClipboard() (func(), error) {
+	runtime.LockOSThread()
+	if r, _, err := openClipboard.Call(0); r == 0 {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		closeClipboard.Call()
+		runtime.UnlockOSThread()
+	}, nil
+}
+
+func (s *BaseSystem) readClipboard() (string, error) {
+	unlock, err := lockClipboard()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	h, _, _ := getClipboardData.Call(cfUnicodeText)
+	if h == 0 {
+		// the clipboard holds no text
+		return "", nil
+	}
+	p, _, err := globalLock.Call(h)
+	if p == 0 {
+		return "", err
+	}
+	defer globalUnlock.Call(h)
+
+	base := pointer(p)
+	var text []uint16
+	for i := uintptr(0); ; i += 2 {
+		c := *(*uint16)(unsafe.Pointer(uintptr(base) + i))
+		if c == 0 {
+			break
+		}
+		text = append(text, c)
+	}
+	return syscall.UTF16ToString(text), nil
+}
+
+func (s *BaseSystem) writeClipboard(text string) error {
+	utf16, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+
+	h, _, err := globalAlloc.Call(gmemMoveable, uintptr(len(utf16)*2))
+	if h == 0 {
+		return err
+	}
+	p, _, err := globalLock.Call(h)
+	if p == 0 {
+		globalFree.Call(h)
+		return err
+	}
+	copy((*[1 << 29]uint16)(pointer(p))[:len(utf16):len(utf16)], utf16)
+	globalUnlock.Call(h)
+
+	unlock, err := lockClipboard()
+	if err != nil {
+		globalFree.Call(h)
+		return err
+	}
+	defer unlock()
+
+	if r, _, err := emptyClipboard.Call(); r == 0 {
+		globalFree.Call(h)
+		return err
+	}
+	// once set, the data belongs to the clipboard
+	if r, _, err := setClipboardData.Call(cfUnicodeText, h); r == 0 {
+		globalFree.Call(h)
+		return err
+	}
+	return nil
+}
+
+// pointer converts the address of memory locked with GlobalLock, which the
+// garbage collector doesn't manage, to a pointer
+func pointer(p uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&p))
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"flag"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// WithSortLocale adds a `--sort-locale` flag which overrides the locale used
+// to sort output for humans. Use `--sort-locale C` for stable byte-order
+// sorting in machine-readable output.
+func WithSortLocale() Option {
+	return func(c *config) {
+		c.sortLocale = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.String("sort-locale", "", "`locale` used to sort output; C sorts by byte value")
+		})
+	}
+}
+
+// Collator orders strings as a reader of a particular locale would expect:
+// letters are compared without regard to accents or case first, then by
+// accent, then by case. A small set of locales which order some letters
+// differently are tailored. The C and POSIX locales order strings by byte
+// value.
+type Collator struct {
+	locale  string
+	bytes   bool
+	tailors map[rune]int
+}
+
+// NewCollator returns a Collator for a locale identifier such as `sv_SE.UTF-8`
+func NewCollator(locale string) *Collator {
+	language := strings.ToLower(locale)
+	if i := strings.IndexAny(language, "_-.@"); i >= 0 {
+		language = language[:i]
+	}
+
+	c := &Collator{locale: locale}
+	switch language {
+	case "", "c", "posix":
+		c.bytes = true
+	default:
+		c.tailors = collationTailors[language]
+	}
+	return c
+}
+
+// Collate returns a Collator for the System's locale. The locale is taken from
+// the `--sort-locale` flag when present, otherwise from the LC_ALL,
+// LC_COLLATE or LANG environment variables.
+func Collate(sys System) *Collator {
+	if s, ok := baseOf(sys); ok && len(s.SortLocale) > 0 {
+		return NewCollator(s.SortLocale)
+	}
+	return NewCollator(locale(sys, "LC_COLLATE"))
+}
+
+// locale returns the locale configured for the given category in the
+// environment of sys
+func locale(sys interface{ Getenv(string) string }, category string) string {
+	for _, name := range []string{"LC_ALL", category, "LANG"} {
+		if v := sys.Getenv(name); len(v) > 0 {
+			return v
+		}
+	}
+	return ""
+}
+
+// Compare returns -1, 0 or 1 as a sorts before, the same as, or after b
+func (c *Collator) Compare(a, b string) int {
+	if c.bytes {
+		return strings.Compare(a, b)
+	}
+
+	ka, kb := c.key(a), c.key(b)
+	for level := 0; level < 3; level++ {
+		if r := compareWeights(ka[level], kb[level]); r != 0 {
+			return r
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// Strings sorts a slice of strings in place
+func (c *Collator) Strings(s []string) {
+	sort.SliceStable(s, func(i, j int) bool {
+		return c.Compare(s[i], s[j]) < 0
+	})
+}
+
+// SortStrings sorts a slice of strings in place for the System's locale
+func SortStrings(sys System, s []string) {
+	Collate(sys).Strings(s)
+}
+
+// key returns the primary (base letter), secondary (accent) and tertiary
+// (case) weights of s
+func (c *Collator) key(s string) [3][]int {
+	var k [3][]int
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		tertiary := 0
+		if lower != r {
+			tertiary = 1
+		}
+
+		if w, ok := c.tailors[lower]; ok {
+			k[0] = append(k[0], w)
+			k[1] = append(k[1], 0)
+			k[2] = append(k[2], tertiary)
+			continue
+		}
+
+		base, accented := foldAccent(lower)
+		for _, b := range base {
+			k[0] = append(k[0], int(b)*4)
+			k[1] = append(k[1], accented)
+			k[2] = append(k[2], tertiary)
+		}
+	}
+	return k
+}
+
+func compareWeights(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// foldAccent returns the unaccented letters for a lowercase rune and a weight
+// distinguishing the accent
+func foldAccent(r rune) (string, int) {
+	for i, group := range accentGroups {
+		if j := strings.IndexRune(group.accented, r); j >= 0 {
+			return group.base, i*16 + j + 1
+		}
+	}
+	return string(r), 0
+}
+
+var accentGroups = []struct {
+	base     string
+	accented string
+}{
+	{"a", "àáâãäåāăą"},
+	{"ae", "æ"},
+	{"c", "çćĉċč"},
+	{"d", "ďđð"},
+	{"e", "èéêëēĕėęě"},
+	{"g", "ĝğġģ"},
+	{"h", "ĥħ"},
+	{"i", "ìíîïĩīĭįı"},
+	{"j", "ĵ"},
+	{"k", "ķ"},
+	{"l", "ĺļľŀł"},
+	{"n", "ñńņňŉ"},
+	{"o", "òóôõöøōŏő"},
+	{"oe", "œ"},
+	{"r", "ŕŗř"},
+	{"s", "śŝşš"},
+	{"ss", "ß"},
+	{"t", "ţťŧ"},
+	{"th", "þ"},
+	{"u", "ùúûüũūŭůűų"},
+	{"w", "ŵ"},
+	{"y", "ýÿŷ"},
+	{"z", "źżž"},
+}
+
+// collationTailors gives primary weights to letters which a language sorts
+// as distinct letters rather than accented variants
+var collationTailors = map[string]map[rune]int{
+	"sv": {'å': 'z'*4 + 1, 'ä': 'z'*4 + 2, 'æ': 'z'*4 + 2, 'ö': 'z'*4 + 3, 'ø': 'z'*4 + 3},
+	"fi": {'å': 'z'*4 + 1, 'ä': 'z'*4 + 2, 'æ': 'z'*4 + 2, 'ö': 'z'*4 + 3, 'ø': 'z'*4 + 3},
+	"da": {'æ': 'z'*4 + 1, 'ä': 'z'*4 + 1, 'ø': 'z'*4 + 2, 'ö': 'z'*4 + 2, 'å': 'z'*4 + 3},
+	"nb": {'æ': 'z'*4 + 1, 'ä': 'z'*4 + 1, 'ø': 'z'*4 + 2, 'ö': 'z'*4 + 2, 'å': 'z'*4 + 3},
+	"nn": {'æ': 'z'*4 + 1, 'ä': 'z'*4 + 1, 'ø': 'z'*4 + 2, 'ö': 'z'*4 + 2, 'å': 'z'*4 + 3},
+	"no": {'æ': 'z'*4 + 1, 'ä': 'z'*4 + 1, 'ø': 'z'*4 + 2, 'ö': 'z'*4 + 2, 'å': 'z'*4 + 3},
+	"es": {'ñ': 'n'*4 + 1},
+	"et": {'š': 's'*4 + 1, 'ž': 's'*4 + 2, 'õ': 'w'*4 + 1, 'ä': 'w'*4 + 2, 'ö': 'w'*4 + 3, 'ü': 'w'*4 + 4},
+	"tr": {'ç': 'c'*4 + 1, 'ğ': 'g'*4 + 1, 'ı': 'h'*4 + 1, 'ö': 'o'*4 + 1, 'ş': 's'*4 + 1, 'ü': 'u'*4 + 1},
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollator(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected string
+	}{
+		{"en_US.UTF-8", "Äpfel apple banana Zebra zoo"},
+		{"sv_SE.UTF-8", "apple banana Zebra zoo Äpfel"},
+		{"C", "Zebra apple banana zoo Äpfel"},
+	}
+
+	for _, test := range tests {
+		words := []string{"zoo", "Äpfel", "banana", "Zebra", "apple"}
+		NewCollator(test.locale).Strings(words)
+		if sorted := strings.Join(words, " "); sorted != test.expected {
+			t.Errorf("%s: expected %s, received %s\n", test.locale, test.expected, sorted)
+		}
+	}
+}
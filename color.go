@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// Color is a terminal color or style applied by System.Color
+type Color int
+
+// Colors and styles, with the values of their SGR escape sequences
+const (
+	Bold      Color = 1
+	Dim       Color = 2
+	Italic    Color = 3
+	Underline Color = 4
+
+	Black   Color = 30
+	Red     Color = 31
+	Green   Color = 32
+	Yellow  Color = 33
+	Blue    Color = 34
+	Magenta Color = 35
+	Cyan    Color = 36
+	White   Color = 37
+)
+
+// WithNoColor adds a `--no-color` flag which turns off colors and styles,
+// as setting NO_COLOR does
+func WithNoColor() Option {
+	return func(c *config) {
+		c.noColor = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("no-color", false, "don't color output")
+		})
+	}
+}
+
+// colored reports whether output should be colored: it is unless output
+// isn't a terminal, NO_COLOR is set, TERM is `dumb` or NoColor is set
+func (s *BaseSystem) colored() bool {
+	return !s.NoColor && len(s.Getenv("NO_COLOR")) == 0 && s.Getenv("TERM") != "dumb" && isTerminal(s.Out)
+}
+
+// Color returns the operands, formatted as by Sprint, in the color c when
+// output is colored
+func (s *BaseSystem) Color(c Color, a ...interface{}) string {
+	return s.colorize(c, fmt.Sprint(a...))
+}
+
+// Colorf returns the arguments, formatted as by Sprintf, in the color c
+// when output is colored
+func (s *BaseSystem) Colorf(c Color, format string, a ...interface{}) string {
+	return s.colorize(c, fmt.Sprintf(format, a...))
+}
+
+func (s *BaseSystem) colorize(c Color, text string) string {
+	if len(text) == 0 || !s.colored() {
+		return text
+	}
+	return "\x1b[" + strconv.Itoa(int(c)) + "m" + text + styleReset
+}
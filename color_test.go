@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type testColorCommand struct {
+	DefaultHelp
+	printed string
+}
+
+func (c *testColorCommand) Command(ctx context.Context, args []string, s System) error {
+	c.printed = s.Colorf(Red, "%d failed", 2)
+	return nil
+}
+
+func TestColor(t *testing.T) {
+	system, _ := NewTestSystem(t, nil, nil)
+	if ok := system.Color(Green, "ok"); ok != "\x1b[32mok\x1b[0m" {
+		t.Errorf("expected green text at a terminal, received %q\n", ok)
+	}
+
+	system, _ = NewTestSystem(t, nil, map[string]string{"NO_COLOR": "1"})
+	if ok := system.Color(Green, "ok"); ok != "ok" {
+		t.Errorf("expected plain text when NO_COLOR is set, received %q\n", ok)
+	}
+
+	memory, _ := NewMemorySystem(MemorySystemOptions{})
+	if ok := memory.Color(Bold, "o", "k"); ok != "ok" {
+		t.Errorf("expected plain text when output isn't a terminal, received %q\n", ok)
+	}
+
+	command := &testColorCommand{}
+	system, _ = NewTestSystem(t, []string{"test", "--no-color"}, nil)
+	ExpectExitCode(t, Main(context.Background(), command, system, WithNoColor()), ExitOK)
+	if command.printed != "2 failed" {
+		t.Errorf("expected plain text with --no-color, received %q\n", command.printed)
+	}
+
+	// recording the run's output leaves it attached to the terminal
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	environment := map[string]string{"XDG_STATE_HOME": dir}
+	command = &testColorCommand{}
+	system, _ = NewTestSystem(t, []string{"test"}, environment)
+	ExpectExitCode(t, Main(context.Background(), command, system, WithRunLog("test")), ExitOK)
+	if command.printed != "\x1b[31m2 failed\x1b[0m" {
+		t.Errorf("expected red text while the run is recorded, received %q\n", command.printed)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// MinimumVersion returns the oldest version of the CLI a server supports,
+// e.g. from the metadata endpoint of an API
+type MinimumVersion func(ctx context.Context, sys System) (string, error)
+
+// WithCompatCheck adds a `--check-compat` flag to the `version` subcommand,
+// which asks the server for the oldest version of the CLI it supports and
+// fails if this one is older, so that users learn to upgrade before a
+// request fails in a way they can't explain. Requires WithVersion.
+func WithCompatCheck(minimum MinimumVersion) Option {
+	return func(c *config) {
+		c.compat = minimum
+	}
+}
+
+// checkCompat compares version with the minimum the server supports, saying
+// that it is compatible unless quiet is set. A version which can't be
+// compared, such as a development build, is warned about rather than
+// failing.
+func checkCompat(ctx context.Context, sys System, version string, minimum MinimumVersion, quiet bool) error {
+	required, err := minimum(ctx, sys)
+	if err != nil {
+		return err
+	}
+
+	older, ok := versionBefore(version, required)
+	switch {
+	case !ok:
+		sys.Logf(tr(sys, "Unable to compare version %q with the minimum the server supports, %s\n"), version, required)
+		return nil
+	case older:
+		return &ExitError{
+			Status:  ExitFailure,
+			Message: Localize(sys, "Version %s is no longer supported by the server, which requires %s or later; please upgrade", version, required),
+		}
+	case quiet:
+		return nil
+	}
+	_, err = sys.Println(Localize(sys, "Compatible with the server, which requires %s or later", required))
+	return err
+}
+
+// versionBefore reports whether version a precedes b, comparing them as
+// semantic versions, with or without a leading `v`, and whether both could
+// be compared. A pre-release precedes its release; build metadata is
+// ignored.
+func versionBefore(a, b string) (before, ok bool) {
+	va, preA, okA := parseVersion(a)
+	vb, preB, okB := parseVersion(b)
+	if !okA || !okB {
+		return false, false
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			return x < y, true
+		}
+	}
+	switch {
+	case len(preA) > 0 && len(preB) > 0:
+		return comparePrerelease(preA, preB) < 0, true
+	default:
+		return len(preA) > 0 && len(preB) == 0, true
+	}
+}
+
+// parseVersion splits a version such as `v1.2.3-rc.1+build` into its numbers
+// and pre-release
+func parseVersion(version string) (numbers []int, prerelease string, ok bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version, prerelease = version[:i], version[i+1:]
+	}
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, "", false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, prerelease, true
+}
+
+// comparePrerelease compares pre-releases by their dot-separated
+// identifiers, numerically where both are numbers, as semantic versioning
+// orders them
+func comparePrerelease(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, errX := strconv.Atoi(pa[i])
+		y, errY := strconv.Atoi(pb[i])
+		switch {
+		case errX == nil && errY == nil:
+			if x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case errX == nil:
+			return -1
+		case errY == nil:
+			return 1
+		default:
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return len(pa) - len(pb)
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVersionBefore(t *testing.T) {
+	for _, c := range []struct {
+		a, b       string
+		before, ok bool
+	}{
+		{"1.2.3", "1.2.4", true, true},
+		{"v1.10.0", "1.9.9", false, true},
+		{"1.2", "1.2.0", false, true},
+		{"2.0.0-rc.1", "2.0.0", true, true},
+		{"2.0.0-rc.2", "2.0.0-rc.10", true, true},
+		{"2.0.0-alpha", "2.0.0-alpha.1", true, true},
+		{"2.0.0+build.7", "2.0.0", false, true},
+		{"(devel)", "1.0.0", false, false},
+	} {
+		if before, ok := versionBefore(c.a, c.b); before != c.before || ok != c.ok {
+			t.Errorf("expected %s before %s to be %t, %t; received %t, %t\n", c.a, c.b, c.before, c.ok, before, ok)
+		}
+	}
+}
+
+func TestCheckCompat(t *testing.T) {
+	minimum := WithCompatCheck(func(ctx context.Context, sys System) (string, error) {
+		return "1.4.0", nil
+	})
+	command := &testMainCommand{&testCommand{}, &testSubcommand{&testCommand{}}}
+
+	for _, c := range []struct {
+		version string
+		status  int
+		stdout  string
+		stderr  string
+	}{
+		{"1.4.2", ExitOK, `testmain version 1\.4\.2\nCompatible with the server, which requires 1\.4\.0 or later`, `^$`},
+		{"1.3.9", ExitFailure, `testmain version 1\.3\.9`, `Version 1\.3\.9 is no longer supported by the server, which requires 1\.4\.0 or later; please upgrade`},
+		{"(devel)", ExitOK, `testmain version \(devel\)\n$`, `Unable to compare version "\(devel\)" with the minimum the server supports, 1\.4\.0`},
+	} {
+		system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"testmain", "version", "--check-compat"}})
+		ExpectExitCode(t, Main(context.Background(), command, system, minimum, WithVersion(VersionInfo{Version: c.version})), c.status)
+		ExpectMatch(t, *output.Stdout, c.stdout)
+		ExpectMatch(t, *output.Stderr, c.stderr)
+	}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"testmain", "version", "--check-compat"}})
+	ExpectExitCode(t, Main(context.Background(), command, system, WithVersion(VersionInfo{Version: "1.0.0"})), ExitUsage)
+	ExpectMatch(t, *output.Stderr, `flag provided but not defined: -check-compat`)
+}
@@ -0,0 +1,560 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompletionProtocolVersion is the version of the protocol spoken between
+// generated shell completion scripts and the hidden `__complete` command. It
+// is incremented whenever a change is made which existing scripts or
+// third-party tools could not handle.
+//
+// Version 1 of the protocol works as follows. The shell invokes the program
+// as `<program> __complete <words...> <partial>`, where the words are the
+// command line typed so far and the partial is the word being completed (which
+// may be empty). The program writes one candidate per line to STDOUT,
+// optionally followed by a tab and a description, then a final line consisting
+// of a colon followed by the decimal value of a CompletionDirective.
+const CompletionProtocolVersion = 1
+
+// CompletionCommandName is the reserved first argument which requests
+// completions from a program
+const CompletionCommandName = "__complete"
+
+// CompletionDirective is a set of flags instructing the shell how to treat the
+// candidates returned by `__complete`
+type CompletionDirective int
+
+const (
+	// CompletionDefault lets the shell apply its default behavior
+	CompletionDefault CompletionDirective = 0
+
+	// CompletionError indicates that completion failed and the candidates
+	// should be ignored
+	CompletionError CompletionDirective = 1 << (iota - 1)
+
+	// CompletionNoSpace prevents the shell from adding a space after the
+	// completed word
+	CompletionNoSpace
+
+	// CompletionNoFileComp prevents the shell from falling back to file name
+	// completion when there are no candidates
+	CompletionNoFileComp
+
+	// CompletionKeepOrder asks the shell not to sort the candidates
+	CompletionKeepOrder
+
+	// CompletionFilterFileExt treats the candidates as file extensions which
+	// file name completion should be limited to
+	CompletionFilterFileExt
+
+	// CompletionFilterDirs limits file name completion to directories
+	CompletionFilterDirs
+)
+
+var completionDirectiveNames = []struct {
+	name      string
+	directive CompletionDirective
+}{
+	{"error", CompletionError},
+	{"no-space", CompletionNoSpace},
+	{"no-file-completion", CompletionNoFileComp},
+	{"keep-order", CompletionKeepOrder},
+	{"filter-file-extension", CompletionFilterFileExt},
+	{"filter-directories", CompletionFilterDirs},
+}
+
+// Exit statuses returned by `__complete`. Scripts should treat any status
+// other than these as an error.
+const (
+	CompletionExitOK    = 0
+	CompletionExitError = 1
+)
+
+// CompletionProtocol describes the completion protocol implemented by this
+// version of the library
+type CompletionProtocol struct {
+	Version    int            `json:"version"`
+	Command    string         `json:"command"`
+	Directives map[string]int `json:"directives"`
+	ExitCodes  map[string]int `json:"exit_codes"`
+}
+
+// DescribeCompletion returns a description of the completion protocol
+func DescribeCompletion() CompletionProtocol {
+	directives := make(map[string]int, len(completionDirectiveNames))
+	for _, d := range completionDirectiveNames {
+		directives[d.name] = int(d.directive)
+	}
+
+	return CompletionProtocol{
+		Version:    CompletionProtocolVersion,
+		Command:    CompletionCommandName,
+		Directives: directives,
+		ExitCodes: map[string]int{
+			"ok":    CompletionExitOK,
+			"error": CompletionExitError,
+		},
+	}
+}
+
+// WithCompletion installs a `completion` subcommand which generates shell
+// completion scripts for the command tree
+func WithCompletion() Option {
+	return func(c *config) {
+		c.subcommands["completion"] = &CompletionCommand{}
+	}
+}
+
+// CompletionCommand is a subcommand which provides shell completion for a
+// command tree. It is normally installed as `completion` beneath the root
+// command by WithCompletion.
+type CompletionCommand struct {
+	DefaultHelp
+
+	describe bool
+}
+
+// Subcommands returns a subcommand for each shell a script can be generated
+// for
+func (c *CompletionCommand) Subcommands() CLI {
+	return CLI{
+		"bash":       &completionScriptCommand{shell: ShellBash},
+		"zsh":        &completionScriptCommand{shell: ShellZsh},
+		"fish":       &completionScriptCommand{shell: ShellFish},
+		"powershell": &completionScriptCommand{shell: ShellPowerShell},
+		"install":    &completionInstallCommand{},
+	}
+}
+
+// Synopsis describes the completion command
+func (c *CompletionCommand) Synopsis() string {
+	return "Generate shell completion scripts"
+}
+
+// Flags defines the flags accepted by the completion command
+func (c *CompletionCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.describe, "describe", false,
+		"print a JSON description of the completion protocol")
+}
+
+// Command runs the completion command
+func (c *CompletionCommand) Command(ctx context.Context, args []string, sys System) error {
+	if c.describe {
+		b, err := json.MarshalIndent(DescribeCompletion(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = sys.Println(string(b))
+		return err
+	}
+
+	return &ExitError{Status: ExitFailure, Message: Localize(sys, "No completion action given; see --help")}
+}
+
+// completionScriptCommand prints the completion script for a shell
+type completionScriptCommand struct {
+	DefaultHelp
+
+	shell Shell
+	root  Command
+	cfg   *config
+}
+
+// Synopsis describes the completion script command
+func (c *completionScriptCommand) Synopsis() string {
+	return fmt.Sprintf("Generate the completion script for %s", c.shell)
+}
+
+// bindTree is called by Main with the command tree being run
+func (c *completionScriptCommand) bindTree(root Command, cfg *config) {
+	c.root, c.cfg = root, cfg
+}
+
+// Command prints the completion script
+func (c *completionScriptCommand) Command(ctx context.Context, args []string, sys System) error {
+	name := "program"
+	if arguments := sys.Args(); len(arguments) > 0 {
+		name = programName(arguments[0])
+	}
+
+	var b strings.Builder
+	if err := writeCompletion(&b, c.shell, name, c.root, c.cfg); err != nil {
+		return err
+	}
+	_, err := sys.Print(b.String())
+	return err
+}
+
+// completionInstallCommand writes the completion script for the user's shell
+// to where the shell will load it from
+type completionInstallCommand struct {
+	DefaultHelp
+
+	root Command
+	cfg  *config
+}
+
+// Synopsis describes the completion install command
+func (c *completionInstallCommand) Synopsis() string {
+	return "Install the completion script for your shell"
+}
+
+// Description describes the completion install command in detail
+func (c *completionInstallCommand) Description() string {
+	return `Install the completion script for the given shell, or for the shell
+named by $SHELL if none is given. Bash and fish load the script
+automatically; instructions are printed for zsh and PowerShell, which must
+be configured to load it once. Installing again updates the script.`
+}
+
+// bindTree is called by Main with the command tree being run
+func (c *completionInstallCommand) bindTree(root Command, cfg *config) {
+	c.root, c.cfg = root, cfg
+}
+
+// Command installs the completion script
+func (c *completionInstallCommand) Command(ctx context.Context, args []string, sys System) error {
+	name := "program"
+	if arguments := sys.Args(); len(arguments) > 0 {
+		name = programName(arguments[0])
+	}
+
+	shell := sys.Shell()
+	if len(args) > 0 {
+		shell = Shell(args[0])
+	}
+
+	path, instructions, err := completionInstallPath(sys, shell, name)
+	if err != nil {
+		return &ExitError{Status: ExitFailure, Message: err.Error()}
+	}
+
+	var b strings.Builder
+	if err := writeCompletion(&b, shell, name, c.root, c.cfg); err != nil {
+		return err
+	}
+
+	if existing, err := sys.ReadFile(path); err == nil && string(existing) == b.String() {
+		sys.Printf(tr(sys, "Completion for %s is already installed at %s\n"), shell, path)
+	} else {
+		if err := sys.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := sys.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			return err
+		}
+		sys.Printf(tr(sys, "Installed completion for %s at %s\n"), shell, path)
+	}
+
+	if len(instructions) > 0 {
+		sys.Printf("\n%s\n", instructions)
+	}
+	return nil
+}
+
+// completionInstallPath returns the path a shell loads the completion script
+// for the program named name from, along with instructions for loading it if
+// the shell doesn't do so automatically
+func completionInstallPath(sys System, shell Shell, name string) (path, instructions string, err error) {
+	switch shell {
+	case ShellBash:
+		dir, err := dataHome(sys)
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(dir, "bash-completion", "completions", name), "", nil
+
+	case ShellZsh:
+		dir, err := dataHome(sys)
+		if err != nil {
+			return "", "", err
+		}
+		dir = filepath.Join(dir, "zsh", "site-functions")
+		return filepath.Join(dir, "_"+name), fmt.Sprintf(
+			"If you haven't already, add this line to ~/.zshrc before compinit is called:\n\n"+
+				"    fpath=(%s $fpath)", quotePOSIX(dir)), nil
+
+	case ShellFish:
+		dir, err := configHome(sys)
+		if err != nil {
+			return "", "", err
+		}
+		return filepath.Join(dir, "fish", "completions", name+".fish"), "", nil
+
+	case ShellPowerShell:
+		dir := sys.Getenv("LOCALAPPDATA")
+		if len(dir) == 0 {
+			if dir, err = dataHome(sys); err != nil {
+				return "", "", err
+			}
+		}
+		path := filepath.Join(dir, name, "completion.ps1")
+		return path, fmt.Sprintf(
+			"If you haven't already, add this line to your PowerShell profile ($PROFILE):\n\n"+
+				"    . %s", quotePowerShell(path)), nil
+	}
+	return "", "", fmt.Errorf("Completion can't be installed for %s; name one of bash, zsh, fish or powershell", shell)
+}
+
+// WriteCompletion writes a completion script for the given shell, which
+// completes the names of the subcommands and flags of the command tree
+// rooted at root. Name is the name the program is invoked by. Options should
+// be those passed to Main, so that built-in subcommands and flags are
+// completed as well.
+func WriteCompletion(w io.Writer, shell Shell, name string, root Command, opts ...Option) error {
+	return writeCompletion(w, shell, name, root, newConfig(opts))
+}
+
+func writeCompletion(w io.Writer, shell Shell, name string, root Command, cfg *config) error {
+	nodes := completionTree(root, cfg)
+	switch shell {
+	case ShellBash:
+		return writeBashCompletion(w, name, nodes)
+	case ShellZsh:
+		return writeZshCompletion(w, name, nodes)
+	case ShellFish:
+		return writeFishCompletion(w, name, nodes)
+	case ShellPowerShell:
+		return writePowerShellCompletion(w, name, nodes)
+	}
+	return fmt.Errorf("Completion is not supported for %s", shell)
+}
+
+// complete implements the `__complete` command. Words are the command line
+// typed so far, followed by the partial word being completed. Subcommands
+// and flags are completed with their descriptions, and positional arguments
+// by commands implementing CompletesArgs. The value of a flag, and the
+// arguments of other runnable commands, are left to the shell's file name
+// completion. Flags which can't be parsed before asking a command for its
+// arguments produce the CompletionError directive.
+func complete(ctx context.Context, root Command, words []string, cfg *config, sys System) int {
+	var toComplete string
+	if len(words) > 0 {
+		toComplete = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	cmd, path, rest, passthrough := resolve(root, words, cfg.subcommands)
+	f, _ := newFlagSet(cmd, strings.Join(path, " "), cfg)
+	flags, args := splitFlags(f, rest)
+	positional := len(args) > 0 || len(passthrough) > 0
+	for _, token := range rest {
+		if token == "--" {
+			positional = true
+		}
+	}
+
+	var candidates []string
+	var arguments bool
+	directive := CompletionNoFileComp
+	switch {
+	case !positional && completingFlagValue(f, rest):
+		directive = CompletionDefault
+
+	case !positional && strings.HasPrefix(toComplete, "-"):
+		f.VisitAll(func(fl *flag.Flag) {
+			_, usage := flag.UnquoteUsage(fl)
+			candidates = append(candidates, completionCandidate(flagName(HelpFlag{Name: fl.Name}), usage))
+		})
+		if f.Lookup("h") == nil {
+			candidates = append(candidates, completionCandidate("-h", "show help"))
+		}
+		if f.Lookup("help") == nil {
+			candidates = append(candidates, completionCandidate("--help", "show help"))
+		}
+		sort.Strings(candidates)
+
+	default:
+		arguments = true
+		if b, ok := cmd.(HasSubcommands); ok && !positional {
+			subcommands := CLI{}
+			if len(path) == 0 {
+				for k, v := range cfg.subcommands {
+					subcommands[k] = v
+				}
+			}
+			for k, v := range b.Subcommands() {
+				subcommands[k] = v
+			}
+			for _, e := range subcommands.entries("", false, false) {
+				if !e.Hidden {
+					candidates = append(candidates, completionCandidate(e.Name, e.Synopsis))
+				}
+			}
+		}
+
+		if b, ok := cmd.(CompletesArgs); ok {
+			// the flags typed so far may narrow the candidates, so they must
+			// be understood before the command is asked for them
+			if err := f.Parse(flags); err != nil {
+				sys.Log(err)
+				sys.Printf(":%d\n", CompletionError)
+				return CompletionExitError
+			}
+			candidates = append(candidates, b.Complete(ctx, toComplete, sys)...)
+		}
+	}
+
+	var matched []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			matched = append(matched, c)
+		}
+	}
+
+	// fall back to file names for the arguments of a runnable command
+	if _, ok := cmd.(Action); ok && arguments && len(matched) == 0 {
+		directive = CompletionDefault
+	}
+
+	// leave the cursor after a candidate which is only a prefix, such as a
+	// directory or `key=`, so that the user may continue typing
+	if len(matched) == 1 {
+		word := strings.SplitN(matched[0], "\t", 2)[0]
+		if strings.HasSuffix(word, "/") || strings.HasSuffix(word, "=") {
+			directive |= CompletionNoSpace
+		}
+	}
+
+	for _, c := range matched {
+		sys.Println(c)
+	}
+	sys.Printf(":%d\n", directive)
+	return CompletionExitOK
+}
+
+// completingFlagValue reports whether the last of the tokens is a flag which
+// expects a value, so that the word being completed is that value
+func completingFlagValue(f *flag.FlagSet, tokens []string) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	if len(last) < 2 || last[0] != '-' || last == "--" || strings.Contains(last, "=") {
+		return false
+	}
+	fl := f.Lookup(strings.TrimLeft(last, "-"))
+	return fl != nil && !isBoolFlag(fl)
+}
+
+// completionCandidate formats a candidate and its description as a line of
+// `__complete` output
+func completionCandidate(word, description string) string {
+	description = strings.SplitN(strings.TrimSpace(description), "\n", 2)[0]
+	if len(description) == 0 {
+		return word
+	}
+	return word + "\t" + description
+}
+
+// completionNode describes a command within the tree being completed
+type completionNode struct {
+	// path is the names of the subcommands leading to the command
+	path []string
+
+	subcommands []completionSubcommand
+
+	// flags are the names of the command's flags, including dashes
+	flags []string
+
+	// dynamic is set if the command completes its arguments at runtime
+	dynamic bool
+}
+
+type completionSubcommand struct {
+	name     string
+	aliases  []string
+	synopsis string
+}
+
+// completionTree walks the command tree rooted at root, returning a node for
+// each command which isn't hidden. Built-in subcommands and flags are
+// included as Main would dispatch to them.
+func completionTree(root Command, cfg *config) []completionNode {
+	var nodes []completionNode
+
+	var walk func(cmd Command, path []string, builtins CLI)
+	walk = func(cmd Command, path []string, builtins CLI) {
+		n := completionNode{path: path}
+		_, n.dynamic = cmd.(CompletesArgs)
+
+		f, _ := newFlagSet(cmd, strings.Join(path, " "), cfg)
+		f.VisitAll(func(fl *flag.Flag) {
+			n.flags = append(n.flags, flagName(HelpFlag{Name: fl.Name}))
+		})
+		if f.Lookup("h") == nil {
+			n.flags = append(n.flags, "-h")
+		}
+		if f.Lookup("help") == nil {
+			n.flags = append(n.flags, "--help")
+		}
+		sort.Strings(n.flags)
+
+		b, ok := cmd.(HasSubcommands)
+		if !ok {
+			nodes = append(nodes, n)
+			return
+		}
+
+		subcommands := CLI{}
+		for k, v := range builtins {
+			subcommands[k] = v
+		}
+		for k, v := range b.Subcommands() {
+			subcommands[k] = v
+		}
+
+		var children []Entry
+		for _, e := range subcommands.entries("", false, false) {
+			if e.Hidden {
+				continue
+			}
+			n.subcommands = append(n.subcommands, completionSubcommand{e.Name, e.Aliases, e.Synopsis})
+			children = append(children, e)
+		}
+		nodes = append(nodes, n)
+
+		for _, e := range children {
+			walk(e.Command, append(path[:len(path):len(path)], e.Name), nil)
+		}
+	}
+	walk(root, nil, cfg.subcommands)
+
+	return nodes
+}
+
+// completionTransition describes the subcommand reached by typing a word
+// beneath a command in a completion script
+type completionTransition struct {
+	// keys are the paths of the subcommand's name and aliases
+	keys []string
+
+	// path is the subcommand's own path
+	path string
+}
+
+// completionTransitions returns a transition for every subcommand in the
+// tree, which completion scripts use to follow the words typed so far
+func completionTransitions(nodes []completionNode) []completionTransition {
+	var transitions []completionTransition
+	for _, n := range nodes {
+		parent := n.path[:len(n.path):len(n.path)]
+		for _, sub := range n.subcommands {
+			t := completionTransition{path: completionPath(append(parent, sub.name))}
+			t.keys = append(t.keys, t.path)
+			for _, alias := range sub.aliases {
+				t.keys = append(t.keys, completionPath(append(parent, alias)))
+			}
+			transitions = append(transitions, t)
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeBashCompletion writes a bash completion script for the program named
+// name. The script follows the words typed so far down the command tree, in
+// the same way Main resolves subcommands, then completes the names of the
+// subcommands or flags of the command it arrives at. Positional arguments
+// are completed by the program itself, through `__complete`, if the command
+// implements CompletesArgs; otherwise they fall back to the shell's default
+// completion.
+func writeBashCompletion(w io.Writer, name string, nodes []completionNode) error {
+	fn := "_" + completionIdentifier(name) + "_completion"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# bash completion for %s\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString(`	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local path="" positional="" commands="" flags="" dynamic="" word line i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		word="${COMP_WORDS[i]}"
+		if [[ "$word" == "--" ]]; then
+			positional=1
+			break
+		fi
+		if [[ "$word" == -* ]]; then
+			continue
+		fi
+		case "$path/$word" in
+`)
+	for _, t := range completionTransitions(nodes) {
+		patterns := make([]string, len(t.keys))
+		for i, key := range t.keys {
+			patterns[i] = quotePOSIX(key)
+		}
+		fmt.Fprintf(&b, "\t\t%s) path=%s ;;\n", strings.Join(patterns, "|"), quotePOSIX(t.path))
+	}
+	b.WriteString(`		*)
+			positional=1
+			break
+			;;
+		esac
+	done
+
+	case "$path" in
+`)
+	for _, n := range nodes {
+		names := make([]string, len(n.subcommands))
+		for i, sub := range n.subcommands {
+			names[i] = sub.name
+		}
+		fmt.Fprintf(&b, "\t%s)\n", quotePOSIXWord(completionPath(n.path)))
+		fmt.Fprintf(&b, "\t\tcommands=%s\n", quotePOSIXWord(strings.Join(names, " ")))
+		fmt.Fprintf(&b, "\t\tflags=%s\n", quotePOSIXWord(strings.Join(n.flags, " ")))
+		if n.dynamic {
+			b.WriteString("\t\tdynamic=1\n")
+		}
+		b.WriteString("\t\t;;\n")
+	}
+	fmt.Fprintf(&b, `	esac
+	if [[ -n "$positional" ]]; then
+		commands=""
+	fi
+
+	if [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+		return
+	fi
+
+	COMPREPLY=($(compgen -W "$commands" -- "$cur"))
+	if [[ -n "$dynamic" ]]; then
+		local -a candidates=()
+		local directive=0
+		while IFS= read -r line; do
+			case "$line" in
+			:*) directive="${line#:}" ;;
+			*) candidates+=("${line%%%%$'\t'*}") ;;
+			esac
+		done < <("${COMP_WORDS[0]}" %s "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null)
+		if (( directive & %d )); then
+			return
+		fi
+		COMPREPLY+=("${candidates[@]}")
+		if (( directive & %d )); then
+			compopt -o nospace 2>/dev/null
+		fi
+		if (( directive & %d )); then
+			compopt +o default 2>/dev/null
+		fi
+	fi
+}
+complete -o default -F %s %s
+`, CompletionCommandName, CompletionError, CompletionNoSpace, CompletionNoFileComp, fn, quotePOSIX(name))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// completionPath returns the key identifying a command within a completion
+// script
+func completionPath(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(path, "/")
+}
+
+// completionIdentifier returns name with any characters which may not appear
+// in a shell function name replaced
+func completionIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// quotePOSIXWord quotes s as a single word, which may be empty, e.g. as a
+// case pattern
+func quotePOSIXWord(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	return quotePOSIX(s)
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeFishCompletion writes a fish completion script for the program named
+// name. Subcommands are offered with their synopses; file names are offered
+// when there are no other candidates, unless the program directs otherwise.
+// Fish adds no space after a candidate ending in `/` or `=` of its own
+// accord, so the no-space directive needs no handling.
+func writeFishCompletion(w io.Writer, name string, nodes []completionNode) error {
+	fn := "__" + completionIdentifier(name) + "_complete"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# fish completion for %s\n", name)
+	fmt.Fprintf(&b, "function %s\n", fn)
+	b.WriteString(`    set -l tokens (commandline -opc)
+    set -l program $tokens[1]
+    set -e tokens[1]
+    set -l current (commandline -ct)
+    set -l cmdpath ''
+    set -l positional 0
+    set -l dynamic 0
+    for word in $tokens
+        if test "$word" = '--'
+            set positional 1
+            break
+        end
+        if string match -q -- '-*' "$word"
+            continue
+        end
+        switch "$cmdpath/$word"
+`)
+	for _, t := range completionTransitions(nodes) {
+		patterns := make([]string, len(t.keys))
+		for i, key := range t.keys {
+			patterns[i] = quoteFish(escapeFishPattern(key))
+		}
+		fmt.Fprintf(&b, "            case %s\n", strings.Join(patterns, " "))
+		fmt.Fprintf(&b, "                set cmdpath %s\n", quoteFish(t.path))
+	}
+	b.WriteString(`            case '*'
+                set positional 1
+                break
+        end
+    end
+
+    set -l commands
+    set -l flags
+    switch "$cmdpath"
+`)
+	for _, n := range nodes {
+		commands := make([]string, len(n.subcommands))
+		for i, sub := range n.subcommands {
+			commands[i] = quoteFish(sub.name + "\t" + sub.synopsis)
+		}
+		flags := make([]string, len(n.flags))
+		for i, fl := range n.flags {
+			flags[i] = quoteFish(fl)
+		}
+
+		fmt.Fprintf(&b, "        case %s\n", quoteFish(escapeFishPattern(completionPath(n.path))))
+		if len(commands) > 0 {
+			fmt.Fprintf(&b, "            set commands %s\n", strings.Join(commands, " "))
+		}
+		fmt.Fprintf(&b, "            set flags %s\n", strings.Join(flags, " "))
+		if n.dynamic {
+			b.WriteString("            set dynamic 1\n")
+		}
+	}
+	fmt.Fprintf(&b, `    end
+    if test $positional = 1
+        set commands
+    end
+
+    if string match -q -- '-*' "$current"
+        printf '%%s\n' $flags
+        return
+    end
+
+    set -l directive 0
+    if test $dynamic = 1
+        set -l candidates
+        for line in ($program %s $tokens "$current" 2>/dev/null)
+            if string match -q -- ':*' "$line"
+                set directive (string sub -s 2 -- "$line")
+            else
+                set -a candidates $line
+            end
+        end
+        if test (math "floor($directive / %d) %% 2") -eq 0
+            set -a commands $candidates
+        end
+    end
+    if test (count $commands) -gt 0
+        printf '%%s\n' $commands
+    else if test (math "floor($directive / %d) %% 2") -eq 0
+        return 1
+    end
+end
+
+complete -c %s -f -n '%s >/dev/null' -a '(%s)'
+`, CompletionCommandName, CompletionError, CompletionNoFileComp, quoteFish(name), fn, fn)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeFishPattern escapes the wildcards in a fish case pattern
+func escapeFishPattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writePowerShellCompletion writes a PowerShell script which registers an
+// argument completer for the program named name. It may be dot-sourced from
+// the user's profile.
+func writePowerShellCompletion(w io.Writer, name string, nodes []completionNode) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# PowerShell completion for %s\n", name)
+	fmt.Fprintf(&b, "Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", quotePowerShellLiteral(name))
+	b.WriteString(`    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $next = New-Object 'System.Collections.Generic.Dictionary[string,string]'
+`)
+	for _, t := range completionTransitions(nodes) {
+		for _, key := range t.keys {
+			fmt.Fprintf(&b, "    $next[%s] = %s\n", quotePowerShellLiteral(key), quotePowerShellLiteral(t.path))
+		}
+	}
+	b.WriteString(`
+    $commands = New-Object 'System.Collections.Generic.Dictionary[string,object]'
+    $flags = New-Object 'System.Collections.Generic.Dictionary[string,object]'
+    $synopses = New-Object 'System.Collections.Generic.Dictionary[string,string]'
+    $dynamic = New-Object 'System.Collections.Generic.HashSet[string]'
+`)
+	for _, n := range nodes {
+		path := quotePowerShellLiteral(completionPath(n.path))
+		commands := make([]string, len(n.subcommands))
+		for i, sub := range n.subcommands {
+			commands[i] = quotePowerShellLiteral(sub.name)
+			if len(sub.synopsis) > 0 {
+				subpath := completionPath(append(n.path[:len(n.path):len(n.path)], sub.name))
+				fmt.Fprintf(&b, "    $synopses[%s] = %s\n", quotePowerShellLiteral(subpath), quotePowerShellLiteral(sub.synopsis))
+			}
+		}
+		flags := make([]string, len(n.flags))
+		for i, fl := range n.flags {
+			flags[i] = quotePowerShellLiteral(fl)
+		}
+		fmt.Fprintf(&b, "    $commands[%s] = @(%s)\n", path, strings.Join(commands, ", "))
+		fmt.Fprintf(&b, "    $flags[%s] = @(%s)\n", path, strings.Join(flags, ", "))
+		if n.dynamic {
+			fmt.Fprintf(&b, "    [void]$dynamic.Add(%s)\n", path)
+		}
+	}
+	fmt.Fprintf(&b, `
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -lt $cursorPosition -or ($_.Extent.EndOffset -eq $cursorPosition -and $wordToComplete -eq '') } |
+        ForEach-Object { $_.ToString() })
+
+    $path = ''
+    $positional = $false
+    foreach ($word in ($words | Select-Object -Skip 1)) {
+        if ($word -eq '--') {
+            $positional = $true
+            break
+        }
+        if ($word.StartsWith('-')) {
+            continue
+        }
+        if ($next.ContainsKey("$path/$word")) {
+            $path = $next["$path/$word"]
+        } else {
+            $positional = $true
+            break
+        }
+    }
+
+    if ($wordToComplete.StartsWith('-')) {
+        foreach ($flag in $flags[$path]) {
+            if ($flag.StartsWith($wordToComplete, [System.StringComparison]::Ordinal)) {
+                [System.Management.Automation.CompletionResult]::new($flag, $flag, 'ParameterName', $flag)
+            }
+        }
+    } elseif (-not $positional) {
+        foreach ($command in $commands[$path]) {
+            if ($command.StartsWith($wordToComplete, [System.StringComparison]::Ordinal)) {
+                $synopsis = $command
+                if ($synopses.ContainsKey("$path/$command")) {
+                    $synopsis = $synopses["$path/$command"]
+                }
+                [System.Management.Automation.CompletionResult]::new($command, $command, 'ParameterValue', $synopsis)
+            }
+        }
+    }
+    if (-not $wordToComplete.StartsWith('-') -and $dynamic.Contains($path)) {
+        $arguments = @($words | Select-Object -Skip 1)
+        $lines = @(& $words[0] %s @arguments $wordToComplete 2>$null)
+        $directive = 0
+        if ($lines.Count -gt 0 -and "$($lines[-1])".StartsWith(':')) {
+            $directive = [int]"$($lines[-1])".Substring(1)
+        }
+        if (($directive -band %d) -eq 0) {
+            foreach ($line in $lines) {
+                if ($line -and -not $line.StartsWith(':')) {
+                    $fields = $line.Split("`+"`t"+`", 2)
+                    $description = $fields[0]
+                    if ($fields.Count -gt 1) {
+                        $description = $fields[1]
+                    }
+                    [System.Management.Automation.CompletionResult]::new($fields[0], $fields[0], 'ParameterValue', $description)
+                }
+            }
+        }
+    }
+}
+`, CompletionCommandName, CompletionError)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quotePowerShellLiteral quotes s as a PowerShell string literal, even if it
+// would be safe as a bare word
+func quotePowerShellLiteral(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCompletionDirectives(t *testing.T) {
+	// these values are part of the completion protocol and must never change
+	for _, c := range []struct {
+		directive CompletionDirective
+		value     int
+	}{
+		{CompletionDefault, 0},
+		{CompletionError, 1},
+		{CompletionNoSpace, 2},
+		{CompletionNoFileComp, 4},
+		{CompletionKeepOrder, 8},
+		{CompletionFilterFileExt, 16},
+		{CompletionFilterDirs, 32},
+	} {
+		if int(c.directive) != c.value {
+			t.Errorf("directive has value %d, expected %d\n", c.directive, c.value)
+		}
+	}
+}
+
+func TestCompletionDescribe(t *testing.T) {
+	result, output := runMain(t, &CompletionCommand{}, []string{"completion", "--describe"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	expected := `{
+  "version": 1,
+  "command": "__complete",
+  "directives": {
+    "error": 1,
+    "filter-directories": 32,
+    "filter-file-extension": 16,
+    "keep-order": 8,
+    "no-file-completion": 4,
+    "no-space": 2
+  },
+  "exit_codes": {
+    "error": 1,
+    "ok": 0
+  }
+}
+`
+	if got := normalizeNewlines(output.STDOUT.String()); got != expected {
+		t.Errorf("unexpected protocol description\n%s", diffLines(expected, got))
+	}
+}
+
+type testCompletesCommand struct {
+	DefaultHelp
+
+	archived bool
+}
+
+func (c *testCompletesCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.archived, "archived", false, "include archived widgets")
+}
+
+func (c *testCompletesCommand) Complete(ctx context.Context, toComplete string, s System) []string {
+	if c.archived {
+		return []string{"config/", "gadget", "gizmo", "sprocket"}
+	}
+	return []string{"config/", "gadget", "gizmo"}
+}
+
+func (c *testCompletesCommand) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestComplete(t *testing.T) {
+	for _, c := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"g"}, "gadget\ngizmo\n:4\n"},
+		{[]string{"gi"}, "gizmo\n:4\n"},
+		{[]string{"s"}, ":0\n"},
+		{[]string{"--archived", "s"}, "sprocket\n:4\n"},
+		{[]string{"--arch"}, "--archived\tinclude archived widgets\n:4\n"},
+		{[]string{"--archived", ""}, "config/\ngadget\ngizmo\nsprocket\n:4\n"},
+		{[]string{"c"}, "config/\n:6\n"},
+	} {
+		args := append([]string{"widgets", CompletionCommandName}, c.args...)
+		result, output := runMain(t, &testCompletesCommand{}, args)
+		ExpectExitCode(t, result, CompletionExitOK)
+		if got := normalizeNewlines(output.STDOUT.String()); got != c.expected {
+			t.Errorf("%q: expected %q, received %q\n", c.args, c.expected, got)
+		}
+	}
+
+	for _, c := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{""}, "list\tList widgets\n:4\n"},
+		{[]string{"-v", "l"}, "list\tList widgets\n:4\n"},
+		{[]string{"x"}, ":4\n"},
+		{[]string{"--o"}, "--output\toutput format\n:4\n"},
+		{[]string{"--output", ""}, ":0\n"},
+		{[]string{"list", ""}, ":0\n"},
+		{[]string{"list", "--h"}, "--help\tshow help\n:4\n"},
+	} {
+		args := append([]string{"widgets", CompletionCommandName}, c.args...)
+		result, output := runMain(t, &testHelpCommand{}, args)
+		ExpectExitCode(t, result, CompletionExitOK)
+		if got := normalizeNewlines(output.STDOUT.String()); got != c.expected {
+			t.Errorf("%q: expected %q, received %q\n", c.args, c.expected, got)
+		}
+	}
+
+	args := []string{"widgets", CompletionCommandName, "--archived=maybe", ""}
+	result, output := runMain(t, &testCompletesCommand{}, args)
+	ExpectExitCode(t, result, CompletionExitError)
+	if got := normalizeNewlines(output.STDOUT.String()); got != fmt.Sprintf(":%d\n", CompletionError) {
+		t.Errorf("expected the error directive for an invalid flag, received %q\n", got)
+	}
+
+	var b strings.Builder
+	if err := WriteCompletion(&b, ShellBash, "widgets", &testCompletesCommand{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "dynamic=1") {
+		t.Errorf("expected the script to complete arguments dynamically\n%s", b.String())
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets", "completion", "bash"},
+		WithCompletion(), WithVersion(VersionInfo{Version: "1.0.0"}))
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "widgets.bash")
+	if err := ioutil.WriteFile(script, []byte(normalizeNewlines(output.STDOUT.String())), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		line     string
+		expected string
+	}{
+		{"widgets ", "completion list version"},
+		{"widgets l", "list"},
+		{"widgets --o", "--output"},
+		{"widgets -v ", "completion list version"},
+		{"widgets completion ", "bash fish install powershell zsh"},
+		{"widgets list --", "--help --version"},
+		{"widgets version --j", "--json"},
+		{"widgets bogus ", ""},
+	} {
+		words := strings.Split(c.line, " ")
+		check := fmt.Sprintf(`source %s
+COMP_WORDS=(%s)
+COMP_CWORD=%d
+_widgets_completion
+echo "${COMPREPLY[*]}"`, quotePOSIX(script), ShellQuote(words, ShellBash), len(words)-1)
+
+		out, err := exec.Command(bash, "--norc", "-c", check).Output()
+		if err != nil {
+			t.Fatalf("%q: %s", c.line, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != c.expected {
+			t.Errorf("%q: expected %q, received %q\n", c.line, c.expected, got)
+		}
+	}
+}
+
+func TestBashDynamicCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	args := []string{"widgets", CompletionCommandName, "--archived=maybe", ""}
+	result, output := runMain(t, &testCompletesCommand{}, args)
+	ExpectExitCode(t, result, CompletionExitError)
+	if got := normalizeNewlines(output.STDOUT.String()); got != fmt.Sprintf(":%d\n", CompletionError) {
+		t.Errorf("expected the error directive for an invalid flag, received %q\n", got)
+	}
+
+	var b strings.Builder
+	if err := WriteCompletion(&b, ShellBash, "widgets", &testCompletesCommand{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		output   string
+		expected string
+	}{
+		{`gadget\tA gadget\ngizmo\n:4\n`, "gadget gizmo"},
+		{`gadget\n:1\n`, ""},
+	} {
+		// the program is replaced by a function printing the output of
+		// __complete
+		check := fmt.Sprintf(`%s
+widgets() { printf '%s'; }
+COMP_WORDS=(widgets g)
+COMP_CWORD=1
+_widgets_completion
+echo "${COMPREPLY[*]}"`, b.String(), c.output)
+
+		out, err := exec.Command(bash, "--norc", "-c", check).Output()
+		if err != nil {
+			t.Fatalf("%q: %s", c.output, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != c.expected {
+			t.Errorf("%q: expected %q, received %q\n", c.output, c.expected, got)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, c := range []struct {
+		shell    Shell
+		expected []string
+	}{
+		{ShellZsh, []string{
+			"#compdef widgets",
+			"/completion/zsh) cmdpath=/completion/zsh ;;",
+			"commands=('completion:Generate shell completion scripts' 'list:List widgets')",
+			"flags=(--help --output -h -v)",
+			"compdef _widgets widgets",
+		}},
+		{ShellFish, []string{
+			"case /completion/fish",
+			"set cmdpath /completion/fish",
+			"set commands 'completion\tGenerate shell completion scripts' 'list\tList widgets'",
+			"complete -c widgets -f -n '__widgets_complete >/dev/null' -a '(__widgets_complete)'",
+		}},
+		{ShellPowerShell, []string{
+			"Register-ArgumentCompleter -Native -CommandName 'widgets'",
+			"$next['/completion/powershell'] = '/completion/powershell'",
+			"$synopses['/list'] = 'List widgets'",
+			"$commands[''] = @('completion', 'list')",
+		}},
+	} {
+		var b strings.Builder
+		if err := WriteCompletion(&b, c.shell, "widgets", &testHelpCommand{}, WithCompletion()); err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("%s: expected the script to contain %q\n%s", c.shell, expected, b.String())
+			}
+		}
+	}
+
+	if err := WriteCompletion(ioutil.Discard, ShellCmd, "widgets", &testHelpCommand{}); err == nil {
+		t.Errorf("expected an error for an unsupported shell\n")
+	}
+}
+
+func TestCompletionInstall(t *testing.T) {
+	sandbox := Sandbox(t)
+	sandbox.Setenv("SHELL", "/bin/bash")
+
+	install := func(args ...string) (int, *TestOutput) {
+		system, output := sandbox.System(append([]string{"widgets", "completion", "install"}, args...))
+		wait := system.Capture()
+		result := Main(context.Background(), &testHelpCommand{}, system, WithCompletion())
+		wait()
+		return result, output
+	}
+
+	path := filepath.Join(sandbox.DataHome, "bash-completion", "completions", "widgets")
+	result, output := install()
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	ExpectMatch(t, *output.STDOUT, `Installed completion for bash at `+regexp.QuoteMeta(path))
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), "complete -o default -F _widgets_completion widgets") {
+		t.Errorf("expected a bash completion script\n%s", script)
+	}
+
+	result, output = install()
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	ExpectMatch(t, *output.STDOUT, `Completion for bash is already installed`)
+
+	result, output = install("zsh")
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	dir := filepath.Join(sandbox.DataHome, "zsh", "site-functions")
+	if _, err := os.Stat(filepath.Join(dir, "_widgets")); err != nil {
+		t.Error(err)
+	}
+	ExpectMatch(t, *output.STDOUT, `fpath=\(`+regexp.QuoteMeta(quotePOSIX(dir))+` \$fpath\)`)
+
+	result, output = install("fish")
+	if result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if _, err := os.Stat(filepath.Join(sandbox.ConfigHome, "fish", "completions", "widgets.fish")); err != nil {
+		t.Error(err)
+	}
+
+	result, output = install("sh")
+	ExpectExitCode(t, result, ExitFailure)
+	ExpectMatch(t, *output.STDERR, `Completion can't be installed for sh`)
+}
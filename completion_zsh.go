@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeZshCompletion writes a zsh completion script for the program named
+// name. It may be sourced, or installed as `_<name>` in a directory on
+// $fpath. Subcommands are offered with their synopses.
+func writeZshCompletion(w io.Writer, name string, nodes []completionNode) error {
+	fn := "_" + completionIdentifier(name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#compdef %s\n\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+
+	// path is tied to PATH in zsh, so the command's path is kept in cmdpath
+	b.WriteString(`	local cmdpath="" positional="" dynamic="" directive=0 word line i
+	local -a commands flags candidates suffix
+	for ((i = 2; i < CURRENT; i++)); do
+		word="${words[i]}"
+		if [[ "$word" == "--" ]]; then
+			positional=1
+			break
+		fi
+		if [[ "$word" == -* ]]; then
+			continue
+		fi
+		case "$cmdpath/$word" in
+`)
+	for _, t := range completionTransitions(nodes) {
+		patterns := make([]string, len(t.keys))
+		for i, key := range t.keys {
+			patterns[i] = quotePOSIX(key)
+		}
+		fmt.Fprintf(&b, "\t\t%s) cmdpath=%s ;;\n", strings.Join(patterns, "|"), quotePOSIX(t.path))
+	}
+	b.WriteString(`		*)
+			positional=1
+			break
+			;;
+		esac
+	done
+
+	case "$cmdpath" in
+`)
+	for _, n := range nodes {
+		commands := make([]string, len(n.subcommands))
+		for i, sub := range n.subcommands {
+			described := strings.Replace(sub.name, ":", `\:`, -1)
+			if len(sub.synopsis) > 0 {
+				described += ":" + sub.synopsis
+			}
+			commands[i] = quotePOSIX(described)
+		}
+		flags := make([]string, len(n.flags))
+		for i, fl := range n.flags {
+			flags[i] = quotePOSIX(fl)
+		}
+
+		fmt.Fprintf(&b, "\t%s)\n", quotePOSIXWord(completionPath(n.path)))
+		fmt.Fprintf(&b, "\t\tcommands=(%s)\n", strings.Join(commands, " "))
+		fmt.Fprintf(&b, "\t\tflags=(%s)\n", strings.Join(flags, " "))
+		if n.dynamic {
+			b.WriteString("\t\tdynamic=1\n")
+		}
+		b.WriteString("\t\t;;\n")
+	}
+	fmt.Fprintf(&b, `	esac
+	if [[ -n "$positional" ]]; then
+		commands=()
+	fi
+
+	if [[ "${words[CURRENT]}" == -* ]]; then
+		compadd -a flags
+		return
+	fi
+
+	if [[ -n "$dynamic" ]]; then
+		for line in "${(@f)$("${words[1]}" %s "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}"; do
+			if [[ "$line" == :* ]]; then
+				directive="${line#:}"
+			elif [[ -n "$line" ]]; then
+				candidates+=("${line%%%%$'\t'*}")
+			fi
+		done
+		if (( directive & %d )); then
+			candidates=()
+		fi
+		if (( directive & %d )); then
+			suffix=(-S '')
+		fi
+	fi
+
+	if (( ${#commands} )); then
+		_describe -t commands command commands
+	fi
+	if (( ${#candidates} )); then
+		compadd "${suffix[@]}" -a candidates
+	elif (( ! ${#commands} && ! (directive & %d) )); then
+		_files
+	fi
+}
+
+if [[ "${funcstack[1]}" == %s ]]; then
+	%s "$@"
+else
+	compdef %s %s
+fi
+`, CompletionCommandName, CompletionError, CompletionNoSpace, CompletionNoFileComp,
+		quotePOSIX(fn), fn, fn, quotePOSIX(name))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
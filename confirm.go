@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"flag"
+	"strings"
+)
+
+// WithAssumeYes adds `--yes` and `-y` flags which answer every confirmation
+// prompt affirmatively, for use in scripts and other non-interactive contexts
+func WithAssumeYes() Option {
+	return func(c *config) {
+		c.assumeYes = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			yes := f.Bool("yes", false, "answer yes to all confirmation prompts")
+			f.BoolVar(yes, "y", false, "answer yes to all confirmation prompts")
+		})
+	}
+}
+
+// ConfirmByTyping asks the user to type the name of a resource in order to
+// proceed with a destructive action. It returns nil if the name was typed
+// exactly. Confirmation is skipped when the System assumes yes; otherwise it
+// fails without prompting when input isn't attached to a terminal, so that
+// scripts must opt in explicitly with `--yes`.
+func ConfirmByTyping(sys System, resourceName string) error {
+	if sys.AssumesYes() {
+		return nil
+	}
+	if !sys.Interactive() {
+		return &ExitError{
+			Status:  ExitFailure,
+			Message: tr(sys, "Refusing to continue without confirmation; use --yes to override"),
+		}
+	}
+
+	if _, err := sys.Printf(tr(sys, "This action cannot be undone. Type %q to confirm: "), resourceName); err != nil {
+		return err
+	}
+
+	answer, err := readLine(sys)
+	if err != nil {
+		return err
+	}
+
+	if answer != resourceName {
+		return &ExitError{
+			Status:  ExitFailure,
+			Message: Localize(sys, "Confirmation did not match %q; aborting", resourceName),
+		}
+	}
+	return nil
+}
+
+// readLine reads a single line of input, without its line ending
+func readLine(sys System) (string, error) {
+	s, ok := baseOf(sys)
+	if !ok {
+		var line string
+		_, err := sys.Scanf("%s\n", &line)
+		return line, err
+	}
+	return s.readLine()
+}
+
+// readLine reads a single line of the System's input a byte at a time, so
+// that nothing following it is consumed. If a read abandoned by readContext
+// is still waiting for input, its line is returned instead.
+func (s *BaseSystem) readLine() (string, error) {
+	if done := s.pending.take(); done != nil {
+		r := <-done
+		return r.text, r.err
+	}
+
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := s.In.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			if len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(string(line), "\r"), nil
+}
+
+// Confirm asks the user a yes or no question, returning true if they answered
+// yes. Like ConfirmByTyping, it returns true without prompting when the System
+// assumes yes and fails when input isn't attached to a terminal. Answers in
+// the language of the System's locale are accepted, e.g. `o` or `oui` in
+// French, as well as English ones.
+func Confirm(sys System, question string) (bool, error) {
+	if sys.AssumesYes() {
+		return true, nil
+	}
+	if !sys.Interactive() {
+		return false, &ExitError{
+			Status:  ExitFailure,
+			Message: tr(sys, "Refusing to continue without confirmation; use --yes to override"),
+		}
+	}
+
+	if _, err := sys.Printf("%s %s: ", question, inputYesNo(sys).hint); err != nil {
+		return false, err
+	}
+
+	answer, err := readLine(sys)
+	if err != nil {
+		return false, err
+	}
+
+	return isYes(sys, answer), nil
+}
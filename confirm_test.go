@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"log"
+	"testing"
+)
+
+func TestConfirmByTyping(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, nil)
+	go func() {
+		system.Console.ExpectString("to confirm: ")
+		system.Console.SendLine("production")
+	}()
+
+	if err := ConfirmByTyping(system, "production"); err != nil {
+		t.Errorf("expected confirmation to succeed, received %s\n", err)
+	}
+
+	system, _ = NewTestSystem(t, []string{"test"}, nil)
+	go func() {
+		system.Console.ExpectString("to confirm: ")
+		system.Console.SendLine("prod")
+	}()
+
+	if err := ConfirmByTyping(system, "production"); err == nil {
+		t.Errorf("expected confirmation to fail with a mismatched name\n")
+	}
+}
+
+func TestConfirmByTypingNonInteractive(t *testing.T) {
+	system := &UnixSystem{&BaseSystem{
+		In:     &bytes.Buffer{},
+		Out:    &bytes.Buffer{},
+		Logger: log.New(&bytes.Buffer{}, "", 0),
+	}}
+
+	if err := ConfirmByTyping(system, "production"); err == nil {
+		t.Errorf("expected confirmation to fail without a terminal\n")
+	}
+
+	system.AssumeYes = true
+	if err := ConfirmByTyping(system, "production"); err != nil {
+		t.Errorf("expected confirmation to be skipped, received %s\n", err)
+	}
+
+	prefixed := PrefixSystem(system, "[production] ")
+	if err := ConfirmByTyping(prefixed, "production"); err != nil {
+		t.Errorf("expected confirmation through a prefixed system to be skipped, received %s\n", err)
+	}
+
+	system.AssumeYes = false
+	if err := ConfirmByTyping(prefixed, "production"); err == nil {
+		t.Errorf("expected confirmation through a prefixed system to fail without a terminal\n")
+	}
+}
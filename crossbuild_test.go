@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestCrossBuild checks that the package and its tests compile for the
+// platforms whose files aren't built on this one
+func TestCrossBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping cross-compilation in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	for _, goos := range []string{"windows", "darwin", "linux"} {
+		t.Run(goos, func(t *testing.T) {
+			cmd := exec.Command(goTool, "vet", ".")
+			cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH=amd64", "CGO_ENABLED=0")
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("expected the package to build for %s\n%s", goos, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// debugHTTPMaxBody is how much of a body `--debug-http` logs
+	debugHTTPMaxBody = 2048
+
+	// debugHTTPMaxRead is the largest body which is read to be redacted
+	debugHTTPMaxRead = 64 << 10
+)
+
+// WithDebugHTTP adds a `--debug-http` flag which logs each request made with
+// the System's HTTPClient and its response: the method, URL, status and how
+// long the request took, and the start of each body. Secrets are redacted as
+// they are by `--curl`. Binary bodies aren't logged.
+func WithDebugHTTP() Option {
+	return func(c *config) {
+		c.debugHTTP = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("debug-http", false, "log HTTP requests and responses")
+		})
+	}
+}
+
+// debugTransport logs requests and their responses
+type debugTransport struct {
+	sys  *BaseSystem
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := req.Method + " " + redactURL(req.URL)
+	t.sys.Logf("HTTP %s\n", target)
+	if body, ok := requestBody(req); ok {
+		t.logBody(req.Header.Get("Content-Type"), body)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.sys.Logf("HTTP %s failed after %s: %s\n", target, elapsed, err)
+		return nil, err
+	}
+	t.sys.Logf("HTTP %s: %s in %s\n", target, resp.Status, elapsed)
+
+	// the body of a connection switched to another protocol, such as a
+	// websocket, is the connection itself
+	if resp.Body != nil && resp.Body != http.NoBody && resp.StatusCode != http.StatusSwitchingProtocols {
+		start, err := ioutil.ReadAll(io.LimitReader(resp.Body, debugHTTPMaxRead+1))
+		if err == nil || len(start) > 0 {
+			t.logBody(resp.Header.Get("Content-Type"), start)
+		}
+		resp.Body = &replayedBody{Reader: io.MultiReader(bytes.NewReader(start), resp.Body), Closer: resp.Body}
+	}
+	return resp, nil
+}
+
+// logBody logs the start of a body. A body is redacted as a whole, so a body
+// too large to be read isn't logged.
+func (t *debugTransport) logBody(contentType string, body []byte) {
+	if len(body) > debugHTTPMaxRead {
+		t.sys.Logf("  (body of more than %d bytes not shown)\n", debugHTTPMaxRead)
+		return
+	}
+	body = redactBody(contentType, body)
+
+	truncated := len(body) > debugHTTPMaxBody
+	if truncated {
+		body = body[:debugHTTPMaxBody]
+		// a character cut in two doesn't make the body binary
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(body); i++ {
+			body = body[:len(body)-1]
+		}
+	}
+	switch {
+	case len(body) == 0:
+	case !utf8.Valid(body):
+		t.sys.Logf("  (binary data)\n")
+	case truncated:
+		t.sys.Logf("  %s... (truncated)\n", body)
+	default:
+		t.sys.Logf("  %s\n", body)
+	}
+}
+
+// replayedBody is a response body whose start has already been read
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testDebugHTTPCommand struct {
+	DefaultHelp
+	url string
+}
+
+func (c *testDebugHTTPCommand) Command(ctx context.Context, args []string, s System) error {
+	resp, err := s.HTTPClient().Post(c.url+"/login", "application/x-www-form-urlencoded",
+		strings.NewReader("username=alice&password=hunter2"))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	_, err = s.Println(len(body))
+	return err
+}
+
+func TestDebugHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"abc123","motd":"` + strings.Repeat("x", 3000) + `"}`))
+	}))
+	defer server.Close()
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"login", "--debug-http"}})
+	command := &testDebugHTTPCommand{url: server.URL}
+	ExpectExitCode(t, Main(context.Background(), command, system, WithDebugHTTP()), ExitOK)
+
+	// the command reads the whole body, though only its start is logged
+	ExpectMatch(t, *output.Stdout, `^3035\n$`)
+	ExpectMatch(t, *output.Stderr, `HTTP POST http://127\.0\.0\.1:\d+/login\n`)
+	ExpectMatch(t, *output.Stderr, `  password=REDACTED&username=alice\n`)
+	ExpectMatch(t, *output.Stderr, `HTTP POST http://127\.0\.0\.1:\d+/login: 200 OK in \d+m?s\n`)
+	ExpectMatch(t, *output.Stderr, `  \{"access_token":"REDACTED","motd":"x+\.\.\. \(truncated\)\n`)
+	if strings.Contains(output.Stderr.String(), "abc123") || strings.Contains(output.Stderr.String(), "hunter2") {
+		t.Errorf("expected secrets to be redacted, received:\n%s\n", output.Stderr)
+	}
+
+	system, output = NewMemorySystem(MemorySystemOptions{Arguments: []string{"login"}})
+	ExpectExitCode(t, Main(context.Background(), command, system, WithDebugHTTP()), ExitOK)
+	if output.Stderr.Len() > 0 {
+		t.Errorf("expected nothing to be logged without --debug-http, received %q\n", output.Stderr.String())
+	}
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	debugLogDir = "logs"
+
+	// debugLogMaxSize is the size beyond which the debug log is rotated
+	debugLogMaxSize = 1024 * 1024
+
+	// debugLogKeep is the number of rotated logs kept
+	debugLogKeep = 3
+
+	// debugLogMaxAge is the age beyond which rotated logs are removed
+	debugLogMaxAge = 14 * 24 * time.Hour
+)
+
+// WithDebugLog mirrors everything app logs to a persistent log in its state
+// directory, so that users can retrieve the diagnostics of a failed run they
+// didn't capture. Each line is stamped with the time, the run's trace ID and
+// the command. The log is rotated once it exceeds 1MiB, and rotated logs are
+// removed after two weeks. A `logs` subcommand displays the log.
+func WithDebugLog(app string) Option {
+	return func(c *config) {
+		c.debugLog = app
+		c.subcommands["logs"] = &LogsCommand{App: app}
+	}
+}
+
+// debugLogPath returns the path of the current debug log of app
+func debugLogPath(sys System, app string) (string, error) {
+	dir, err := stateDir(sys, app)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, debugLogDir, app+".log"), nil
+}
+
+// startDebugLog mirrors the System's logger to the debug log of app,
+// returning a function which stops it
+func startDebugLog(sys System, app, command, traceID string) (stop func(), err error) {
+	s, ok := baseOf(sys)
+	if !ok {
+		return func() {}, nil
+	}
+
+	path, err := debugLogPath(sys, app)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := rotateDebugLog(path, time.Now()); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments := sys.Args(); len(arguments) > 0 {
+		command = strings.TrimSpace(programName(arguments[0]) + " " + command)
+	}
+	if len(traceID) > 8 {
+		traceID = traceID[:8]
+	}
+	w := &debugLogWriter{w: f, prefix: traceID + " " + command + ": "}
+	w.Write([]byte(fmt.Sprintf("started %q\n", sys.Args())))
+
+	logger := s.Logger
+	s.Logger = log.New(io.MultiWriter(logger.Writer(), w), logger.Prefix(), logger.Flags())
+	return func() {
+		s.Logger = logger
+		f.Close()
+	}, nil
+}
+
+// rotateDebugLog renames the log at path if it has grown too large, and
+// removes rotated logs which are too old or too many
+func rotateDebugLog(path string, now time.Time) error {
+	rotated := func(n int) string {
+		return fmt.Sprintf("%s.%d", path, n)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() >= debugLogMaxSize {
+		if err := os.Remove(rotated(debugLogKeep)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for n := debugLogKeep - 1; n >= 1; n-- {
+			if err := os.Rename(rotated(n), rotated(n+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(path, rotated(1)); err != nil {
+			return err
+		}
+	}
+
+	for n := 1; n <= debugLogKeep; n++ {
+		if info, err := os.Stat(rotated(n)); err == nil && now.Sub(info.ModTime()) > debugLogMaxAge {
+			if err := os.Remove(rotated(n)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// debugLogWriter stamps each line written to it
+type debugLogWriter struct {
+	w      io.Writer
+	prefix string
+}
+
+func (w *debugLogWriter) Write(p []byte) (int, error) {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format(time.RFC3339)
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		fmt.Fprintf(&b, "%s %s%s\n", stamp, w.prefix, line)
+	}
+	if _, err := io.WriteString(w.w, b.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// LogsCommand is a subcommand which displays the debug log
+type LogsCommand struct {
+	DefaultHelp
+
+	App string
+
+	lines int
+	all   bool
+	path  bool
+}
+
+// Synopsis describes the logs command
+func (c *LogsCommand) Synopsis() string {
+	return "Show the diagnostics logged by recent runs"
+}
+
+// Flags defines the flags of the logs command
+func (c *LogsCommand) Flags(f *flag.FlagSet) {
+	f.IntVar(&c.lines, "n", 100, "show the last `n` lines; 0 for all")
+	f.BoolVar(&c.all, "all", false, "include rotated logs")
+	f.BoolVar(&c.path, "path", false, "print the path of the log rather than its contents")
+}
+
+// Command prints the end of the debug log
+func (c *LogsCommand) Command(ctx context.Context, args []string, sys System) error {
+	path, err := debugLogPath(sys, c.App)
+	if err != nil {
+		return err
+	}
+	if c.path {
+		_, err := sys.Println(path)
+		return err
+	}
+
+	paths := []string{path}
+	if c.all {
+		for n := 1; n <= debugLogKeep; n++ {
+			paths = append([]string{fmt.Sprintf("%s.%d", path, n)}, paths...)
+		}
+	}
+
+	var lines []string
+	for _, p := range paths {
+		b, err := sys.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")...)
+	}
+	if len(lines) == 0 {
+		return &ExitError{Status: ExitFailure, Message: Localize(sys, "Nothing has been logged")}
+	}
+
+	if c.lines > 0 && len(lines) > c.lines {
+		lines = lines[len(lines)-c.lines:]
+	}
+	_, err = sys.Println(strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testLogRoot struct{}
+
+func (c *testLogRoot) Help() {}
+
+func (c *testLogRoot) Subcommands() CLI {
+	return CLI{"sync": &testLogCommand{}}
+}
+
+func TestDebugLog(t *testing.T) {
+	sandbox := Sandbox(t)
+	run := func(args ...string) (int, *MemoryOutput) {
+		system, output := NewMemorySystem(MemorySystemOptions{
+			Arguments:   append([]string{"widgets"}, args...),
+			Environment: sandbox.Environment,
+		})
+		return Main(context.Background(), &testLogRoot{}, system, WithDebugLog("widgets")), output
+	}
+
+	result, output := run("sync")
+	ExpectExitCode(t, result, ExitFailure)
+	ExpectMatch(t, *output.Stderr, `syncing widgets`)
+
+	b, err := ioutil.ReadFile(filepath.Join(sandbox.StateHome, "widgets", "logs", "widgets.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamp := `\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ [0-9a-f]{8} widgets sync: `
+	for _, re := range []string{
+		`(?m)^` + stamp + `started \["widgets" "sync"\]$`,
+		`(?m)^` + stamp + `syncing widgets$`,
+		`(?m)^` + stamp + `Unable to reach api\n` + stamp + `retry later$`,
+	} {
+		if !regexp.MustCompile(re).Match(b) {
+			t.Errorf("expected the debug log to match %s\n%s", re, b)
+		}
+	}
+
+	result, output = run("logs", "-n", "2")
+	ExpectExitCode(t, result, ExitOK)
+	if lines := strings.Split(strings.TrimSpace(output.Stdout.String()), "\n"); len(lines) != 2 ||
+		!strings.HasSuffix(lines[1], "retry later") {
+		t.Errorf("expected the last 2 lines of the log, received\n%s", output.Stdout)
+	}
+
+	result, output = run("logs", "--path")
+	ExpectExitCode(t, result, ExitOK)
+	ExpectMatch(t, *output.Stdout, regexp.QuoteMeta(filepath.Join("widgets", "logs", "widgets.log")))
+}
+
+func TestRotateDebugLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debuglog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "widgets.log")
+	for _, name := range []string{path + ".2", path + ".3"} {
+		if err := ioutil.WriteFile(name, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(path, bytes.Repeat([]byte("x"), debugLogMaxSize), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-debugLogMaxAge - time.Hour)
+	if err := os.Chtimes(path+".2", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotateDebugLog(path, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the full log to be rotated\n")
+	}
+	if info, err := os.Stat(path + ".1"); err != nil || info.Size() != debugLogMaxSize {
+		t.Errorf("expected the full log to become the first rotated log\n")
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected the second rotated log, which is too old, to be removed once it became the third\n")
+	}
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// UnifiedDiff returns the differences between a and b in unified diff format,
+// labelling them with the given names. It returns an empty string if a and b
+// are identical.
+func UnifiedDiff(aName, bName string, a, b []byte) string {
+	ops := diffLinesOps(splitLines(string(a)), splitLines(string(b)))
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = next
+		}
+
+		hunk := ops[start:end]
+		aStart, bStart := hunk[0].a, hunk[0].b
+		aCount, bCount := 0, 0
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		i = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// diffLinesOps computes an edit script turning a into b from the longest
+// common subsequence of their lines. Inputs too large to compare line by line
+// are treated as entirely replaced.
+func diffLinesOps(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > 4000000 {
+		for i, line := range a {
+			ops = append(ops, diffOp{'-', line, i, 0})
+		}
+		for j, line := range b {
+			ops = append(ops, diffOp{'+', line, len(a), j})
+		}
+		return ops
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		}
+	}
+	return ops
+}
+
+// execFunc runs a program, as System.Exec does
+type execFunc func(ctx context.Context, name string, args []string, opts ...ExecOption) (Result, error)
+
+// externalDiff presents the differences between old and new. The tool named
+// by $DIFFTOOL is used if set, followed by git's configured difftool when
+// useGit is true; otherwise a unified diff is printed. Tools are run with
+// run, the Exec method of the System presenting the differences, on files
+// written within temp, its temporary directory.
+func (s *BaseSystem) externalDiff(old, new []byte, useGit bool, run execFunc, temp string) error {
+	ctx := context.Background()
+	tool := s.Getenv("DIFFTOOL")
+
+	var gitTool bool
+	if len(tool) == 0 && useGit {
+		result, err := run(ctx, "git", []string{"config", "--get", "diff.tool"})
+		gitTool = err == nil && len(strings.TrimSpace(string(result.Stdout))) > 0
+	}
+
+	if len(tool) == 0 && !gitTool {
+		_, err := s.Print(UnifiedDiff("old", "new", old, new))
+		return err
+	}
+
+	dir, err := ioutil.TempDir(temp, "diff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	oldPath, newPath := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	if err := ioutil.WriteFile(oldPath, old, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(newPath, new, 0600); err != nil {
+		return err
+	}
+
+	name, args := "git", []string{"difftool", "--no-prompt", "--no-index", oldPath, newPath}
+	if !gitTool {
+		name, args = shellCommand(tool + " " + ShellQuote([]string{oldPath, newPath}, scriptShell()))
+	}
+
+	_, err = run(ctx, name, args, ExecStdin(s.In), ExecStdout(s.Out), ExecStderr(s.Logger.Writer()))
+	if e, ok := err.(*ExitError); ok && e.Status == 1 {
+		// diff tools conventionally exit with 1 when the inputs differ
+		return nil
+	}
+	return err
+}
+
+// ExternalDiff presents the differences between old and new using the user's
+// preferred diff tool
+func (s *BaseSystem) ExternalDiff(old, new []byte) error {
+	return s.externalDiff(old, new, true, s.Exec, s.TempDir())
+}
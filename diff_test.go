@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	b := "one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n"
+
+	expected := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`
+	if diff := UnifiedDiff("a", "b", []byte(a), []byte(b)); diff != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, diff)
+	}
+
+	if diff := UnifiedDiff("a", "b", []byte(a), []byte(a)); diff != "" {
+		t.Errorf("expected no differences, received:\n%s", diff)
+	}
+}
+
+type testDiffCommand struct{}
+
+func (c *testDiffCommand) Help() {}
+
+func (c *testDiffCommand) Command(ctx context.Context, args []string, s System) error {
+	return s.ExternalDiff([]byte("old contents\n"), []byte("new contents\n"))
+}
+
+func TestExternalDiffQuotesForScriptShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+
+	// a temporary directory whose name would be mangled by quoting for fish
+	dir, err := ioutil.TempDir("", `it's a\dir`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", dir)
+
+	environment := map[string]string{"DIFFTOOL": "cat", "SHELL": "/usr/bin/fish"}
+	result, output := runMainWithEnv(t, &testDiffCommand{}, []string{"testdiff"}, environment)
+	if result != 0 {
+		t.Errorf("command did not return a 0 status: %s\n", output.STDERR.String())
+	}
+	ExpectMatch(t, *output.STDOUT, `old contents\r?\nnew contents`)
+}
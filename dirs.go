@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// WithDirFlags adds `--config-dir`, `--state-dir`, `--cache-dir` and
+// `--data-dir` flags which replace the directories app keeps its
+// configuration, state, cache and data in, so that isolated instances, such
+// as a new version under test, may run side by side with separate state. The
+// directories may also be set with environment variables named after app,
+// e.g. `MY_APP_STATE_DIR` for `my-app`; flags take precedence. The path
+// helpers, and the features which keep files, honor them when asked for the
+// directories of app; other applications' directories are unchanged.
+func WithDirFlags(app string) Option {
+	return func(c *config) {
+		c.dirs = app
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.String("config-dir", "", "keep configuration in `dir`")
+			f.String("state-dir", "", "keep state in `dir`")
+			f.String("cache-dir", "", "keep the cache in `dir`")
+			f.String("data-dir", "", "keep data in `dir`")
+		})
+	}
+}
+
+// overrideDirs sets the directories replaced with the flags of WithDirFlags,
+// or the environment variables named after app
+func (s *BaseSystem) overrideDirs(app string, framework frameworkFlags) error {
+	prefix := strings.ToUpper(promName(app))
+	for _, d := range []struct {
+		name     string
+		override *string
+	}{
+		{"config", &s.ConfigDirOverride},
+		{"state", &s.StateDirOverride},
+		{"cache", &s.CacheDirOverride},
+		{"data", &s.DataDirOverride},
+	} {
+		dir := framework.value(d.name + "-dir")
+		if len(dir) == 0 {
+			dir = s.Getenv(prefix + "_" + strings.ToUpper(d.name) + "_DIR")
+		}
+		if len(dir) == 0 {
+			continue
+		}
+		abs, err := s.Abs(dir)
+		if err != nil {
+			return err
+		}
+		*d.override = abs
+	}
+	s.DirOverrideApp = app
+	return nil
+}
+
+// overridden returns override if it is set and applies to app
+func (s *BaseSystem) overridden(override, app string) (string, bool) {
+	if len(override) == 0 || (len(s.DirOverrideApp) > 0 && s.DirOverrideApp != app) {
+		return "", false
+	}
+	return override, true
+}
+
+// stateDir returns the directory in which app should keep state which
+// persists between runs, following the XDG base directory specification.
+// Paths are resolved through the System's environment so that tests can
+// redirect them. StateDirOverride replaces the directory if it is set for app.
+func stateDir(sys System, app string) (string, error) {
+	if s, ok := baseOf(sys); ok {
+		if dir, ok := s.overridden(s.StateDirOverride, app); ok {
+			return dir, nil
+		}
+	}
+	if dir := sys.Getenv("XDG_STATE_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, app), nil
+	}
+	if dir := sys.Getenv("LOCALAPPDATA"); len(dir) > 0 {
+		return filepath.Join(dir, app, "State"), nil
+	}
+	if home := sys.Getenv("HOME"); len(home) > 0 {
+		return filepath.Join(home, ".local", "state", app), nil
+	}
+	return "", fmt.Errorf("Unable to determine state directory; HOME is not set")
+}
+
+// ConfigDir returns the directory app keeps its configuration in:
+// $XDG_CONFIG_HOME/app if set, else %APPDATA%\app on Windows,
+// ~/Library/Application Support/app on macOS, or ~/.config/app, unless
+// ConfigDirOverride is set for app
+func (s *BaseSystem) ConfigDir(app string) (string, error) {
+	if dir, ok := s.overridden(s.ConfigDirOverride, app); ok {
+		return dir, nil
+	}
+	if dir := s.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, app), nil
+	}
+	if dir := s.Getenv("APPDATA"); len(dir) > 0 {
+		return filepath.Join(dir, app), nil
+	}
+	if home := s.Getenv("HOME"); len(home) > 0 {
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, "Library", "Application Support", app), nil
+		}
+		return filepath.Join(home, ".config", app), nil
+	}
+	return "", fmt.Errorf("Unable to determine configuration directory; HOME is not set")
+}
+
+// CacheDir returns the directory app may keep data which can be recreated
+// if lost in: $XDG_CACHE_HOME/app if set, else %LOCALAPPDATA%\app\Cache on
+// Windows, ~/Library/Caches/app on macOS, or ~/.cache/app, unless
+// CacheDirOverride is set for app
+func (s *BaseSystem) CacheDir(app string) (string, error) {
+	if dir, ok := s.overridden(s.CacheDirOverride, app); ok {
+		return dir, nil
+	}
+	if dir := s.Getenv("XDG_CACHE_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, app), nil
+	}
+	if dir := s.Getenv("LOCALAPPDATA"); len(dir) > 0 {
+		return filepath.Join(dir, app, "Cache"), nil
+	}
+	if home := s.Getenv("HOME"); len(home) > 0 {
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, "Library", "Caches", app), nil
+		}
+		return filepath.Join(home, ".cache", app), nil
+	}
+	return "", fmt.Errorf("Unable to determine cache directory; HOME is not set")
+}
+
+// DataDir returns the directory app keeps data other than configuration in:
+// $XDG_DATA_HOME/app if set, else %LOCALAPPDATA%\app\Data on Windows,
+// ~/Library/Application Support/app on macOS, or ~/.local/share/app, unless
+// DataDirOverride is set for app
+func (s *BaseSystem) DataDir(app string) (string, error) {
+	if dir, ok := s.overridden(s.DataDirOverride, app); ok {
+		return dir, nil
+	}
+	if dir := s.Getenv("XDG_DATA_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, app), nil
+	}
+	if dir := s.Getenv("LOCALAPPDATA"); len(dir) > 0 {
+		return filepath.Join(dir, app, "Data"), nil
+	}
+	if home := s.Getenv("HOME"); len(home) > 0 {
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, "Library", "Application Support", app), nil
+		}
+		return filepath.Join(home, ".local", "share", app), nil
+	}
+	return "", fmt.Errorf("Unable to determine data directory; HOME is not set")
+}
+
+// runtimeDir returns the directory in which sockets and other per-session
+// files should be created. XDG_RUNTIME_DIR is used if set; otherwise a
+// directory for the user is created within the temporary directory.
+func runtimeDir(sys System) (string, error) {
+	if dir := sys.Getenv("XDG_RUNTIME_DIR"); len(dir) > 0 {
+		return dir, nil
+	}
+	if dir := sys.Getenv("LOCALAPPDATA"); len(dir) > 0 {
+		return filepath.Join(dir, "Temp", "go-cli"), nil
+	}
+
+	return filepath.Join(tempRoot(sys), fmt.Sprintf("go-cli-%d", sys.UID())), nil
+}
+
+// tempRoot returns the directory in which temporary files should be created,
+// taken from the System's environment as os.TempDir takes it from the
+// process's
+func tempRoot(sys interface{ Getenv(string) string }) string {
+	for _, name := range []string{"TMPDIR", "TEMP", "TMP"} {
+		if dir := sys.Getenv(name); len(dir) > 0 {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// dataHome returns the base directory for user data files, following the XDG
+// base directory specification
+func dataHome(sys System) (string, error) {
+	if dir := sys.Getenv("XDG_DATA_HOME"); len(dir) > 0 {
+		return dir, nil
+	}
+	if home := sys.Getenv("HOME"); len(home) > 0 {
+		return filepath.Join(home, ".local", "share"), nil
+	}
+	return "", fmt.Errorf("Unable to determine data directory; HOME is not set")
+}
+
+// configHome returns the base directory for user configuration files,
+// following the XDG base directory specification
+func configHome(sys System) (string, error) {
+	if dir := sys.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return dir, nil
+	}
+	if home := sys.Getenv("HOME"); len(home) > 0 {
+		return filepath.Join(home, ".config"), nil
+	}
+	return "", fmt.Errorf("Unable to determine configuration directory; HOME is not set")
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestAppDirs(t *testing.T) {
+	home := filepath.FromSlash("/home/tester")
+	for _, c := range []struct {
+		environment         map[string]string
+		config, cache, data string
+		onlyLinux           bool
+	}{
+		{
+			environment: map[string]string{
+				"HOME":            home,
+				"XDG_CONFIG_HOME": filepath.FromSlash("/xdg/config"),
+				"XDG_CACHE_HOME":  filepath.FromSlash("/xdg/cache"),
+				"XDG_DATA_HOME":   filepath.FromSlash("/xdg/data"),
+			},
+			config: "/xdg/config/widgets",
+			cache:  "/xdg/cache/widgets",
+			data:   "/xdg/data/widgets",
+		},
+		{
+			environment: map[string]string{
+				"APPDATA":      filepath.FromSlash("/Users/tester/AppData/Roaming"),
+				"LOCALAPPDATA": filepath.FromSlash("/Users/tester/AppData/Local"),
+			},
+			config: "/Users/tester/AppData/Roaming/widgets",
+			cache:  "/Users/tester/AppData/Local/widgets/Cache",
+			data:   "/Users/tester/AppData/Local/widgets/Data",
+		},
+		{
+			environment: map[string]string{"HOME": home},
+			config:      "/home/tester/.config/widgets",
+			cache:       "/home/tester/.cache/widgets",
+			data:        "/home/tester/.local/share/widgets",
+			onlyLinux:   true,
+		},
+	} {
+		if c.onlyLinux && runtime.GOOS != "linux" {
+			continue
+		}
+		system, _ := NewMemorySystem(MemorySystemOptions{Environment: c.environment})
+		for _, d := range []struct {
+			name     string
+			dir      func(string) (string, error)
+			expected string
+		}{
+			{"ConfigDir", system.ConfigDir, c.config},
+			{"CacheDir", system.CacheDir, c.cache},
+			{"DataDir", system.DataDir, c.data},
+		} {
+			dir, err := d.dir("widgets")
+			if err != nil || dir != filepath.FromSlash(d.expected) {
+				t.Errorf("expected %s to return %s, received %s (%v)\n", d.name, filepath.FromSlash(d.expected), dir, err)
+			}
+		}
+	}
+
+	system, _ := NewMemorySystem(MemorySystemOptions{Environment: map[string]string{}})
+	if _, err := system.ConfigDir("widgets"); err == nil {
+		t.Errorf("expected an error without HOME\n")
+	}
+}
+
+type testDirsCommand struct {
+	DefaultHelp
+}
+
+func (c *testDirsCommand) Command(ctx context.Context, args []string, sys System) error {
+	config, _ := sys.ConfigDir("widgets")
+	state, _ := stateDir(sys, "widgets")
+	cache, _ := sys.CacheDir("widgets")
+	data, _ := sys.DataDir("widgets")
+	other, _ := sys.ConfigDir("gadgets")
+	sys.Println(config)
+	sys.Println(state)
+	sys.Println(cache)
+	sys.Println(data)
+	sys.Println(other)
+	return nil
+}
+
+func TestDirFlags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths beginning with a slash aren't absolute on Windows")
+	}
+	home := filepath.FromSlash("/home/tester")
+	run := func(environment map[string]string, args ...string) string {
+		environment["HOME"] = home
+		system, output := NewMemorySystem(MemorySystemOptions{
+			Arguments:   append([]string{"widgets"}, args...),
+			Environment: environment,
+		})
+		s, _ := baseOf(system)
+		s.Dir = filepath.FromSlash("/work")
+		ExpectExitCode(t, Main(context.Background(), &testDirsCommand{}, system, WithDirFlags("widgets")), ExitOK)
+		return output.Stdout.String()
+	}
+
+	expected := filepath.FromSlash("/home/tester/.config/widgets\n/home/tester/.local/state/widgets\n" +
+		"/home/tester/.cache/widgets\n/home/tester/.local/share/widgets\n/home/tester/.config/gadgets\n")
+	if runtime.GOOS == "linux" {
+		if actual := run(map[string]string{}); actual != expected {
+			t.Errorf("expected the default directories:\n%s\nreceived:\n%s", expected, actual)
+		}
+	}
+
+	// only the directories of widgets, not gadgets, are overridden
+	expected = filepath.FromSlash("/work/config\n/sandbox/state\n/work/cache\n/sandbox/data\n")
+	actual := run(map[string]string{
+		"WIDGETS_STATE_DIR": filepath.FromSlash("/sandbox/state"),
+		"WIDGETS_CACHE_DIR": filepath.FromSlash("/sandbox/cache"),
+		"WIDGETS_DATA_DIR":  filepath.FromSlash("/sandbox/data"),
+	}, "--config-dir", "config", "--cache-dir", "cache")
+	if !strings.HasPrefix(actual, expected) || strings.HasSuffix(actual, filepath.FromSlash("/work/config\n")) {
+		t.Errorf("expected the overridden directories:\n%s\nreceived:\n%s", expected, actual)
+	}
+}
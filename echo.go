@@ -0,0 +1,40 @@
+package cli
+
+// SetEcho turns the terminal's echoing of input on or off, for commands
+// which read several values without echoing them. It returns ErrNotTerminal
+// if input isn't attached to a terminal.
+func (s *BaseSystem) SetEcho(on bool) error {
+	f, ok := s.In.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(s.In) {
+		return ErrNotTerminal
+	}
+	return setEcho(int(f.Fd()), on)
+}
+
+// ReadLineNoEcho reads a line of input without echoing it, for values which
+// are sensitive but aren't passwords, such as one-time codes. Unlike
+// ReadPassword the terminal's line editing is kept, and asterisks are never
+// shown. A newline is printed once the line has been read, since the one
+// typed isn't echoed. If input isn't a terminal the line is read as it is.
+func (s *BaseSystem) ReadLineNoEcho() (string, error) {
+	return readLineNoEcho(s, s.SetEcho)
+}
+
+// readLineNoEcho reads a line of the System's input with echo turned off by
+// setEcho
+func readLineNoEcho(s *BaseSystem, setEcho func(on bool) error) (string, error) {
+	if err := setEcho(false); err == ErrNotTerminal {
+		return s.readLine()
+	} else if err != nil {
+		return "", err
+	}
+
+	line, err := s.readLine()
+	if echoErr := setEcho(true); err == nil {
+		err = echoErr
+	}
+	if _, printErr := s.Println(); err == nil {
+		err = printErr
+	}
+	return line, err
+}
@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package cli
+
+import "errors"
+
+func setEcho(fd int, on bool) error {
+	return errors.New("Echo can't be turned off on this platform")
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadLineNoEcho(t *testing.T) {
+	system, output := NewTestSystem(t, []string{"test"}, nil)
+	go func() {
+		system.Console.ExpectString("Code: ")
+		system.Console.SendLine("123456")
+	}()
+
+	// turn echo off before prompting, so that the code can't be sent first
+	if err := system.SetEcho(false); err != nil {
+		t.Fatal(err)
+	}
+	system.Print("Code: ")
+	code, err := system.ReadLineNoEcho()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "123456" {
+		t.Errorf("expected the code sent, received %q\n", code)
+	}
+
+	go system.Console.SendLine("visible")
+	if name, err := readLine(system); err != nil || name != "visible" {
+		t.Fatalf("expected a line after echo was restored, received %q (%v)", name, err)
+	}
+
+	system.Capture()()
+	if echoed := output.STDOUT.String(); strings.Contains(echoed, "123456") || !strings.Contains(echoed, "visible") {
+		t.Errorf("expected only the code not to be echoed, received %q\n", echoed)
+	}
+}
+
+func TestScreenSetEcho(t *testing.T) {
+	system, _ := NewScreenTestSystem(t, []string{"test"}, nil, 2, 20)
+	system.Expecter().SendLine("123456")
+	system.Print("Code: ")
+
+	if code, err := system.ReadLineNoEcho(); err != nil || code != "123456" {
+		t.Fatalf("expected the code sent, received %q (%v)", code, err)
+	}
+	if actual := system.Screen().String(); actual != "Code:" {
+		t.Errorf("expected the code not to be echoed, received %q\n", actual)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package cli
+
+import "golang.org/x/sys/unix"
+
+// setEcho turns the terminal fd's echoing of input on or off, leaving the
+// rest of its settings, such as line editing, as they are
+func setEcho(fd int, on bool) error {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return err
+	}
+	if on {
+		termios.Lflag |= unix.ECHO
+	} else {
+		termios.Lflag &^= unix.ECHO
+	}
+	return unix.IoctlSetTermios(fd, ioctlSetTermios, termios)
+}
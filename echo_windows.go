@@ -0,0 +1,24 @@
+package cli
+
+import "syscall"
+
+// enableEchoInput is the console mode in which Windows echoes input
+const enableEchoInput = 0x4
+
+// setEcho turns the console fd's echoing of input on or off
+func setEcho(fd int, on bool) error {
+	var mode uint32
+	handle := syscall.Handle(fd)
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return err
+	}
+	if on {
+		mode |= enableEchoInput
+	} else {
+		mode &^= enableEchoInput
+	}
+	if r, _, err := setConsoleMode.Call(uintptr(handle), uintptr(mode)); r == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+)
+
+// Edit opens initial in the user's editor, as `git commit` does, and returns
+// the contents once the editor exits. The editor is $VISUAL, or $EDITOR, or
+// else vi, or notepad on Windows, and is run by the shell so that it may
+// include arguments, e.g. `code --wait`. Initial is written to a temporary
+// file named by pattern, as ioutil.TempFile names files, so that the editor
+// can recognize its type from an extension, e.g. `*.yaml`.
+func (s *BaseSystem) Edit(initial []byte, pattern string) ([]byte, error) {
+	return s.edit(initial, pattern, s.Exec)
+}
+
+// edit opens initial in the user's editor, run with run
+func (s *BaseSystem) edit(initial []byte, pattern string, run execFunc) ([]byte, error) {
+	f, err := s.CreateTemp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.Write(initial)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name, args := shellCommand(editor(s) + " " + ShellQuote([]string{path}, scriptShell()))
+	if _, err := run(context.Background(), name, args,
+		ExecStdin(s.In), ExecStdout(s.Out), ExecStderr(s.Logger.Writer()),
+	); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// editor returns the command which runs the user's editor
+func editor(env interface{ Getenv(string) string }) string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if command := env.Getenv(name); len(command) > 0 {
+			return command
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
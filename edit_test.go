@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestFakeEditor(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, nil)
+	system.FakeEditor(func(content []byte) ([]byte, error) {
+		return bytes.Replace(content, []byte("replicas: 1"), []byte("replicas: 3"), 1), nil
+	})
+
+	edited, err := system.Edit([]byte("name: widgets\nreplicas: 1\n"), "*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(edited) != "name: widgets\nreplicas: 3\n" {
+		t.Errorf("expected the fake's edits, received %q\n", edited)
+	}
+}
+
+func TestEditRunsEditor(t *testing.T) {
+	sandbox := Sandbox(t)
+	sandbox.Setenv("EDITOR", "widget-editor --wait")
+	system, _ := sandbox.System([]string{"test"})
+
+	var script string
+	system.FakeExec("*", func(ctx context.Context, call ExecCall) int {
+		script = call.Args[len(call.Args)-1]
+		return 0
+	})
+
+	edited, err := system.Edit([]byte("unchanged\n"), "*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(edited) != "unchanged\n" {
+		t.Errorf("expected the initial content, received %q\n", edited)
+	}
+	if !strings.HasPrefix(script, "widget-editor --wait ") || !strings.Contains(script, ".md") {
+		t.Errorf("expected $EDITOR to be run with a markdown file, received %q\n", script)
+	}
+	if files, _ := ioutil.ReadDir(sandbox.TempDir); len(files) != 0 {
+		t.Errorf("expected the temporary file to be removed, found %d file(s)\n", len(files))
+	}
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnvironment(t *testing.T) {
+	system, _ := NewScreenTestSystem(t, []string{"test"}, map[string]string{"EMPTY": ""}, 5, 40)
+
+	if v, ok := system.LookupEnv("EMPTY"); !ok || v != "" {
+		t.Errorf("expected EMPTY to be set and empty, received %q, %t\n", v, ok)
+	}
+	if _, ok := system.LookupEnv("UNSET"); ok {
+		t.Error("expected UNSET not to be set")
+	}
+
+	if err := system.Setenv("GIT_DIR", "/src/widgets/.git"); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Unsetenv("EMPTY"); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Setenv("A=B", "c"); err == nil {
+		t.Error("expected an error for a name containing =")
+	}
+
+	system.FakeExec("git", FakeOutput("", 0))
+	if _, err := system.Exec(context.Background(), "git", []string{"status"}); err != nil {
+		t.Fatal(err)
+	}
+	env := system.ExecCalls()[0].Env
+	if len(env) != 1 || env[0] != "GIT_DIR=/src/widgets/.git" {
+		t.Errorf("expected programs to inherit the changed environment, received %q\n", env)
+	}
+}
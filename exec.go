@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ExecCall describes a program run with System.Exec. ExecOptions fill it in,
+// and a TestSystem hands it to the ExecFake standing in for the program.
+type ExecCall struct {
+	Name string
+	Args []string
+
+	// Dir is the directory the program runs in, the System's working
+	// directory by default
+	Dir string
+
+	// Env is the program's environment: the System's, followed by any
+	// variables added with ExecEnv
+	Env []string
+
+	// Stdin is read by the program; it reads nothing if nil. Output written
+	// to Stdout and Stderr is captured in the Result unless they are set.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ExecOption configures a program run with System.Exec
+type ExecOption func(*ExecCall)
+
+// ExecDir runs a program in dir, resolved against the System's working
+// directory
+func ExecDir(dir string) ExecOption {
+	return func(c *ExecCall) {
+		c.Dir = dir
+	}
+}
+
+// ExecEnv adds variables, formatted as "KEY=value", to the environment of a
+// program
+func ExecEnv(variables ...string) ExecOption {
+	return func(c *ExecCall) {
+		c.Env = append(c.Env, variables...)
+	}
+}
+
+// ExecStdin attaches r to the input of a program
+func ExecStdin(r io.Reader) ExecOption {
+	return func(c *ExecCall) {
+		c.Stdin = r
+	}
+}
+
+// ExecStdout writes the output of a program to w rather than capturing it
+func ExecStdout(w io.Writer) ExecOption {
+	return func(c *ExecCall) {
+		c.Stdout = w
+	}
+}
+
+// ExecStderr writes the diagnostics of a program to w rather than capturing
+// them
+func ExecStderr(w io.Writer) ExecOption {
+	return func(c *ExecCall) {
+		c.Stderr = w
+	}
+}
+
+// Result describes a program which has exited. Stdout and Stderr hold what it
+// wrote, unless the streams were redirected with ExecStdout or ExecStderr.
+type Result struct {
+	Status int
+	Stdout []byte
+	Stderr []byte
+}
+
+// Exec runs a program and waits for it to exit. Programs are found on the
+// System's PATH. A program which exits with a nonzero status returns an
+// *ExitError with that status, so that a command may return it to exit as the
+// program did.
+func (s *BaseSystem) Exec(ctx context.Context, name string, args []string, opts ...ExecOption) (Result, error) {
+	return s.exec(ctx, name, args, opts, s.runProcess)
+}
+
+// exec prepares a call of Exec and runs it with run, which returns the
+// program's exit status
+func (s *BaseSystem) exec(
+	ctx context.Context, name string, args []string, opts []ExecOption,
+	run func(context.Context, *ExecCall) (int, error),
+) (Result, error) {
+	call := &ExecCall{Name: name, Args: args}
+	for _, opt := range opts {
+		opt(call)
+	}
+	if len(call.Dir) > 0 {
+		call.Dir = s.resolve(call.Dir)
+	} else {
+		call.Dir = s.Dir
+	}
+	call.Env = append(s.Environ(), call.Env...)
+
+	var stdout, stderr bytes.Buffer
+	if call.Stdout == nil {
+		call.Stdout = &stdout
+	}
+	if call.Stderr == nil {
+		call.Stderr = &stderr
+	}
+
+	status, err := run(ctx, call)
+	result := Result{Status: status, Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	if err == nil && status != ExitOK {
+		err = &ExitError{Status: status, Message: fmt.Sprintf(
+			translate(locale(s, "LC_MESSAGES"), "%s exited with status %d"), name, status)}
+	}
+	return result, err
+}
+
+// runProcess runs a call of Exec as a process
+func (s *BaseSystem) runProcess(ctx context.Context, call *ExecCall) (int, error) {
+	path, err := s.lookPath(call.Name)
+	if err != nil {
+		return -1, err
+	}
+
+	cmd := exec.CommandContext(ctx, path, call.Args...)
+	cmd.Dir = call.Dir
+	cmd.Env = call.Env
+	cmd.Stdin = call.Stdin
+	cmd.Stdout = call.Stdout
+	cmd.Stderr = call.Stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if e, ok := err.(*exec.ExitError); ok {
+		return e.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return ExitOK, nil
+}
+
+// lookPath finds a program on the System's PATH rather than the process's,
+// so that the programs a test installs in a sandbox are found
+func (s *BaseSystem) lookPath(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) {
+		return s.resolve(name), nil
+	}
+	path, ok := s.LookupEnv("PATH")
+	if !ok {
+		return exec.LookPath(name)
+	}
+
+	extensions := []string{""}
+	if runtime.GOOS == "windows" && filepath.Ext(name) == "" {
+		extensions = filepath.SplitList(strings.ToLower(s.Getenv("PATHEXT")))
+		if len(extensions) == 0 {
+			extensions = []string{".com", ".exe", ".bat", ".cmd"}
+		}
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if len(dir) == 0 {
+			dir = "."
+		}
+		for _, ext := range extensions {
+			candidate := s.resolve(filepath.Join(dir, name+ext))
+			info, err := os.Stat(candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" || info.Mode()&0111 != 0 {
+				return candidate, nil
+			}
+		}
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testExecCommand struct{}
+
+func (c *testExecCommand) Help() {}
+
+func (c *testExecCommand) Command(ctx context.Context, args []string, s System) error {
+	result, err := s.Exec(ctx, "git", []string{"rev-parse", "HEAD"},
+		ExecDir("repo"), ExecEnv("GIT_PAGER=cat"), ExecStdin(strings.NewReader("input")))
+	if err != nil {
+		return err
+	}
+	_, err = s.Printf("commit %s", result.Stdout)
+	return err
+}
+
+func TestExecFake(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"testexec"}, map[string]string{"HOME": "/home/test"}, 5, 40)
+	system.FakeExec("git", func(ctx context.Context, call ExecCall) int {
+		input, _ := ioutil.ReadAll(call.Stdin)
+		call.Stdout.Write([]byte("abc123 " + string(input) + "\n"))
+		return 0
+	})
+	system.FakeExec("*", FakeOutput("", 127))
+
+	if result := Main(context.Background(), &testExecCommand{}, system); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	ExpectMatch(t, *output.STDOUT, `commit abc123 input`)
+
+	calls := system.ExecCalls()
+	if len(calls) != 1 {
+		t.Fatalf("expected a single call, received %d\n", len(calls))
+	}
+	if calls[0].Name != "git" || !reflect.DeepEqual(calls[0].Args, []string{"rev-parse", "HEAD"}) {
+		t.Errorf("expected git rev-parse HEAD, received %s %q\n", calls[0].Name, calls[0].Args)
+	}
+	if expected := system.resolve("repo"); calls[0].Dir != expected {
+		t.Errorf("expected to run in %s, received %s\n", expected, calls[0].Dir)
+	}
+	if expected := []string{"HOME=/home/test", "GIT_PAGER=cat"}; !reflect.DeepEqual(calls[0].Env, expected) {
+		t.Errorf("expected environment %q, received %q\n", expected, calls[0].Env)
+	}
+}
+
+func TestExecFakeStatus(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"testexec"}, nil, 5, 40)
+	system.FakeExec("*", FakeOutput("", 128))
+
+	if result := Main(context.Background(), &testExecCommand{}, system); result != 128 {
+		t.Errorf("expected the program's status 128, received %d\n", result)
+	}
+	ExpectMatch(t, *output.STDERR, `git exited with status 128`)
+}
+
+func TestExecStub(t *testing.T) {
+	sandbox := Sandbox(t)
+	stub := sandbox.Stub("git", StubResponse{Stdout: "abc123\n", Stderr: "warning\n"})
+	system, _ := sandbox.System([]string{"testexec"})
+	if err := system.MkdirAll("repo", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := system.Exec(context.Background(), "git", []string{"rev-parse", "HEAD"},
+		ExecDir("repo"), ExecEnv("GIT_PAGER=cat"), ExecStdin(strings.NewReader("input")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Stdout) != "abc123\n" || string(result.Stderr) != "warning\n" {
+		t.Errorf("expected the stub's output, received %q and %q\n", result.Stdout, result.Stderr)
+	}
+
+	calls := stub.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected a single call, received %d\n", len(calls))
+	}
+	if !reflect.DeepEqual(calls[0].Args, []string{"rev-parse", "HEAD"}) {
+		t.Errorf("expected rev-parse HEAD, received %q\n", calls[0].Args)
+	}
+	if calls[0].Env["GIT_PAGER"] != "cat" {
+		t.Errorf("expected the call's environment, received %v\n", calls[0].Env)
+	}
+	if calls[0].Stdin != "input" {
+		t.Errorf("expected input, received %q\n", calls[0].Stdin)
+	}
+}
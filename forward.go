@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+)
+
+// Dialer opens a connection to a remote service, e.g. through an SSH client
+// or an API's tunnel
+type Dialer func(ctx context.Context) (net.Conn, error)
+
+// Forward listens on localAddr, e.g. `localhost:8080`, or `localhost:0` for
+// any free port, and proxies each connection accepted through a connection
+// opened with dial, for commands which connect the user to a remote service.
+// The address bound is printed once Forward is listening. A connection which
+// can't be forwarded is logged and closed, and other connections carry on.
+//
+// Forward returns nil once ctx is done or the user interrupts it, closing
+// the listener and every connection it forwarded.
+func Forward(ctx context.Context, sys System, localAddr string, dial Dialer) error {
+	listener, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupts := sys.Notify(ctx, os.Interrupt)
+
+	if _, err := sys.Println(Localize(sys, "Forwarding %s; press Ctrl-C to stop", listener.Addr())); err != nil {
+		listener.Close()
+		return err
+	}
+
+	f := &forwarder{conns: map[net.Conn]struct{}{}}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-interrupts:
+			cancel()
+		}
+		listener.Close()
+		f.closeAll()
+	}()
+
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			stopped := ctx.Err() != nil
+			cancel()
+			f.wg.Wait()
+			if stopped {
+				return nil
+			}
+			return err
+		}
+		if !f.add(local) {
+			continue
+		}
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			defer f.remove(local)
+
+			remote, err := dial(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					sys.Logf(tr(sys, "Unable to forward connection: %s\n"), err)
+				}
+				return
+			}
+			if !f.add(remote) {
+				return
+			}
+			defer f.remove(remote)
+			proxy(local, remote)
+		}()
+	}
+}
+
+// forwarder tracks the connections Forward has open, so that they may be
+// closed when it stops
+type forwarder struct {
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// add tracks conn, closing it and returning false if the forwarder has
+// stopped
+func (f *forwarder) add(conn net.Conn) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		conn.Close()
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	return true
+}
+
+// remove closes conn and stops tracking it
+func (f *forwarder) remove(conn net.Conn) {
+	f.mu.Lock()
+	delete(f.conns, conn)
+	f.mu.Unlock()
+	conn.Close()
+}
+
+// closeAll closes every connection, and those added later
+func (f *forwarder) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for conn := range f.conns {
+		conn.Close()
+	}
+}
+
+// proxy copies data between a and b in both directions until both are done.
+// When one side finishes sending, the other is told, if it can be, so that
+// protocols which half-close a connection work.
+func proxy(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	copyTo := func(dst, src net.Conn) {
+		defer wg.Done()
+		io.Copy(dst, src)
+		if c, ok := dst.(interface{ CloseWrite() error }); ok {
+			c.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+	go copyTo(a, b)
+	go copyTo(b, a)
+	wg.Wait()
+}
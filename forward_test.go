@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// freeAddress returns a local address which was free when it was asked for
+func freeAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func TestForward(t *testing.T) {
+	remote, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	go func() {
+		for {
+			conn, err := remote.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	refuse := true
+	dial := func(ctx context.Context) (net.Conn, error) {
+		if refuse {
+			refuse = false
+			return nil, errors.New("tunnel refused")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", remote.Addr().String())
+	}
+
+	system, output := NewTestSystem(t, nil, nil)
+	address := freeAddress(t)
+	done := make(chan error, 1)
+	go func() {
+		done <- Forward(context.Background(), system, address, dial)
+	}()
+	system.Console.ExpectString("Forwarding " + address + "; press Ctrl-C to stop")
+
+	// the first connection isn't forwarded, and is closed
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected a connection which can't be forwarded to be closed, received %v\n", err)
+	}
+	conn.Close()
+
+	conn, err = net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("ping\n"))
+	if line, err := bufio.NewReader(conn).ReadString('\n'); err != nil || line != "ping\n" {
+		t.Errorf("expected the remote service to echo ping, received %q (%v)\n", line, err)
+	}
+
+	for !system.Signal(os.Interrupt) {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil once interrupted, received %s\n", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Forward did not return once interrupted")
+	}
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Errorf("expected the forwarded connection to be closed\n")
+	}
+	ExpectMatch(t, *output.STDERR, `Unable to forward connection: tunnel refused`)
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// docs holds the descriptions of command types added with RegisterDoc
+var docs = struct {
+	sync.Mutex
+	descriptions map[reflect.Type][2]string
+}{descriptions: map[reflect.Type][2]string{}}
+
+// RegisterDoc sets the synopsis and description of commands of the type of
+// cmd, which are used when the type doesn't implement HasSynopsis or
+// HasDescription. It is called by the code written by GenerateDocs.
+func RegisterDoc(cmd Command, synopsis, description string) {
+	docs.Lock()
+	defer docs.Unlock()
+	docs.descriptions[commandType(cmd)] = [2]string{synopsis, description}
+}
+
+// registeredDoc returns the synopsis and description registered for the type
+// of cmd
+func registeredDoc(cmd Command) (synopsis, description string, ok bool) {
+	docs.Lock()
+	defer docs.Unlock()
+	d, ok := docs.descriptions[commandType(cmd)]
+	return d[0], d[1], ok
+}
+
+// commandType returns the type of cmd, or the type it points to
+func commandType(cmd Command) reflect.Type {
+	t := reflect.TypeOf(cmd)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// GenerateDocs writes to the file output, in the package in dir, the Go
+// source of an init function registering the doc comment of each command type
+// declared in the package as its synopsis and description, so that help and
+// godoc never diverge. Generate the file with go:generate and a program
+// excluded from the build:
+//
+//	//go:generate go run gendoc.go
+//
+//	//go:build ignore
+//	// +build ignore
+//
+//	package main
+//
+//	import (
+//		"log"
+//
+//		cli "github.com/akb/go-cli"
+//	)
+//
+//	func main() {
+//		if err := cli.GenerateDocs(".", "doc_generated.go"); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// A command type is one with a Help or Command method, or which embeds
+// DefaultHelp, and which doesn't implement HasSynopsis or HasDescription. A
+// doc comment begins with the type's name, as godoc would have it, which is
+// left out of help: the doc comment `DeployCommand deploys the site.` is the
+// synopsis `Deploys the site`. The synopsis is the first sentence, and the
+// description the whole comment.
+func GenerateDocs(dir, output string) error {
+	fset := token.NewFileSet()
+	packages, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		if strings.HasSuffix(fi.Name(), "_test.go") || fi.Name() == filepath.Base(output) {
+			return false
+		}
+		// leave out files excluded from the build, such as the generator
+		match, err := build.Default.MatchFile(dir, fi.Name())
+		return err == nil && match
+	}, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	if len(packages) != 1 {
+		return fmt.Errorf("found %d packages in %s, expected 1", len(packages), dir)
+	}
+
+	var source []byte
+	for name, pkg := range packages {
+		p := doc.New(pkg, name, doc.AllDecls)
+		if source, err = generateDocs(p); err != nil {
+			return err
+		}
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+	return ioutil.WriteFile(output, source, 0666)
+}
+
+// generateDocs returns the source of a file registering the doc comments of
+// the command types in p
+func generateDocs(p *doc.Package) ([]byte, error) {
+	var types []*doc.Type
+	for _, t := range p.Types {
+		if ast.IsExported(t.Name) && len(strings.TrimSpace(t.Doc)) > 0 && isCommandType(t) {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by cli.GenerateDocs; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", p.Name)
+	if len(types) == 0 {
+		return format.Source(b.Bytes())
+	}
+	fmt.Fprintf(&b, "import cli %q\n\n", reflect.TypeOf(ExitError{}).PkgPath())
+	fmt.Fprintf(&b, "func init() {\n")
+	for _, t := range types {
+		synopsis, description := commandDoc(t.Name, t.Doc)
+		fmt.Fprintf(&b, "cli.RegisterDoc((*%s)(nil),\n%q,\n%q)\n", t.Name, synopsis, description)
+	}
+	fmt.Fprintf(&b, "}\n")
+	return format.Source(b.Bytes())
+}
+
+// isCommandType reports whether t declares a Help or Command method, or
+// embeds DefaultHelp, and describes itself with neither a Synopsis nor a
+// Description method
+func isCommandType(t *doc.Type) bool {
+	command := false
+	for _, m := range t.Methods {
+		switch m.Name {
+		case "Synopsis", "Description":
+			return false
+		case "Help", "Command":
+			command = true
+		}
+	}
+	if command {
+		return true
+	}
+	for _, spec := range t.Decl.Specs {
+		s, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		st, ok := s.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, f := range st.Fields.List {
+			if len(f.Names) > 0 {
+				continue
+			}
+			if sel, ok := f.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "DefaultHelp" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commandDoc derives the synopsis and description of a command from the doc
+// comment of its type, leaving out the name of the type which begins it.
+// Lines of a paragraph are joined so that help may wrap them; indented
+// blocks, such as examples, are kept as they are.
+func commandDoc(name, comment string) (synopsis, description string) {
+	comment = strings.TrimSpace(comment)
+	if strings.HasPrefix(comment, name+" ") {
+		comment = strings.TrimSpace(comment[len(name):])
+		r, n := utf8.DecodeRuneInString(comment)
+		comment = string(unicode.ToUpper(r)) + comment[n:]
+	}
+
+	paragraphs := strings.Split(comment, "\n\n")
+	for i, p := range paragraphs {
+		if !strings.HasPrefix(p, " ") && !strings.HasPrefix(p, "\t") {
+			paragraphs[i] = strings.Join(strings.Fields(p), " ")
+		}
+	}
+	description = strings.Join(paragraphs, "\n\n")
+	synopsis = strings.TrimSuffix(doc.Synopsis(comment), ".")
+	return synopsis, description
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDocSource = `package commands
+
+import (
+	"context"
+
+	cli "github.com/akb/go-cli"
+)
+
+// DeployCommand deploys the site to a target. It builds the site first.
+//
+// The target is one of
+// staging or production:
+//
+//	deploy staging
+type DeployCommand struct {
+	cli.DefaultHelp
+}
+
+func (c *DeployCommand) Command(ctx context.Context, args []string, sys cli.System) error {
+	return nil
+}
+
+// StatusCommand prints the status of the site.
+type StatusCommand struct{}
+
+func (c StatusCommand) Help() {}
+
+// Synopsis is given, so the doc comment isn't used
+func (c StatusCommand) Synopsis() string {
+	return "Print the status"
+}
+
+// options aren't a command
+type options struct{}
+`
+
+const testDocGenerated = `// Code generated by cli.GenerateDocs; DO NOT EDIT.
+
+package commands
+
+import cli "github.com/akb/go-cli"
+
+func init() {
+	cli.RegisterDoc((*DeployCommand)(nil),
+		"Deploys the site to a target",
+		"Deploys the site to a target. It builds the site first.\n\nThe target is one of staging or production:\n\n\tdeploy staging")
+}
+`
+
+const testDocGenerator = `//go:build ignore
+// +build ignore
+
+package main
+
+// GeneratorCommand isn't in the package
+type GeneratorCommand struct{}
+
+func (c GeneratorCommand) Help() {}
+
+func main() {}
+`
+
+func TestGenerateDocs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "commands.go"), []byte(testDocSource), 0666); err != nil {
+		t.Fatal(err)
+	}
+	// the generator beside the package is excluded from the build
+	if err := ioutil.WriteFile(filepath.Join(dir, "gendoc.go"), []byte(testDocGenerator), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// the generated file is left out when generating it again
+	for i := 0; i < 2; i++ {
+		if err := GenerateDocs(dir, "doc_generated.go"); err != nil {
+			t.Fatal(err)
+		}
+		generated, err := ioutil.ReadFile(filepath.Join(dir, "doc_generated.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(generated) != testDocGenerated {
+			t.Errorf("generated:\n%s\nexpected:\n%s", generated, testDocGenerated)
+		}
+	}
+}
+
+type testDocCommand struct {
+	DefaultHelp
+}
+
+func TestRegisterDoc(t *testing.T) {
+	RegisterDoc((*testDocCommand)(nil), "Does things", "Does things.\n\nMore about them.")
+	defer func() {
+		docs.Lock()
+		delete(docs.descriptions, commandType(&testDocCommand{}))
+		docs.Unlock()
+	}()
+
+	synopsis, description := describe(&testDocCommand{})
+	if synopsis != "Does things" || description != "Does things.\n\nMore about them." {
+		t.Errorf("described as %q, %q", synopsis, description)
+	}
+
+	synopsis, _ = describe(&testSubcommand{})
+	if synopsis == "Does things" {
+		t.Error("described another type with the registered doc")
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// WithGitHubActions reports the outcome of each run to GitHub Actions when the
+// program is run by a workflow. The run's status, duration and counts of
+// items and findings are set as step outputs, which later steps may read as
+// `steps.<id>.outputs.status` etc., and a markdown summary of the run is added
+// to the workflow's summary page. A `--no-github-actions` flag turns the
+// reporting off.
+func WithGitHubActions() Option {
+	return func(c *config) {
+		c.githubActions = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("no-github-actions", false, "don't report to GitHub Actions when run by a workflow")
+		})
+	}
+}
+
+// inGitHubActions reports whether the program is being run by a GitHub
+// Actions workflow
+func inGitHubActions(sys System) bool {
+	return sys.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// reportToGitHubActions adds the outcome of a run to the step outputs and
+// summary of the workflow running the program
+func reportToGitHubActions(sys System, record RunRecord) error {
+	if path := sys.Getenv("GITHUB_OUTPUT"); len(path) > 0 {
+		if err := appendFile(sys, path, githubOutputs(sys, record)); err != nil {
+			return err
+		}
+	}
+	if path := sys.Getenv("GITHUB_STEP_SUMMARY"); len(path) > 0 {
+		var b strings.Builder
+		if err := renderGitHubSummary(&b, record); err != nil {
+			return err
+		}
+		if err := appendFile(sys, path, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// githubOutputs formats the step outputs of a run, in the syntax of the file
+// named by GITHUB_OUTPUT
+func githubOutputs(sys System, record RunRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "status=%d\n", record.Status)
+	fmt.Fprintf(&b, "duration=%.3f\n", record.Duration.Seconds())
+
+	if len(record.Error) > 0 {
+		// a value which may span lines is written between delimiters which
+		// mustn't appear within it
+		delimiter := "EOF_" + sys.RandomID()
+		fmt.Fprintf(&b, "error<<%s\n%s\n%s\n", delimiter, record.Error, delimiter)
+	}
+
+	if len(record.Items) > 0 {
+		failed := 0
+		for _, item := range record.Items {
+			if item.Status != ExitOK {
+				failed++
+			}
+		}
+		fmt.Fprintf(&b, "items=%d\n", len(record.Items))
+		fmt.Fprintf(&b, "failed-items=%d\n", failed)
+	}
+
+	if len(record.Findings) > 0 {
+		levels := map[string]int{}
+		for _, f := range record.Findings {
+			levels[f.Level]++
+		}
+		fmt.Fprintf(&b, "findings=%d\n", len(record.Findings))
+		fmt.Fprintf(&b, "errors=%d\n", levels[FindingError])
+		fmt.Fprintf(&b, "warnings=%d\n", levels[FindingWarning])
+	}
+	return b.String()
+}
+
+// renderGitHubSummary renders a run as markdown for the workflow summary: the
+// markdown output format, followed by the run's items and findings
+func renderGitHubSummary(b *strings.Builder, record RunRecord) error {
+	b.WriteString("### ")
+	if err := renderMarkdown(b, record); err != nil {
+		return err
+	}
+
+	if len(record.Items) > 0 {
+		b.WriteString("\n| Item | Status | Duration |\n| --- | --- | --- |\n")
+		for _, item := range record.Items {
+			status := "✅ succeeded"
+			if item.Status != ExitOK {
+				status = fmt.Sprintf("❌ failed with status %d", item.Status)
+				if len(item.Error) > 0 {
+					status += ": " + item.Error
+				}
+			}
+			fmt.Fprintf(b, "| %s | %s | %s |\n", markdownCell(item.Name), markdownCell(status),
+				item.Duration.Round(time.Millisecond))
+		}
+	}
+
+	if len(record.Findings) > 0 {
+		b.WriteString("\n")
+		for _, f := range record.Findings {
+			location := f.Path
+			if len(location) > 0 && f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", location, f.Line)
+			}
+			if len(location) > 0 {
+				location = markdownCode(location) + " "
+			}
+			fmt.Fprintf(b, "- **%s** %s%s\n", f.Level, location, f.Message)
+		}
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+// markdownCell escapes text for a cell of a markdown table
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+// appendFile adds text to the end of the named file, creating it if need be
+func appendFile(sys System, name, text string) error {
+	data, err := sys.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return sys.WriteFile(name, append(data, text...), 0644)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestGitHubActions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outputs := filepath.Join(dir, "output")
+	summary := filepath.Join(dir, "summary.md")
+	if err := ioutil.WriteFile(summary, []byte("# Deploy\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	environment := map[string]string{
+		"GITHUB_ACTIONS":      "true",
+		"GITHUB_OUTPUT":       outputs,
+		"GITHUB_STEP_SUMMARY": summary,
+	}
+
+	run := func(args ...string) int {
+		cmd := &testTargetsCommand{Targets{Available: []string{"production", "staging"}}}
+		system, _ := NewMemorySystem(MemorySystemOptions{
+			Arguments:   append([]string{"widgets"}, args...),
+			Environment: environment,
+		})
+		return Main(context.Background(), cmd, system, WithGitHubActions())
+	}
+
+	ExpectExitCode(t, run("--all-targets"), 4)
+
+	b, err := ioutil.ReadFile(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, re := range []string{
+		`(?m)^status=4$`,
+		`(?m)^duration=\d+\.\d{3}$`,
+		`(?m)^error<<(EOF_\w+)\n1 of 2 targets failed\n(EOF_\w+)$`,
+		`(?m)^items=2$`,
+		`(?m)^failed-items=1$`,
+	} {
+		if !regexp.MustCompile(re).Match(b) {
+			t.Errorf("expected step outputs to match %s\n%s", re, b)
+		}
+	}
+
+	if b, err = ioutil.ReadFile(summary); err != nil {
+		t.Fatal(err)
+	}
+	for _, re := range []string{
+		"^# Deploy\n### `widgets` failed with status 4: 1 of 2 targets failed\n",
+		`(?m)^\| production \| ✅ succeeded \| \S+ \|$`,
+		`(?m)^\| staging \| ❌ failed with status 4: unreachable \| \S+ \|$`,
+	} {
+		if !regexp.MustCompile(re).Match(b) {
+			t.Errorf("expected the summary to match %q\n%s", re, b)
+		}
+	}
+
+	os.Remove(outputs)
+	ExpectExitCode(t, run("--all-targets", "--no-github-actions"), 4)
+	if _, err := os.Stat(outputs); !os.IsNotExist(err) {
+		t.Errorf("expected --no-github-actions to turn off reporting\n")
+	}
+
+	delete(environment, "GITHUB_ACTIONS")
+	ExpectExitCode(t, run("--all-targets"), 4)
+	if _, err := os.Stat(outputs); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be reported outside of GitHub Actions\n")
+	}
+}
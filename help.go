@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// DefaultHelp may be embedded in a command to have help generated for it
+// from the command's synopsis, flags and subcommands, rather than writing a
+// Help method by hand. A command embedding DefaultHelp which doesn't
+// implement Action shows its help when run.
+type DefaultHelp struct {
+	show func()
+}
+
+// Help displays the generated help. It may be called from within the
+// command's Command method once Main has dispatched to it; it does nothing
+// when the command is used outside of Main.
+func (h DefaultHelp) Help() {
+	if h.show != nil {
+		h.show()
+	}
+}
+
+func (h *DefaultHelp) bindHelp(show func()) {
+	h.show = show
+}
+
+func (DefaultHelp) generatedHelp() {}
+
+// HelpFlag describes a flag within generated help
+type HelpFlag struct {
+	Name string
+
+	// Value is a placeholder for the flag's value; it is empty for boolean
+	// flags
+	Value string
+
+	Usage   string
+	Default string
+}
+
+// HelpData is the information from which help is generated
+type HelpData struct {
+	// Name is the full name of the command, including the program name
+	Name string
+
+	Usage       string
+	Synopsis    string
+	Description string
+	Commands    []Entry
+	Flags       []HelpFlag
+	Examples    []Example
+
+	// Width is the number of columns help is wrapped to. If it is zero, the
+	// System's terminal width is used.
+	Width int
+}
+
+// showHelp displays help for cmd, generating it on w if cmd embeds DefaultHelp
+func showHelp(sys System, w io.Writer, cmd Command, path []string, f *flag.FlagSet, cfg *config) {
+	if _, ok := cmd.(interface{ generatedHelp() }); !ok {
+		cmd.Help()
+		return
+	}
+
+	var builtins CLI
+	if len(path) == 0 {
+		builtins = cfg.subcommands
+	}
+	tmpl := DefaultHelpTemplate
+	if b, ok := cmd.(HasHelpTemplate); ok {
+		tmpl = b.HelpTemplate()
+	} else if len(cfg.helpTemplate) > 0 {
+		tmpl = cfg.helpTemplate
+	}
+
+	data := newHelpData(sys, cmd, path, f, builtins)
+	if err := RenderHelpTemplate(sys, w, tmpl, data); err != nil {
+		sys.Log(err.Error())
+	}
+}
+
+func newHelpData(sys System, cmd Command, path []string, f *flag.FlagSet, builtins CLI) HelpData {
+	var name []string
+	if arguments := sys.Args(); len(arguments) > 0 {
+		name = append(name, programName(arguments[0]))
+	}
+	name = append(name, path...)
+
+	data := HelpData{Name: strings.Join(name, " "), Width: sys.TerminalWidth()}
+	data.Synopsis, data.Description = describe(cmd)
+	if b, ok := cmd.(HasExamples); ok {
+		data.Examples = b.Examples()
+	}
+
+	subcommands := CLI{}
+	if b, ok := cmd.(HasSubcommands); ok {
+		for k, v := range builtins {
+			subcommands[k] = v
+		}
+		for k, v := range b.Subcommands() {
+			subcommands[k] = v
+		}
+	}
+	for _, e := range subcommands.entries("", false, false) {
+		if !e.Hidden {
+			data.Commands = append(data.Commands, e)
+		}
+	}
+
+	if f != nil {
+		f.VisitAll(func(fl *flag.Flag) {
+			value, usage := flag.UnquoteUsage(fl)
+			hf := HelpFlag{Name: fl.Name, Value: value, Usage: usage}
+			switch fl.DefValue {
+			case "", "false", "0", "[]":
+			default:
+				hf.Default = fl.DefValue
+			}
+			data.Flags = append(data.Flags, hf)
+		})
+	}
+
+	usage := []string{data.Name}
+	if len(data.Flags) > 0 {
+		usage = append(usage, "[flags]")
+	}
+	_, runnable := cmd.(Action)
+	switch {
+	case len(data.Commands) > 0 && runnable:
+		usage = append(usage, "[command]")
+	case len(data.Commands) > 0:
+		usage = append(usage, "<command>")
+	case runnable:
+		usage = append(usage, "[arguments]")
+	}
+	data.Usage = strings.Join(usage, " ")
+
+	return data
+}
+
+// DefaultHelpTemplate is the text/template used to render generated help. It
+// is executed with a HelpData value and may use the functions `commands` and
+// `flags`, which format aligned tables of HelpData.Commands and
+// HelpData.Flags, `flagName` and `flagUsage` which format a single HelpFlag,
+// `examples` which formats HelpData.Examples, `wrap` which wraps text to
+// HelpData.Width, `markdown` which renders markdown, such as a help topic,
+// with System.RenderMarkdown, and `t` which translates a message for the
+// System's locale.
+// Tables are wrapped with a hanging indent.
+const DefaultHelpTemplate = `{{t "Usage:"}} {{.Usage}}
+{{- if .Description}}
+
+{{wrap .Description}}
+{{- else if .Synopsis}}
+
+{{wrap .Synopsis}}
+{{- end}}
+{{- if .Commands}}
+
+{{t "Commands:"}}
+{{commands .Commands}}
+{{- end}}
+{{- if .Flags}}
+
+{{t "Flags:"}}
+{{flags .Flags}}
+{{- end}}
+{{- if .Examples}}
+
+{{t "Examples:"}}
+{{examples .Examples}}
+{{- end}}
+`
+
+// HasHelpTemplate is an interface for commands that customize the layout of
+// their generated help
+type HasHelpTemplate interface {
+	// HelpTemplate should return a text/template to be used in place of
+	// DefaultHelpTemplate
+	HelpTemplate() string
+}
+
+// WithHelpTemplate replaces DefaultHelpTemplate for every command which
+// doesn't provide its own template
+func WithHelpTemplate(tmpl string) Option {
+	return func(c *config) {
+		c.helpTemplate = tmpl
+	}
+}
+
+// helpFuncs returns the functions available to help templates, which wrap
+// their output to width and translate messages for the System's locale
+func helpFuncs(sys System, width int) template.FuncMap {
+	return template.FuncMap{
+		"commands": func(entries []Entry) string {
+			rows := make([][2]string, len(entries))
+			for i, e := range entries {
+				rows[i] = [2]string{e.Name, e.Synopsis}
+			}
+			return columns(rows, width)
+		},
+		"flags": func(flags []HelpFlag) string {
+			rows := make([][2]string, len(flags))
+			for i, fl := range flags {
+				rows[i] = [2]string{flagName(fl), flagUsage(fl)}
+			}
+			return columns(rows, width)
+		},
+		"flagName":  flagName,
+		"flagUsage": flagUsage,
+		"examples": func(examples []Example) string {
+			return formatExamples(examples, width)
+		},
+		"wrap": func(text string) string {
+			return Wrap(text, width, "")
+		},
+		"markdown": func(text string) string {
+			return sys.RenderMarkdown(text)
+		},
+		"t": func(message string) string {
+			return tr(sys, message)
+		},
+	}
+}
+
+// RenderHelp writes help generated from data to w using DefaultHelpTemplate
+func RenderHelp(sys System, w io.Writer, data HelpData) error {
+	return RenderHelpTemplate(sys, w, DefaultHelpTemplate, data)
+}
+
+// RenderHelpTemplate writes help generated from data to w using the given
+// text/template. Help the user asked for belongs on the System's Stdout, and
+// usage shown for a mistake on its Stderr.
+func RenderHelpTemplate(sys System, w io.Writer, tmpl string, data HelpData) error {
+	if data.Width <= 0 {
+		data.Width = sys.TerminalWidth()
+	}
+
+	t, err := template.New("help").Funcs(helpFuncs(sys, data.Width)).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func flagName(fl HelpFlag) string {
+	name := "--" + fl.Name
+	if len(fl.Name) == 1 {
+		name = "-" + fl.Name
+	}
+	if len(fl.Value) > 0 {
+		name += " " + fl.Value
+	}
+	return name
+}
+
+func flagUsage(fl HelpFlag) string {
+	if len(fl.Default) > 0 {
+		return fmt.Sprintf("%s (default %s)", fl.Usage, fl.Default)
+	}
+	return fl.Usage
+}
+
+// formatExamples formats examples as indented, copyable command lines, each
+// preceded by its description as a shell comment
+func formatExamples(examples []Example, width int) string {
+	available := width - len("  # ")
+	if available < minWrapWidth {
+		available = minWrapWidth
+	}
+
+	blocks := make([]string, len(examples))
+	for i, e := range examples {
+		var b strings.Builder
+		if len(e.Description) > 0 {
+			for _, line := range wrapText(e.Description, available) {
+				fmt.Fprintf(&b, "  # %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "  $ %s", e.Command)
+		blocks[i] = b.String()
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// minWrapWidth is the narrowest a column of text is wrapped to; narrower
+// terminals get overlong lines rather than one word per line
+const minWrapWidth = 20
+
+// columns formats indented rows of two columns, aligning the second and
+// wrapping it to width with a hanging indent
+func columns(rows [][2]string, width int) string {
+	var b strings.Builder
+	first := 0
+	for _, row := range rows {
+		if n := utf8.RuneCountInString(row[0]); n > first {
+			first = n
+		}
+	}
+
+	indent := strings.Repeat(" ", 2+first+2)
+	available := width - len(indent)
+	if available < minWrapWidth {
+		available = minWrapWidth
+	}
+
+	for _, row := range rows {
+		if len(row[1]) == 0 {
+			fmt.Fprintf(&b, "  %s\n", row[0])
+			continue
+		}
+
+		pad := strings.Repeat(" ", first-utf8.RuneCountInString(row[0]))
+		for i, line := range wrapText(row[1], available) {
+			if i == 0 {
+				fmt.Fprintf(&b, "  %s%s  %s\n", row[0], pad, line)
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s\n", indent, line)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+)
+
+type testHelpCommand struct {
+	DefaultHelp
+
+	verbose bool
+	output  string
+}
+
+func (c *testHelpCommand) Synopsis() string { return "Manage widgets" }
+
+func (c *testHelpCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.verbose, "v", false, "print more")
+	f.StringVar(&c.output, "output", "text", "output `format`")
+}
+
+func (c *testHelpCommand) Subcommands() CLI {
+	return CLI{"list": &testHelpSubcommand{}}
+}
+
+type testHelpSubcommand struct {
+	DefaultHelp
+}
+
+func (c *testHelpSubcommand) Synopsis() string { return "List widgets" }
+
+func (c *testHelpSubcommand) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestDefaultHelp(t *testing.T) {
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets", "--help"},
+		WithVersion(VersionInfo{Version: "1.0.0"}))
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `Usage: widgets \[flags\] <command>`)
+	ExpectMatch(t, *output.STDOUT, `Manage widgets`)
+	ExpectMatch(t, *output.STDOUT, `list +List widgets`)
+	ExpectMatch(t, *output.STDOUT, `version +Print version information`)
+	ExpectMatch(t, *output.STDOUT, `--output format +output format \(default text\)`)
+	ExpectMatch(t, *output.STDOUT, `-v +print more`)
+}
+
+func TestUsageOnStderr(t *testing.T) {
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets", "--bogus"})
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.STDERR, `Usage: widgets \[flags\] <command>`)
+	if output.STDOUT.Len() > 0 {
+		t.Errorf("expected usage for a mistake not to be written to STDOUT\n%s", output.STDOUT)
+	}
+}
+
+func TestParentFlagsInHelp(t *testing.T) {
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets", "help"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `(?s)Commands:.*list +List widgets.*Flags:.*`+
+		`--output format +output format \(default text\).*-v +print more`)
+}
+
+func TestHelpForNonAction(t *testing.T) {
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `Usage: widgets`)
+
+	result, output = runMain(t, &testHelpCommand{}, []string{"widgets", "bogus"})
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.STDERR, `Unknown command: bogus`)
+}
+
+type testHelpGroup struct {
+	*testCommand
+}
+
+func (c *testHelpGroup) Help() {
+	c.helpDidRun = true
+}
+
+func (c *testHelpGroup) Subcommands() CLI {
+	return CLI{"list": &testHelpSubcommand{}}
+}
+
+func TestNonActionWithoutDefaultHelp(t *testing.T) {
+	cmd := &testHelpGroup{&testCommand{}}
+	result, output := runMain(t, cmd, []string{"widgets", "bogus"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	if cmd.helpDidRun || output.STDOUT.Len() > 0 {
+		t.Errorf("help was shown but should not have been\n")
+	}
+}
+
+type testHelpCallingCommand struct {
+	DefaultHelp
+}
+
+func (c *testHelpCallingCommand) Synopsis() string { return "Needs an argument" }
+
+func (c *testHelpCallingCommand) Command(ctx context.Context, args []string, s System) error {
+	if len(args) == 0 {
+		c.Help()
+	}
+	return nil
+}
+
+func TestDefaultHelpCalledByCommand(t *testing.T) {
+	result, output := runMain(t, &testHelpCallingCommand{}, []string{"needy"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `Usage: needy`)
+	ExpectMatch(t, *output.STDOUT, `Needs an argument`)
+}
+
+func TestHelpTemplate(t *testing.T) {
+	tmpl := `{{.Name}} - {{.Synopsis}}
+{{range .Commands}}* {{.Name}}
+{{end}}`
+	result, output := runMain(t, &testHelpCommand{}, []string{"widgets", "help"},
+		WithHelpTemplate(tmpl))
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `^widgets - Manage widgets\r?\n\* list`)
+}
+
+type testDescribedCommand struct {
+	DefaultHelp
+}
+
+func (c *testDescribedCommand) Description() string {
+	return `Remove widgets permanently.
+
+Removed widgets cannot be recovered.`
+}
+
+func (c *testDescribedCommand) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestDescription(t *testing.T) {
+	entries := CLI{"remove": &testDescribedCommand{}}.Entries("")
+	if entries[0].Synopsis != "Remove widgets permanently." {
+		t.Errorf("expected synopsis from description, received %q\n", entries[0].Synopsis)
+	}
+
+	result, output := runMain(t, &testDescribedCommand{}, []string{"remove", "-h"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `Removed widgets cannot be recovered\.`)
+}
+
+type testExamplesCommand struct {
+	DefaultHelp
+}
+
+func (c *testExamplesCommand) Synopsis() string { return "Deploy widgets" }
+
+func (c *testExamplesCommand) Examples() []Example {
+	return []Example{
+		{Description: "Deploy the current branch to staging", Command: "widgets deploy staging"},
+		{Command: "widgets deploy --all"},
+	}
+}
+
+func (c *testExamplesCommand) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestExamples(t *testing.T) {
+	entries := CLI{"deploy": &testExamplesCommand{}}.Entries("")
+	if len(entries[0].Examples) != 2 {
+		t.Errorf("expected examples in the entry, received %+v\n", entries[0].Examples)
+	}
+
+	result, output := runMain(t, &testExamplesCommand{}, []string{"widgets", "--help"})
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	expected := "Examples:\r\n" +
+		"  # Deploy the current branch to staging\r\n" +
+		"  $ widgets deploy staging\r\n\r\n" +
+		"  $ widgets deploy --all\r\n"
+	if got := output.STDOUT.String(); !strings.HasSuffix(got, expected) {
+		t.Errorf("unexpected help\n%s", diffLines(expected, got))
+	}
+}
+
+type testWideHelpCommand struct {
+	DefaultHelp
+
+	output string
+}
+
+func (c *testWideHelpCommand) Synopsis() string {
+	return "Synchronize widgets between the local inventory and the remote warehouse"
+}
+
+func (c *testWideHelpCommand) Flags(f *flag.FlagSet) {
+	f.StringVar(&c.output, "output", "text",
+		"output `format` for the synchronization report, one of text or json")
+}
+
+func TestHelpWrapping(t *testing.T) {
+	system, output := NewTestSystem(t, []string{"widgets", "--help"}, nil)
+	system.Width = 40
+
+	wait := system.Capture()
+	result := Main(context.Background(), &testWideHelpCommand{}, system)
+	wait()
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	expected := "Usage: widgets [flags]\r\n\r\n" +
+		"Synchronize widgets between the local\r\n" +
+		"inventory and the remote warehouse\r\n\r\n" +
+		"Flags:\r\n" +
+		"  --output format  output format for the\r\n" +
+		"                   synchronization\r\n" +
+		"                   report, one of text\r\n" +
+		"                   or json (default\r\n" +
+		"                   text)\r\n"
+	if got := output.STDOUT.String(); got != expected {
+		t.Errorf("unexpected help\n%s", diffLines(expected, got))
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, map[string]string{"COLUMNS": "100"})
+	if width := system.TerminalWidth(); width != 100 {
+		t.Errorf("expected the width from COLUMNS, received %d\n", width)
+	}
+
+	system.Width = 33
+	if width := system.TerminalWidth(); width != 33 {
+		t.Errorf("expected the pinned width, received %d\n", width)
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"sort"
+)
+
+// WithCurl adds a `--curl` flag which prints each request made with the
+// System's HTTPClient as an equivalent curl command, so that users may
+// reproduce and report problems with an API outside the program. Passwords,
+// tokens and other secrets are redacted from URLs, headers and form and JSON
+// bodies.
+func WithCurl() Option {
+	return func(c *config) {
+		c.curl = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("curl", false, "print HTTP requests as curl commands")
+		})
+	}
+}
+
+// HTTPClient returns a client which sends requests with Transport, tracing
+// them as the framework's flags ask
+func (s *BaseSystem) HTTPClient() *http.Client {
+	transport := s.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if s.DebugHTTP {
+		transport = &debugTransport{sys: s, next: transport}
+	}
+	if s.EchoCurl {
+		transport = &curlTransport{sys: s, next: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// curlTransport prints each request as a curl command before sending it
+type curlTransport struct {
+	sys  *BaseSystem
+	next http.RoundTripper
+}
+
+func (t *curlTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.sys.Eprintln(ShellQuote(curlArgs(req), t.sys.Shell()))
+	return t.next.RoundTrip(req)
+}
+
+// curlArgs returns the arguments of a curl command which makes req, with
+// secrets redacted. The body is included if it may be read again, as it may
+// be for requests made with http.NewRequest.
+func curlArgs(req *http.Request) []string {
+	args := []string{"curl"}
+	if req.Method != http.MethodGet && len(req.Method) > 0 {
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, redactURL(req.URL))
+
+	if len(req.Host) > 0 && req.Host != req.URL.Host {
+		args = append(args, "-H", "Host: "+req.Host)
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			args = append(args, "-H", name+": "+redactHeader(name, value))
+		}
+	}
+
+	if body, ok := requestBody(req); ok {
+		args = append(args, "--data-binary", string(redactBody(req.Header.Get("Content-Type"), body)))
+	}
+	return args
+}
+
+// requestBody returns the body of req if it has one which may be read again
+// without consuming it
+func requestBody(req *http.Request) ([]byte, bool) {
+	if req.GetBody == nil || req.ContentLength == 0 {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	return b, err == nil
+}
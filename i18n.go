@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var catalog = struct {
+	sync.RWMutex
+	messages map[string]map[string]string
+}{messages: map[string]map[string]string{}}
+
+// RegisterTranslations adds translations for a locale, such as `fr` or
+// `pt_BR`, to the message catalog. Messages are keyed by their English
+// format strings, as passed to Localize; the translations must use the same
+// formatting verbs in the same order, although `%[n]s` style indexes may be
+// used to reorder them. Applications may register translations of their own
+// messages as well as replace those built into the library.
+func RegisterTranslations(locale string, messages map[string]string) {
+	catalog.Lock()
+	defer catalog.Unlock()
+
+	locale = normalizeLocale(locale)
+	m, ok := catalog.messages[locale]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		catalog.messages[locale] = m
+	}
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// Localize formats a message for the System's locale, which is taken from the
+// LC_ALL, LC_MESSAGES or LANG environment variables. A translation for the
+// full locale (e.g. `pt_BR`) is preferred over one for its language (`pt`);
+// the English format is used when neither has been registered.
+func Localize(sys System, format string, a ...interface{}) string {
+	return fmt.Sprintf(translate(locale(sys, "LC_MESSAGES"), format), a...)
+}
+
+// tr returns the translation of format for the System's locale, for use with
+// the System's formatting methods
+func tr(sys System, format string) string {
+	return translate(locale(sys, "LC_MESSAGES"), format)
+}
+
+// translate returns the translation of format for locale
+func translate(locale, format string) string {
+	locale = normalizeLocale(locale)
+	if len(locale) == 0 {
+		return format
+	}
+
+	catalog.RLock()
+	defer catalog.RUnlock()
+
+	candidates := []string{locale}
+	if i := strings.IndexByte(locale, '_'); i >= 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	for _, c := range candidates {
+		if t, ok := catalog.messages[c][format]; ok {
+			return t
+		}
+	}
+	return format
+}
+
+// normalizeLocale reduces a locale identifier such as `fr_CA.UTF-8@euro` to
+// its language and territory, `fr_CA`
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.Replace(locale, "-", "_", -1)
+
+	switch locale {
+	case "C", "POSIX":
+		return ""
+	}
+	if i := strings.IndexByte(locale, '_'); i >= 0 {
+		return strings.ToLower(locale[:i]) + "_" + strings.ToUpper(locale[i+1:])
+	}
+	return strings.ToLower(locale)
+}
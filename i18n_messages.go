@@ -0,0 +1,358 @@
+package cli
+
+// Translations of the library's own messages
+func init() {
+	RegisterTranslations("de", map[string]string{
+		"Usage:":    "Verwendung:",
+		"Commands:": "Befehle:",
+		"Flags:":    "Optionen:",
+		"Examples:": "Beispiele:",
+
+		"Failed to parse command-line arguments:\n%s\n": "Befehlszeilenargumente konnten nicht verarbeitet werden:\n%s\n",
+		"Unknown command: %s\n":                         "Unbekannter Befehl: %s\n",
+		"Unknown output format: %s\n":                   "Unbekanntes Ausgabeformat: %s\n",
+		"Unable to render output: %s\n":                 "Ausgabe konnte nicht aufbereitet werden: %s\n",
+		"Pipeline failed: %s\n":                         "Pipeline fehlgeschlagen: %s\n",
+		"Pager failed: %s\n":                            "Pager fehlgeschlagen: %s\n",
+		"Interrupted":                                   "Abgebrochen",
+
+		"Unknown report format: %s":                                "Unbekanntes Berichtsformat: %s",
+		"Reports are named as format:path, e.g. junit:results.xml": "Berichte werden als Format:Pfad angegeben, z. B. junit:results.xml",
+		"Unable to write report: %s\n":                             "Bericht konnte nicht geschrieben werden: %s\n",
+		"Unable to report to GitHub Actions: %s\n":                 "Bericht an GitHub Actions fehlgeschlagen: %s\n",
+		"Unable to write metrics: %s\n":                            "Metriken konnten nicht geschrieben werden: %s\n",
+		"Unknown log target: %s":                                   "Unbekanntes Protokollziel: %s",
+		"Unable to log to %s: %s":                                  "Protokollierung nach %s nicht möglich: %s",
+		"Unable to open debug log: %s\n":                           "Debug-Protokoll konnte nicht geöffnet werden: %s\n",
+		"Nothing has been logged":                                  "Es wurde nichts protokolliert",
+		"%s exited with status %d":                                 "%s wurde mit Status %d beendet",
+
+		"Kept temporary files in %s\n":           "Temporäre Dateien in %s behalten\n",
+		"Unable to remove temporary files: %s\n": "Temporäre Dateien konnten nicht entfernt werden: %s\n",
+
+		"%s was updated to %s. Show what's new?": "%s wurde auf %s aktualisiert. Neuigkeiten anzeigen?",
+		"Unable to show what's new: %s\n":        "Neuigkeiten konnten nicht angezeigt werden: %s\n",
+
+		"Refusing to continue without confirmation; use --yes to override": "Ohne Bestätigung wird nicht fortgefahren; mit --yes überspringen",
+		"This action cannot be undone. Type %q to confirm: ":               "Diese Aktion kann nicht rückgängig gemacht werden. Zur Bestätigung %q eingeben: ",
+		"Confirmation did not match %q; aborting":                          "Bestätigung stimmt nicht mit %q überein; Abbruch",
+		"Not a number: %q": "Keine Zahl: %q",
+
+		"Confirm password: ":                "Passwort bestätigen: ",
+		"Passwords do not match; try again": "Die Passwörter stimmen nicht überein; bitte erneut versuchen",
+		"Passwords did not match":           "Die Passwörter stimmten nicht überein",
+
+		"Authentication code: ":                               "Authentifizierungscode: ",
+		"Enter the 6 to 8 digit code from your authenticator": "Den 6- bis 8-stelligen Code aus der Authentifizierungs-App eingeben",
+		"No valid authentication code was entered":            "Es wurde kein gültiger Authentifizierungscode eingegeben",
+
+		"Your session has expired; logging in again": "Ihre Sitzung ist abgelaufen; erneute Anmeldung",
+		"%s; log in again to continue":               "%s; zum Fortfahren erneut anmelden",
+
+		"Your credentials were refused; try logging in again":                 "Ihre Anmeldedaten wurden abgelehnt; melden Sie sich erneut an",
+		"You don't have permission to do this":                                "Sie haben keine Berechtigung dafür",
+		"Try again in %s":                                                     "In %s erneut versuchen",
+		"Try again later":                                                     "Später erneut versuchen",
+		"The service failed; if this persists, report it with the request ID": "Der Dienst ist fehlgeschlagen; melden Sie es mit der Anfrage-ID, falls es weiterhin auftritt",
+		"Check that the name or ID is correct":                                "Prüfen Sie, ob der Name oder die ID stimmt",
+		"Request ID: %s":                                                      "Anfrage-ID: %s",
+
+		"Unable to connect: %s; retrying in %s\n":           "Verbindung nicht möglich: %s; neuer Versuch in %s\n",
+		"Connection lost: %s; reconnecting in %s\n":         "Verbindung verloren: %s; erneute Verbindung in %s\n",
+		"Unsupported stream URL: %s":                        "Nicht unterstützte Stream-URL: %s",
+		"The server did not accept the websocket handshake": "Der Server hat den WebSocket-Handshake nicht angenommen",
+
+		"Forwarding %s; press Ctrl-C to stop": "Weiterleitung von %s; mit Strg+C beenden",
+		"Unable to forward connection: %s\n":  "Verbindung konnte nicht weitergeleitet werden: %s\n",
+
+		"Serving %s; press Ctrl-C to stop":      "%s wird bereitgestellt; mit Strg+C beenden",
+		"Unable to open a browser: %s\n":        "Browser konnte nicht geöffnet werden: %s\n",
+		"Stopping after %s without a request\n": "Beendet nach %s ohne Anfrage\n",
+
+		"Unable to compare version %q with the minimum the server supports, %s\n":                     "Version %q kann nicht mit der vom Server mindestens unterstützten Version %s verglichen werden\n",
+		"Version %s is no longer supported by the server, which requires %s or later; please upgrade": "Version %s wird vom Server nicht mehr unterstützt, der %s oder neuer verlangt; bitte aktualisieren",
+		"Compatible with the server, which requires %s or later":                                      "Kompatibel mit dem Server, der %s oder neuer verlangt",
+
+		"Invalid --format: %s":                    "Ungültiges --format: %s",
+		"--format can't be used with --output %s": "--format kann nicht zusammen mit --output %s verwendet werden",
+
+		"ETA %s": "noch %s",
+
+		"Unable to record usage: %s\n": "Nutzung konnte nicht gezählt werden: %s\n",
+		"Uses counted since %s":        "Nutzung gezählt seit %s",
+		"No uses have been counted":    "Es wurde noch keine Nutzung gezählt",
+
+		"Enter one record per line as %s, then an empty line to finish:": "Einen Datensatz pro Zeile als %s eingeben, zum Abschluss eine leere Zeile:",
+
+		"Open %s in your browser":           "%s im Browser öffnen",
+		"Unable to edit without a terminal": "Bearbeiten ohne Terminal nicht möglich",
+
+		"%s already exists; use --force to overwrite it or --skip to keep it": "%s existiert bereits; mit --force überschreiben oder mit --skip behalten",
+		"%s already exists: (o)verwrite, (s)kip, (d)iff or (a)bort? ":         "%s existiert bereits: (o) überschreiben, (s) überspringen, (d) Unterschiede oder (a) abbrechen? ",
+		"--force and --skip can't be used together":                           "--force und --skip können nicht zusammen verwendet werden",
+		"Aborted":   "Abgebrochen",
+		"create":    "erstellen",
+		"identical": "identisch",
+		"skip":      "überspringen",
+		"overwrite": "überschreiben",
+
+		"Rolling back %d change(s)\n":    "%d Änderung(en) werden zurückgenommen\n",
+		"Rollback %d of %d failed: %s\n": "Zurücknahme %d von %d fehlgeschlagen: %s\n",
+		"Rollback %d of %d complete\n":   "Zurücknahme %d von %d abgeschlossen\n",
+
+		"Nothing to do.":                  "Nichts zu tun.",
+		"Plan: %d operation(s)\n":         "Plan: %d Vorgang/Vorgänge\n",
+		"Apply these changes?":            "Diese Änderungen anwenden?",
+		"Plan was not applied":            "Plan wurde nicht angewendet",
+		"done":                            "erledigt",
+		"failed":                          "fehlgeschlagen",
+		"Step %d of %d failed":            "Schritt %d von %d fehlgeschlagen",
+		"%d of %d targets failed":         "%d von %d Zielen fehlgeschlagen",
+		"Unable to cache result: %s\n":    "Ergebnis konnte nicht zwischengespeichert werden: %s\n",
+		"Unable to save run record: %s\n": "Ausführungsprotokoll konnte nicht gespeichert werden: %s\n",
+
+		"Unable to read response file: %s":               "Antwortdatei konnte nicht gelesen werden: %s",
+		"Invalid response file %s: %s":                   "Ungültige Antwortdatei %s: %s",
+		"No completion action given; see --help":         "Keine Vervollständigungsaktion angegeben; siehe --help",
+		"Installed completion for %s at %s\n":            "Vervollständigung für %s unter %s installiert\n",
+		"Completion for %s is already installed at %s\n": "Vervollständigung für %s ist bereits unter %s installiert\n",
+		"Invalid run number: %s":                         "Ungültige Ausführungsnummer: %s",
+		"Only %d run(s) recorded":                        "Nur %d Ausführung(en) protokolliert",
+	})
+
+	RegisterTranslations("es", map[string]string{
+		"Usage:":    "Uso:",
+		"Commands:": "Comandos:",
+		"Flags:":    "Opciones:",
+		"Examples:": "Ejemplos:",
+
+		"Failed to parse command-line arguments:\n%s\n": "No se pudieron interpretar los argumentos:\n%s\n",
+		"Unknown command: %s\n":                         "Comando desconocido: %s\n",
+		"Unknown output format: %s\n":                   "Formato de salida desconocido: %s\n",
+		"Unable to render output: %s\n":                 "No se pudo presentar la salida: %s\n",
+		"Pipeline failed: %s\n":                         "La tubería falló: %s\n",
+		"Pager failed: %s\n":                            "Falló el paginador: %s\n",
+		"Interrupted":                                   "Interrumpido",
+
+		"Unknown report format: %s":                                "Formato de informe desconocido: %s",
+		"Reports are named as format:path, e.g. junit:results.xml": "Los informes se indican como formato:ruta, p. ej. junit:results.xml",
+		"Unable to write report: %s\n":                             "No se pudo escribir el informe: %s\n",
+		"Unable to report to GitHub Actions: %s\n":                 "No se pudo informar a GitHub Actions: %s\n",
+		"Unable to write metrics: %s\n":                            "No se pudieron escribir las métricas: %s\n",
+		"Unknown log target: %s":                                   "Destino de registro desconocido: %s",
+		"Unable to log to %s: %s":                                  "No se puede registrar en %s: %s",
+		"Unable to open debug log: %s\n":                           "No se pudo abrir el registro de depuración: %s\n",
+		"Nothing has been logged":                                  "No se ha registrado nada",
+		"%s exited with status %d":                                 "%s terminó con el estado %d",
+
+		"Kept temporary files in %s\n":           "Se conservaron los archivos temporales en %s\n",
+		"Unable to remove temporary files: %s\n": "No se pudieron eliminar los archivos temporales: %s\n",
+
+		"%s was updated to %s. Show what's new?": "%s se actualizó a %s. ¿Mostrar las novedades?",
+		"Unable to show what's new: %s\n":        "No se pudieron mostrar las novedades: %s\n",
+
+		"Refusing to continue without confirmation; use --yes to override": "No se continuará sin confirmación; use --yes para omitirla",
+		"This action cannot be undone. Type %q to confirm: ":               "Esta acción no se puede deshacer. Escriba %q para confirmar: ",
+		"Confirmation did not match %q; aborting":                          "La confirmación no coincide con %q; cancelando",
+		"Not a number: %q": "No es un número: %q",
+
+		"Confirm password: ":                "Confirme la contraseña: ",
+		"Passwords do not match; try again": "Las contraseñas no coinciden; inténtelo de nuevo",
+		"Passwords did not match":           "Las contraseñas no coincidieron",
+
+		"Authentication code: ":                               "Código de autenticación: ",
+		"Enter the 6 to 8 digit code from your authenticator": "Introduzca el código de 6 a 8 dígitos de su aplicación de autenticación",
+		"No valid authentication code was entered":            "No se introdujo ningún código de autenticación válido",
+
+		"Your session has expired; logging in again": "Su sesión ha caducado; iniciando sesión de nuevo",
+		"%s; log in again to continue":               "%s; inicie sesión de nuevo para continuar",
+
+		"Your credentials were refused; try logging in again":                 "Se rechazaron sus credenciales; intente iniciar sesión de nuevo",
+		"You don't have permission to do this":                                "No tiene permiso para hacer esto",
+		"Try again in %s":                                                     "Inténtelo de nuevo en %s",
+		"Try again later":                                                     "Inténtelo de nuevo más tarde",
+		"The service failed; if this persists, report it with the request ID": "El servicio falló; si persiste, notifíquelo con el ID de la solicitud",
+		"Check that the name or ID is correct":                                "Compruebe que el nombre o el ID sean correctos",
+		"Request ID: %s":                                                      "ID de la solicitud: %s",
+
+		"Unable to connect: %s; retrying in %s\n":           "No se pudo conectar: %s; reintentando en %s\n",
+		"Connection lost: %s; reconnecting in %s\n":         "Conexión perdida: %s; reconectando en %s\n",
+		"Unsupported stream URL: %s":                        "URL de flujo no admitida: %s",
+		"The server did not accept the websocket handshake": "El servidor no aceptó el protocolo de enlace de WebSocket",
+
+		"Forwarding %s; press Ctrl-C to stop": "Reenviando %s; pulse Ctrl-C para detener",
+		"Unable to forward connection: %s\n":  "No se pudo reenviar la conexión: %s\n",
+
+		"Serving %s; press Ctrl-C to stop":      "Sirviendo %s; pulse Ctrl-C para detener",
+		"Unable to open a browser: %s\n":        "No se pudo abrir un navegador: %s\n",
+		"Stopping after %s without a request\n": "Deteniendo tras %s sin peticiones\n",
+
+		"Unable to compare version %q with the minimum the server supports, %s\n":                     "No se pudo comparar la versión %q con la mínima que admite el servidor, %s\n",
+		"Version %s is no longer supported by the server, which requires %s or later; please upgrade": "El servidor ya no admite la versión %s y requiere %s o posterior; actualice",
+		"Compatible with the server, which requires %s or later":                                      "Compatible con el servidor, que requiere %s o posterior",
+
+		"Invalid --format: %s":                    "--format no válido: %s",
+		"--format can't be used with --output %s": "--format no se puede usar con --output %s",
+
+		"ETA %s": "faltan %s",
+
+		"Unable to record usage: %s\n": "No se pudo contar el uso: %s\n",
+		"Uses counted since %s":        "Usos contados desde %s",
+		"No uses have been counted":    "No se ha contado ningún uso",
+
+		"Enter one record per line as %s, then an empty line to finish:": "Introduzca un registro por línea como %s y una línea vacía para terminar:",
+
+		"Open %s in your browser":           "Abra %s en su navegador",
+		"Unable to edit without a terminal": "No se puede editar sin una terminal",
+
+		"%s already exists; use --force to overwrite it or --skip to keep it": "%s ya existe; use --force para sobrescribirlo o --skip para conservarlo",
+		"%s already exists: (o)verwrite, (s)kip, (d)iff or (a)bort? ":         "%s ya existe: (o) sobrescribir, (s) omitir, (d) diferencias o (a) cancelar? ",
+		"--force and --skip can't be used together":                           "--force y --skip no se pueden usar juntos",
+		"Aborted":   "Cancelado",
+		"create":    "crear",
+		"identical": "idéntico",
+		"skip":      "omitir",
+		"overwrite": "sobrescribir",
+
+		"Rolling back %d change(s)\n":    "Revirtiendo %d cambio(s)\n",
+		"Rollback %d of %d failed: %s\n": "La reversión %d de %d falló: %s\n",
+		"Rollback %d of %d complete\n":   "Reversión %d de %d completada\n",
+
+		"Nothing to do.":                  "Nada que hacer.",
+		"Plan: %d operation(s)\n":         "Plan: %d operación(es)\n",
+		"Apply these changes?":            "¿Aplicar estos cambios?",
+		"Plan was not applied":            "El plan no se aplicó",
+		"done":                            "hecho",
+		"failed":                          "falló",
+		"Step %d of %d failed":            "El paso %d de %d falló",
+		"%d of %d targets failed":         "%d de %d destinos fallaron",
+		"Unable to cache result: %s\n":    "No se pudo guardar el resultado en caché: %s\n",
+		"Unable to save run record: %s\n": "No se pudo guardar el registro de ejecución: %s\n",
+
+		"Unable to read response file: %s":               "No se pudo leer el archivo de respuestas: %s",
+		"Invalid response file %s: %s":                   "Archivo de respuestas no válido %s: %s",
+		"No completion action given; see --help":         "No se indicó ninguna acción de autocompletado; consulte --help",
+		"Installed completion for %s at %s\n":            "Autocompletado para %s instalado en %s\n",
+		"Completion for %s is already installed at %s\n": "El autocompletado para %s ya está instalado en %s\n",
+		"Invalid run number: %s":                         "Número de ejecución no válido: %s",
+		"Only %d run(s) recorded":                        "Solo hay %d ejecución(es) registrada(s)",
+	})
+
+	RegisterTranslations("fr", map[string]string{
+		"Usage:":    "Utilisation :",
+		"Commands:": "Commandes :",
+		"Flags:":    "Options :",
+		"Examples:": "Exemples :",
+
+		"Failed to parse command-line arguments:\n%s\n": "Impossible d'analyser les arguments :\n%s\n",
+		"Unknown command: %s\n":                         "Commande inconnue : %s\n",
+		"Unknown output format: %s\n":                   "Format de sortie inconnu : %s\n",
+		"Unable to render output: %s\n":                 "Impossible de mettre en forme la sortie : %s\n",
+		"Pipeline failed: %s\n":                         "Échec du pipeline : %s\n",
+		"Pager failed: %s\n":                            "Échec du pager : %s\n",
+		"Interrupted":                                   "Interrompu",
+
+		"Unknown report format: %s":                                "Format de rapport inconnu : %s",
+		"Reports are named as format:path, e.g. junit:results.xml": "Les rapports s'indiquent sous la forme format:chemin, p. ex. junit:results.xml",
+		"Unable to write report: %s\n":                             "Impossible d'écrire le rapport : %s\n",
+		"Unable to report to GitHub Actions: %s\n":                 "Impossible de rendre compte à GitHub Actions : %s\n",
+		"Unable to write metrics: %s\n":                            "Impossible d'écrire les métriques : %s\n",
+		"Unknown log target: %s":                                   "Destination de journalisation inconnue : %s",
+		"Unable to log to %s: %s":                                  "Impossible de journaliser vers %s : %s",
+		"Unable to open debug log: %s\n":                           "Impossible d'ouvrir le journal de débogage : %s\n",
+		"Nothing has been logged":                                  "Rien n'a été journalisé",
+		"%s exited with status %d":                                 "%s s'est terminé avec le statut %d",
+
+		"Kept temporary files in %s\n":           "Fichiers temporaires conservés dans %s\n",
+		"Unable to remove temporary files: %s\n": "Impossible de supprimer les fichiers temporaires : %s\n",
+
+		"%s was updated to %s. Show what's new?": "%s a été mis à jour vers %s. Afficher les nouveautés ?",
+		"Unable to show what's new: %s\n":        "Impossible d'afficher les nouveautés : %s\n",
+
+		"Refusing to continue without confirmation; use --yes to override": "Refus de continuer sans confirmation ; utilisez --yes pour passer outre",
+		"This action cannot be undone. Type %q to confirm: ":               "Cette action est irréversible. Tapez %q pour confirmer : ",
+		"Confirmation did not match %q; aborting":                          "La confirmation ne correspond pas à %q ; abandon",
+		"Not a number: %q": "Pas un nombre : %q",
+
+		"Confirm password: ":                "Confirmez le mot de passe : ",
+		"Passwords do not match; try again": "Les mots de passe ne correspondent pas ; réessayez",
+		"Passwords did not match":           "Les mots de passe ne correspondaient pas",
+
+		"Authentication code: ":                               "Code d'authentification : ",
+		"Enter the 6 to 8 digit code from your authenticator": "Saisissez le code à 6 à 8 chiffres de votre application d'authentification",
+		"No valid authentication code was entered":            "Aucun code d'authentification valide n'a été saisi",
+
+		"Your session has expired; logging in again": "Votre session a expiré ; nouvelle connexion",
+		"%s; log in again to continue":               "%s ; reconnectez-vous pour continuer",
+
+		"Your credentials were refused; try logging in again":                 "Vos identifiants ont été refusés ; essayez de vous reconnecter",
+		"You don't have permission to do this":                                "Vous n'avez pas la permission de faire cela",
+		"Try again in %s":                                                     "Réessayez dans %s",
+		"Try again later":                                                     "Réessayez plus tard",
+		"The service failed; if this persists, report it with the request ID": "Le service a échoué ; si cela persiste, signalez-le avec l'identifiant de la requête",
+		"Check that the name or ID is correct":                                "Vérifiez que le nom ou l'identifiant est correct",
+		"Request ID: %s":                                                      "Identifiant de la requête : %s",
+
+		"Unable to connect: %s; retrying in %s\n":           "Connexion impossible : %s ; nouvel essai dans %s\n",
+		"Connection lost: %s; reconnecting in %s\n":         "Connexion perdue : %s ; reconnexion dans %s\n",
+		"Unsupported stream URL: %s":                        "URL de flux non prise en charge : %s",
+		"The server did not accept the websocket handshake": "Le serveur n'a pas accepté la négociation WebSocket",
+
+		"Forwarding %s; press Ctrl-C to stop": "Redirection de %s ; appuyez sur Ctrl-C pour arrêter",
+		"Unable to forward connection: %s\n":  "Impossible de rediriger la connexion : %s\n",
+
+		"Serving %s; press Ctrl-C to stop":      "%s est servi ; appuyez sur Ctrl-C pour arrêter",
+		"Unable to open a browser: %s\n":        "Impossible d'ouvrir un navigateur : %s\n",
+		"Stopping after %s without a request\n": "Arrêt après %s sans requête\n",
+
+		"Unable to compare version %q with the minimum the server supports, %s\n":                     "Impossible de comparer la version %q avec la version minimale prise en charge par le serveur, %s\n",
+		"Version %s is no longer supported by the server, which requires %s or later; please upgrade": "La version %s n'est plus prise en charge par le serveur, qui exige %s ou ultérieure ; veuillez mettre à jour",
+		"Compatible with the server, which requires %s or later":                                      "Compatible avec le serveur, qui exige %s ou ultérieure",
+
+		"Invalid --format: %s":                    "--format non valide : %s",
+		"--format can't be used with --output %s": "--format ne peut pas être utilisé avec --output %s",
+
+		"ETA %s": "reste %s",
+
+		"Unable to record usage: %s\n": "Impossible de compter l'utilisation : %s\n",
+		"Uses counted since %s":        "Utilisations comptées depuis le %s",
+		"No uses have been counted":    "Aucune utilisation n'a été comptée",
+
+		"Enter one record per line as %s, then an empty line to finish:": "Saisissez un enregistrement par ligne sous la forme %s, puis une ligne vide pour terminer :",
+
+		"Open %s in your browser":           "Ouvrez %s dans votre navigateur",
+		"Unable to edit without a terminal": "Impossible de modifier sans terminal",
+
+		"%s already exists; use --force to overwrite it or --skip to keep it": "%s existe déjà ; utilisez --force pour l'écraser ou --skip pour le conserver",
+		"%s already exists: (o)verwrite, (s)kip, (d)iff or (a)bort? ":         "%s existe déjà : (o) écraser, (s) ignorer, (d) différences ou (a) abandonner ? ",
+		"--force and --skip can't be used together":                           "--force et --skip ne peuvent pas être utilisés ensemble",
+		"Aborted":   "Abandonné",
+		"create":    "créer",
+		"identical": "identique",
+		"skip":      "ignorer",
+		"overwrite": "écraser",
+
+		"Rolling back %d change(s)\n":    "Annulation de %d modification(s)\n",
+		"Rollback %d of %d failed: %s\n": "Échec de l'annulation %d sur %d : %s\n",
+		"Rollback %d of %d complete\n":   "Annulation %d sur %d terminée\n",
+
+		"Nothing to do.":                  "Rien à faire.",
+		"Plan: %d operation(s)\n":         "Plan : %d opération(s)\n",
+		"Apply these changes?":            "Appliquer ces modifications ?",
+		"Plan was not applied":            "Le plan n'a pas été appliqué",
+		"done":                            "terminé",
+		"failed":                          "échec",
+		"Step %d of %d failed":            "Échec de l'étape %d sur %d",
+		"%d of %d targets failed":         "%d cibles sur %d en échec",
+		"Unable to cache result: %s\n":    "Impossible de mettre le résultat en cache : %s\n",
+		"Unable to save run record: %s\n": "Impossible d'enregistrer l'historique d'exécution : %s\n",
+
+		"Unable to read response file: %s":               "Impossible de lire le fichier de réponses : %s",
+		"Invalid response file %s: %s":                   "Fichier de réponses %s invalide : %s",
+		"No completion action given; see --help":         "Aucune action de complétion indiquée ; voir --help",
+		"Installed completion for %s at %s\n":            "Complétion pour %s installée dans %s\n",
+		"Completion for %s is already installed at %s\n": "La complétion pour %s est déjà installée dans %s\n",
+		"Invalid run number: %s":                         "Numéro d'exécution invalide : %s",
+		"Only %d run(s) recorded":                        "Seulement %d exécution(s) enregistrée(s)",
+	})
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestLocalizedMessages(t *testing.T) {
+	environment := map[string]string{"LANG": "fr_FR.UTF-8"}
+	result, output := runMainWithEnv(t, &testHelpCommand{}, []string{"widgets", "bogus"}, environment)
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.STDERR, `Commande inconnue : bogus`)
+
+	_, output = runMainWithEnv(t, &testHelpCommand{}, []string{"widgets", "--help"}, environment)
+	ExpectMatch(t, *output.STDOUT, `Utilisation : widgets`)
+
+	_, output = runMainWithEnv(t, &testHelpCommand{}, []string{"widgets", "@missing.txt"}, environment,
+		WithResponseFiles())
+	ExpectMatch(t, *output.STDERR, `Impossible de lire le fichier de réponses`)
+}
+
+func TestLocalizeFallback(t *testing.T) {
+	RegisterTranslations("pt", map[string]string{"Hello, %s": "Olá, %s"})
+	RegisterTranslations("pt_BR", map[string]string{"Goodbye, %s": "Tchau, %s"})
+
+	cases := []struct {
+		lang, format, expected string
+	}{
+		{"pt_BR.UTF-8", "Goodbye, %s", "Tchau, world"},
+		{"pt_BR.UTF-8", "Hello, %s", "Olá, world"},
+		{"pt_PT", "Goodbye, %s", "Goodbye, world"},
+		{"C", "Hello, %s", "Hello, world"},
+		{"", "Hello, %s", "Hello, world"},
+	}
+	for _, c := range cases {
+		system, _ := NewTestSystem(t, nil, map[string]string{"LANG": c.lang})
+		if actual := Localize(system, c.format, "world"); actual != c.expected {
+			t.Errorf("expected %q for %q in %q, received %q\n", c.expected, c.format, c.lang, actual)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// ScanContext reads a line of input and scans it as fmt.Sscan does, storing
+// space-separated values in successive arguments. If ctx is done before the
+// line has been entered, ScanContext returns ctx's error, and the line
+// entered next is returned by the System's next read instead.
+func ScanContext(ctx context.Context, sys System, a ...interface{}) (int, error) {
+	line, err := readContext(ctx, sys, func() (string, error) {
+		return readLine(sys)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return fmt.Sscan(line, a...)
+}
+
+// readResult is the outcome of a read from the System's input
+type readResult struct {
+	text string
+	err  error
+}
+
+// pendingRead is a read abandoned by readContext, which is still waiting for
+// input. The next read takes its result, so that the line the user enters
+// isn't lost, rather than starting another read which competes with it.
+type pendingRead struct {
+	mu   sync.Mutex
+	done chan readResult
+}
+
+// take returns the result of the abandoned read, if there is one, leaving
+// none pending
+func (p *pendingRead) take() chan readResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	done := p.done
+	p.done = nil
+	return done
+}
+
+// leave makes done the result of the next read
+func (p *pendingRead) leave(done chan readResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = done
+}
+
+// readContext calls read, which reads input, and waits for it to return or
+// for ctx to be done. In the latter case read is left to finish in the
+// background, and the line it reads is returned by the System's next read.
+// Any change read made to the state of the terminal, such as turning off
+// echo to read a password, is undone.
+func readContext(ctx context.Context, sys System, read func() (string, error)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s, ok := baseOf(sys)
+	var done chan readResult
+	if ok {
+		done = s.pending.take()
+	}
+	restore := func() {}
+	if done == nil {
+		restore = saveTerminal(sys)
+		done = make(chan readResult, 1)
+		go func() {
+			text, err := read()
+			done <- readResult{text, err}
+		}()
+	}
+
+	select {
+	case r := <-done:
+		return r.text, r.err
+	case <-ctx.Done():
+		restore()
+		if ok {
+			s.pending.leave(done)
+		}
+		return "", ctx.Err()
+	}
+}
+
+// saveTerminal returns a function which restores the terminal input is
+// attached to, if it is, to its present state
+func saveTerminal(sys System) (restore func()) {
+	s, ok := baseOf(sys)
+	if !ok || !isTerminal(s.In) {
+		return func() {}
+	}
+	fd := int(s.In.(interface{ Fd() uintptr }).Fd())
+	state, err := term.GetState(fd)
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		term.Restore(fd, state)
+	}
+}
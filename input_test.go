@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanContext(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader("7 widgets\n")})
+	var count int
+	var name string
+	if n, err := ScanContext(context.Background(), system, &count, &name); err != nil || n != 2 {
+		t.Fatalf("expected 2 values, received %d (%v)\n", n, err)
+	}
+	if count != 7 || name != "widgets" {
+		t.Errorf("expected 7 widgets, received %d %s\n", count, name)
+	}
+}
+
+func TestReadContextCancelled(t *testing.T) {
+	in, w := io.Pipe()
+	defer w.Close()
+	system, _ := NewMemorySystem(MemorySystemOptions{Input: in})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var count int
+	if _, err := ScanContext(ctx, system, &count); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, received %v\n", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadPasswordContext(ctx, system, "Password: "); err != context.Canceled {
+		t.Errorf("expected the read to be cancelled, received %v\n", err)
+	}
+
+	// the line entered after the read was abandoned isn't lost
+	go w.Write([]byte("7\nwidgets\n"))
+	if _, err := ScanContext(context.Background(), system, &count); err != nil || count != 7 {
+		t.Errorf("expected the next read to receive 7, received %d (%v)\n", count, err)
+	}
+	if line, err := readLine(system); err != nil || line != "widgets" {
+		t.Errorf("expected the following line, received %q (%v)\n", line, err)
+	}
+}
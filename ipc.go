@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+)
+
+var ipcName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// errIPCUnsupported is returned by ListenIPC and DialIPC on platforms
+// without Unix domain sockets
+var errIPCUnsupported = errors.New("IPC is not supported on " + runtime.GOOS)
+
+// errIPCInUse is returned when another process is listening on a name
+var errIPCInUse = errors.New("Another process is already listening")
+
+// ipcPath returns the path of the socket for name within the user's runtime
+// directory, creating the directory if necessary and checking that no other
+// user can reach it
+func ipcPath(sys System, name string) (string, error) {
+	if !ipcSupported {
+		return "", errIPCUnsupported
+	}
+	if !ipcName.MatchString(name) {
+		return "", fmt.Errorf("Invalid IPC name %q", name)
+	}
+
+	dir, err := runtimeDir(sys)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+".sock")
+	if len(path) > maxIPCPath {
+		return "", fmt.Errorf("Unable to use %s; sockets are limited to paths of %d bytes, "+
+			"so set XDG_RUNTIME_DIR to a shorter directory", path, maxIPCPath)
+	}
+
+	if err := sys.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ListenIPC listens for connections from other processes run by the same
+// user, such as other invocations of the same CLI, on a socket identified by
+// name. The socket is created in the user's runtime directory, which must not
+// be accessible to other users. The listening process holds a lock beside
+// the socket, so a socket left behind by a process which exited without
+// cleaning up is replaced, but it is an error for another process to be
+// listening on name already. The socket is removed when the listener is
+// closed, which happens when ctx is done.
+//
+// Unix domain sockets are used, whose paths are limited to around 100
+// bytes. Windows isn't supported, and ListenIPC returns an error there.
+func ListenIPC(ctx context.Context, sys System, name string) (net.Listener, error) {
+	path, err := ipcPath(sys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockIPC(path)
+	if err == errIPCInUse {
+		return nil, fmt.Errorf("Another process is already listening on %s", name)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := sys.Remove(path); err != nil && !os.IsNotExist(err) {
+		unlock()
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		unlock()
+		return nil, err
+	}
+
+	listener := &ipcListener{l, unlock}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	return listener, nil
+}
+
+// ipcListener releases the lock on its socket when it is closed
+type ipcListener struct {
+	net.Listener
+	unlock func()
+}
+
+func (l *ipcListener) Close() error {
+	err := l.Listener.Close()
+	l.unlock()
+	return err
+}
+
+// DialIPC connects to the socket identified by name on which another process
+// run by the same user is listening with ListenIPC
+func DialIPC(ctx context.Context, sys System, name string) (net.Conn, error) {
+	path, err := ipcPath(sys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", path)
+}
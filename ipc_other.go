@@ -0,0 +1,19 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package cli
+
+// ipcSupported reports whether ListenIPC and DialIPC work on this platform.
+// Named pipes aren't implemented, and the runtime directory's access can't
+// be checked, so IPC is refused rather than exposed to other users.
+const ipcSupported = false
+
+var maxIPCPath = 0
+
+func checkPrivateDir(dir string) error {
+	return errIPCUnsupported
+}
+
+func lockIPC(path string) (unlock func(), err error) {
+	return nil, errIPCUnsupported
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestIPC(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC is not supported on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	system, _ := NewTestSystem(t, []string{"testipc"}, map[string]string{"XDG_RUNTIME_DIR": dir})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l, err := ListenIPC(ctx, system, "testipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("echo " + line))
+	}()
+
+	conn, err := DialIPC(ctx, system, "testipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("hello\n"))
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	conn.Close()
+	if err != nil || reply != "echo hello\n" {
+		t.Errorf("expected an echoed reply, received %q (%v)\n", reply, err)
+	}
+
+	if _, err := ListenIPC(ctx, system, "testipc"); err == nil {
+		t.Errorf("expected a second listener on the same name to fail\n")
+	}
+
+	l.Close()
+	if _, err := os.Stat(filepath.Join(dir, "testipc.sock")); !os.IsNotExist(err) {
+		t.Errorf("socket was not removed when the listener closed\n")
+	}
+
+	if _, err := ListenIPC(ctx, system, "../escape"); err == nil {
+		t.Errorf("expected an invalid name to be rejected\n")
+	}
+}
+
+func TestIPCRejectsSharedDirectory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC is not supported on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	system, _ := NewTestSystem(t, []string{"testipc"}, map[string]string{"XDG_RUNTIME_DIR": dir})
+	if _, err := ListenIPC(context.Background(), system, "testipc"); err == nil {
+		t.Errorf("expected a world-accessible runtime directory to be refused\n")
+	}
+}
+
+func TestIPCReplacesStaleSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC is not supported on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a socket left behind by a process which exited without removing it
+	stale, err := net.Listen("unix", filepath.Join(dir, "testipc.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	system, _ := NewTestSystem(t, []string{"testipc"}, map[string]string{"XDG_RUNTIME_DIR": dir})
+	l, err := ListenIPC(context.Background(), system, "testipc")
+	if err != nil {
+		t.Fatalf("expected the stale socket to be replaced: %s", err)
+	}
+	l.Close()
+
+	// the lock is released when the listener closes
+	if l, err = ListenIPC(context.Background(), system, "testipc"); err != nil {
+		t.Fatalf("expected to listen again once the listener closed: %s", err)
+	}
+	l.Close()
+}
+
+func TestIPCPathLength(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC is not supported on Windows")
+	}
+
+	dir := filepath.Join(os.TempDir(), strings.Repeat("d", 120))
+	system, _ := NewTestSystem(t, []string{"testipc"}, map[string]string{"XDG_RUNTIME_DIR": dir})
+	_, err := ListenIPC(context.Background(), system, "testipc")
+	if err == nil || !strings.Contains(err.Error(), "XDG_RUNTIME_DIR") {
+		t.Errorf("expected a path which is too long to be refused, received %v\n", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the runtime directory not to be created\n")
+	}
+}
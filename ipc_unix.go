@@ -0,0 +1,64 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ipcSupported reports whether ListenIPC and DialIPC work on this platform
+const ipcSupported = true
+
+// maxIPCPath is the length of the longest path a Unix domain socket may have,
+// leaving room for sun_path's terminating NUL
+var maxIPCPath = len(unix.RawSockaddrUnix{}.Path) - 1
+
+// checkPrivateDir returns an error unless dir is owned by the current user
+// and inaccessible to anyone else
+func checkPrivateDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("Refusing to use %s; it is owned by another user", dir)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("Refusing to use %s; it is accessible to other users", dir)
+	}
+	return nil
+}
+
+// lockIPC takes an exclusive lock on the socket at path, which is held by the
+// process listening on it, so that a socket left behind by a process which
+// exited can be told apart from one in use and replaced without racing
+// another process doing the same. It returns errIPCInUse if another process
+// holds the lock.
+func lockIPC(path string) (unlock func(), err error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		f.Close()
+		if err == unix.EWOULDBLOCK {
+			return nil, errIPCInUse
+		}
+		return nil, err
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unix.Flock(int(f.Fd()), unix.LOCK_UN)
+			f.Close()
+		})
+	}, nil
+}
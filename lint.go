@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HasErrors is implemented by commands which declare the errors they may
+// return, so that LintMessages can check their messages
+type HasErrors interface {
+	// Errors should return the errors whose messages may be shown to the user
+	Errors() []error
+}
+
+// LintIssue describes a message which doesn't follow the style rules checked
+// by LintMessages
+type LintIssue struct {
+	// Path is the space-separated list of subcommand names leading to the
+	// command, or empty for the root command
+	Path string
+
+	Message string
+}
+
+func (i LintIssue) String() string {
+	if len(i.Path) == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Path, i.Message)
+}
+
+// LintMessages checks the messages of every command in the tree rooted at
+// root against the style used for the framework's own messages, and returns
+// the issues found. It is intended to be called from a test, so that large
+// CLIs stay consistent:
+//
+//   - synopses, descriptions, deprecation notices and error messages begin
+//     with a capital letter
+//   - synopses, flag usage text and error messages don't end with a period
+//   - visible commands have a synopsis, and every flag has usage text
+//   - examples name a command that exists, with flags it accepts
+//
+// Options should be the same as those passed to Main, so that examples using
+// built-in subcommands and flags resolve.
+func LintMessages(root Command, opts ...Option) []LintIssue {
+	cfg := newConfig(opts)
+
+	issues := lintCommand(root, "", false, cfg, root)
+	if b, ok := root.(HasSubcommands); ok {
+		for _, e := range b.Subcommands().Entries("") {
+			issues = append(issues, lintCommand(e.Command, e.Path, e.Hidden, cfg, root)...)
+		}
+	}
+	return issues
+}
+
+// lintCommand checks the messages of a single command. Path is empty for the
+// root command.
+func lintCommand(cmd Command, path string, hidden bool, cfg *config, root Command) []LintIssue {
+	var issues []LintIssue
+	report := func(format string, a ...interface{}) {
+		issues = append(issues, LintIssue{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	synopsis, description := describe(cmd)
+	if len(synopsis) == 0 {
+		if len(path) > 0 && !hidden {
+			report("missing synopsis")
+		}
+	} else {
+		if strings.Contains(synopsis, "\n") {
+			report("synopsis should be a single line")
+		}
+		if !startsCapitalized(synopsis) {
+			report("synopsis should begin with a capital letter: %q", synopsis)
+		}
+		if endsWithPeriod(synopsis) {
+			report("synopsis should not end with a period: %q", synopsis)
+		}
+	}
+	if len(description) > 0 && !startsCapitalized(description) {
+		report("description should begin with a capital letter")
+	}
+
+	if b, ok := cmd.(HasDeprecation); ok {
+		if message := b.Deprecated(); len(message) > 0 && !startsCapitalized(message) {
+			report("deprecation notice should begin with a capital letter: %q", message)
+		}
+	}
+
+	if b, ok := cmd.(HasFlags); ok {
+		f := flag.NewFlagSet(path, flag.ContinueOnError)
+		b.Flags(f)
+		f.VisitAll(func(fl *flag.Flag) {
+			name := flagName(HelpFlag{Name: fl.Name})
+			if len(strings.TrimSpace(fl.Usage)) == 0 {
+				report("flag %s has no usage text", name)
+			} else if endsWithPeriod(fl.Usage) {
+				report("usage text of flag %s should not end with a period: %q", name, fl.Usage)
+			}
+		})
+	}
+
+	if b, ok := cmd.(HasExamples); ok {
+		for _, example := range b.Examples() {
+			if len(example.Description) > 0 && !startsCapitalized(example.Description) {
+				report("example description should begin with a capital letter: %q", example.Description)
+			}
+			if err := lintExample(root, example.Command, cfg); err != nil {
+				report("example %q: %s", example.Command, err)
+			}
+		}
+	}
+
+	if b, ok := cmd.(HasErrors); ok {
+		for _, err := range b.Errors() {
+			message := err.Error()
+			if len(message) == 0 {
+				report("error has no message")
+				continue
+			}
+			if !startsCapitalized(message) {
+				report("error should begin with a capital letter: %q", message)
+			}
+			if endsWithPeriod(message) {
+				report("error should not end with a period: %q", message)
+			}
+		}
+	}
+
+	return issues
+}
+
+// lintExample resolves an example command line as Main would, returning an
+// error if it names an unknown subcommand or its flags fail to parse
+func lintExample(root Command, example string, cfg *config) error {
+	tokens, err := splitResponseFile(example)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	cmd, path, rest, _ := resolve(root, tokens[1:], cfg.subcommands)
+	if len(path) == 0 && len(rest) > 0 && rest[0] == "help" {
+		if _, ok := root.(HasSubcommands); ok {
+			cmd, path, rest, _ = resolve(root, rest[1:], cfg.subcommands)
+		}
+	}
+
+	f, _ := newFlagSet(cmd, strings.Join(path, " "), cfg)
+	flags, args := splitFlags(f, rest)
+	if err := f.Parse(flags); err != nil && err != flag.ErrHelp {
+		return err
+	}
+
+	if _, ok := cmd.(Action); !ok {
+		if _, ok := cmd.(HasSubcommands); ok && len(args) > 0 {
+			return fmt.Errorf("unknown command %s", args[0])
+		}
+	}
+	return nil
+}
+
+// startsCapitalized reports whether s doesn't begin with a lowercase letter.
+// Messages may begin with a flag or other literal, e.g. `--target`.
+func startsCapitalized(s string) bool {
+	r, _ := utf8.DecodeRuneInString(strings.TrimSpace(s))
+	return !unicode.IsLower(r)
+}
+
+// endsWithPeriod reports whether s ends with a single period, allowing
+// ellipses
+func endsWithPeriod(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasSuffix(s, ".") && !strings.HasSuffix(s, "...")
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+)
+
+type testLintCommand struct {
+	DefaultHelp
+}
+
+func (c *testLintCommand) Synopsis() string { return "Manage gadgets" }
+
+func (c *testLintCommand) Subcommands() CLI {
+	return CLI{
+		"list":   &testLintList{},
+		"remove": &testLintRemove{},
+	}
+}
+
+type testLintList struct {
+	DefaultHelp
+	all bool
+}
+
+func (c *testLintList) Synopsis() string { return "List gadgets" }
+
+func (c *testLintList) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.all, "all", false, "include archived gadgets")
+}
+
+func (c *testLintList) Examples() []Example {
+	return []Example{
+		{Description: "List every gadget", Command: "gadgets list --all"},
+		{Command: "gadgets --version"},
+		{Command: "gadgets help list"},
+	}
+}
+
+func (c *testLintList) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+type testLintRemove struct {
+	DefaultHelp
+	force bool
+}
+
+func (c *testLintRemove) Synopsis() string { return "remove a gadget." }
+
+func (c *testLintRemove) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.force, "force", false, "")
+}
+
+func (c *testLintRemove) Examples() []Example {
+	return []Example{
+		{Description: "remove a gadget", Command: "gadgets remove --forse sprocket"},
+		{Command: "gadgets delete sprocket"},
+	}
+}
+
+func (c *testLintRemove) Errors() []error {
+	return []error{errors.New("Gadget is in use"), errors.New("gadget not found.")}
+}
+
+func (c *testLintRemove) Command(ctx context.Context, args []string, s System) error {
+	return nil
+}
+
+func TestLintMessages(t *testing.T) {
+	issues := LintMessages(&testLintCommand{}, WithVersion(VersionInfo{Version: "1.0.0"}))
+
+	expected := []string{
+		`remove: synopsis should begin with a capital letter: "remove a gadget."`,
+		`remove: synopsis should not end with a period: "remove a gadget."`,
+		`remove: flag --force has no usage text`,
+		`remove: example description should begin with a capital letter: "remove a gadget"`,
+		`remove: example "gadgets remove --forse sprocket": flag provided but not defined: -forse`,
+		`remove: example "gadgets delete sprocket": unknown command delete`,
+		`remove: error should begin with a capital letter: "gadget not found."`,
+		`remove: error should not end with a period: "gadget not found."`,
+	}
+	if len(issues) != len(expected) {
+		t.Errorf("expected %d issues, found %d\n%q", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		if i < len(expected) && issue.String() != expected[i] {
+			t.Errorf("expected %q, received %q\n", expected[i], issue.String())
+		}
+	}
+}
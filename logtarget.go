@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log targets which may be selected with `--log-target`
+const (
+	LogTargetStderr   = "stderr"
+	LogTargetSyslog   = "syslog"
+	LogTargetJournald = "journald"
+)
+
+// logPriority is the syslog severity messages logged with System.Log are sent
+// with: informational, matching the priority systemd gives to what a service
+// writes to standard error
+const logPriority = 6
+
+// syslogFacilityUser is the facility messages are sent to syslog with
+const syslogFacilityUser = 1
+
+// journalSocket is the socket of the systemd journal's native protocol
+var journalSocket = "/run/systemd/journal/socket"
+
+// syslogSockets are the sockets a local syslog daemon may listen on
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// WithLogTarget adds a `--log-target` flag which sends messages logged by app
+// to syslog or the systemd journal rather than standard error, for programs
+// run unattended, e.g. by a systemd timer. Messages sent to the journal are
+// tagged with the command and the run's trace ID as the fields CLI_COMMAND
+// and CLI_TRACE_ID, so that every message of a run may be found with
+// `journalctl CLI_TRACE_ID=...`.
+func WithLogTarget(app string) Option {
+	return func(c *config) {
+		c.logTarget = app
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.String("log-target", LogTargetStderr,
+				"send logged messages to `target`: stderr, syslog or journald")
+		})
+	}
+}
+
+// setLogTarget redirects the System's logger to target, returning a function
+// which restores it and closes the connection. The command and trace ID tag
+// messages sent to the journal.
+func setLogTarget(sys System, target, app, command, traceID string) (restore func(), err error) {
+	s, ok := baseOf(sys)
+	if !ok || target == LogTargetStderr {
+		return func() {}, nil
+	}
+
+	if arguments := sys.Args(); len(arguments) > 0 {
+		command = strings.TrimSpace(programName(arguments[0]) + " " + command)
+	}
+
+	var w io.WriteCloser
+	switch target {
+	case LogTargetJournald:
+		w, err = dialJournal(map[string]string{
+			"SYSLOG_IDENTIFIER": app,
+			"CLI_COMMAND":       command,
+			"CLI_TRACE_ID":      traceID,
+		})
+	case LogTargetSyslog:
+		w, err = dialSyslog(app)
+	default:
+		return nil, &ExitError{Status: ExitUsage, Message: Localize(sys, "Unknown log target: %s", target)}
+	}
+	if err != nil {
+		return nil, &ExitError{Status: ExitFailure, Message: Localize(sys, "Unable to log to %s: %s", target, err)}
+	}
+
+	logger := s.Logger
+	s.Logger = log.New(w, "", 0)
+	return func() {
+		s.Logger = logger
+		w.Close()
+	}, nil
+}
+
+// journalWriter sends each message written to it to the systemd journal
+type journalWriter struct {
+	conn   net.Conn
+	fields map[string]string
+}
+
+// dialJournal connects to the journal, tagging each message with fields
+func dialJournal(fields map[string]string) (*journalWriter, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &journalWriter{conn: conn, fields: fields}, nil
+}
+
+func (w *journalWriter) Write(p []byte) (int, error) {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", fmt.Sprint(logPriority))
+	writeJournalField(&b, "MESSAGE", strings.TrimSuffix(string(p), "\n"))
+	for key, value := range w.fields {
+		if len(value) > 0 {
+			writeJournalField(&b, key, value)
+		}
+	}
+
+	if _, err := w.conn.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *journalWriter) Close() error {
+	return w.conn.Close()
+}
+
+// writeJournalField encodes a field in the journal's native protocol. Values
+// spanning lines are preceded by their length rather than terminated by a
+// newline.
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	if !strings.ContainsRune(value, '\n') {
+		fmt.Fprintf(b, "%s=%s\n", key, value)
+		return
+	}
+	b.WriteString(key)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// syslogWriter sends each message written to it to the local syslog daemon
+type syslogWriter struct {
+	conn net.Conn
+	tag  string
+}
+
+// dialSyslog connects to the local syslog daemon, tagging each message with
+// tag
+func dialSyslog(tag string) (*syslogWriter, error) {
+	var err error
+	for _, socket := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			var conn net.Conn
+			if conn, err = net.Dial(network, socket); err == nil {
+				return &syslogWriter{conn: conn, tag: tag}, nil
+			}
+		}
+	}
+	return nil, err
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	_, err := fmt.Fprintf(w.conn, "<%d>%s %s[%d]: %s\n",
+		syslogFacilityUser*8+logPriority, time.Now().Format(time.Stamp), w.tag, os.Getpid(), message)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *syslogWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+)
+
+type testLogCommand struct{}
+
+func (c *testLogCommand) Help() {}
+
+func (c *testLogCommand) Command(ctx context.Context, args []string, s System) error {
+	s.Log("syncing widgets")
+	return &ExitError{Status: ExitFailure, Message: "Unable to reach api\nretry later"}
+}
+
+// listenLog listens for datagrams on a socket in a temporary directory
+func listenLog(t *testing.T) (string, *net.UnixConn) {
+	if runtime.GOOS == "windows" {
+		t.Skip("datagram sockets are unavailable")
+	}
+
+	dir, err := ioutil.TempDir("", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+// readLog returns the next datagram received by conn
+func readLog(t *testing.T, conn *net.UnixConn) []byte {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b := make([]byte, 4096)
+	n, err := conn.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b[:n]
+}
+
+func TestJournaldLogTarget(t *testing.T) {
+	path, conn := listenLog(t)
+	socket := journalSocket
+	journalSocket = path
+	defer func() { journalSocket = socket }()
+
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Arguments: []string{"widgets", "--log-target", "journald"},
+	})
+	result := Main(context.Background(), &testLogCommand{}, system, WithLogTarget("widgets"))
+	ExpectExitCode(t, result, ExitFailure)
+	if output.Stderr.Len() > 0 {
+		t.Errorf("expected nothing to be logged to stderr, received %q\n", output.Stderr)
+	}
+
+	message := readLog(t, conn)
+	for _, re := range []string{
+		`(?m)^PRIORITY=6$`,
+		`(?m)^MESSAGE=syncing widgets$`,
+		`(?m)^SYSLOG_IDENTIFIER=widgets$`,
+		`(?m)^CLI_COMMAND=widgets$`,
+		`(?m)^CLI_TRACE_ID=[0-9a-f]+$`,
+	} {
+		if !regexp.MustCompile(re).Match(message) {
+			t.Errorf("expected the journal entry to match %s\n%q", re, message)
+		}
+	}
+
+	message = readLog(t, conn)
+	multiline := "MESSAGE\n\x1f\x00\x00\x00\x00\x00\x00\x00Unable to reach api\nretry later\n"
+	if !bytes.Contains(message, []byte(multiline)) {
+		t.Errorf("expected a multiline message to be length-prefixed\n%q", message)
+	}
+}
+
+func TestSyslogLogTarget(t *testing.T) {
+	path, conn := listenLog(t)
+	sockets := syslogSockets
+	syslogSockets = []string{path}
+	defer func() { syslogSockets = sockets }()
+
+	system, _ := NewMemorySystem(MemorySystemOptions{
+		Arguments: []string{"widgets", "--log-target", "syslog"},
+	})
+	result := Main(context.Background(), &testLogCommand{}, system, WithLogTarget("widgets"))
+	ExpectExitCode(t, result, ExitFailure)
+
+	message := readLog(t, conn)
+	if !regexp.MustCompile(`^<14>\w{3} [ \d]\d \d\d:\d\d:\d\d widgets\[\d+\]: syncing widgets\n$`).Match(message) {
+		t.Errorf("unexpected syslog message %q\n", message)
+	}
+}
+
+func TestUnknownLogTarget(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Arguments: []string{"widgets", "--log-target", "splunk"},
+	})
+	result := Main(context.Background(), &testLogCommand{}, system, WithLogTarget("widgets"))
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.Stderr, `Unknown log target: splunk`)
+}
@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Highlighter colors source code for display in a terminal
+type Highlighter func(code string) string
+
+var highlighters = struct {
+	sync.RWMutex
+	languages map[string]Highlighter
+}{languages: map[string]Highlighter{}}
+
+// RegisterHighlighter makes RenderMarkdown color fenced code blocks in lang,
+// e.g. ```go, with highlight when output is a terminal. No highlighters are
+// built in, so that programs which don't show code needn't carry them.
+func RegisterHighlighter(lang string, highlight Highlighter) {
+	highlighters.Lock()
+	defer highlighters.Unlock()
+	highlighters.languages[strings.ToLower(lang)] = highlight
+}
+
+// Terminal styles used to render markdown
+const (
+	styleBold      = "\x1b[1m"
+	styleItalic    = "\x1b[3m"
+	styleUnderline = "\x1b[4m"
+	styleDim       = "\x1b[2m"
+	styleCode      = "\x1b[36m"
+	styleReset     = "\x1b[0m"
+)
+
+// RenderMarkdown renders markdown for display, wrapped to the terminal's
+// width. Headings, emphasis, code and links are styled when output is
+// colored; otherwise their markup is reduced to plain text.
+func (s *BaseSystem) RenderMarkdown(src string) string {
+	return renderTerminalMarkdown(src, s.TerminalWidth(), s.colored())
+}
+
+var (
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownRule    = regexp.MustCompile(`^ {0,3}([-*_])( *[-*_]){2,} *$`)
+	markdownItem    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	markdownQuote   = regexp.MustCompile(`^\s*> ?(.*)$`)
+	markdownInline  = regexp.MustCompile("`[^`]+`|\\*\\*[^*]+\\*\\*|__[^_]+__|\\*[^*\\s][^*]*\\*|\\[[^\\]]+\\]\\([^)\\s]+\\)")
+)
+
+// renderTerminalMarkdown renders the blocks of src, separated by blank lines:
+// paragraphs, headings, lists, block quotes, code blocks and rules
+func renderTerminalMarkdown(src string, width int, styled bool) string {
+	lines := strings.Split(strings.Replace(src, "\r\n", "\n", -1), "\n")
+	r := markdownRenderer{width: width, styled: styled}
+
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			r.add(r.wrap(r.inline(strings.Join(paragraph, " ")), "", ""))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence := trimmed[:3]
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1]))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			r.code(code, lang)
+
+		case len(trimmed) == 0:
+			flush()
+
+		case len(paragraph) == 0 && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")):
+			var code []string
+			for ; i < len(lines); i++ {
+				if l := lines[i]; strings.HasPrefix(l, "    ") {
+					code = append(code, l[4:])
+				} else if strings.HasPrefix(l, "\t") {
+					code = append(code, l[1:])
+				} else if len(strings.TrimSpace(l)) == 0 {
+					code = append(code, "")
+				} else {
+					break
+				}
+			}
+			i--
+			for len(code) > 0 && len(code[len(code)-1]) == 0 {
+				code = code[:len(code)-1]
+			}
+			r.code(code, "")
+
+		case markdownHeading.MatchString(trimmed):
+			flush()
+			m := markdownHeading.FindStringSubmatch(trimmed)
+			r.heading(len(m[1]), m[2])
+
+		case markdownRule.MatchString(line):
+			flush()
+			r.rule()
+
+		case markdownItem.MatchString(line):
+			flush()
+			var items []string
+			for ; i < len(lines) && len(strings.TrimSpace(lines[i])) > 0; i++ {
+				if markdownItem.MatchString(lines[i]) || len(items) == 0 {
+					items = append(items, lines[i])
+				} else {
+					items[len(items)-1] += " " + strings.TrimSpace(lines[i])
+				}
+			}
+			i--
+			r.list(items)
+
+		case markdownQuote.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && markdownQuote.MatchString(lines[i]); i++ {
+				quoted = append(quoted, markdownQuote.FindStringSubmatch(lines[i])[1])
+			}
+			i--
+			prefix := "> "
+			if styled {
+				prefix = styleDim + "│" + styleReset + " "
+			}
+			quote := renderTerminalMarkdown(strings.Join(quoted, "\n"), width-2, styled)
+			r.add(prefix + strings.Replace(quote, "\n", "\n"+prefix, -1))
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flush()
+	return strings.Join(r.blocks, "\n\n")
+}
+
+// markdownRenderer collects the rendered blocks of a markdown document
+type markdownRenderer struct {
+	width  int
+	styled bool
+	blocks []string
+}
+
+func (r *markdownRenderer) add(block string) {
+	r.blocks = append(r.blocks, block)
+}
+
+// style wraps text in a terminal style, if output is styled
+func (r *markdownRenderer) style(style, text string) string {
+	if !r.styled {
+		return text
+	}
+	return style + text + styleReset
+}
+
+func (r *markdownRenderer) heading(level int, text string) {
+	text = r.inline(text)
+	switch {
+	case r.styled && level == 1:
+		r.add(styleBold + styleUnderline + plainText(text) + styleReset)
+	case r.styled:
+		r.add(styleBold + plainText(text) + styleReset)
+	case level <= 2:
+		underline := "="
+		if level == 2 {
+			underline = "-"
+		}
+		r.add(text + "\n" + strings.Repeat(underline, utf8.RuneCountInString(text)))
+	default:
+		r.add(text)
+	}
+}
+
+func (r *markdownRenderer) rule() {
+	width := r.width
+	if width > defaultTerminalWidth {
+		width = defaultTerminalWidth
+	}
+	if r.styled {
+		r.add(r.style(styleDim, strings.Repeat("─", width)))
+		return
+	}
+	r.add(strings.Repeat("-", width))
+}
+
+// code renders a code block indented and unwrapped, highlighted if a
+// highlighter is registered for its language
+func (r *markdownRenderer) code(lines []string, lang string) {
+	code := strings.Join(lines, "\n")
+	if r.styled {
+		highlighters.RLock()
+		highlight, ok := highlighters.languages[strings.ToLower(lang)]
+		highlighters.RUnlock()
+		if ok {
+			code = highlight(code)
+		}
+	}
+	r.add(Indent(code, "    "))
+}
+
+// list renders list items with a hanging indent, nesting items by the
+// indentation of their markers
+func (r *markdownRenderer) list(items []string) {
+	var rendered []string
+	for _, item := range items {
+		m := markdownItem.FindStringSubmatch(item)
+		if m == nil {
+			rendered = append(rendered, r.wrap(r.inline(strings.TrimSpace(item)), "", ""))
+			continue
+		}
+		indent := strings.Repeat("  ", len(strings.Replace(m[1], "\t", "    ", -1))/2)
+
+		marker := "-"
+		if r.styled {
+			marker = "•"
+		}
+		if n, err := strconv.Atoi(strings.TrimRight(m[2], ".)")); err == nil {
+			marker = strconv.Itoa(n) + "."
+		}
+		first := indent + marker + " "
+		rendered = append(rendered, r.wrap(r.inline(m[3]), first, strings.Repeat(" ", utf8.RuneCountInString(first))))
+	}
+	r.add(strings.Join(rendered, "\n"))
+}
+
+// wrap wraps text to the width, prefixing the first line with first and the
+// rest with rest
+func (r *markdownRenderer) wrap(text, first, rest string) string {
+	width := r.width - utf8.RuneCountInString(first)
+	if width < minWrapWidth {
+		width = minWrapWidth
+	}
+	lines := wrapText(text, width)
+	for i := range lines {
+		if i == 0 {
+			lines[i] = first + lines[i]
+		} else {
+			lines[i] = rest + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline renders code spans, emphasis and links
+func (r *markdownRenderer) inline(text string) string {
+	return markdownInline.ReplaceAllStringFunc(text, func(m string) string {
+		switch {
+		case m[0] == '`':
+			if !r.styled {
+				return m
+			}
+			return r.style(styleCode, m[1:len(m)-1])
+		case strings.HasPrefix(m, "**") || strings.HasPrefix(m, "__"):
+			return r.style(styleBold, m[2:len(m)-2])
+		case m[0] == '*':
+			return r.style(styleItalic, m[1:len(m)-1])
+		default:
+			i := strings.Index(m, "](")
+			text, url := m[1:i], m[i+2:len(m)-1]
+			if text == url {
+				return r.style(styleUnderline, url)
+			}
+			return r.style(styleUnderline, text) + " " + r.style(styleDim, "("+url+")")
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMarkdown = `# What's new in 2.0
+
+Widgets now sync **in parallel**, and ` + "`widgets sync`" + ` accepts a
+[catalog](https://example.com/catalog).
+
+## Changes
+
+- Faster syncing of very large catalogs with thousands of widgets
+  - Nested item
+1. Numbered
+
+> Upgrading requires re-authenticating.
+
+` + "```sh" + `
+widgets login
+` + "```" + `
+
+---
+`
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	expected := `What's new in 2.0
+=================
+
+Widgets now sync in parallel, and ` + "`widgets sync`" + `
+accepts a catalog (https://example.com/catalog).
+
+Changes
+-------
+
+- Faster syncing of very large catalogs with
+  thousands of widgets
+  - Nested item
+1. Numbered
+
+> Upgrading requires re-authenticating.
+
+    widgets login
+
+--------------------------------------------------`
+	if rendered := renderTerminalMarkdown(testMarkdown, 50, false); rendered != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, rendered)
+	}
+}
+
+func TestRenderMarkdownStyled(t *testing.T) {
+	RegisterHighlighter("sh", func(code string) string {
+		return "\x1b[32m" + code + "\x1b[0m"
+	})
+	defer func() {
+		highlighters.Lock()
+		delete(highlighters.languages, "sh")
+		highlighters.Unlock()
+	}()
+
+	rendered := renderTerminalMarkdown(testMarkdown, 50, true)
+	for _, expected := range []string{
+		styleBold + styleUnderline + "What's new in 2.0" + styleReset,
+		styleBold + "in" + " parallel" + styleReset,
+		styleCode + "widgets sync" + styleReset,
+		"• Faster syncing",
+		"    \x1b[32mwidgets login\x1b[0m",
+	} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("expected %q within:\n%s", expected, rendered)
+		}
+	}
+
+	for _, line := range strings.Split(rendered, "\n") {
+		if n := visibleLength(line); n > 50 {
+			t.Errorf("expected lines of at most 50 columns, received %d: %q", n, line)
+		}
+	}
+}
+
+func TestSystemRenderMarkdown(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Width: 40})
+	if rendered := system.RenderMarkdown("Run `widgets sync` **now**"); rendered != "Run `widgets sync` now" {
+		t.Errorf("expected plain text for output which isn't a terminal, received %q", rendered)
+	}
+}
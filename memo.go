@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WithCache enables Memo for app, caching results in its cache directory, and
+// adds `--no-cache` and `--refresh` flags. `--no-cache` neither reads nor
+// writes cached results; `--refresh` ignores cached results but stores fresh
+// ones.
+func WithCache(app string) Option {
+	return func(c *config) {
+		c.cache = app
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("no-cache", false, "don't use or store cached results")
+			f.Bool("refresh", false, "ignore cached results and store fresh ones")
+		})
+	}
+}
+
+type memoCache struct {
+	sys     System
+	dir     string
+	noCache bool
+	refresh bool
+
+	mu    sync.Mutex
+	swept bool
+}
+
+// memoEntry is a cached result, stored as JSON in its own file
+type memoEntry struct {
+	Expires time.Time `json:"expires"`
+	Data    []byte    `json:"data"`
+}
+
+// Memo returns the result of fn, caching it for ttl. Results are keyed by the
+// path of the running command and key, which is normalized by encoding it as
+// JSON, so any value which encodes consistently may be used. If caching
+// wasn't enabled with WithCache, fn is always called. Failing to cache a
+// result is logged but doesn't fail the call.
+func Memo(ctx context.Context, key interface{}, ttl time.Duration, fn func() ([]byte, error)) ([]byte, error) {
+	c, ok := ctx.Value("cache").(*memoCache)
+	if !ok || c.noCache {
+		return fn()
+	}
+
+	origin, _ := ctx.Value("origin").(string)
+	normalized, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to use %T as a cache key: %s", key, err)
+	}
+	sum := sha256.Sum256(append([]byte(origin+"\x00"), normalized...))
+	path := filepath.Join(c.dir, fmt.Sprintf("%x.json", sum))
+
+	if !c.refresh {
+		if e, ok := c.read(path); ok && time.Now().Before(e.Expires) {
+			return e.Data, nil
+		}
+	}
+
+	b, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.write(path, memoEntry{Expires: time.Now().Add(ttl), Data: b}); err != nil {
+		c.sys.Logf(tr(c.sys, "Unable to cache result: %s\n"), err)
+	}
+	return b, nil
+}
+
+func (c *memoCache) read(path string) (memoEntry, bool) {
+	var e memoEntry
+	b, err := c.sys.ReadFile(path)
+	if err != nil {
+		return e, false
+	}
+	return e, json.Unmarshal(b, &e) == nil
+}
+
+func (c *memoCache) write(path string, e memoEntry) error {
+	if err := c.sys.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := c.sys.WriteFile(path, b, 0600); err != nil {
+		return err
+	}
+
+	c.sweep()
+	return nil
+}
+
+// sweep removes expired and unreadable entries from the cache, once per run
+func (c *memoCache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.swept {
+		return
+	}
+	c.swept = true
+
+	infos, err := c.sys.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(c.dir, info.Name())
+		if e, ok := c.read(path); !ok || now.After(e.Expires) {
+			c.sys.Remove(path)
+		}
+	}
+}
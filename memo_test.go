@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testMemoCommand struct {
+	calls int
+}
+
+func (c *testMemoCommand) Help() {}
+
+func (c *testMemoCommand) Command(ctx context.Context, args []string, s System) error {
+	b, err := Memo(ctx, map[string]string{"region": "us-east"}, time.Hour, func() ([]byte, error) {
+		c.calls++
+		return []byte("expensive"), nil
+	})
+	if err != nil {
+		return err
+	}
+	s.Println(string(b))
+	return nil
+}
+
+func TestMemo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	environment := map[string]string{"XDG_CACHE_HOME": dir}
+
+	// an expired entry left by an earlier run
+	stale := filepath.Join(dir, "testmemo", "memo", "stale.json")
+	if err := os.MkdirAll(filepath.Dir(stale), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(stale, []byte(`{"expires":"2000-01-01T00:00:00Z"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &testMemoCommand{}
+	for _, arguments := range [][]string{
+		{"testmemo"},
+		{"testmemo"},
+		{"testmemo", "--refresh"},
+		{"testmemo", "--no-cache"},
+	} {
+		result, _ := runMainWithEnv(t, cmd, arguments, environment, WithCache("testmemo"))
+		if result != 0 {
+			t.Errorf("command did not return a 0 status\n")
+		}
+	}
+
+	if cmd.calls != 3 {
+		t.Errorf("expected 3 uncached calls, received %d\n", cmd.calls)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expired cache entry was not removed\n")
+	}
+}
+
+func TestMemoWriteFailure(t *testing.T) {
+	f, err := ioutil.TempFile("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// the cache directory can't be created beneath a regular file
+	environment := map[string]string{"XDG_CACHE_HOME": f.Name()}
+	cmd := &testMemoCommand{}
+	result, output := runMainWithEnv(t, cmd, []string{"testmemo"}, environment, WithCache("testmemo"))
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `expensive`)
+	ExpectMatch(t, *output.STDERR, `Unable to cache result`)
+}
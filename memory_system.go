@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// MemorySystemOptions configures a System created by NewMemorySystem
+type MemorySystemOptions struct {
+	Arguments   []string
+	Environment map[string]string
+
+	// Input is read by the command. If nil, the command reads from the
+	// Stdin buffer returned by NewMemorySystem, which may be filled before
+	// the command is run.
+	Input io.Reader
+
+	// Interactive makes the System report that input is attached to a
+	// terminal, so that prompts are shown rather than refused
+	Interactive bool
+
+	// AssumeYes answers confirmation prompts affirmatively without reading
+	// input
+	AssumeYes bool
+
+	// Width is the number of columns output is wrapped to; 80 if unset
+	Width int
+
+	// Dir is the System's working directory; the process's if unset.
+	// Changing it with Chdir doesn't affect the process.
+	Dir string
+}
+
+// MemoryOutput holds the buffers of a System created by NewMemorySystem. The
+// buffers aren't safe for concurrent use, so they should be read once the
+// command has returned.
+type MemoryOutput struct {
+	Stdin  *bytes.Buffer
+	Stdout *bytes.Buffer
+	Stderr *bytes.Buffer
+}
+
+// MemorySystem is a System whose input and output are in memory, for
+// programs which embed command execution, such as servers which run commands
+// on behalf of users, notebooks and bots. Unlike a TestSystem it has no
+// pseudoterminal and doesn't depend on the testing package. It never starts
+// other programs to interact with the user: passwords are read from its
+// input, diffs are printed rather than shown in an external tool, URLs
+// given to OpenBrowser are printed for the user to open, Edit fails and the
+// clipboard is kept in memory. Nor does it catch the signals sent to the
+// process; those sent with Signal are delivered instead.
+type MemorySystem struct {
+	*BaseSystem
+
+	interactive bool
+
+	clipboard struct {
+		sync.Mutex
+		text string
+	}
+}
+
+// NewMemorySystem returns a MemorySystem along with its buffers. Logged
+// messages are written to Stderr without timestamps.
+func NewMemorySystem(opts MemorySystemOptions) (*MemorySystem, *MemoryOutput) {
+	output := &MemoryOutput{
+		Stdin:  &bytes.Buffer{},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+	}
+
+	in := opts.Input
+	if in == nil {
+		in = output.Stdin
+	}
+	environment := opts.Environment
+	if environment == nil {
+		environment = map[string]string{}
+	}
+	dir := opts.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+	width := opts.Width
+	if width <= 0 {
+		width = defaultTerminalWidth
+	}
+
+	return &MemorySystem{
+		BaseSystem: &BaseSystem{
+			In:          in,
+			Out:         output.Stdout,
+			Err:         output.Stderr,
+			Logger:      log.New(output.Stderr, "", 0),
+			Environment: environment,
+			Arguments:   opts.Arguments,
+			Dir:         dir,
+			AssumeYes:   opts.AssumeYes,
+			Width:       width,
+		},
+		interactive: opts.Interactive,
+	}, output
+}
+
+// Interactive reports whether the System was created with Interactive set
+func (s *MemorySystem) Interactive() bool {
+	return s.interactive
+}
+
+// ReadPassword reads a line of input. Nothing is echoed, since the System's
+// output isn't a terminal.
+func (s *MemorySystem) ReadPassword() (string, error) {
+	return readLine(s)
+}
+
+// Edit fails, since there is no terminal to run an editor in
+func (s *MemorySystem) Edit(initial []byte, pattern string) ([]byte, error) {
+	return nil, &ExitError{Status: ExitFailure, Message: tr(s, "Unable to edit without a terminal")}
+}
+
+// OpenBrowser prints the URL, asking the user to open it
+func (s *MemorySystem) OpenBrowser(address string) error {
+	if err := checkBrowserURL(address); err != nil {
+		return err
+	}
+	_, err := s.Println(Localize(s, "Open %s in your browser", address))
+	return err
+}
+
+// ReadClipboard returns the text of the System's clipboard
+func (s *MemorySystem) ReadClipboard() (string, error) {
+	s.clipboard.Lock()
+	defer s.clipboard.Unlock()
+	return s.clipboard.text, nil
+}
+
+// WriteClipboard puts text on the System's clipboard
+func (s *MemorySystem) WriteClipboard(text string) error {
+	s.clipboard.Lock()
+	defer s.clipboard.Unlock()
+	s.clipboard.text = text
+	return nil
+}
+
+// ExternalDiff prints a unified diff of old and new
+func (s *MemorySystem) ExternalDiff(old, new []byte) error {
+	_, err := s.Print(UnifiedDiff("old", "new", old, new))
+	return err
+}
+
+// Notify returns a channel which receives the given signals when they are
+// sent with Signal, until ctx is done
+func (s *MemorySystem) Notify(ctx context.Context, signals ...os.Signal) <-chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	s.subscribe(ctx, ch, signals)
+	return ch
+}
+
+// Signal delivers sig to the channels returned by Notify, e.g. to forward a
+// request to reload from the program embedding the command. It reports
+// whether the command was listening for sig.
+func (s *MemorySystem) Signal(sig os.Signal) bool {
+	return s.signalled(sig)
+}
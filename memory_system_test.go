@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type testMemoryCommand struct {
+	DefaultHelp
+}
+
+func (c *testMemoryCommand) Command(ctx context.Context, args []string, s System) error {
+	var name string
+	if _, err := s.Scanf("%s\n", &name); err != nil {
+		return err
+	}
+	s.Printf("Hello, %s\n", name)
+
+	ok, err := Confirm(s, "Continue?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &ExitError{Status: ExitFailure, Message: "Not continuing"}
+	}
+	return nil
+}
+
+func TestMemorySystem(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Arguments:   []string{"greet"},
+		Interactive: true,
+	})
+	output.Stdin.WriteString("world\ny\n")
+
+	result := Main(context.Background(), &testMemoryCommand{}, system)
+	ExpectExitCode(t, result, ExitOK)
+
+	if expected := "Hello, world\nContinue? [y/N]: "; output.Stdout.String() != expected {
+		t.Errorf("expected output %q, received %q\n", expected, output.Stdout.String())
+	}
+	if output.Stderr.Len() > 0 {
+		t.Errorf("expected nothing to be logged, received %q\n", output.Stderr.String())
+	}
+
+	system, output = NewMemorySystem(MemorySystemOptions{Arguments: []string{"greet"}})
+	output.Stdin.WriteString("world\n")
+	ExpectExitCode(t, Main(context.Background(), &testMemoryCommand{}, system), ExitFailure)
+	ExpectMatch(t, *output.Stderr, `Refusing to continue without confirmation`)
+}
+
+func TestEprint(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	system.Println("output")
+	system.Eprintf("warning: %s\n", "disk nearly full")
+
+	prefixed := PrefixSystem(system, "[a] ")
+	prefixed.Eprint("partial ")
+	prefixed.Eprintln("diagnostic")
+	prefixed.Println("result")
+
+	if expected := "output\n[a] result\n"; output.Stdout.String() != expected {
+		t.Errorf("expected output %q, received %q\n", expected, output.Stdout.String())
+	}
+	if expected := "warning: disk nearly full\n[a] partial diagnostic\n"; output.Stderr.String() != expected {
+		t.Errorf("expected diagnostics %q, received %q\n", expected, output.Stderr.String())
+	}
+}
+
+func TestStreams(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	output.Stdin.WriteString("copied\n")
+
+	if _, err := io.Copy(system.Stdout(), system.Stdin()); err != nil {
+		t.Fatal(err)
+	}
+	prefixed := PrefixSystem(system, "[a] ")
+	if err := json.NewEncoder(prefixed.Stdout()).Encode(map[string]int{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprint(prefixed.Stderr(), "first\nsecond\n")
+
+	if expected := "copied\n[a] {\"n\":1}\n"; output.Stdout.String() != expected {
+		t.Errorf("expected output %q, received %q\n", expected, output.Stdout.String())
+	}
+	if expected := "[a] first\n[a] second\n"; output.Stderr.String() != expected {
+		t.Errorf("expected diagnostics %q, received %q\n", expected, output.Stderr.String())
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithPrometheusTextfile writes metrics describing the runs of app to
+// `app.prom` within dir once each run completes, for the textfile collector of
+// the Prometheus node exporter. This makes scheduled runs, such as cron jobs,
+// observable without a wrapper script. Metrics are labeled by command:
+//
+//   - `<app>_last_run_timestamp_seconds` is when the last run completed
+//   - `<app>_last_run_duration_seconds` is how long it took
+//   - `<app>_last_run_status` is the status it exited with
+//   - `<app>_runs_total` counts runs, also labeled by status
+//
+// The file is read and rewritten by each run, so concurrent runs of the
+// program may lose a count.
+func WithPrometheusTextfile(app, dir string) Option {
+	return func(c *config) {
+		c.metrics = &promTextfile{
+			prefix: promName(app),
+			path:   filepath.Join(dir, app+".prom"),
+		}
+	}
+}
+
+// promTextfile is a file of metrics for the textfile collector
+type promTextfile struct {
+	prefix string
+	path   string
+}
+
+// promMetrics describes the metrics written to a promTextfile, in the order
+// they are written
+var promMetrics = []struct {
+	name, kind, help string
+}{
+	{"last_run_timestamp_seconds", "gauge", "Time the last run completed, in seconds since the epoch."},
+	{"last_run_duration_seconds", "gauge", "Duration of the last run, in seconds."},
+	{"last_run_status", "gauge", "Exit status of the last run."},
+	{"runs_total", "counter", "Number of runs completed, by exit status."},
+}
+
+// update records a run in the file, keeping the samples of previous runs
+func (p *promTextfile) update(sys System, record RunRecord) error {
+	samples, err := p.read(sys)
+	if err != nil {
+		return err
+	}
+
+	command := promLabel("command", record.Command)
+	status := promLabel("status", strconv.Itoa(record.Status))
+	end := record.Start.Add(record.Duration)
+	samples[p.prefix+"_last_run_timestamp_seconds{"+command+"}"] = float64(end.UnixNano()) / 1e9
+	samples[p.prefix+"_last_run_duration_seconds{"+command+"}"] = record.Duration.Seconds()
+	samples[p.prefix+"_last_run_status{"+command+"}"] = float64(record.Status)
+	samples[p.prefix+"_runs_total{"+command+","+status+"}"]++
+
+	var b bytes.Buffer
+	for _, m := range promMetrics {
+		name := p.prefix + "_" + m.name
+		var series []string
+		for s := range samples {
+			if strings.HasPrefix(s, name+"{") {
+				series = append(series, s)
+			}
+		}
+		if len(series) == 0 {
+			continue
+		}
+		sort.Strings(series)
+
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, m.help, name, m.kind)
+		for _, s := range series {
+			fmt.Fprintf(&b, "%s %s\n", s, strconv.FormatFloat(samples[s], 'f', -1, 64))
+		}
+	}
+
+	if err := sys.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	return sys.WriteFile(p.path, b.Bytes(), 0644)
+}
+
+// read returns the samples in the file, keyed by metric name and labels.
+// Samples of metrics other than those the file is written with are dropped.
+func (p *promTextfile) read(sys System) (map[string]float64, error) {
+	samples := map[string]float64{}
+	data, err := sys.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return samples, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		i := strings.LastIndexByte(line, ' ')
+		if len(line) == 0 || line[0] == '#' || i < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			continue
+		}
+		for _, m := range promMetrics {
+			if strings.HasPrefix(line, p.prefix+"_"+m.name+"{") {
+				samples[line[:i]] = value
+			}
+		}
+	}
+	return samples, scanner.Err()
+}
+
+// promName converts s into a valid metric name
+func promName(s string) string {
+	name := []rune(s)
+	for i, r := range name {
+		valid := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9'
+		if !valid {
+			name[i] = '_'
+		}
+	}
+	return string(name)
+}
+
+// promLabel formats a label with an escaped value
+func promLabel(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return fmt.Sprintf(`%s="%s"`, name, value)
+}
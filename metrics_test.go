@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestPrometheusTextfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(cmd Command, args ...string) int {
+		system, _ := NewMemorySystem(MemorySystemOptions{Arguments: append([]string{"widgets"}, args...)})
+		return Main(context.Background(), cmd, system, WithPrometheusTextfile("widget-sync", dir))
+	}
+	ExpectExitCode(t, run(&testOutputCommand{}, "api"), ExitFailure)
+	ExpectExitCode(t, run(&testOutputCommand{}, "api"), ExitFailure)
+	ExpectExitCode(t, run(&testExitCommand{status: ExitOK}), ExitOK)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "widget-sync.prom"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, re := range []string{
+		`(?m)^# TYPE widget_sync_runs_total counter$`,
+		`(?m)^widget_sync_runs_total\{command="widgets",status="1"\} 2$`,
+		`(?m)^widget_sync_runs_total\{command="widgets",status="0"\} 1$`,
+		`(?m)^widget_sync_last_run_status\{command="widgets"\} 0$`,
+		`(?m)^widget_sync_last_run_duration_seconds\{command="widgets"\} [0-9.e-]+$`,
+		`(?m)^widget_sync_last_run_timestamp_seconds\{command="widgets"\} \d+(\.\d+)?$`,
+	} {
+		if !regexp.MustCompile(re).Match(b) {
+			t.Errorf("expected metrics to match %s\n%s", re, b)
+		}
+	}
+}
+
+func TestPromLabel(t *testing.T) {
+	if actual := promLabel("command", "say \"hi\"\\\n"); actual != `command="say \"hi\"\\\n"` {
+		t.Errorf("unexpected label %s\n", actual)
+	}
+	if actual := promName("9-lives.app"); actual != "__lives_app" {
+		t.Errorf("unexpected name %s\n", actual)
+	}
+}
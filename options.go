@@ -0,0 +1,138 @@
+package cli
+
+import "flag"
+
+// Option configures optional behavior of Main
+type Option func(*config)
+
+type config struct {
+	// subcommands are built-in subcommands installed beneath the root command.
+	// Subcommands defined by the root command take precedence.
+	subcommands CLI
+
+	// flags define framework-level flags available to every command. Flags
+	// defined by the command itself take precedence.
+	flags []func(*flag.FlagSet)
+
+	version *VersionInfo
+	pipe    bool
+	pager   bool
+
+	// changelog is offered when the version differs from the last which ran
+	changelog *changelogOffer
+
+	// reauth logs the user in again when a command's session expires
+	reauth *reauth
+
+	// compat returns the oldest version the server supports, checked by
+	// `version --check-compat`
+	compat MinimumVersion
+
+	assumeYes     bool
+	responseFiles bool
+	expandEnv     bool
+	sortLocale    bool
+	strictInput   bool
+	githubActions bool
+	keepTemp      bool
+	conflictFlags bool
+	maskPasswords bool
+	curl          bool
+	debugHTTP     bool
+	noColor       bool
+
+	// runLog is the name of the application whose runs are recorded
+	runLog string
+
+	// cache is the name of the application whose results Memo caches
+	cache string
+
+	// outputs are the formats which may be selected with `--output`
+	outputs map[string]OutputRenderer
+
+	// reports are the formats which may be written with `--report`
+	reports map[string]OutputRenderer
+
+	// metrics is updated with each run
+	metrics *promTextfile
+
+	// logTarget is the name of the application whose messages are sent to
+	// the target selected with `--log-target`
+	logTarget string
+
+	// debugLog is the name of the application whose messages are mirrored
+	// to its debug log
+	debugLog string
+
+	// dirs is the name of the application whose directories may be
+	// replaced with `--config-dir`, `--state-dir` and `--cache-dir`
+	dirs string
+
+	// usage is the name of the application whose commands' uses are counted
+	usage string
+
+	helpTemplate string
+}
+
+// WithEnvExpansion expands `$VAR` and `${VAR}` references in positional
+// arguments using the System's environment before the command is run, so that
+// arguments are interpreted identically regardless of the invoking shell.
+// Flags and arguments passed through to a catch-all command are not expanded.
+func WithEnvExpansion() Option {
+	return func(c *config) {
+		c.expandEnv = true
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{subcommands: CLI{}}
+	for _, o := range opts {
+		o(c)
+	}
+	if v, ok := c.subcommands["version"].(*VersionCommand); ok {
+		v.compat = c.compat
+	}
+	return c
+}
+
+// defineFlags adds the framework-level flags to f, skipping any that have
+// already been defined by the command. It returns the flags which were added,
+// so that a command's own flag of the same name isn't mistaken for one.
+func (c *config) defineFlags(f *flag.FlagSet) frameworkFlags {
+	defined := frameworkFlags{}
+	for _, define := range c.flags {
+		scratch := flag.NewFlagSet("", flag.ContinueOnError)
+		define(scratch)
+		scratch.VisitAll(func(fl *flag.Flag) {
+			if f.Lookup(fl.Name) == nil {
+				f.Var(fl.Value, fl.Name, fl.Usage)
+				defined[fl.Name] = fl.Value
+			}
+		})
+	}
+	return defined
+}
+
+// frameworkFlags holds the values of the framework-level flags defined for a
+// run, keyed by name
+type frameworkFlags map[string]flag.Value
+
+// isSet reports whether the named flag was set to a true value on the
+// command line
+func (ff frameworkFlags) isSet(name string) bool {
+	if g, ok := ff[name].(flag.Getter); ok {
+		if b, ok := g.Get().(bool); ok {
+			return b
+		}
+	}
+	return false
+}
+
+// value returns the string representation of the named flag's value, or an
+// empty string if the flag isn't defined
+func (ff frameworkFlags) value(name string) string {
+	if v, ok := ff[name]; ok {
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"strings"
+	"unicode"
+)
+
+// otpAttempts is how many times PromptOTP asks for a code before giving up
+const otpAttempts = 3
+
+// PromptOTP asks for the one-time code of a second authentication factor,
+// reading it without echo. Whitespace is removed, so that codes pasted from
+// an authenticator which groups their digits, e.g. `123 456`, are accepted.
+// A code which isn't 6 to 8 digits is asked for again, up to three times in
+// all. Checking the code with the service is left to the caller.
+func PromptOTP(sys System) (string, error) {
+	for attempt := 1; ; attempt++ {
+		if _, err := sys.Print(tr(sys, "Authentication code: ")); err != nil {
+			return "", err
+		}
+		answer, err := sys.ReadLineNoEcho()
+		if err != nil {
+			return "", err
+		}
+
+		code := strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, answer)
+		if isOTP(code) {
+			return code, nil
+		}
+
+		if attempt == otpAttempts {
+			return "", &ExitError{
+				Status:  ExitFailure,
+				Message: tr(sys, "No valid authentication code was entered"),
+			}
+		}
+		if _, err := sys.Println(tr(sys, "Enter the 6 to 8 digit code from your authenticator")); err != nil {
+			return "", err
+		}
+	}
+}
+
+// isOTP reports whether code is 6 to 8 ASCII digits
+func isOTP(code string) bool {
+	if len(code) < 6 || len(code) > 8 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptOTP(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader("12345\n123 456\n")})
+
+	code, err := PromptOTP(system)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "123456" {
+		t.Errorf("expected the code without whitespace, received %q\n", code)
+	}
+	ExpectMatch(t, *output.Stdout, `Enter the 6 to 8 digit code`)
+
+	system, _ = NewMemorySystem(MemorySystemOptions{Input: strings.NewReader("abcdef\n1\n123456789\n")})
+	if _, err := PromptOTP(system); err == nil {
+		t.Error("expected an error after three invalid codes")
+	}
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputRenderer renders the record of a run in an output format selected
+// with `--output`. The record's Command begins with the program's name, and
+// its Output holds everything the command printed.
+type OutputRenderer func(w io.Writer, record RunRecord) error
+
+// WithOutput adds an `--output` flag which selects how the result of a run is
+// presented. The default, `text`, prints output as the command writes it.
+// Other formats capture the output and render it, together with the run's
+// status and events, once the command has returned:
+//
+//   - `markdown` renders a fenced code block, collapsing long output
+//   - `slack` renders Slack mrkdwn, truncating long output
+//   - `html` renders a standalone report with WriteHTMLReport
+//   - `sarif` renders findings for code scanning tools with WriteSARIF
+//
+// Escape sequences are removed, so that automations relaying output into chat
+// don't post terminal control codes.
+func WithOutput() Option {
+	return func(c *config) {
+		c.addOutput("markdown", renderMarkdown)
+		c.addOutput("slack", renderSlack)
+		c.addOutput("html", WriteHTMLReport)
+		c.addOutput("sarif", WriteSARIF)
+	}
+}
+
+// WithOutputFormat adds a format to the `--output` flag, which is installed
+// if it isn't already
+func WithOutputFormat(name string, render OutputRenderer) Option {
+	return func(c *config) {
+		c.addOutput(name, render)
+	}
+}
+
+// addOutput registers an output format, installing the `--output` flag along
+// with the first
+func (c *config) addOutput(name string, render OutputRenderer) {
+	if c.outputs == nil {
+		c.outputs = map[string]OutputRenderer{}
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			formats := []string{"text"}
+			for name := range c.outputs {
+				formats = append(formats, name)
+			}
+			sort.Strings(formats)
+			f.String("output", "", "output `format`: "+strings.Join(formats, ", "))
+		})
+	}
+	c.outputs[name] = render
+}
+
+// captureOutput redirects the output of sys into buf until the returned
+// function is called. It returns nil if the System's output can't be
+// redirected.
+func captureOutput(sys System, buf *bytes.Buffer) (restore func()) {
+	s, ok := baseOf(sys)
+	if !ok {
+		return nil
+	}
+	out := s.Out
+	s.Out = buf
+	return func() { s.Out = out }
+}
+
+// escapeSequence matches terminal control sequences: CSI sequences such as
+// colors, OSC sequences such as titles and hyperlinks, and two-character
+// escapes
+var escapeSequence = regexp.MustCompile(
+	"\x1b\\[[0-?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)|\x1b[@-Z\\\\-_]")
+
+// plainText removes terminal control sequences from s. Lines redrawn with a
+// carriage return, such as progress bars, are reduced to what was drawn last.
+func plainText(s string) string {
+	s = escapeSequence.ReplaceAllString(s, "")
+	s = strings.Replace(s, "\r\n", "\n", -1)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			lines[i] = line[j+1:]
+		}
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// runSummary describes the outcome of a run in a sentence, with the command
+// formatted by code
+func runSummary(record RunRecord, code func(string) string) string {
+	command := code(strings.TrimSpace(record.Command + " " + strings.Join(record.Args, " ")))
+	if record.Status == ExitOK {
+		return fmt.Sprintf("%s succeeded", command)
+	}
+	summary := fmt.Sprintf("%s failed with status %d", command, record.Status)
+	if len(record.Error) > 0 {
+		summary += ": " + record.Error
+	}
+	return summary
+}
+
+// markdownCollapseLines is the number of lines of output beyond which the
+// markdown renderer collapses it
+const markdownCollapseLines = 20
+
+// renderMarkdown renders a run as GitHub-flavored markdown
+func renderMarkdown(w io.Writer, record RunRecord) error {
+	var b strings.Builder
+	b.WriteString(runSummary(record, markdownCode))
+	b.WriteString("\n")
+
+	if len(record.Events) > 0 {
+		b.WriteString("\n")
+		for _, e := range record.Events {
+			fmt.Fprintf(&b, "- **%s** %s\n", e.Kind, e.Message)
+		}
+	}
+
+	if output := plainText(record.Output); len(output) > 0 {
+		fence := "```"
+		for strings.Contains(output, fence) {
+			fence += "`"
+		}
+		block := fmt.Sprintf("%s\n%s\n%s\n", fence, output, fence)
+
+		b.WriteString("\n")
+		if lines := strings.Count(output, "\n") + 1; lines > markdownCollapseLines {
+			fmt.Fprintf(&b, "<details>\n<summary>Output (%d lines)</summary>\n\n%s\n</details>\n", lines, block)
+		} else {
+			b.WriteString(block)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCode formats s as inline code
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// slackMaxLines is the number of lines of output the slack renderer shows
+const slackMaxLines = 40
+
+// renderSlack renders a run as Slack mrkdwn
+func renderSlack(w io.Writer, record RunRecord) error {
+	var b strings.Builder
+	b.WriteString(slackEscape(runSummary(record, func(s string) string {
+		return "`" + strings.Replace(s, "`", "'", -1) + "`"
+	})))
+	b.WriteString("\n")
+
+	for _, e := range record.Events {
+		fmt.Fprintf(&b, "• *%s* %s\n", slackEscape(e.Kind), slackEscape(e.Message))
+	}
+
+	if output := plainText(record.Output); len(output) > 0 {
+		lines := strings.Split(output, "\n")
+		var omitted int
+		if len(lines) > slackMaxLines {
+			omitted = len(lines) - slackMaxLines
+			lines = lines[:slackMaxLines]
+		}
+
+		// Slack has no way of escaping a fence within a code block
+		output = strings.Replace(strings.Join(lines, "\n"), "```", "`\u200b``", -1)
+		fmt.Fprintf(&b, "```\n%s\n```\n", slackEscape(output))
+		if omitted > 0 {
+			fmt.Fprintf(&b, "_%d more lines not shown_\n", omitted)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// slackEscape escapes the characters Slack interprets as markup in text
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
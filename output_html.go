@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteHTMLReport writes a standalone HTML page describing a run: its
+// command, status and timing, the events recorded with RecordEvent, each
+// value emitted with Emit as a table, and the command's output. Sections are
+// collapsible, and the page has no external dependencies, so it may be
+// attached to an email or published as a CI artifact.
+func WriteHTMLReport(w io.Writer, record RunRecord) error {
+	data := htmlReport{
+		Title:    strings.TrimSpace(record.Command + " " + strings.Join(record.Args, " ")),
+		Record:   record,
+		Start:    record.Start.Format(time.RFC3339),
+		Duration: record.Duration.Round(time.Millisecond).String(),
+		Output:   plainText(record.Output),
+	}
+	for i, value := range record.Data {
+		t := tabulate(value)
+		t.Title = "Data"
+		if len(record.Data) > 1 {
+			t.Title = fmt.Sprintf("Data %d", i+1)
+		}
+		data.Data = append(data.Data, t)
+	}
+	return htmlReportTemplate.Execute(w, data)
+}
+
+type htmlReport struct {
+	Title    string
+	Record   RunRecord
+	Start    string
+	Duration string
+	Data     []htmlTable
+	Output   string
+}
+
+// htmlTable is a value laid out as a table. Values which can't be are shown
+// as preformatted text instead.
+type htmlTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+	Text    string
+}
+
+// tabulate lays out a value as a table. A slice of structs or maps has a row
+// per element and a column per field or key; a struct or map has a row per
+// field or key.
+func tabulate(value interface{}) htmlTable {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return htmlTable{Text: "null"}
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var t htmlTable
+		columns := map[string]int{}
+		var rows []map[string]string
+		for i := 0; i < v.Len(); i++ {
+			fields := tableFields(v.Index(i))
+			if fields == nil {
+				fields = []tableField{{"Value", formatCell(v.Index(i))}}
+			}
+			row := map[string]string{}
+			for _, f := range fields {
+				if _, ok := columns[f.name]; !ok {
+					columns[f.name] = len(t.Columns)
+					t.Columns = append(t.Columns, f.name)
+				}
+				row[f.name] = f.value
+			}
+			rows = append(rows, row)
+		}
+		for _, row := range rows {
+			cells := make([]string, len(t.Columns))
+			for name, value := range row {
+				cells[columns[name]] = value
+			}
+			t.Rows = append(t.Rows, cells)
+		}
+		return t
+
+	case reflect.Struct, reflect.Map:
+		t := htmlTable{Columns: []string{"Field", "Value"}}
+		if v.Kind() == reflect.Map {
+			t.Columns[0] = "Key"
+		}
+		for _, f := range tableFields(v) {
+			t.Rows = append(t.Rows, []string{f.name, f.value})
+		}
+		return t
+	}
+	return htmlTable{Text: formatCell(v)}
+}
+
+type tableField struct {
+	name  string
+	value string
+}
+
+// tableFields returns the exported fields of a struct, named as they would be
+// in JSON, or the entries of a map sorted by key. It returns nil for any other
+// value.
+func tableFields(v reflect.Value) []tableField {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var fields []tableField
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if len(sf.PkgPath) > 0 {
+				continue
+			}
+			name := sf.Name
+			if tag := strings.Split(sf.Tag.Get("json"), ",")[0]; tag == "-" {
+				continue
+			} else if len(tag) > 0 {
+				name = tag
+			}
+			fields = append(fields, tableField{name, formatCell(v.Field(i))})
+		}
+		if fields == nil {
+			fields = []tableField{}
+		}
+
+	case reflect.Map:
+		fields = []tableField{}
+		for _, key := range v.MapKeys() {
+			fields = append(fields, tableField{fmt.Sprint(key.Interface()), formatCell(v.MapIndex(key))})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	}
+	return fields
+}
+
+// formatCell formats a value for a table cell. Composite values are shown as
+// JSON.
+func formatCell(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		b, err := json.Marshal(v.Interface())
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.5em; font-family: monospace; }
+summary { cursor: pointer; font-weight: 600; margin: 1em 0 0.5em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #d0d7de; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.succeeded { color: #1a7f37; }
+.failed { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Status</th><td class="{{if eq .Record.Status 0}}succeeded{{else}}failed{{end}}">{{.Record.Status}}</td></tr>
+{{- if .Record.Error}}
+<tr><th>Error</th><td>{{.Record.Error}}</td></tr>
+{{- end}}
+<tr><th>Started</th><td>{{.Start}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{- if .Record.TraceID}}
+<tr><th>Trace ID</th><td>{{.Record.TraceID}}</td></tr>
+{{- end}}
+</table>
+{{- if .Record.Events}}
+<details open>
+<summary>Events</summary>
+<table>
+<tr><th>Time</th><th>Kind</th><th>Message</th></tr>
+{{- range .Record.Events}}
+<tr><td>{{.Time.Format "15:04:05"}}</td><td>{{.Kind}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+</details>
+{{- end}}
+{{- range $table := .Data}}
+<details open>
+<summary>{{$table.Title}}</summary>
+{{- if $table.Columns}}
+<table>
+<tr>{{range $table.Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range $table.Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- else}}
+<pre>{{$table.Text}}</pre>
+{{- end}}
+</details>
+{{- end}}
+{{- if .Output}}
+<details>
+<summary>Output</summary>
+<pre>{{.Output}}</pre>
+</details>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sarifVersion is the version of SARIF written by WriteSARIF
+const sarifVersion = "2.1.0"
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// WriteSARIF writes a run as a SARIF log, which GitHub code scanning and
+// other static analysis tooling can consume. Each finding recorded with
+// RecordFinding becomes a result at its location, and each warning recorded
+// with RecordEvent becomes a result without one. The run's status and error
+// are reported as the tool's invocation.
+func WriteSARIF(w io.Writer, record RunRecord) error {
+	program := strings.SplitN(record.Command, " ", 2)[0]
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: program}},
+		Invocations: []sarifInvocation{{
+			ExecutionSuccessful: record.Status == ExitOK,
+			ExitCode:            record.Status,
+			StartTimeUTC:        record.Start.UTC().Format(time.RFC3339),
+			EndTimeUTC:          record.Start.Add(record.Duration).UTC().Format(time.RFC3339),
+		}},
+		Results: []sarifResult{},
+	}
+	if len(record.Error) > 0 {
+		run.Invocations[0].Notifications = []sarifNotification{{
+			Level:   FindingError,
+			Message: sarifMessage{Text: record.Error},
+		}}
+	}
+
+	rules := map[string]bool{}
+	for _, f := range record.Findings {
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   f.Level,
+			Message: sarifMessage{Text: f.Message},
+		}
+		if len(result.Level) == 0 {
+			result.Level = FindingWarning
+		}
+		if len(f.Path) > 0 {
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.Path)},
+			}
+			if f.Line > 0 {
+				location.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+		run.Results = append(run.Results, result)
+
+		if len(f.RuleID) > 0 && !rules[f.RuleID] {
+			rules[f.RuleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: f.RuleID})
+		}
+	}
+	for _, e := range record.Events {
+		if e.Kind == EventWarning {
+			run.Results = append(run.Results, sarifResult{
+				Level:   FindingWarning,
+				Message: sarifMessage{Text: e.Message},
+			})
+		}
+	}
+
+	b, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool                `json:"executionSuccessful"`
+	ExitCode            int                 `json:"exitCode"`
+	StartTimeUTC        string              `json:"startTimeUtc"`
+	EndTimeUTC          string              `json:"endTimeUtc"`
+	Notifications       []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
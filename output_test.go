@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testOutputCommand struct {
+	DefaultHelp
+
+	lines int
+}
+
+func (c *testOutputCommand) Command(ctx context.Context, args []string, s System) error {
+	RecordEvent(ctx, EventWarning, "3 widgets are <deprecated>")
+	s.Println("\x1b[1mNAME\x1b[0m    STATUS")
+	s.Print("50%\r100%\n")
+	for i := 0; i < c.lines; i++ {
+		s.Println(i)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("Unable to reach %s", args[0])
+	}
+	return nil
+}
+
+func runOutput(t *testing.T, cmd Command, args ...string) (int, *MemoryOutput) {
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: append([]string{"widgets"}, args...)})
+	return Main(context.Background(), cmd, system, WithOutput()), output
+}
+
+func TestMarkdownOutput(t *testing.T) {
+	result, output := runOutput(t, &testOutputCommand{}, "--output", "markdown", "api")
+	ExpectExitCode(t, result, ExitFailure)
+
+	expected := "`widgets api` failed with status 1: Unable to reach api\n" +
+		"\n" +
+		"- **warning** 3 widgets are <deprecated>\n" +
+		"\n" +
+		"```\n" +
+		"NAME    STATUS\n" +
+		"100%\n" +
+		"```\n"
+	if got := output.Stdout.String(); got != expected {
+		t.Errorf("unexpected markdown\n%s", diffLines(expected, got))
+	}
+
+	result, output = runOutput(t, &testOutputCommand{lines: markdownCollapseLines}, "--output", "markdown")
+	ExpectExitCode(t, result, ExitOK)
+	ExpectMatch(t, *output.Stdout, "(?s)^`widgets` succeeded\n.*<details>\n<summary>Output \\(22 lines\\)</summary>\n\n```\n")
+}
+
+func TestSlackOutput(t *testing.T) {
+	result, output := runOutput(t, &testOutputCommand{lines: slackMaxLines}, "--output", "slack")
+	ExpectExitCode(t, result, ExitOK)
+
+	got := output.Stdout.String()
+	for _, expected := range []string{
+		"`widgets` succeeded\n",
+		"• *warning* 3 widgets are &lt;deprecated&gt;\n",
+		"```\nNAME    STATUS\n100%\n0\n",
+		"\n37\n```\n_2 more lines not shown_\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in the output\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "\x1b") {
+		t.Errorf("expected escape sequences to be removed\n%q", got)
+	}
+}
+
+func TestUnknownOutputFormat(t *testing.T) {
+	result, output := runOutput(t, &testOutputCommand{}, "--output", "xml")
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.Stderr, `Unknown output format: xml`)
+}
+
+type testWidget struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Tags   []string
+	secret string
+}
+
+type testReportCommand struct {
+	DefaultHelp
+}
+
+func (c *testReportCommand) Command(ctx context.Context, args []string, s System) error {
+	RecordEvent(ctx, EventWarning, "3 widgets are <deprecated>")
+	Emit(ctx, []testWidget{
+		{Name: "sprocket", Status: "ok", Tags: []string{"a", "b"}},
+		{Name: "gizmo", Status: "failed"},
+	})
+	Emit(ctx, map[string]int{"total": 2, "failed": 1})
+	s.Println("2 widgets")
+	return nil
+}
+
+func TestHTMLReport(t *testing.T) {
+	result, output := runOutput(t, &testReportCommand{}, "--output", "html")
+	ExpectExitCode(t, result, ExitOK)
+
+	got := output.Stdout.String()
+	for _, expected := range []string{
+		"<title>widgets</title>",
+		`<td class="succeeded">0</td>`,
+		"<td>warning</td><td>3 widgets are &lt;deprecated&gt;</td>",
+		"<summary>Data 1</summary>",
+		"<tr><th>name</th><th>status</th><th>Tags</th></tr>",
+		"<tr><td>sprocket</td><td>ok</td><td>[&#34;a&#34;,&#34;b&#34;]</td></tr>",
+		"<tr><td>gizmo</td><td>failed</td><td>null</td></tr>",
+		"<tr><th>Key</th><th>Value</th></tr>",
+		"<tr><td>failed</td><td>1</td></tr>\n<tr><td>total</td><td>2</td></tr>",
+		"<pre>2 widgets</pre>",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in the report\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("expected unexported fields to be omitted\n%s", got)
+	}
+}
+
+type testScanCommand struct {
+	DefaultHelp
+}
+
+func (c *testScanCommand) Command(ctx context.Context, args []string, s System) error {
+	RecordFinding(ctx, Finding{
+		RuleID: "unused", Level: FindingWarning, Message: "x is unused",
+		Path: filepath.Join("pkg", "main.go"), Line: 12, Column: 2,
+	})
+	RecordFinding(ctx, Finding{RuleID: "license", Level: FindingError, Message: "LICENSE is missing"})
+	RecordEvent(ctx, EventWarning, "vendor directory skipped")
+	return &ExitError{Status: ExitFailure, Message: "2 problems found"}
+}
+
+func TestSARIFOutput(t *testing.T) {
+	result, output := runOutput(t, &testScanCommand{}, "--output", "sarif")
+	ExpectExitCode(t, result, ExitFailure)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Invocations []struct {
+				ExecutionSuccessful bool `json:"executionSuccessful"`
+				ExitCode            int  `json:"exitCode"`
+			} `json:"invocations"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(output.Stdout.Bytes(), &log); err != nil {
+		t.Fatalf("%s\n%s", err, output.Stdout)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected a single SARIF 2.1.0 run\n%s", output.Stdout)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "widgets" || len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("unexpected tool %+v\n", run.Tool)
+	}
+	if len(run.Invocations) != 1 || run.Invocations[0].ExecutionSuccessful || run.Invocations[0].ExitCode != 1 {
+		t.Errorf("unexpected invocations %+v\n", run.Invocations)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, found %d\n%s", len(run.Results), output.Stdout)
+	}
+
+	unused := run.Results[0]
+	if unused.RuleID != "unused" || unused.Level != "warning" || len(unused.Locations) != 1 {
+		t.Fatalf("unexpected result %+v\n", unused)
+	}
+	location := unused.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "pkg/main.go" || location.Region.StartLine != 12 || location.Region.StartColumn != 2 {
+		t.Errorf("unexpected location %+v\n", location)
+	}
+	if run.Results[1].Level != "error" || len(run.Results[1].Locations) != 0 {
+		t.Errorf("unexpected result %+v\n", run.Results[1])
+	}
+	if run.Results[2].Message.Text != "vendor directory skipped" {
+		t.Errorf("expected the warning event as a result, found %+v\n", run.Results[2])
+	}
+}
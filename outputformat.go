@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// formatFuncs are the functions available to `--format` templates, named
+// as in sprig, which docker and helm users know
+var formatFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"title":      strings.Title,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join": func(sep string, v interface{}) string {
+		var parts []string
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			for i := 0; i < rv.Len(); i++ {
+				parts = append(parts, fmt.Sprint(rv.Index(i).Interface()))
+			}
+		}
+		return strings.Join(parts, sep)
+	},
+	"default": func(d, v interface{}) interface{} {
+		if rv := reflect.ValueOf(v); !rv.IsValid() || rv.IsZero() {
+			return d
+		}
+		return v
+	},
+	"trunc": func(n int, s string) string {
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	},
+	"pad": func(n int, v interface{}) string {
+		s := fmt.Sprint(v)
+		if pad := n - utf8.RuneCountInString(s); pad > 0 {
+			return s + strings.Repeat(" ", pad)
+		}
+		return s
+	},
+}
+
+// parseFormat parses the template given with `--format`
+func parseFormat(sys System, text string) (*template.Template, error) {
+	t, err := template.New("format").Funcs(formatFuncs).Parse(text)
+	if err != nil {
+		return nil, &ExitError{Status: ExitUsage, Message: Localize(sys, "Invalid --format: %s", err)}
+	}
+	return t, nil
+}
+
+// printFormat prints value with t, once for each element if it is a list,
+// ending each with a newline
+func printFormat(sys System, t *template.Template, value interface{}) error {
+	items := []interface{}{value}
+	if isList(value) {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		items = make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).Interface()
+		}
+	}
+
+	var b bytes.Buffer
+	for _, item := range items {
+		if err := t.Execute(&b, item); err != nil {
+			return err
+		}
+		b.WriteByte('\n')
+	}
+	_, err := sys.Print(b.String())
+	return err
+}
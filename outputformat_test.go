@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOutputFormat(t *testing.T) {
+	command := &testOutputsCommand{widgets: []testOutputWidget{
+		{Name: "sprocket", Count: 3, Tags: []string{"metal", "small"}},
+		{Name: "gizmo", Count: 12},
+	}}
+
+	for _, c := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"widgets", "--format", "{{.Name}} {{.Count}}"}, "^sprocket 3\ngizmo 12\n$"},
+		{[]string{"widgets", "--format", `{{pad 9 .Name}}{{join "," .Tags | default "-"}}`}, "^sprocket metal,small\ngizmo    -\n$"},
+		{[]string{"widgets", "--format", "{{upper .Name}} {{json .Tags}}", "gizmo"}, "^GIZMO null\n$"},
+	} {
+		system, output := NewMemorySystem(MemorySystemOptions{Arguments: c.args})
+		ExpectExitCode(t, Main(context.Background(), command, system), ExitOK)
+		ExpectMatch(t, *output.Stdout, c.expected)
+	}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets", "--format", "{{.Name"}})
+	ExpectExitCode(t, Main(context.Background(), command, system), ExitUsage)
+	ExpectMatch(t, *output.Stderr, `Invalid --format: template: format:1: unclosed action`)
+
+	system, output = NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets", "-o", "json", "--format", "{{.Name}}"}})
+	ExpectExitCode(t, Main(context.Background(), command, system), ExitUsage)
+	ExpectMatch(t, *output.Stderr, `--format can't be used with --output json`)
+}
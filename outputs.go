@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Outputs is an interface for commands which return data rather than print
+// it. Such commands are given `--output` and `-o` flags, selecting whether
+// their result is printed as text, `json` or `yaml`, so that scripts may
+// rely on output which doesn't change as the text is reworded. A `--format`
+// flag prints each element of the result, or the result if it isn't a list,
+// with a text/template, as `docker ps --format` does; the functions of
+// sprig which shape text, such as `upper`, `join` and `default`, may be used.
+//
+// As text, a value which implements fmt.Stringer is printed as it formats
+// itself. A slice of structs or maps is printed as a table with a row per
+// element, and a struct or map as a row per field; fields are named as they
+// would be in JSON.
+type Outputs interface {
+	// Output performs the command and returns its result
+	Output(context.Context, []string, System) (interface{}, error)
+}
+
+// dataFormats are the formats in which the result of an Outputs command may
+// be printed
+var dataFormats = map[string]func(w io.Writer, value interface{}) error{
+	"json": writeJSON,
+	"yaml": writeYAML,
+}
+
+// defineOutputFlags adds the `--output` and `-o` flags of a command which
+// implements Outputs to f, unless the command defines flags of those names.
+// The formats of WithOutput, if given, are listed alongside those of data.
+func (c *config) defineOutputFlags(f *flag.FlagSet, defined frameworkFlags) {
+	formats := []string{"json", "text", "yaml"}
+	for name := range c.outputs {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	usage := "output `format`: " + strings.Join(formats, ", ")
+
+	output, ok := defined["output"]
+	if ok {
+		f.Lookup("output").Usage = usage
+	} else if f.Lookup("output") == nil {
+		f.String("output", "", usage)
+		output = f.Lookup("output").Value
+		defined["output"] = output
+	} else {
+		return
+	}
+	if f.Lookup("o") == nil {
+		f.Var(output, "o", "shorthand for --output `format`")
+	}
+	if f.Lookup("format") == nil {
+		f.String("format", "", "print each result with a Go `template`")
+		defined["format"] = f.Lookup("format").Value
+	}
+}
+
+// outputAction performs a command which implements Outputs, printing its
+// result in format or with the template given with `--format`
+type outputAction struct {
+	cmd      Outputs
+	format   string
+	template string
+}
+
+func (a *outputAction) Command(ctx context.Context, args []string, sys System) error {
+	var t *template.Template
+	if len(a.template) > 0 {
+		if _, ok := dataFormats[a.format]; ok {
+			return &ExitError{Status: ExitUsage, Message: Localize(sys, "--format can't be used with --output %s", a.format)}
+		}
+		var err error
+		if t, err = parseFormat(sys, a.template); err != nil {
+			return err
+		}
+	}
+
+	value, err := a.cmd.Output(ctx, args, sys)
+	if err != nil {
+		return err
+	}
+	Emit(ctx, value)
+
+	if t != nil {
+		return printFormat(sys, t, value)
+	}
+	write, ok := dataFormats[a.format]
+	if !ok {
+		return printText(sys, value)
+	}
+	var b bytes.Buffer
+	if err := write(&b, value); err != nil {
+		return err
+	}
+	_, err = sys.Print(b.String())
+	return err
+}
+
+// writeJSON writes value as indented JSON
+func writeJSON(w io.Writer, value interface{}) error {
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(value)
+}
+
+// printText prints value as a person would read it
+func printText(sys System, value interface{}) error {
+	if s, ok := value.(fmt.Stringer); ok {
+		_, err := sys.Println(s.String())
+		return err
+	}
+
+	t := tabulate(value)
+	if t.Columns == nil {
+		if isList(value) {
+			return nil
+		}
+		_, err := sys.Println(t.Text)
+		return err
+	}
+
+	var table Table
+	if isList(value) {
+		for _, name := range t.Columns {
+			table.Columns = append(table.Columns, Column{Header: strings.ToUpper(name)})
+		}
+	} else {
+		// the fields of a single value are listed without headers
+		table.Columns = make([]Column, 2)
+		for _, row := range t.Rows {
+			row[0] += ":"
+		}
+	}
+	for _, row := range t.Rows {
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			values[i] = v
+		}
+		table.Append(values...)
+	}
+	return table.Print(sys)
+}
+
+// isList reports whether value is a slice or array, other than of bytes
+func isList(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+type testOutputWidget struct {
+	Name   string   `json:"name"`
+	Count  int      `json:"count"`
+	Tags   []string `json:"tags,omitempty"`
+	Secret string   `json:"-"`
+}
+
+type testOutputsCommand struct {
+	DefaultHelp
+	widgets []testOutputWidget
+}
+
+func (c *testOutputsCommand) Output(ctx context.Context, args []string, sys System) (interface{}, error) {
+	if len(args) > 0 {
+		for _, w := range c.widgets {
+			if w.Name == args[0] {
+				return w, nil
+			}
+		}
+	}
+	return c.widgets, nil
+}
+
+func TestOutputs(t *testing.T) {
+	command := &testOutputsCommand{widgets: []testOutputWidget{
+		{Name: "sprocket", Count: 3, Tags: []string{"metal", "yes: really"}, Secret: "x"},
+		{Name: "0123", Count: 12},
+	}}
+
+	for _, c := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"widgets"}, "" +
+			"NAME      COUNT  TAGS\n" +
+			"sprocket  3      \\[\"metal\",\"yes: really\"\\]\n" +
+			"0123      12     null\n$"},
+		{[]string{"widgets", "sprocket"}, "" +
+			"name:   sprocket\n" +
+			"count:  3\n" +
+			"tags:   \\[\"metal\",\"yes: really\"\\]\n$"},
+		{[]string{"widgets", "-o", "json", "sprocket"}, "" +
+			"{\n" +
+			"  \"name\": \"sprocket\",\n" +
+			"  \"count\": 3,\n" +
+			"  \"tags\": \\[\n" +
+			"    \"metal\",\n" +
+			"    \"yes: really\"\n" +
+			"  \\]\n" +
+			"}\n$"},
+		{[]string{"widgets", "--output", "yaml"}, "" +
+			"- name: sprocket\n" +
+			"  count: 3\n" +
+			"  tags:\n" +
+			"    - metal\n" +
+			"    - \"yes: really\"\n" +
+			"- name: \"0123\"\n" +
+			"  count: 12\n$"},
+	} {
+		system, output := NewMemorySystem(MemorySystemOptions{Arguments: c.args})
+		ExpectExitCode(t, Main(context.Background(), command, system), ExitOK)
+		ExpectMatch(t, *output.Stdout, "^"+c.expected)
+	}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets", "-o", "toml"}})
+	ExpectExitCode(t, Main(context.Background(), command, system), ExitUsage)
+	ExpectMatch(t, *output.Stderr, `Unknown output format: toml`)
+
+	system, output = NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets", "--output", "markdown"}})
+	ExpectExitCode(t, Main(context.Background(), command, system, WithOutput()), ExitOK)
+	ExpectMatch(t, *output.Stdout, "`widgets` succeeded\n\n```\nNAME +COUNT +TAGS\n")
+}
+
+func TestOutputsHelp(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets", "--help"}})
+	ExpectExitCode(t, Main(context.Background(), &testOutputsCommand{}, system, WithOutput()), ExitOK)
+	ExpectMatch(t, *output.Stdout, `-o format +shorthand for --output format`)
+	ExpectMatch(t, *output.Stdout, `--output format +output format: html, json, markdown, sarif, slack, text,\s+yaml`)
+}
+
+func TestYAMLString(t *testing.T) {
+	for s, expected := range map[string]string{
+		"plain words":  "plain words",
+		"":             `""`,
+		"true":         `"true"`,
+		"No":           `"No"`,
+		"1.5":          `"1.5"`,
+		"-flag":        `"-flag"`,
+		"a: b":         `"a: b"`,
+		"a # comment":  `"a # comment"`,
+		"two\nlines":   `"two\nlines"`,
+		"https://x.io": "https://x.io",
+	} {
+		if actual := yamlString(s); actual != expected {
+			t.Errorf("expected %q as %s, received %s\n", s, expected, actual)
+		}
+	}
+}
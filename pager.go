@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"runtime"
+	"sync"
+	"unicode/utf8"
+)
+
+// WithPager pages a command's output through $PAGER, or `less -R`, when it
+// is longer than the terminal is high, as git does. Output is held back
+// until it fills the screen, so output which fits is printed as usual. Output
+// which isn't a terminal, such as a pipe, is never paged. A `--no-pager` flag
+// disables paging, as does setting PAGER to `cat` or to nothing.
+func WithPager() Option {
+	return func(c *config) {
+		c.pager = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("no-pager", false, "don't page output")
+		})
+	}
+}
+
+// pagerCommand returns the command which runs the user's pager, or false if
+// paging is disabled
+func pagerCommand(sys System) (string, bool) {
+	pager, ok := sys.LookupEnv("PAGER")
+	if !ok {
+		if runtime.GOOS == "windows" {
+			return "more", true
+		}
+		return "less -R", true
+	}
+	return pager, len(pager) > 0 && pager != "cat"
+}
+
+// startPager redirects the output of sys to a pagerWriter, returning a
+// function which restores it and waits for the pager to exit
+func startPager(ctx context.Context, sys System) (stop func() error) {
+	s, ok := baseOf(sys)
+	command, page := pagerCommand(sys)
+	if !ok || !page || !isTerminal(s.Out) {
+		return func() error { return nil }
+	}
+	width, height, err := sys.TermSize()
+	if err != nil || height <= 1 {
+		return func() error { return nil }
+	}
+
+	out := s.Out
+	p := &pagerWriter{
+		out:    out,
+		width:  width,
+		height: height,
+		start: func(r io.Reader) (Result, error) {
+			name, args := shellCommand(command)
+			return sys.Exec(ctx, name, args, ExecStdin(r), ExecStdout(out), ExecStderr(s.Logger.Writer()))
+		},
+	}
+	s.Out = p
+	return func() error {
+		s.Out = out
+		return p.close()
+	}
+}
+
+// pagerWriter holds output back until it is longer than the terminal is
+// high, then starts the pager and streams output to it
+type pagerWriter struct {
+	out           io.Writer
+	width, height int
+	start         func(r io.Reader) (Result, error)
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	row, col int
+
+	// pipe is written to once the pager has started, and done receives its
+	// exit
+	pipe *io.PipeWriter
+	done chan error
+}
+
+// Fd returns the terminal output is paged to, so that the size of the
+// terminal is still reported, and colors still used, while paging
+func (p *pagerWriter) Fd() uintptr {
+	return p.out.(interface{ Fd() uintptr }).Fd()
+}
+
+func (p *pagerWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pipe != nil {
+		return p.pipe.Write(b)
+	}
+
+	p.buf.Write(b)
+	for _, c := range b {
+		switch {
+		case c == '\n':
+			p.row, p.col = p.row+1, 0
+		case utf8.RuneStart(c):
+			if p.col++; p.col > p.width {
+				p.row, p.col = p.row+1, 1
+			}
+		}
+	}
+	// the last row of the screen is left for the shell's prompt
+	if p.row < p.height-1 {
+		return len(b), nil
+	}
+
+	r, w := io.Pipe()
+	p.pipe = w
+	p.done = make(chan error, 1)
+	go func() {
+		_, err := p.start(r)
+		// once the user quits the pager, further output is discarded
+		r.CloseWithError(err)
+		p.done <- err
+	}()
+	if _, err := p.pipe.Write(p.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// close prints output which didn't fill the screen, or else waits for the
+// user to quit the pager
+func (p *pagerWriter) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pipe == nil {
+		_, err := p.out.Write(p.buf.Bytes())
+		return err
+	}
+	p.pipe.Close()
+	return <-p.done
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+type testPagerCommand struct {
+	lines int
+}
+
+func (c *testPagerCommand) Help() {}
+
+func (c *testPagerCommand) Command(ctx context.Context, args []string, s System) error {
+	for i := 1; i <= c.lines; i++ {
+		if _, err := s.Printf("line %d\n", i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pagerSystem returns a TestSystem whose screen is 5 lines high, with a fake
+// pager which records what it was given
+func pagerSystem(t *testing.T, arguments []string) (*TestSystem, *TestOutput, *string) {
+	system, output := NewTestSystem(t, arguments, map[string]string{"PAGER": "less"})
+	system.Width, system.Height = 40, 5
+
+	paged := new(string)
+	system.FakeExec("*", func(ctx context.Context, call ExecCall) int {
+		b, _ := ioutil.ReadAll(call.Stdin)
+		*paged = string(b)
+		return 0
+	})
+	return system, output, paged
+}
+
+func TestPager(t *testing.T) {
+	system, output, paged := pagerSystem(t, []string{"testpager"})
+	if result := Main(context.Background(), &testPagerCommand{lines: 10}, system, WithPager()); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+
+	var expected string
+	for i := 1; i <= 10; i++ {
+		expected += fmt.Sprintf("line %d\n", i)
+	}
+	if *paged != expected {
+		t.Errorf("expected every line to be paged, received %q\n", *paged)
+	}
+	if calls := system.ExecCalls(); len(calls) != 1 || calls[0].Args[len(calls[0].Args)-1] != "less" {
+		t.Errorf("expected $PAGER to be run, received %v\n", calls)
+	}
+}
+
+func TestPagerShortOutput(t *testing.T) {
+	for _, arguments := range [][]string{{"testpager"}, {"testpager", "--no-pager"}} {
+		lines := 3
+		if len(arguments) > 1 {
+			lines = 10
+		}
+		system, output, paged := pagerSystem(t, arguments)
+		if result := Main(context.Background(), &testPagerCommand{lines: lines}, system, WithPager()); result != 0 {
+			t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+		}
+		system.Capture()()
+
+		if len(*paged) > 0 {
+			t.Errorf("expected %q not to be paged, received %q\n", arguments, *paged)
+		}
+		ExpectMatch(t, *output.STDOUT, fmt.Sprintf("line %d", lines))
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// newPasswordAttempts is how many times ReadNewPassword asks for a password
+// before giving up
+const newPasswordAttempts = 3
+
+// ReadPassword prints prompt and reads a password without echoing it. A
+// newline is printed once it has been read, whether or not reading
+// succeeded, so that subsequent output starts on a line of its own.
+func ReadPassword(sys System, prompt string) (string, error) {
+	if _, err := sys.Print(prompt); err != nil {
+		return "", err
+	}
+	password, err := sys.ReadPassword()
+	if _, printErr := sys.Println(); err == nil {
+		err = printErr
+	}
+	return password, err
+}
+
+// ReadPasswordContext is ReadPassword, returning ctx's error if ctx is done
+// before the password has been entered, e.g. because the user pressed
+// Ctrl-C or a deadline passed. Echo is turned back on, and the line entered
+// next is returned by the System's next read instead.
+func ReadPasswordContext(ctx context.Context, sys System, prompt string) (string, error) {
+	if _, err := sys.Print(prompt); err != nil {
+		return "", err
+	}
+	password, err := readContext(ctx, sys, sys.ReadPassword)
+	if _, printErr := sys.Println(); err == nil {
+		err = printErr
+	}
+	return password, err
+}
+
+// ReadNewPassword asks for a new password with prompt and then asks for it
+// again to confirm it, starting over if the two don't match. It fails after
+// three mismatches.
+func ReadNewPassword(sys System, prompt string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		password, err := ReadPassword(sys, prompt)
+		if err != nil {
+			return "", err
+		}
+		confirmation, err := ReadPassword(sys, tr(sys, "Confirm password: "))
+		if err != nil {
+			return "", err
+		}
+		if password == confirmation {
+			return password, nil
+		}
+
+		if attempt == newPasswordAttempts {
+			return "", &ExitError{
+				Status:  ExitFailure,
+				Message: tr(sys, "Passwords did not match"),
+			}
+		}
+		if _, err := sys.Println(tr(sys, "Passwords do not match; try again")); err != nil {
+			return "", err
+		}
+	}
+}
+
+// WithMaskedPasswords makes passwords echo an asterisk for each character
+// typed, rather than nothing, for users who are confused by silent entry
+func WithMaskedPasswords() Option {
+	return func(c *config) {
+		c.maskPasswords = true
+	}
+}
+
+// readMaskedTerminal puts the terminal fd in raw mode and reads a password
+// from in with readMasked
+func readMaskedTerminal(fd int, in io.Reader, echo io.Writer) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+	return readMasked(in, echo)
+}
+
+// readMasked reads a line from a terminal in raw mode, writing an asterisk
+// to echo for each character typed. Backspace deletes the last character and
+// Ctrl-U every character; Ctrl-C, and Ctrl-D with nothing typed, return
+// io.EOF. Other control characters and escape sequences, such as arrow keys,
+// are ignored.
+func readMasked(in io.Reader, echo io.Writer) (string, error) {
+	var password []byte
+	b := make([]byte, 1)
+	escape := false
+	for {
+		if _, err := in.Read(b); err != nil {
+			return "", err
+		}
+
+		switch c := b[0]; {
+		case escape:
+			escape = c == '[' || c == 'O' || (c >= '0' && c <= '9') || c == ';'
+		case c == '\r' || c == '\n':
+			return string(password), nil
+		case c == 3 || (c == 4 && len(password) == 0):
+			return "", io.EOF
+		case c == 0x7f || c == '\b':
+			if _, size := utf8.DecodeLastRune(password); size > 0 {
+				password = password[:len(password)-size]
+				echo.Write([]byte("\b \b"))
+			}
+		case c == 0x15:
+			for n := utf8.RuneCount(password); n > 0; n-- {
+				echo.Write([]byte("\b \b"))
+			}
+			password = password[:0]
+		case c == 0x1b:
+			escape = true
+		case c < 0x20:
+		default:
+			password = append(password, c)
+			if utf8.RuneStart(c) {
+				echo.Write([]byte("*"))
+			}
+		}
+	}
+}
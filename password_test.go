@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPassword(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader("hunter2\n")})
+
+	password, err := ReadPassword(system, "Password: ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2" {
+		t.Errorf("expected the password entered, received %q\n", password)
+	}
+	if output.Stdout.String() != "Password: \n" {
+		t.Errorf("expected the prompt followed by a newline, received %q\n", output.Stdout)
+	}
+}
+
+func TestReadNewPassword(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Input: strings.NewReader("hunter2\nhunter3\nhunter2\nhunter2\n"),
+	})
+
+	password, err := ReadNewPassword(system, "New password: ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2" {
+		t.Errorf("expected the confirmed password, received %q\n", password)
+	}
+	expected := "New password: \nConfirm password: \nPasswords do not match; try again\n" +
+		"New password: \nConfirm password: \n"
+	if output.Stdout.String() != expected {
+		t.Errorf("expected:\n%q\nreceived:\n%q", expected, output.Stdout)
+	}
+
+	system, _ = NewMemorySystem(MemorySystemOptions{
+		Input: strings.NewReader(strings.Repeat("one\ntwo\n", newPasswordAttempts)),
+	})
+	if _, err := ReadNewPassword(system, "New password: "); err == nil {
+		t.Error("expected an error once the passwords didn't match repeatedly")
+	}
+}
+
+func TestMaskedPassword(t *testing.T) {
+	system, _ := NewScreenTestSystem(t, []string{"test"}, nil, 2, 20)
+	system.MaskPasswords = true
+	system.Expecter().Send("hunterx\x7f2é\x1b[D\n")
+
+	password, err := ReadPassword(system, "Password: ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2é" {
+		t.Errorf("expected the password typed, received %q\n", password)
+	}
+	if actual := system.Screen().String(); actual != "Password: ********" {
+		t.Errorf("expected an asterisk per character, received %q\n", actual)
+	}
+}
+
+func TestMaskedPasswordTerminal(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, nil)
+	system.MaskPasswords = true
+	go func() {
+		system.Console.ExpectString("Password: ")
+		system.Console.Send("hunter2\x15swordfish\r")
+	}()
+
+	password, err := ReadPassword(system, "Password: ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "swordfish" {
+		t.Errorf("expected the password typed after clearing, received %q\n", password)
+	}
+}
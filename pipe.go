@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// WithPipe adds a `--pipe` flag which streams everything a command prints
+// through a shell pipeline, e.g. `--pipe 'sort -k2 | head'`. The pipeline's
+// output is written to the System's original output. Main still returns the
+// command's own exit status; failures of the pipeline are logged.
+func WithPipe() Option {
+	return func(c *config) {
+		c.pipe = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.String("pipe", "", "stream output through the given shell pipeline")
+		})
+	}
+}
+
+// startPipe runs pipeline in a shell with System.Exec and redirects the output
+// of sys into it, returning the System the command should print through. The
+// returned function restores the original output and waits for the pipeline
+// to exit.
+func startPipe(ctx context.Context, sys System, pipeline string) (piped System, wait func() error) {
+	r, w := io.Pipe()
+	out := sys.Stdout()
+	restore := func() {}
+	piped = &pipeSystem{System: sys, out: w}
+	if s, ok := baseOf(sys); ok {
+		// redirecting the BaseSystem itself keeps the features which reach
+		// through to it working. The pipeline writes to the original output
+		// directly, as the command's writes wait for it to read them.
+		out = s.Out
+		s.Out = w
+		restore = func() { s.Out = out }
+		piped = sys
+	}
+
+	type exit struct {
+		result Result
+		err    error
+	}
+	done := make(chan exit, 1)
+	name, args := shellCommand(pipeline)
+	go func() {
+		result, err := sys.Exec(ctx, name, args, ExecStdin(r), ExecStdout(out))
+		// once the pipeline has exited, the command's output is discarded
+		// rather than blocking
+		r.CloseWithError(err)
+		done <- exit{result, err}
+	}()
+
+	return piped, func() error {
+		restore()
+		w.Close()
+		e := <-done
+		// the pipeline's diagnostics are written once it has exited, rather
+		// than interleaved with messages logged by the command
+		sys.Eprint(string(e.result.Stderr))
+		return e.err
+	}
+}
+
+// pipeSystem is a System whose output is written to a pipeline
+type pipeSystem struct {
+	System
+	out io.Writer
+}
+
+func (s *pipeSystem) Print(a ...interface{}) (int, error) {
+	return fmt.Fprint(s.out, a...)
+}
+
+func (s *pipeSystem) Printf(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(s.out, format, a...)
+}
+
+func (s *pipeSystem) Println(a ...interface{}) (int, error) {
+	return fmt.Fprintln(s.out, a...)
+}
+
+func (s *pipeSystem) Stdout() io.Writer {
+	return s.out
+}
+
+// TermSize reports that output isn't attached to a terminal
+func (s *pipeSystem) TermSize() (int, int, error) {
+	return 0, 0, ErrNotTerminal
+}
+
+// scriptShell returns the shell which shellCommand runs scripts with.
+// Arguments interpolated into a script must be quoted for it, rather than for
+// the user's login shell.
+func scriptShell() Shell {
+	if runtime.GOOS == "windows" {
+		return ShellCmd
+	}
+	return ShellSh
+}
+
+// shellCommand returns the program and arguments which run script in the
+// platform's shell
+func shellCommand(script string) (string, []string) {
+	if scriptShell() == ShellCmd {
+		return "cmd", []string{"/C", script}
+	}
+	return "sh", []string{"-c", script}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"flag"
+
+	"github.com/pkg/errors"
+)
+
+// Step is a single operation within a Plan
+type Step struct {
+	// Description is shown to the user when the plan is reviewed and while
+	// the step is applied
+	Description string
+
+	// Apply performs the operation
+	Apply func(context.Context) error
+
+	// Rollback, if set, undoes the operation. It is registered with
+	// OnRollback once the step has been applied successfully.
+	Rollback RollbackFunc
+}
+
+// Plan is a list of operations which a command intends to perform. The plan
+// is shown to the user before any of it is applied; with the `--plan` flag the
+// command stops there, in the manner of `terraform plan`. Commands should call
+// the Plan's Flags method from their own.
+type Plan struct {
+	Steps []*Step
+
+	// Confirm asks the user to approve the plan before applying it
+	Confirm bool
+
+	planOnly bool
+}
+
+// Flags defines the `--plan` flag
+func (p *Plan) Flags(f *flag.FlagSet) {
+	f.BoolVar(&p.planOnly, "plan", false, "show the planned operations and exit")
+}
+
+// Add appends a step to the plan and returns it, so that a Rollback may be
+// set on it
+func (p *Plan) Add(description string, apply func(context.Context) error) *Step {
+	step := &Step{Description: description, Apply: apply}
+	p.Steps = append(p.Steps, step)
+	return step
+}
+
+// Render prints the planned operations
+func (p *Plan) Render(sys System) {
+	if len(p.Steps) == 0 {
+		sys.Println(tr(sys, "Nothing to do."))
+		return
+	}
+
+	sys.Printf(tr(sys, "Plan: %d operation(s)\n"), len(p.Steps))
+	for i, step := range p.Steps {
+		sys.Printf("  %d. %s\n", i+1, step.Description)
+	}
+}
+
+// Execute renders the plan and, unless only the plan was requested, applies
+// each step in order, reporting progress as it goes. Execution stops at the
+// first step which fails; steps applied before it are rolled back by Main if
+// they provided a Rollback function.
+func (p *Plan) Execute(ctx context.Context, sys System) error {
+	p.Render(sys)
+	for _, step := range p.Steps {
+		RecordEvent(ctx, EventPlan, step.Description)
+	}
+	if p.planOnly || len(p.Steps) == 0 {
+		return nil
+	}
+
+	if p.Confirm {
+		ok, err := Confirm(sys, tr(sys, "Apply these changes?"))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &ExitError{Status: ExitFailure, Message: tr(sys, "Plan was not applied")}
+		}
+	}
+
+	for i, step := range p.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sys.Printf("[%d/%d] %s... ", i+1, len(p.Steps), step.Description)
+		if err := step.Apply(ctx); err != nil {
+			sys.Println(tr(sys, "failed"))
+			RecordEvent(ctx, EventOperation, step.Description+": failed: "+err.Error())
+			return errors.Wrapf(err, tr(sys, "Step %d of %d failed"), i+1, len(p.Steps))
+		}
+		sys.Println(tr(sys, "done"))
+		RecordEvent(ctx, EventOperation, step.Description+": done")
+
+		if step.Rollback != nil {
+			OnRollback(ctx, step.Rollback)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+)
+
+type testPlanCommand struct {
+	Plan
+
+	applied []string
+}
+
+func (c *testPlanCommand) Help() {}
+
+func (c *testPlanCommand) Flags(f *flag.FlagSet) {
+	c.Plan.Flags(f)
+}
+
+func (c *testPlanCommand) Command(ctx context.Context, args []string, s System) error {
+	for _, name := range []string{"create bucket", "upload files", "update dns"} {
+		name := name
+		step := c.Add(name, func(ctx context.Context) error {
+			if name == "update dns" {
+				return errors.New("dns unavailable")
+			}
+			c.applied = append(c.applied, name)
+			return nil
+		})
+		step.Rollback = func(ctx context.Context) error {
+			c.applied = c.applied[:len(c.applied)-1]
+			return nil
+		}
+	}
+	return c.Execute(ctx, s)
+}
+
+func TestPlanOnly(t *testing.T) {
+	cmd := &testPlanCommand{}
+	result, output := runMain(t, cmd, []string{"testplan", "--plan"})
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	if len(cmd.applied) > 0 {
+		t.Errorf("steps were applied with --plan: %v\n", cmd.applied)
+	}
+	ExpectMatch(t, *output.STDOUT, `Plan: 3 operation\(s\)`)
+	ExpectMatch(t, *output.STDOUT, `3\. update dns`)
+}
+
+func TestPlanFailure(t *testing.T) {
+	cmd := &testPlanCommand{}
+	result, output := runMain(t, cmd, []string{"testplan"})
+
+	if result != 1 {
+		t.Errorf("expected a 1 status, received %d\n", result)
+	}
+	if len(cmd.applied) > 0 {
+		t.Errorf("applied steps were not rolled back: %v\n", cmd.applied)
+	}
+	ExpectMatch(t, *output.STDOUT, `\[3/3\] update dns\.\.\. failed`)
+	ExpectMatch(t, *output.STDERR, `Step 3 of 3 failed: dns unavailable`)
+}
+
+func TestPlanAddReturnsStableStep(t *testing.T) {
+	p := &Plan{}
+	first := p.Add("first", func(ctx context.Context) error { return nil })
+	for i := 0; i < 8; i++ {
+		p.Add("another", func(ctx context.Context) error { return nil })
+	}
+	first.Rollback = func(ctx context.Context) error { return nil }
+
+	if p.Steps[0].Rollback == nil {
+		t.Errorf("Rollback set on a step returned by Add was lost\n")
+	}
+}
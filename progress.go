@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressBar reports the progress of a long operation, such as a download,
+// towards a total
+type ProgressBar interface {
+	// Add advances the progress by n
+	Add(n int64)
+
+	// Set sets the progress to n
+	Set(n int64)
+
+	// Finish reports the progress a last time; later updates are ignored
+	Finish()
+}
+
+var (
+	// progressRedraw is how often a bar on a terminal is redrawn
+	progressRedraw = 100 * time.Millisecond
+
+	// progressInterval is how often progress is printed when diagnostics
+	// aren't a terminal
+	progressInterval = 5 * time.Second
+)
+
+// Progress returns a ProgressBar labelled label, counting towards total, or
+// counting without a total if total isn't positive. Progress is shown with
+// diagnostics: as a bar with an estimate of the time remaining, redrawn in
+// place, on a terminal, and otherwise as a line of text every few seconds, so
+// that logs of CI jobs show that the operation is alive without filling up.
+func (s *BaseSystem) Progress(total int64, label string) ProgressBar {
+	now := time.Now()
+	return &progressBar{
+		s:        s,
+		total:    total,
+		label:    label,
+		terminal: isTerminal(s.stderr()),
+		start:    now,
+		drawn:    now,
+	}
+}
+
+type progressBar struct {
+	s        *BaseSystem
+	total    int64
+	label    string
+	terminal bool
+
+	mu       sync.Mutex
+	current  int64
+	start    time.Time
+	drawn    time.Time
+	printed  bool
+	finished bool
+}
+
+func (p *progressBar) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.update(p.current + n)
+}
+
+func (p *progressBar) Set(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.update(n)
+}
+
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	p.finished = true
+	if p.terminal {
+		p.s.Eprint("\r\x1b[K" + p.bar(time.Now()) + "\n")
+	} else {
+		p.s.Eprintln(p.line())
+	}
+}
+
+// update sets the progress, reporting it if it is time to
+func (p *progressBar) update(n int64) {
+	if p.finished {
+		return
+	}
+	p.current = n
+	now := time.Now()
+	interval := progressInterval
+	if p.terminal {
+		interval = progressRedraw
+	}
+	if p.printed && now.Sub(p.drawn) < interval {
+		return
+	}
+	if !p.printed && !p.terminal && now.Sub(p.start) < interval {
+		return
+	}
+	p.drawn, p.printed = now, true
+	if p.terminal {
+		p.s.Eprint("\r\x1b[K" + p.bar(now))
+	} else {
+		p.s.Eprintln(p.line())
+	}
+}
+
+// line describes the progress as text, e.g. `Downloading: 45% (450/1000)`
+func (p *progressBar) line() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s: %d", p.label, p.current)
+	}
+	return fmt.Sprintf("%s: %d%% (%d/%d)", p.label, p.percent(), p.current, p.total)
+}
+
+// bar draws the progress as a bar fitting the terminal, e.g.
+// `Downloading [=========>          ]  45% ETA 12s`
+func (p *progressBar) bar(now time.Time) string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s %d", p.label, p.current)
+	}
+
+	suffix := fmt.Sprintf(" %3d%%", p.percent())
+	if eta := p.remaining(now); eta >= 0 && p.current < p.total {
+		suffix += " " + fmt.Sprintf(translate(locale(p.s, "LC_MESSAGES"), "ETA %s"), eta)
+	}
+	// the last column is left empty, since writing to it wraps the line on
+	// some terminals
+	width := p.s.TerminalWidth() - visibleLength(p.label) - visibleLength(suffix) - 4
+	if width > 40 {
+		width = 40
+	}
+	if width < 10 {
+		return p.label + suffix
+	}
+
+	filled := int(int64(width) * p.clamped() / p.total)
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	return p.label + " [" + bar + "]" + suffix
+}
+
+// clamped returns the progress between 0 and the total
+func (p *progressBar) clamped() int64 {
+	switch {
+	case p.current < 0:
+		return 0
+	case p.current > p.total:
+		return p.total
+	}
+	return p.current
+}
+
+func (p *progressBar) percent() int64 {
+	return 100 * p.clamped() / p.total
+}
+
+// remaining estimates the time left from the rate of progress so far, in
+// whole seconds, or returns -1 if there has been none
+func (p *progressBar) remaining(now time.Time) time.Duration {
+	elapsed := now.Sub(p.start)
+	done := p.clamped()
+	if done == 0 || elapsed <= 0 {
+		return -1
+	}
+	eta := time.Duration(float64(elapsed) * float64(p.total-done) / float64(done))
+	return eta.Round(time.Second)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressBar(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Width: 60})
+	s, _ := baseOf(system)
+	now := time.Now()
+	p := &progressBar{s: s, total: 1000, label: "Downloading", start: now.Add(-10 * time.Second)}
+
+	p.current = 250
+	expected := "Downloading [" + strings.Repeat("=", 8) + ">" + strings.Repeat(" ", 23) + "]  25% ETA 30s"
+	if actual := p.bar(now); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+
+	p.current = 1200
+	expected = "Downloading [" + strings.Repeat("=", 40) + "] 100%"
+	if actual := p.bar(now); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+
+	p.total = 0
+	if actual := p.bar(now); actual != "Downloading 1200" {
+		t.Errorf("expected a count without a total, received %q\n", actual)
+	}
+}
+
+func TestProgressPlain(t *testing.T) {
+	interval := progressInterval
+	progressInterval = 0
+	defer func() { progressInterval = interval }()
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	p := system.Progress(4, "Uploading")
+	p.Add(1)
+	p.Set(3)
+	p.Finish()
+	p.Add(1)
+
+	expected := "Uploading: 25% (1/4)\nUploading: 75% (3/4)\nUploading: 75% (3/4)\n"
+	if actual := output.Stderr.String(); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+}
+
+func TestProgressTestSystem(t *testing.T) {
+	system, _ := NewTestSystem(t, nil, nil)
+	p := system.Progress(10, "Copying")
+	p.Add(4)
+	p.Add(4)
+	p.Set(10)
+	p.Finish()
+	p.Add(1)
+
+	bars := system.ProgressBars()
+	if len(bars) != 1 {
+		t.Fatalf("expected one bar, received %d\n", len(bars))
+	}
+	b := bars[0]
+	if b.Label != "Copying" || b.Total != 10 || !b.Finished || len(b.Updates) != 3 || b.Updates[1] != 8 {
+		t.Errorf("expected the updates to be recorded, received %+v\n", b)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// WithStrictInput adds a `--strict-input` flag which makes prompts accept
+// only ASCII answers regardless of the locale: `y`/`yes` and `n`/`no` for
+// questions, and numbers with a decimal point. Scripts which answer prompts
+// should pass it so that their input is interpreted identically everywhere.
+func WithStrictInput() Option {
+	return func(c *config) {
+		c.strictInput = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("strict-input", false, "accept only ASCII answers to prompts, ignoring the locale")
+		})
+	}
+}
+
+// strictInput reports whether prompts should ignore the System's locale
+func strictInput(sys System) bool {
+	s, ok := baseOf(sys)
+	return ok && s.StrictInput
+}
+
+// yesNo describes how a language answers yes or no questions
+type yesNo struct {
+	// hint is shown after the question, with the default answer capitalized
+	hint string
+
+	// yes are the affirmative answers; anything else is taken as no
+	yes []string
+}
+
+// englishYesNo is always accepted, and is the only form accepted in strict
+// mode
+var englishYesNo = yesNo{hint: "[y/N]", yes: []string{"y", "yes"}}
+
+// yesNoAnswers are the answers accepted for each language, in addition to
+// English
+var yesNoAnswers = map[string]yesNo{
+	"de": {hint: "[j/N]", yes: []string{"j", "ja"}},
+	"es": {hint: "[s/N]", yes: []string{"s", "si", "sí"}},
+	"fr": {hint: "[o/N]", yes: []string{"o", "oui"}},
+	"it": {hint: "[s/N]", yes: []string{"s", "si", "sì"}},
+	"nl": {hint: "[j/N]", yes: []string{"j", "ja"}},
+	"pt": {hint: "[s/N]", yes: []string{"s", "sim"}},
+}
+
+// inputYesNo returns the answers accepted by the System
+func inputYesNo(sys System) yesNo {
+	if strictInput(sys) {
+		return englishYesNo
+	}
+	if a, ok := yesNoAnswers[language(locale(sys, "LC_MESSAGES"))]; ok {
+		return a
+	}
+	return englishYesNo
+}
+
+// isYes reports whether answer is an affirmative answer accepted by the
+// System
+func isYes(sys System, answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	accepted := append(append([]string(nil), englishYesNo.yes...), inputYesNo(sys).yes...)
+	for _, yes := range accepted {
+		if answer == yes {
+			return true
+		}
+	}
+	return false
+}
+
+// decimalCommaLanguages write numbers with a decimal comma
+var decimalCommaLanguages = map[string]bool{
+	"cs": true, "da": true, "de": true, "es": true, "fi": true, "fr": true,
+	"it": true, "nb": true, "nl": true, "pl": true, "pt": true, "ru": true,
+	"sv": true, "tr": true, "uk": true,
+}
+
+// ParseNumber parses a number typed by the user. Unless the System is in
+// strict input mode, digits may be grouped in threes and a decimal comma is
+// accepted in locales which use one, so that `1 234,5` is read as 1234.5 in
+// French. A group separator anywhere else is an error rather than being
+// ignored, so that `1.5` typed in French isn't read as 15.
+func ParseNumber(sys System, s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if strictInput(sys) {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	decimal, group := ".", ","
+	if decimalCommaLanguages[language(locale(sys, "LC_NUMERIC"))] {
+		decimal, group = ",", "."
+	}
+	number := strings.NewReplacer(" ", group, "\u00a0", group, "\u202f", group, "'", group).Replace(s)
+
+	sign := ""
+	if strings.HasPrefix(number, "-") || strings.HasPrefix(number, "+") {
+		sign, number = number[:1], number[1:]
+	}
+	integer, fraction := number, ""
+	if i := strings.Index(number, decimal); i >= 0 {
+		integer, fraction = number[:i], "."+number[i+1:]
+	}
+	if strings.Contains(integer, group) {
+		// the first group has one to three digits, and the rest three
+		groups := strings.Split(integer, group)
+		for i, g := range groups {
+			if len(g) > 3 || len(g) == 0 || (i > 0 && len(g) < 3) {
+				return 0, &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrSyntax}
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+	return strconv.ParseFloat(sign+integer+fraction, 64)
+}
+
+// PromptNumber asks the user for a number, parsing it with ParseNumber.
+// Unlike Confirm it reads input which isn't attached to a terminal, so that
+// scripts may answer it.
+func PromptNumber(sys System, question string) (float64, error) {
+	if _, err := sys.Printf("%s: ", question); err != nil {
+		return 0, err
+	}
+
+	answer, err := readLine(sys)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := ParseNumber(sys, answer)
+	if err != nil {
+		return 0, &ExitError{
+			Status:  ExitFailure,
+			Message: Localize(sys, "Not a number: %q", strings.TrimSpace(answer)),
+		}
+	}
+	return n, nil
+}
+
+// language returns the language of a locale, e.g. `fr` for `fr_CA.UTF-8`
+func language(locale string) string {
+	locale = normalizeLocale(locale)
+	if i := strings.IndexByte(locale, '_'); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestConfirmLocalized(t *testing.T) {
+	for _, c := range []struct {
+		answer   string
+		strict   bool
+		expected bool
+	}{
+		{"oui", false, true},
+		{"O", false, true},
+		{"y", false, true},
+		{"non", false, false},
+		{"o", true, false},
+		{"yes", true, true},
+	} {
+		system, _ := NewTestSystem(t, []string{"test"}, map[string]string{"LANG": "fr_FR.UTF-8"})
+		system.StrictInput = c.strict
+
+		hint := "[o/N]: "
+		if c.strict {
+			hint = "[y/N]: "
+		}
+		go func(answer string) {
+			system.Console.ExpectString(hint)
+			system.Console.SendLine(answer)
+		}(c.answer)
+
+		confirmed, err := Confirm(system, "Continuer ?")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if confirmed != c.expected {
+			t.Errorf("%q (strict %t): expected %t, received %t\n", c.answer, c.strict, c.expected, confirmed)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for _, c := range []struct {
+		locale   string
+		input    string
+		strict   bool
+		expected float64
+		fails    bool
+	}{
+		{"en_US.UTF-8", "3.5", false, 3.5, false},
+		{"en_US.UTF-8", "1,234.5", false, 1234.5, false},
+		{"fr_FR.UTF-8", "3,5", false, 3.5, false},
+		{"fr_FR.UTF-8", "1 234,5", false, 1234.5, false},
+		{"fr_FR.UTF-8", "1\u202f234,5", false, 1234.5, false},
+		{"de_DE.UTF-8", "1.234,5", false, 1234.5, false},
+		{"de_DE.UTF-8", "-1.234.567", false, -1234567, false},
+		{"fr_FR.UTF-8", "1.5", false, 0, true},
+		{"fr_FR.UTF-8", "1 23,5", false, 0, true},
+		{"en_US.UTF-8", "1,5", false, 0, true},
+		{"en_US.UTF-8", "1,2345", false, 0, true},
+		{"en_US.UTF-8", ",123", false, 0, true},
+		{"en_US.UTF-8", "1,234,5", false, 0, true},
+		{"de_DE.UTF-8", "3,5", true, 0, true},
+		{"de_DE.UTF-8", "3.5", true, 3.5, false},
+		{"C", "abc", false, 0, true},
+	} {
+		system, _ := NewTestSystem(t, []string{"test"}, map[string]string{"LC_NUMERIC": c.locale})
+		system.StrictInput = c.strict
+
+		n, err := ParseNumber(system, c.input)
+		if c.fails {
+			if err == nil {
+				t.Errorf("%s %q: expected an error, received %v\n", c.locale, c.input, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %s\n", c.locale, c.input, err)
+		} else if n != c.expected {
+			t.Errorf("%s %q: expected %v, received %v\n", c.locale, c.input, c.expected, n)
+		}
+	}
+}
+
+func TestPromptNumber(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, map[string]string{"LANG": "de_DE.UTF-8"})
+	go func() {
+		system.Console.ExpectString("Menge: ")
+		system.Console.SendLine("2,5")
+	}()
+
+	n, err := PromptNumber(system, "Menge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2.5 {
+		t.Errorf("expected 2.5, received %v\n", n)
+	}
+}
@@ -0,0 +1,558 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// qrQuietZone is the width, in modules, of the light border drawn around a
+// QR code. The standard asks for 4, but 2 scans reliably from a screen and
+// leaves room for larger codes.
+const qrQuietZone = 2
+
+// QRCode prints data, such as the URL of a device pairing or an OTP
+// enrollment, as a QR code to be scanned from the terminal. The code is drawn
+// with unicode half blocks, two rows to a line, or with ASCII where the
+// locale isn't UTF-8, and light modules are drawn in the foreground color,
+// which reads correctly on the dark background most terminals have. Where
+// output isn't a terminal, or the code doesn't fit, data is printed instead.
+func (s *BaseSystem) QRCode(data string) error {
+	width, _, err := s.TermSize()
+	if err != nil {
+		_, err = s.Println(data)
+		return err
+	}
+	modules, err := qrEncode([]byte(data))
+	if err != nil {
+		_, err = s.Println(data)
+		return err
+	}
+
+	size := len(modules) + 2*qrQuietZone
+	var lines []string
+	switch {
+	case s.unicode() && size <= width:
+		lines = qrHalfBlocks(modules)
+	case 2*size <= width:
+		lines = qrASCII(modules)
+	default:
+		_, err = s.Println(data)
+		return err
+	}
+	_, err = s.Println(strings.Join(lines, "\n"))
+	return err
+}
+
+// unicode reports whether the terminal is expected to display unicode, as
+// it is on Windows or where the locale's character set is UTF-8
+func (s *BaseSystem) unicode() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	ctype := strings.ToUpper(locale(s, "LC_CTYPE"))
+	return strings.Contains(ctype, "UTF-8") || strings.Contains(ctype, "UTF8")
+}
+
+// qrLight reports whether the module at row, column of a code is light,
+// counting the quiet zone
+func qrLight(modules [][]bool, row, column int) bool {
+	row, column = row-qrQuietZone, column-qrQuietZone
+	if row < 0 || column < 0 || row >= len(modules) || column >= len(modules) {
+		return true
+	}
+	return !modules[row][column]
+}
+
+// qrHalfBlocks draws a code with a half block for each module, two rows to a
+// line
+func qrHalfBlocks(modules [][]bool) []string {
+	size := len(modules) + 2*qrQuietZone
+	var lines []string
+	for row := 0; row < size; row += 2 {
+		var b strings.Builder
+		for column := 0; column < size; column++ {
+			upper := qrLight(modules, row, column)
+			lower := row+1 < size && qrLight(modules, row+1, column)
+			switch {
+			case upper && lower:
+				b.WriteRune('█')
+			case upper:
+				b.WriteRune('▀')
+			case lower:
+				b.WriteRune('▄')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// qrASCII draws a code with two characters for each module, so that it is
+// roughly square
+func qrASCII(modules [][]bool) []string {
+	size := len(modules) + 2*qrQuietZone
+	lines := make([]string, size)
+	for row := range lines {
+		var b strings.Builder
+		for column := 0; column < size; column++ {
+			if qrLight(modules, row, column) {
+				b.WriteString("##")
+			} else {
+				b.WriteString("  ")
+			}
+		}
+		lines[row] = b.String()
+	}
+	return lines
+}
+
+// qrBlocks describes the error correction of a version of QR code at level M:
+// the error correction codewords of each block, and the number of blocks in
+// each of its two groups with the data codewords of a block in each
+type qrBlocks struct {
+	ec             int
+	blocks1, data1 int
+	blocks2, data2 int
+}
+
+// qrVersions are the versions of QR code encoded, from 1, at level M, which
+// corrects about 15% of the code being misread. Version 20 holds 666 bytes,
+// more than a URL needs and as many as fit the width of a terminal.
+var qrVersions = []qrBlocks{
+	{10, 1, 16, 0, 0},
+	{16, 1, 28, 0, 0},
+	{26, 1, 44, 0, 0},
+	{18, 2, 32, 0, 0},
+	{24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0},
+	{18, 4, 31, 0, 0},
+	{22, 2, 38, 2, 39},
+	{22, 3, 36, 2, 37},
+	{26, 4, 43, 1, 44},
+	{30, 1, 50, 4, 51},
+	{22, 6, 36, 2, 37},
+	{22, 8, 37, 1, 38},
+	{24, 4, 40, 5, 41},
+	{24, 5, 41, 5, 42},
+	{28, 7, 45, 3, 46},
+	{28, 10, 46, 1, 47},
+	{26, 9, 43, 4, 44},
+	{26, 3, 44, 11, 45},
+	{26, 3, 41, 13, 42},
+}
+
+// qrAlignment lists the rows and columns of the alignment patterns of each
+// version, from 2
+var qrAlignment = [][]int{
+	{6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50}, {6, 30, 54},
+	{6, 32, 58}, {6, 34, 62}, {6, 26, 46, 66}, {6, 26, 48, 70}, {6, 26, 50, 74},
+	{6, 30, 54, 78}, {6, 30, 56, 82}, {6, 30, 58, 86}, {6, 34, 62, 90},
+}
+
+func (b qrBlocks) dataCodewords() int {
+	return b.blocks1*b.data1 + b.blocks2*b.data2
+}
+
+// qrEncode encodes data in byte mode as the smallest QR code which holds it,
+// returning its modules by row and column, true where dark
+func qrEncode(data []byte) ([][]bool, error) {
+	version := 0
+	for v, b := range qrVersions {
+		countBits := 8
+		if v+1 >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*b.dataCodewords() {
+			version = v + 1
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%d bytes are too many for a QR code", len(data))
+	}
+
+	q := newQRCode(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(qrCodewords(version, data))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q.modules, nil
+}
+
+// qrCodewords returns the data and error correction codewords of a code,
+// interleaved as they are drawn
+func qrCodewords(version int, data []byte) []byte {
+	b := qrVersions[version-1]
+
+	var bits qrBits
+	bits.append(0x4, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, c := range data {
+		bits.append(int(c), 8)
+	}
+	capacity := 8 * b.dataCodewords()
+	terminator := capacity - bits.n
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.n%8)%8)
+	for pad := 0xEC; bits.n < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	divisor := qrDivisor(b.ec)
+	var blocks, ecc [][]byte
+	codewords := bits.bytes
+	for i := 0; i < b.blocks1+b.blocks2; i++ {
+		n := b.data1
+		if i >= b.blocks1 {
+			n = b.data2
+		}
+		blocks = append(blocks, codewords[:n])
+		ecc = append(ecc, qrRemainder(codewords[:n], divisor))
+		codewords = codewords[n:]
+	}
+
+	var result []byte
+	for i := 0; i < b.data2 || i < b.data1; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < b.ec; i++ {
+		for _, e := range ecc {
+			result = append(result, e[i])
+		}
+	}
+	return result
+}
+
+// qrBits accumulates a bit stream, most significant bit first
+type qrBits struct {
+	bytes []byte
+	n     int
+}
+
+// append appends the low count bits of value
+func (b *qrBits) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if value>>uint(i)&1 != 0 {
+			b.bytes[b.n/8] |= 0x80 >> uint(b.n%8)
+		}
+		b.n++
+	}
+}
+
+// qrMultiply multiplies x and y in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func qrMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, without its leading coefficient
+func qrDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrMultiply(root, 2)
+	}
+	return result
+}
+
+// qrRemainder returns the error correction codewords of data
+func qrRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= qrMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// qrCode is a QR code being drawn, whose function modules, the finder,
+// timing and alignment patterns and the format and version, aren't masked
+type qrCode struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRCode(version int) *qrCode {
+	size := 17 + 4*version
+	q := &qrCode{version: version, size: size}
+	q.modules = make([][]bool, size)
+	q.function = make([][]bool, size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	return q
+}
+
+// set draws the function module at column x, row y
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrCode) drawFunctionPatterns() {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					d := qrDistance(dx, dy)
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+
+	if q.version >= 2 {
+		positions := qrAlignment[q.version-2]
+		last := len(positions) - 1
+		for i, x := range positions {
+			for j, y := range positions {
+				if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+					continue
+				}
+				for dy := -2; dy <= 2; dy++ {
+					for dx := -2; dx <= 2; dx++ {
+						q.set(x+dx, y+dy, qrDistance(dx, dy) != 1)
+					}
+				}
+			}
+		}
+	}
+
+	// the format is drawn once a mask is chosen; its modules are reserved
+	q.drawFormat(0)
+
+	if q.version >= 7 {
+		rem := q.version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := q.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 != 0
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// qrDistance is the distance of dx, dy from the center of a pattern
+func qrDistance(dx, dy int) int {
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
+
+// qrFormat returns the format bits of level M with mask
+func qrFormat(mask int) int {
+	rem := mask
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (mask<<10 | rem) ^ 0x5412
+}
+
+// drawFormat draws both copies of the format bits
+func (q *qrCode) drawFormat(mask int) {
+	bits := qrFormat(mask)
+	bit := func(i int) bool { return bits>>uint(i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords draws codewords in pairs of columns, zigzagging up and down
+// from the right and skipping function modules
+func (q *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !q.function[y][x] && i < 8*len(codewords) {
+					q.modules[y][x] = codewords[i/8]>>uint(7-i%8)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the modules which aren't function modules selected by
+// mask, so that applying it twice undoes it
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard a code is to read, as the standard does to choose
+// a mask: long runs of a color, blocks of a color, patterns which look like
+// finder patterns and an imbalance of dark and light are penalised
+func (q *qrCode) penalty() int {
+	at := func(row, column int, transpose bool) bool {
+		if transpose {
+			return q.modules[column][row]
+		}
+		return q.modules[row][column]
+	}
+
+	result, dark := 0, 0
+	for _, transpose := range []bool{false, true} {
+		for row := 0; row < q.size; row++ {
+			run := 0
+			for column := 0; column < q.size; column++ {
+				if column > 0 && at(row, column, transpose) == at(row, column-1, transpose) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					result += 3
+				} else if run > 5 {
+					result++
+				}
+
+				if column+11 <= q.size {
+					var pattern int
+					for k := 0; k < 11; k++ {
+						pattern <<= 1
+						if at(row, column+k, transpose) {
+							pattern |= 1
+						}
+					}
+					if pattern == 0x5D0 || pattern == 0x05D {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+
+	total := q.size * q.size
+	deviation := dark*100/total - 50
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	return result + deviation/5*10
+}
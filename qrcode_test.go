@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// the codewords of HELLO WORLD at 1-M, from the worked example at
+	// thonky.com
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if ecc := qrRemainder(data, qrDivisor(10)); !bytes.Equal(ecc, expected) {
+		t.Errorf("expected error correction %v, received %v\n", expected, ecc)
+	}
+}
+
+func TestQRFormatAndVersion(t *testing.T) {
+	for mask, expected := range map[int]int{0: 0x5412, 4: 0x45F9, 7: 0x4AA0} {
+		if bits := qrFormat(mask); bits != expected {
+			t.Errorf("expected mask %d's format to be %015b, received %015b\n", mask, expected, bits)
+		}
+	}
+
+	q := newQRCode(7)
+	q.drawFunctionPatterns()
+	var bits int
+	for i := 17; i >= 0; i-- {
+		bits <<= 1
+		if q.modules[i/3][q.size-11+i%3] {
+			bits |= 1
+		}
+	}
+	if expected := 0x07C94; bits != expected {
+		t.Errorf("expected version 7's bits to be %018b, received %018b\n", expected, bits)
+	}
+}
+
+func TestQRVersions(t *testing.T) {
+	for i, b := range qrVersions {
+		version := i + 1
+		modules := (16*version+128)*version + 64
+		if version >= 2 {
+			n := version/7 + 2
+			modules -= (25*n-10)*n - 55
+		}
+		if version >= 7 {
+			modules -= 36
+		}
+		total := b.blocks1*(b.data1+b.ec) + b.blocks2*(b.data2+b.ec)
+		if total != modules/8 {
+			t.Errorf("expected version %d to have %d codewords, received %d\n", version, modules/8, total)
+		}
+	}
+
+	if _, err := qrEncode(bytes.Repeat([]byte("x"), 667)); err == nil {
+		t.Error("expected 667 bytes to be too many")
+	}
+	modules, err := qrEncode(bytes.Repeat([]byte("x"), 666))
+	if err != nil || len(modules) != 97 {
+		t.Errorf("expected 666 bytes to fit version 20, received %d modules (%v)\n", len(modules), err)
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	const url = "https://example.com/pair?code=ABCD-1234"
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	if err := system.QRCode(url); err != nil {
+		t.Fatal(err)
+	}
+	if output.Stdout.String() != url+"\n" {
+		t.Errorf("expected the URL without a terminal, received %q\n", output.Stdout.String())
+	}
+
+	modules, err := qrEncode([]byte(url))
+	if err != nil || len(modules) != 29 {
+		t.Fatalf("expected a version 3 code, received %d modules (%v)\n", len(modules), err)
+	}
+	lines := qrHalfBlocks(modules)
+	if len(lines) != 17 || strings.Count(lines[0], "█") != 33 {
+		t.Errorf("expected a light border, received:\n%s\n", strings.Join(lines, "\n"))
+	}
+	// the top left finder pattern: a dark ring around a light ring around a
+	// dark square
+	expected := []string{"####              ##", "####  ##########  ##", "####  ##      ##  ##"}
+	for i, line := range qrASCII(modules)[2:5] {
+		if !strings.HasPrefix(line, expected[i]) {
+			t.Errorf("expected line %d to start %q, received %q\n", i+2, expected[i], line)
+		}
+	}
+}
+
+func TestQRCodeTerminal(t *testing.T) {
+	const url = "https://example.com/pair?code=ABCD-1234"
+
+	system, _ := NewScreenTestSystem(t, nil, map[string]string{"LANG": "en_US.UTF-8"}, 20, 40)
+	if err := system.QRCode(url); err != nil {
+		t.Fatal(err)
+	}
+	screen := system.Screen()
+	if line := screen.Line(0); line != strings.Repeat("█", 33) {
+		t.Errorf("expected a light border, received %q\n", line)
+	}
+	if line := screen.Line(1); !strings.HasPrefix(line, "██ ▄▄▄▄▄ ██") {
+		t.Errorf("expected the top of the finder patterns, received %q\n", line)
+	}
+
+	system, _ = NewScreenTestSystem(t, nil, nil, 20, 40)
+	if err := system.QRCode(url); err != nil {
+		t.Fatal(err)
+	}
+	if actual := system.Screen().String(); actual != url {
+		t.Errorf("expected the URL where an ASCII code doesn't fit, received %q\n", actual)
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Rand returns Random, if set, or else crypto/rand.Reader
+func (s *BaseSystem) Rand() io.Reader {
+	s.random.Lock()
+	defer s.random.Unlock()
+
+	if s.Random == nil {
+		return rand.Reader
+	}
+	return lockedRandom{s}
+}
+
+// RandomID returns 32 hexadecimal digits read from Rand. If Rand fails, e.g.
+// because Random is a finite reader which has run out, the digits are read
+// from crypto/rand.Reader instead, or failing that derived from the time.
+func (s *BaseSystem) RandomID() string {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(s.Rand(), b); err == nil {
+		return hex.EncodeToString(b)
+	}
+	if _, err := io.ReadFull(rand.Reader, b); err == nil {
+		return hex.EncodeToString(b)
+	}
+	binary.BigEndian.PutUint64(b, uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint64(b[8:], atomic.AddUint64(&fallbackIDs, 1))
+	return hex.EncodeToString(b)
+}
+
+// fallbackIDs counts the IDs derived from the time, so that IDs made at the
+// same instant differ
+var fallbackIDs uint64
+
+// lockedRandom serializes reads of a System's Random, which may not be safe
+// for concurrent use, as a math/rand.Rand isn't
+type lockedRandom struct {
+	s *BaseSystem
+}
+
+func (l lockedRandom) Read(b []byte) (int, error) {
+	l.s.random.Lock()
+	defer l.s.random.Unlock()
+	return l.s.Random.Read(b)
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestRandomID(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{})
+	id := system.RandomID()
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Errorf("expected 32 hexadecimal digits, received %q\n", id)
+	}
+	if again := system.RandomID(); again == id {
+		t.Errorf("expected another ID, received %q twice\n", id)
+	}
+}
+
+func TestRandomIDExhausted(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Arguments: []string{"test"}})
+	base, _ := baseOf(system)
+	base.Random = bytes.NewReader([]byte{1, 2, 3})
+
+	// Main makes a trace ID on every run
+	ExpectExitCode(t, Main(context.Background(), &testColorCommand{}, system), ExitOK)
+	if id := system.RandomID(); !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Errorf("expected 32 hexadecimal digits, received %q\n", id)
+	}
+}
+
+func TestTestSystemRandom(t *testing.T) {
+	a, _ := NewTestSystem(t, nil, nil)
+	b, _ := NewTestSystem(t, nil, nil)
+	first := a.RandomID()
+	if id := b.RandomID(); id != first {
+		t.Errorf("expected Systems seeded alike to return %q, received %q\n", first, id)
+	}
+
+	a.Seed(2)
+	if id := a.RandomID(); id == first || id == b.RandomID() {
+		t.Errorf("expected another ID once reseeded, received %q\n", id)
+	}
+	a.Seed(1)
+	if id := a.RandomID(); id != first {
+		t.Errorf("expected %q once seeded with 1 again, received %q\n", first, id)
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"io"
+
+	"golang.org/x/term"
+)
+
+// ReadLine prints prompt and reads a line of input. If input is a terminal,
+// the line may be edited as it's typed: the left and right arrows move the
+// cursor, Backspace deletes the character before it and Ctrl-U everything
+// before it, and, if KeepHistory is set, the up and down arrows recall
+// earlier lines. Ctrl-D on an empty line and Ctrl-C return io.EOF. If input
+// isn't a terminal the line is read as it is.
+func (s *BaseSystem) ReadLine(prompt string) (string, error) {
+	f, ok := s.In.(interface{ Fd() uintptr })
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		if _, err := s.Print(prompt); err != nil {
+			return "", err
+		}
+		return s.readLine()
+	}
+
+	fd := int(f.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	editor := s.lineEditor
+	if editor == nil {
+		editor = term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{s.In, s.Out}, prompt)
+		if s.KeepHistory {
+			s.lineEditor = editor
+		}
+	}
+	editor.SetPrompt(prompt)
+	if width, height, err := s.TermSize(); err == nil && width > 0 && height > 0 {
+		editor.SetSize(width, height)
+	}
+	return editor.ReadLine()
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	system, _ := NewTestSystem(t, []string{"test"}, nil)
+	system.KeepHistory = true
+	go func() {
+		system.Console.ExpectString("first> ")
+		system.Console.Send("hello wrld\x1b[D\x1b[D\x1b[Do\r")
+		system.Console.ExpectString("second> ")
+		system.Console.Send("junk\x15\x1b[A\r")
+	}()
+
+	if line, err := system.ReadLine("first> "); err != nil || line != "hello world" {
+		t.Errorf("expected the edited line, received %q (%v)\n", line, err)
+	}
+	if line, err := system.ReadLine("second> "); err != nil || line != "hello world" {
+		t.Errorf("expected the line recalled from history, received %q (%v)\n", line, err)
+	}
+}
+
+func TestReadLineWithoutTerminal(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader("plain\r\nnext\n")})
+
+	if line, err := system.ReadLine("> "); err != nil || line != "plain" {
+		t.Errorf("expected the line as it was given, received %q (%v)\n", line, err)
+	}
+	if output.Stdout.String() != "> " {
+		t.Errorf("expected the prompt, received %q\n", output.Stdout)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Login signs the user in again, e.g. by prompting for their credentials
+type Login func(ctx context.Context, sys System) error
+
+// reauth is the login flow run when a command fails because the user's
+// session expired
+type reauth struct {
+	expired error
+	login   Login
+}
+
+// WithReauth runs login and then the command again, once, when a command
+// returns an error which is, or wraps, expired, so that commands which call
+// an API need not handle expired sessions themselves. The user is told that
+// their session expired before login runs. When input isn't a terminal the
+// user can't log in, so the command fails as it would have. Changes the
+// failed attempt registered with OnRollback are rolled back before it is
+// retried.
+func WithReauth(expired error, login Login) Option {
+	return func(c *config) {
+		c.reauth = &reauth{expired: expired, login: login}
+	}
+}
+
+// isExpired reports whether err is the error of an expired session
+func (r *reauth) isExpired(err error) bool {
+	return err != nil && errors.Is(err, r.expired)
+}
+
+// retry logs the user in and runs the command again, or fails if input
+// isn't a terminal
+func (r *reauth) retry(ctx context.Context, sys System, err error, run func() error) error {
+	if !sys.Interactive() {
+		return &ExitError{
+			Status:  ExitFailure,
+			Message: Localize(sys, "%s; log in again to continue", err),
+		}
+	}
+
+	sys.Log(tr(sys, "Your session has expired; logging in again"))
+	if err := r.login(ctx, sys); err != nil {
+		return err
+	}
+	return run()
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var errTestExpired = errors.New("session expired")
+
+type testReauthCommand struct {
+	DefaultHelp
+	session *string
+	runs    int
+}
+
+func (c *testReauthCommand) Command(ctx context.Context, args []string, s System) error {
+	c.runs++
+	if len(*c.session) == 0 {
+		return errors.Wrap(errTestExpired, "unable to list widgets")
+	}
+	_, err := s.Println("widgets for", *c.session)
+	return err
+}
+
+func TestReauth(t *testing.T) {
+	var session string
+	login := WithReauth(errTestExpired, func(ctx context.Context, sys System) error {
+		session = "alice"
+		return nil
+	})
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets"}, Interactive: true})
+	command := &testReauthCommand{session: &session}
+	ExpectExitCode(t, Main(context.Background(), command, system, login), ExitOK)
+	ExpectMatch(t, *output.Stderr, `Your session has expired; logging in again`)
+	ExpectMatch(t, *output.Stdout, `widgets for alice`)
+	if command.runs != 2 {
+		t.Errorf("expected the command to run twice, ran %d times\n", command.runs)
+	}
+
+	session = ""
+	system, output = NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets"}})
+	command = &testReauthCommand{session: &session}
+	ExpectExitCode(t, Main(context.Background(), command, system, login), ExitFailure)
+	ExpectMatch(t, *output.Stderr, `unable to list widgets: session expired; log in again to continue`)
+	if command.runs != 1 || len(session) > 0 {
+		t.Errorf("expected no login or retry without a terminal, ran %d times\n", command.runs)
+	}
+}
+
+func TestReauthRetriesOnce(t *testing.T) {
+	logins := 0
+	login := WithReauth(errTestExpired, func(ctx context.Context, sys System) error {
+		logins++
+		return nil
+	})
+
+	var session string
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"widgets"}, Interactive: true})
+	command := &testReauthCommand{session: &session}
+	ExpectExitCode(t, Main(context.Background(), command, system, login), ExitFailure)
+	ExpectMatch(t, *output.Stderr, `unable to list widgets: session expired`)
+	if command.runs != 2 || logins != 1 {
+		t.Errorf("expected one login and one retry, received %d login(s) and %d run(s)\n", logins, command.runs)
+	}
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Field describes a column of the records read by ReadRecords
+type Field struct {
+	Name string
+
+	// Required fields may not be empty
+	Required bool
+
+	// Validate, if set, checks a field's value
+	Validate func(value string) error
+}
+
+// Record is a record read by ReadRecords, mapping the name of each field to
+// its value
+type Record map[string]string
+
+// RecordError reports an invalid field of a record. Line and Column count
+// from 1; Column is the position of the field within the record.
+type RecordError struct {
+	Line   int
+	Column int
+	Field  string
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	if len(e.Field) == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d, column %d (%s): %s", e.Line, e.Column, e.Field, e.Err)
+}
+
+// RecordErrors are the errors found in the records read by ReadRecords
+type RecordErrors []*RecordError
+
+func (e RecordErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ReadRecords reads records of the given fields from input, one per line,
+// for commands which take many items at once. Fields are separated by
+// commas, or by tabs if the first line contains one, as when rows are pasted
+// from a spreadsheet, and may be quoted as in CSV. A first line naming the
+// fields is skipped.
+//
+// When input is a terminal the user is told what to enter, and each invalid
+// record is reported as it is entered so that it may be entered again; an
+// empty line finishes. Otherwise every line is read, and if any record is
+// invalid, RecordErrors listing the problems are returned.
+func ReadRecords(sys System, fields []Field) ([]Record, error) {
+	interactive := sys.Interactive()
+	if interactive {
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i] = f.Name
+		}
+		if _, err := sys.Println(Localize(sys,
+			"Enter one record per line as %s, then an empty line to finish:", strings.Join(names, ","),
+		)); err != nil {
+			return nil, err
+		}
+	}
+
+	var records []Record
+	var errs RecordErrors
+	comma := rune(0)
+	for line := 1; ; line++ {
+		var text string
+		var err error
+		if interactive {
+			text, err = sys.ReadLine("> ")
+		} else {
+			text, err = readLine(sys)
+		}
+		if err == io.EOF || (err == nil && interactive && len(strings.TrimSpace(text)) == 0) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		if comma == 0 {
+			comma = ','
+			if strings.ContainsRune(text, '\t') {
+				comma = '\t'
+			}
+			if isHeader(text, comma, fields) {
+				continue
+			}
+		}
+
+		record, invalid := parseRecord(text, comma, line, fields)
+		if len(invalid) == 0 {
+			records = append(records, record)
+			continue
+		}
+		if !interactive {
+			errs = append(errs, invalid...)
+			continue
+		}
+		for _, e := range invalid {
+			if _, err := sys.Eprintln(e.Error()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return records, nil
+}
+
+// splitRecord splits a line of delimited values
+func splitRecord(text string, comma rune) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.Read()
+}
+
+// isHeader reports whether a line names the fields
+func isHeader(text string, comma rune, fields []Field) bool {
+	values, err := splitRecord(text, comma)
+	if err != nil || len(values) != len(fields) {
+		return false
+	}
+	for i, v := range values {
+		if !strings.EqualFold(strings.TrimSpace(v), fields[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRecord parses and validates a line, returning the problems found
+func parseRecord(text string, comma rune, line int, fields []Field) (Record, []*RecordError) {
+	values, err := splitRecord(text, comma)
+	if err != nil {
+		if e, ok := err.(*csv.ParseError); ok {
+			err = e.Err
+		}
+		return nil, []*RecordError{{Line: line, Err: err}}
+	}
+	if len(values) != len(fields) {
+		return nil, []*RecordError{{
+			Line: line,
+			Err:  fmt.Errorf("expected %d fields, received %d", len(fields), len(values)),
+		}}
+	}
+
+	record := Record{}
+	var invalid []*RecordError
+	for i, f := range fields {
+		value := strings.TrimSpace(values[i])
+		err := f.validate(value)
+		if err != nil {
+			invalid = append(invalid, &RecordError{Line: line, Column: i + 1, Field: f.Name, Err: err})
+		}
+		record[f.Name] = value
+	}
+	return record, invalid
+}
+
+func (f Field) validate(value string) error {
+	if len(value) == 0 {
+		if f.Required {
+			return fmt.Errorf("a value is required")
+		}
+		return nil
+	}
+	if f.Validate != nil {
+		return f.Validate(value)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testRecordFields = []Field{
+	{Name: "name", Required: true},
+	{Name: "email", Required: true, Validate: func(value string) error {
+		if !strings.Contains(value, "@") {
+			return fmt.Errorf("not an email address")
+		}
+		return nil
+	}},
+	{Name: "role"},
+}
+
+func TestReadRecords(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader(
+		"Name\tEmail\tRole\n" +
+			"Ada\tada@example.com\tadmin\n" +
+			"\n" +
+			"\"Lovelace, Ada\"\tlovelace@example.com\t\n",
+	)})
+
+	records, err := ReadRecords(system, testRecordFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0]["role"] != "admin" || records[1]["name"] != "Lovelace, Ada" {
+		t.Errorf("unexpected records: %v\n", records)
+	}
+}
+
+func TestReadRecordsErrors(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Input: strings.NewReader(
+		"Ada,ada@example.com,admin\n" +
+			",grace,\n" +
+			"Alan,alan@example.com\n",
+	)})
+
+	_, err := ReadRecords(system, testRecordFields)
+	expected := "line 2, column 1 (name): a value is required\n" +
+		"line 2, column 2 (email): not an email address\n" +
+		"line 3: expected 3 fields, received 2"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%v", expected, err)
+	}
+}
+
+func TestReadRecordsInteractive(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{
+		Interactive: true,
+		Input:       strings.NewReader("Ada,ada,\nAda,ada@example.com,\n\nignored\n"),
+	})
+
+	records, err := ReadRecords(system, testRecordFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["email"] != "ada@example.com" {
+		t.Errorf("expected the corrected record, received %v\n", records)
+	}
+	if !strings.Contains(output.Stderr.String(), "line 1, column 2 (email): not an email address") {
+		t.Errorf("expected the invalid record to be reported, received %q\n", output.Stderr)
+	}
+	ExpectMatch(t, *output.Stdout, `Enter one record per line as name,email,role`)
+}
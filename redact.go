@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in traced requests
+const redacted = "REDACTED"
+
+// sensitiveWords are found in the names of headers, parameters and fields
+// which hold secrets
+var sensitiveWords = []string{
+	"authorization", "token", "secret", "password", "passwd", "key", "cookie",
+	"session", "signature", "credential",
+}
+
+// isSensitive reports whether the header, parameter or field name holds a
+// secret
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns u with its password and sensitive query parameters
+// redacted. A username without a password is redacted too, since services
+// commonly accept a token in its place.
+func redactURL(u *url.URL) string {
+	r := *u
+	if _, ok := r.User.Password(); ok {
+		r.User = url.UserPassword(r.User.Username(), redacted)
+	} else if r.User != nil && len(r.User.Username()) > 0 {
+		r.User = url.User(redacted)
+	}
+	if query := r.Query(); redactValues(query) {
+		r.RawQuery = query.Encode()
+	}
+	return r.String()
+}
+
+// redactValues redacts sensitive values in place, reporting whether any were
+func redactValues(values url.Values) bool {
+	changed := false
+	for name, vs := range values {
+		if !isSensitive(name) {
+			continue
+		}
+		for i := range vs {
+			vs[i] = redacted
+		}
+		changed = true
+	}
+	return changed
+}
+
+// redactHeader returns the value of a header, redacted if it holds a secret.
+// The scheme of an Authorization header is kept.
+func redactHeader(name, value string) string {
+	if !isSensitive(name) {
+		return value
+	}
+	if i := strings.IndexByte(value, ' '); i > 0 && strings.HasSuffix(strings.ToLower(name), "authorization") {
+		return value[:i+1] + redacted
+	}
+	return redacted
+}
+
+// redactBody returns a form or JSON body with the values of sensitive fields
+// redacted. Other bodies are returned as they are.
+func redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err == nil && redactValues(values) {
+			return []byte(values.Encode())
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err == nil && redactJSON(v) {
+			if b, err := json.Marshal(v); err == nil {
+				return b
+			}
+		}
+	}
+	return body
+}
+
+// redactJSON redacts sensitive fields of a decoded JSON value in place,
+// reporting whether any were
+func redactJSON(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if _, ok := value.(string); ok && isSensitive(name) {
+				v[name] = redacted
+				changed = true
+			} else if redactJSON(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registry holds the commands added with Register
+var registry = struct {
+	sync.Mutex
+	commands   map[string]Command
+	duplicates []string
+}{commands: map[string]Command{}}
+
+// Register adds cmd to the command tree at path, a space-separated list of
+// subcommand names such as `users delete`, so that one codebase may build
+// several binaries, such as a full admin CLI and a slim user CLI, from the
+// same tree. Each command registers itself from an init function in a file
+// constrained to the binaries which include it:
+//
+//	//go:build admin
+//
+//	package commands
+//
+//	func init() {
+//		cli.Register("users delete", cli.Lazy(newDeleteUserCommand))
+//	}
+//
+// A command registered beneath another must be registered beneath a
+// NewGroup. The root of a binary is a NewGroup which isn't registered, or a
+// command whose Subcommands returns Registered. Call Validate from a test
+// built with each binary's tags to check that its tree is complete.
+func Register(path string, cmd Command) {
+	path = strings.Join(strings.Fields(path), " ")
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.commands[path]; ok {
+		registry.duplicates = append(registry.duplicates, path)
+	}
+	registry.commands[path] = cmd
+}
+
+// Registered returns the commands registered at the top of the tree
+func Registered() CLI {
+	return registeredBeneath("")
+}
+
+// registeredBeneath returns the commands registered directly beneath the
+// command at parent
+func registeredBeneath(parent string) CLI {
+	registry.Lock()
+	defer registry.Unlock()
+	c := CLI{}
+	for path, cmd := range registry.commands {
+		i := strings.LastIndexByte(path, ' ')
+		if (i < 0 && len(parent) == 0) || (i >= 0 && path[:i] == parent) {
+			c[path[i+1:]] = cmd
+		}
+	}
+	return c
+}
+
+// NewGroup returns a command which groups the commands registered beneath
+// it, and has no action of its own
+func NewGroup(synopsis string) Command {
+	return &group{synopsis: synopsis}
+}
+
+type group struct {
+	DefaultHelp
+	synopsis string
+}
+
+func (g *group) Synopsis() string {
+	return g.synopsis
+}
+
+// Subcommands returns the commands registered beneath the group, or at the
+// top of the tree if the group is the root
+func (g *group) Subcommands() CLI {
+	return registeredBeneath(g.path())
+}
+
+// path returns the path the group is registered at, which is empty for the
+// root
+func (g *group) path() string {
+	registry.Lock()
+	defer registry.Unlock()
+	for path, cmd := range registry.commands {
+		if cmd == Command(g) {
+			return path
+		}
+	}
+	return ""
+}
+
+// Validate checks that the command tree rooted at root is complete in this
+// binary, returning ValidationErrors describing the problems found: a path
+// registered twice, a command registered beneath one which isn't in the
+// binary or isn't a group, and a group with no commands beneath it. It is
+// intended to be called from a test, once for each combination of build tags
+// a binary is built with.
+func Validate(root Command) error {
+	registry.Lock()
+	commands := make(map[string]Command, len(registry.commands))
+	paths := make([]string, 0, len(registry.commands))
+	for path, cmd := range registry.commands {
+		commands[path] = cmd
+		paths = append(paths, path)
+	}
+	duplicates := append([]string{}, registry.duplicates...)
+	registry.Unlock()
+	sort.Strings(paths)
+
+	var issues ValidationErrors
+	for _, path := range duplicates {
+		issues = append(issues, LintIssue{path, "registered more than once"})
+	}
+
+	reachable := map[string]bool{}
+	if b, ok := unwrap(root).(HasSubcommands); ok {
+		for _, e := range b.Subcommands().Entries("") {
+			reachable[e.Path] = true
+			if _, ok := e.Command.(*group); ok && len(registeredBeneath(e.Path)) == 0 {
+				issues = append(issues, LintIssue{e.Path, "group has no commands in this binary"})
+			}
+		}
+	}
+	for _, path := range paths {
+		if reachable[path] {
+			continue
+		}
+		i := strings.LastIndexByte(path, ' ')
+		if i < 0 {
+			issues = append(issues, LintIssue{path, "registered, but the root doesn't include Registered commands"})
+			continue
+		}
+		parent := path[:i]
+		if _, ok := commands[parent]; ok {
+			issues = append(issues, LintIssue{path, fmt.Sprintf("registered beneath %q, which isn't a group", parent)})
+		} else {
+			issues = append(issues, LintIssue{path, fmt.Sprintf("registered beneath %q, which isn't in this binary", parent)})
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	return issues
+}
+
+// ValidationErrors are the problems found in a command tree by Validate
+type ValidationErrors []LintIssue
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, issue := range e {
+		messages[i] = issue.String()
+	}
+	return strings.Join(messages, "\n")
+}
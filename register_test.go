@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type testRegisteredCommand struct {
+	DefaultHelp
+	ran bool
+}
+
+func (c *testRegisteredCommand) Command(ctx context.Context, args []string, s System) error {
+	c.ran = true
+	return nil
+}
+
+// setRegistry replaces the registered commands for a test
+func setRegistry(t *testing.T, commands map[string]Command) {
+	registry.Lock()
+	saved, duplicates := registry.commands, registry.duplicates
+	registry.commands, registry.duplicates = map[string]Command{}, nil
+	registry.Unlock()
+	t.Cleanup(func() {
+		registry.Lock()
+		registry.commands, registry.duplicates = saved, duplicates
+		registry.Unlock()
+	})
+	for path, cmd := range commands {
+		Register(path, cmd)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	setRegistry(t, map[string]Command{
+		"users":        NewGroup("Manage users"),
+		"users list":   &testRegisteredCommand{},
+		"users delete": Lazy(func() Command { return &testRegisteredCommand{} }),
+		"status":       &testRegisteredCommand{},
+	})
+	root := NewGroup("Manage the service")
+	if err := Validate(root); err != nil {
+		t.Fatalf("expected a complete tree, received:\n%s\n", err)
+	}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"admin", "users", "--help"}})
+	ExpectExitCode(t, Main(context.Background(), root, system), ExitOK)
+	ExpectMatch(t, *output.Stdout, `Usage: admin users <command>`)
+	ExpectMatch(t, *output.Stdout, `delete\n +list`)
+
+	command := &testRegisteredCommand{}
+	Register("users list", command)
+	system, _ = NewMemorySystem(MemorySystemOptions{Arguments: []string{"admin", "users", "list"}})
+	ExpectExitCode(t, Main(context.Background(), root, system), ExitOK)
+	if !command.ran {
+		t.Error("expected the command registered last to run")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	setRegistry(t, map[string]Command{
+		"users":              NewGroup("Manage users"),
+		"billing":            NewGroup("Manage billing"),
+		"status":             &testRegisteredCommand{},
+		"status verbose":     &testRegisteredCommand{},
+		"audit export":       &testRegisteredCommand{},
+		"users roles":        NewGroup("Manage roles"),
+		"users roles assign": &testRegisteredCommand{},
+	})
+	Register("status", &testRegisteredCommand{})
+
+	err := Validate(NewGroup("Manage the service"))
+	expected := []string{
+		`status: registered more than once`,
+		`billing: group has no commands in this binary`,
+		`audit export: registered beneath "audit", which isn't in this binary`,
+		`status verbose: registered beneath "status", which isn't a group`,
+	}
+	if err == nil || err.Error() != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\nreceived:\n%v\n", strings.Join(expected, "\n"), err)
+	}
+
+	err = Validate(&testRegisteredCommand{})
+	if err == nil || !strings.Contains(err.Error(), "users: registered, but the root doesn't include Registered commands") {
+		t.Errorf("expected the registered commands to be unreachable, received:\n%v\n", err)
+	}
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Conflict decides what Render does with a file which already exists with
+// different contents
+type Conflict int
+
+const (
+	// ConflictAsk asks the user, or fails if input isn't a terminal
+	ConflictAsk Conflict = iota
+
+	// ConflictOverwrite replaces the existing file
+	ConflictOverwrite
+
+	// ConflictSkip keeps the existing file
+	ConflictSkip
+)
+
+// WithConflictFlags adds `--force` and `--skip` flags which make Render
+// overwrite or keep files which already exist rather than asking, so that
+// scaffolding commands may be run by scripts
+func WithConflictFlags() Option {
+	return func(c *config) {
+		c.conflictFlags = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("force", false, "overwrite files which already exist")
+			f.Bool("skip", false, "keep files which already exist")
+		})
+	}
+}
+
+// Render writes the files of the template tree templates beneath dest,
+// executing those whose names end in `.tmpl` as text/templates with data
+// and writing them without the extension. Other files are copied as they
+// are. Path elements containing `{{` are executed too, so that a file may
+// be named e.g. `{{.Name}}.go.tmpl`.
+//
+// A file which already exists with different contents is a conflict. Unless
+// the System was told what to do with `--force` or `--skip`, the user is
+// asked whether to overwrite it, skip it, view the differences or abort. Each
+// file is reported as it is written.
+func Render(sys System, templates http.FileSystem, data interface{}, dest string) error {
+	files, err := templateFiles(templates, "/")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		target, contents, mode, err := renderFile(templates, name, data)
+		if err != nil {
+			return err
+		}
+		target = filepath.Join(dest, filepath.FromSlash(target))
+		if err := renderTo(sys, target, contents, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateFiles returns the slash-separated paths of the files beneath dir,
+// in order
+func templateFiles(templates http.FileSystem, dir string) ([]string, error) {
+	d, err := templates.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := d.Readdir(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	var files []string
+	for _, info := range infos {
+		name := path.Join(dir, info.Name())
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		within, err := templateFiles(templates, name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, within...)
+	}
+	return files, nil
+}
+
+// renderFile renders the template file name, returning the relative path it
+// is written to and its contents
+func renderFile(templates http.FileSystem, name string, data interface{}) (string, []byte, os.FileMode, error) {
+	f, err := templates.Open(name)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", nil, 0, err
+	}
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	mode := 0644 | info.Mode().Perm()&0111
+
+	target := strings.TrimPrefix(name, "/")
+	if strings.Contains(target, "{{") {
+		var b strings.Builder
+		if err := executeTemplate(&b, name, target, data); err != nil {
+			return "", nil, 0, err
+		}
+		target = b.String()
+	}
+
+	if strings.HasSuffix(target, ".tmpl") {
+		target = strings.TrimSuffix(target, ".tmpl")
+		var b bytes.Buffer
+		if err := executeTemplate(&b, name, string(contents), data); err != nil {
+			return "", nil, 0, err
+		}
+		contents = b.Bytes()
+	}
+	return target, contents, mode, nil
+}
+
+func executeTemplate(w io.Writer, name, text string, data interface{}) error {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// renderTo writes a rendered file to target, resolving a conflict with an
+// existing file
+func renderTo(sys System, target string, contents []byte, mode os.FileMode) error {
+	display := target
+	if rel, err := sys.Rel(target); err == nil {
+		display = rel
+	}
+
+	status := tr(sys, "create")
+	existing, err := sys.ReadFile(target)
+	switch {
+	case err == nil && bytes.Equal(existing, contents):
+		_, err := sys.Printf("%12s  %s\n", tr(sys, "identical"), display)
+		return err
+
+	case err == nil:
+		overwrite, err := resolveConflict(sys, display, existing, contents)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			_, err := sys.Printf("%12s  %s\n", tr(sys, "skip"), display)
+			return err
+		}
+		status = tr(sys, "overwrite")
+
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err := sys.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := sys.WriteFile(target, contents, mode); err != nil {
+		return err
+	}
+	_, err = sys.Printf("%12s  %s\n", status, display)
+	return err
+}
+
+// resolveConflict decides whether to overwrite a file which exists with
+// different contents, asking the user unless told what to do
+func resolveConflict(sys System, display string, existing, contents []byte) (bool, error) {
+	if s, ok := baseOf(sys); ok && s.Conflicts != ConflictAsk {
+		return s.Conflicts == ConflictOverwrite, nil
+	}
+	if !sys.Interactive() {
+		return false, &ExitError{
+			Status:  ExitFailure,
+			Message: Localize(sys, "%s already exists; use --force to overwrite it or --skip to keep it", display),
+		}
+	}
+
+	for {
+		if _, err := sys.Print(Localize(sys, "%s already exists: (o)verwrite, (s)kip, (d)iff or (a)bort? ", display)); err != nil {
+			return false, err
+		}
+		answer, err := readLine(sys)
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "o":
+			return true, nil
+		case "s":
+			return false, nil
+		case "d":
+			if err := sys.ExternalDiff(existing, contents); err != nil {
+				return false, err
+			}
+		case "a":
+			return false, &ExitError{Status: ExitFailure, Message: tr(sys, "Aborted")}
+		}
+	}
+}
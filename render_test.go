@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testTemplates writes a template tree to a temporary directory
+func testTemplates(t *testing.T, files map[string]string) http.FileSystem {
+	dir, err := ioutil.TempDir("", "go-cli-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return http.Dir(dir)
+}
+
+func TestRender(t *testing.T) {
+	sandbox := Sandbox(t)
+	templates := testTemplates(t, map[string]string{
+		"{{.Name}}/main.go.tmpl": "package {{.Name}}\n",
+		"{{.Name}}/LICENSE":      "Do what you like with {{.Name}}\n",
+		"README.md.tmpl":         "# {{.Name}}\n",
+	})
+	data := struct{ Name string }{"widgets"}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Dir: sandbox.Work})
+	if err := Render(system, templates, data, "."); err != nil {
+		t.Fatal(err)
+	}
+	expected := "      create  README.md\n" +
+		"      create  widgets/LICENSE\n" +
+		"      create  widgets/main.go\n"
+	if output.Stdout.String() != filepath.FromSlash(expected) {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, output.Stdout)
+	}
+	if b, _ := system.ReadFile("widgets/main.go"); string(b) != "package widgets\n" {
+		t.Errorf("expected the template to be executed, received %q\n", b)
+	}
+	if b, _ := system.ReadFile("widgets/LICENSE"); string(b) != "Do what you like with {{.Name}}\n" {
+		t.Errorf("expected the file to be copied, received %q\n", b)
+	}
+
+	system.WriteFile("README.md", []byte("# Widgets, the best\n"), 0644)
+	system, _ = NewMemorySystem(MemorySystemOptions{Dir: sandbox.Work})
+	if err := Render(system, templates, data, "."); err == nil {
+		t.Error("expected a conflict without a terminal to fail")
+	}
+
+	system, output = NewMemorySystem(MemorySystemOptions{
+		Dir:         sandbox.Work,
+		Interactive: true,
+		Input:       strings.NewReader("d\ns\n"),
+	})
+	if err := Render(system, templates, data, "."); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"-# Widgets, the best", "+# widgets", "skip  README.md", "identical  widgets/main.go"} {
+		if !strings.Contains(output.Stdout.String(), filepath.FromSlash(expected)) {
+			t.Errorf("expected %q within:\n%s", expected, output.Stdout)
+		}
+	}
+
+	system, _ = NewMemorySystem(MemorySystemOptions{Dir: sandbox.Work})
+	system.Conflicts = ConflictOverwrite
+	if err := Render(system, templates, data, "."); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := system.ReadFile("README.md"); string(b) != "# widgets\n" {
+		t.Errorf("expected the file to be overwritten, received %q\n", b)
+	}
+}
+
+type testRenderCommand struct {
+	templates http.FileSystem
+}
+
+func (c *testRenderCommand) Help() {}
+
+func (c *testRenderCommand) Command(ctx context.Context, args []string, s System) error {
+	return Render(s, c.templates, nil, ".")
+}
+
+func TestConflictFlags(t *testing.T) {
+	sandbox := Sandbox(t)
+	cmd := &testRenderCommand{testTemplates(t, map[string]string{"README.md": "# Widgets\n"})}
+	ioutil.WriteFile(filepath.Join(sandbox.Work, "README.md"), []byte("# Mine\n"), 0600)
+
+	system, output := sandbox.System([]string{"testrender", "--force", "--skip"})
+	if result := Main(context.Background(), cmd, system, WithConflictFlags()); result != ExitUsage {
+		t.Errorf("expected a usage error, received %d\n%s", result, output.STDERR)
+	}
+
+	system, output = sandbox.System([]string{"testrender", "--skip"})
+	if result := Main(context.Background(), cmd, system, WithConflictFlags()); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if b, _ := system.ReadFile("README.md"); string(b) != "# Mine\n" {
+		t.Errorf("expected the file to be kept, received %q\n", b)
+	}
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithReport adds a `--report format:path` flag which writes a report of the
+// run to a file once the command has returned, alongside its usual output.
+// The `junit` format is written by WriteJUnit, so that CI systems present the
+// items of a batch command as test results. More formats may be added with
+// WithReportFormat.
+func WithReport() Option {
+	return func(c *config) {
+		c.addReport("junit", WriteJUnit)
+	}
+}
+
+// WithReportFormat adds a format to the `--report` flag, which is installed
+// if it isn't already
+func WithReportFormat(name string, render OutputRenderer) Option {
+	return func(c *config) {
+		c.addReport(name, render)
+	}
+}
+
+// addReport registers a report format, installing the `--report` flag along
+// with the first
+func (c *config) addReport(name string, render OutputRenderer) {
+	if c.reports == nil {
+		c.reports = map[string]OutputRenderer{}
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			var formats []string
+			for name := range c.reports {
+				formats = append(formats, name)
+			}
+			sort.Strings(formats)
+			f.String("report", "", "write a report to a file, as `format:path` where format is one of "+
+				strings.Join(formats, ", "))
+		})
+	}
+	c.reports[name] = render
+}
+
+// parseReport splits the value of `--report` into its renderer and path
+func (c *config) parseReport(sys System, value string) (OutputRenderer, string, error) {
+	i := strings.IndexByte(value, ':')
+	if i <= 0 || i == len(value)-1 {
+		return nil, "", errors.New(tr(sys, "Reports are named as format:path, e.g. junit:results.xml"))
+	}
+	render, ok := c.reports[value[:i]]
+	if !ok {
+		return nil, "", errors.New(Localize(sys, "Unknown report format: %s", value[:i]))
+	}
+	return render, value[i+1:], nil
+}
+
+// writeReport renders record into the file at path
+func writeReport(sys System, render OutputRenderer, path string, record RunRecord) error {
+	var b bytes.Buffer
+	if err := render(&b, record); err != nil {
+		return err
+	}
+	return sys.WriteFile(path, b.Bytes(), 0644)
+}
+
+// WriteJUnit writes a run as JUnit XML, with a test case for each item
+// recorded with RecordItem, such as each target of ForEachTarget. A run
+// without items is reported as a single test case.
+func WriteJUnit(w io.Writer, record RunRecord) error {
+	command := strings.TrimSpace(record.Command + " " + strings.Join(record.Args, " "))
+	suite := junitSuite{
+		Name:      command,
+		Timestamp: record.Start.UTC().Format("2006-01-02T15:04:05"),
+		Time:      junitSeconds(record.Duration),
+	}
+
+	items := record.Items
+	if len(items) == 0 {
+		items = []ItemResult{{
+			Name:     command,
+			Duration: record.Duration,
+			Status:   record.Status,
+			Error:    record.Error,
+		}}
+	}
+	for _, item := range items {
+		c := junitCase{
+			Name:      item.Name,
+			ClassName: record.Command,
+			Time:      junitSeconds(item.Duration),
+		}
+		if item.Status != ExitOK {
+			message := item.Error
+			if len(message) == 0 {
+				message = fmt.Sprintf("exit status %d", item.Status)
+			}
+			c.Failure = &junitFailure{
+				Message: message,
+				Type:    fmt.Sprintf("exit status %d", item.Status),
+				Text:    message,
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Tests = len(suite.Cases)
+
+	if output := plainText(record.Output); len(output) > 0 {
+		suite.SystemOut = output
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(junitSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSeconds formats a duration as JUnit does, in seconds
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Time      string      `xml:"time,attr"`
+	Cases     []junitCase `xml:"testcase"`
+	SystemOut string      `xml:"system-out,omitempty"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
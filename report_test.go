@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runReport(t *testing.T, cmd Command, args ...string) (int, *MemoryOutput) {
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: append([]string{"widgets"}, args...)})
+	return Main(context.Background(), cmd, system, WithReport()), output
+}
+
+func TestJUnitReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "results.xml")
+
+	cmd := &testTargetsCommand{Targets{Available: []string{"production", "staging"}}}
+	result, output := runReport(t, cmd, "--report", "junit:"+path, "--all-targets")
+	ExpectExitCode(t, result, 4)
+	ExpectMatch(t, *output.Stdout, `\[production\] deploying`)
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string `xml:"name,attr"`
+				Failure *struct {
+					Message string `xml:"message,attr"`
+					Type    string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(b, &report); err != nil {
+		t.Fatalf("%s\n%s", err, b)
+	}
+
+	if report.Tests != 2 || report.Failures != 1 || len(report.Suites) != 1 {
+		t.Fatalf("expected a suite of 2 tests with 1 failure\n%s", b)
+	}
+	suite := report.Suites[0]
+	if suite.Name != "widgets" {
+		t.Errorf("unexpected suite name %q\n", suite.Name)
+	}
+	if suite.Cases[0].Name != "production" || suite.Cases[0].Failure != nil {
+		t.Errorf("expected production to pass\n%s", b)
+	}
+	if failure := suite.Cases[1].Failure; suite.Cases[1].Name != "staging" || failure == nil ||
+		failure.Message != "unreachable" || failure.Type != "exit status 4" {
+		t.Errorf("expected staging to fail\n%s", b)
+	}
+
+	result, output = runReport(t, &testOutputCommand{}, "--report", "junit:"+path, "api")
+	ExpectExitCode(t, result, ExitFailure)
+	if b, err = ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	ExpectMatch(t, *bytes.NewBuffer(b), `<testcase name="widgets api" classname="widgets"`)
+	ExpectMatch(t, *bytes.NewBuffer(b), `<failure message="Unable to reach api" type="exit status 1">`)
+}
+
+func TestUnknownReportFormat(t *testing.T) {
+	result, output := runReport(t, &testOutputCommand{}, "--report", "tap:results.tap")
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.Stderr, `Unknown report format: tap`)
+
+	result, output = runReport(t, &testOutputCommand{}, "--report", "results.xml")
+	ExpectExitCode(t, result, ExitUsage)
+	ExpectMatch(t, *output.Stderr, `format:path`)
+}
@@ -0,0 +1,33 @@
+package cli
+
+import "time"
+
+// resizePollInterval is how often the console's size is checked, since
+// Windows doesn't signal processes when it changes
+const resizePollInterval = 250 * time.Millisecond
+
+// watchResize calls notify each time the size of the console output is
+// attached to changes, until the returned function is called
+func watchResize(s *BaseSystem, notify func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+
+		width, height, _ := s.TermSize()
+		for {
+			select {
+			case <-ticker.C:
+				w, h, err := s.TermSize()
+				if err == nil && (w != width || h != height) {
+					width, height = w, h
+					notify()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
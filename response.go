@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// WithResponseFiles expands command-line tokens of the form `@path` into the
+// arguments listed in the named file before the command line is parsed.
+// Arguments in the file are separated by whitespace or newlines and may be
+// enclosed in single or double quotes to include whitespace. Response files
+// are not expanded recursively, and tokens after `--` are left untouched.
+func WithResponseFiles() Option {
+	return func(c *config) {
+		c.responseFiles = true
+	}
+}
+
+func expandResponseFiles(sys System, tokens []string) ([]string, error) {
+	var expanded []string
+	for i, token := range tokens {
+		if token == "--" {
+			expanded = append(expanded, tokens[i:]...)
+			break
+		}
+
+		if len(token) < 2 || token[0] != '@' {
+			expanded = append(expanded, token)
+			continue
+		}
+
+		b, err := sys.ReadFile(token[1:])
+		if err != nil {
+			return nil, errors.New(Localize(sys, "Unable to read response file: %s", err))
+		}
+
+		args, err := splitResponseFile(string(b))
+		if err != nil {
+			return nil, errors.New(Localize(sys, "Invalid response file %s: %s", token[1:], err))
+		}
+		expanded = append(expanded, args...)
+	}
+	return expanded, nil
+}
+
+func splitResponseFile(content string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range content {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// RollbackFunc undoes a change made by a command
+type RollbackFunc func(context.Context) error
+
+type rollbacks struct {
+	mu  sync.Mutex
+	fns []RollbackFunc
+
+	sys         System
+	cancel      context.CancelFunc
+	signals     <-chan os.Signal
+	untrap      context.CancelFunc
+	trapped     bool
+	interrupted bool
+	done        chan struct{}
+}
+
+// newRollbacks returns a registry of rollbacks for a command run with ctx on
+// sys, and a context which is cancelled if the user interrupts the command
+// after it has registered a rollback
+func newRollbacks(ctx context.Context, sys System) (context.Context, *rollbacks) {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &rollbacks{
+		sys:    sys,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	return context.WithValue(ctx, "rollbacks", r), r
+}
+
+// OnRollback registers fn to undo a change the running command has just made.
+// If the command returns an error or is interrupted, Main calls every
+// registered function in reverse order of registration. OnRollback does
+// nothing if ctx did not come from Main.
+//
+// Once a rollback has been registered, an interrupt from the user cancels
+// ctx rather than ending the program, so that the command can stop and its
+// changes can be rolled back; Main then returns ExitCancelled. A second
+// interrupt ends the program as usual. Commands which never register a
+// rollback keep the default interrupt behavior, or their own.
+func OnRollback(ctx context.Context, fn RollbackFunc) {
+	if r, ok := ctx.Value("rollbacks").(*rollbacks); ok {
+		r.add(fn)
+	}
+}
+
+// OnExit registers fn to be called when Main returns, however the command
+// ended, e.g. to remove temporary files. Functions are called in reverse
+// order of registration, after any rollbacks. OnExit does nothing if ctx did
+// not come from Main.
+func OnExit(ctx context.Context, fn func()) {
+	if e, ok := ctx.Value("exits").(*exits); ok {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.fns = append(e.fns, fn)
+	}
+}
+
+// exits holds the functions registered with OnExit
+type exits struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// newExits returns a registry of functions to call when Main returns, and a
+// context which carries it
+func newExits(ctx context.Context) (context.Context, *exits) {
+	e := &exits{}
+	return context.WithValue(ctx, "exits", e), e
+}
+
+// run calls the registered functions in reverse order
+func (e *exits) run() {
+	e.mu.Lock()
+	fns := e.fns
+	e.fns = nil
+	e.mu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+}
+
+func (r *rollbacks) add(fn RollbackFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fns = append(r.fns, fn)
+	if !r.trapped {
+		r.trapped = true
+		var trap context.Context
+		trap, r.untrap = context.WithCancel(context.Background())
+		r.signals = r.sys.Notify(trap, os.Interrupt)
+		go r.trap()
+	}
+}
+
+// trap cancels the command's context when the user interrupts it
+func (r *rollbacks) trap() {
+	select {
+	case <-r.signals:
+		r.mu.Lock()
+		r.untrap()
+		r.interrupted = true
+		r.mu.Unlock()
+		r.cancel()
+	case <-r.done:
+	}
+}
+
+// release removes the interrupt trap and reports whether an interrupt was
+// received
+func (r *rollbacks) release() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.trapped {
+		r.untrap()
+	}
+	close(r.done)
+	r.cancel()
+	return r.interrupted
+}
+
+// run calls the registered functions in reverse order, reporting progress
+// through sys. The functions are given a context which carries the values of
+// ctx but is never cancelled, so that an interrupted command can still be
+// rolled back.
+func (r *rollbacks) run(ctx context.Context, sys System) {
+	r.mu.Lock()
+	fns := r.fns
+	r.fns = nil
+	r.mu.Unlock()
+
+	if len(fns) == 0 {
+		return
+	}
+
+	ctx = detachedContext{ctx}
+	sys.Logf(tr(sys, "Rolling back %d change(s)\n"), len(fns))
+	for i := len(fns) - 1; i >= 0; i-- {
+		step := len(fns) - i
+		if err := fns[i](ctx); err != nil {
+			sys.Logf(tr(sys, "Rollback %d of %d failed: %s\n"), step, len(fns), err)
+			continue
+		}
+		sys.Logf(tr(sys, "Rollback %d of %d complete\n"), step, len(fns))
+	}
+}
+
+// detachedContext carries the values of its parent but is never cancelled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,397 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of RunEvent
+const (
+	EventPlan      = "plan"
+	EventOperation = "operation"
+	EventWarning   = "warning"
+)
+
+// RunEvent is something notable which happened during a run
+type RunEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Message string    `json:"message"`
+}
+
+// RunRecord is a structured record of a single run of a command
+type RunRecord struct {
+	Command  string        `json:"command"`
+	Args     []string      `json:"args,omitempty"`
+	TraceID  string        `json:"trace_id"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Status   int           `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Events   []RunEvent    `json:"events,omitempty"`
+	Output   string        `json:"output,omitempty"`
+
+	// Data holds the values emitted with Emit, in order
+	Data []interface{} `json:"data,omitempty"`
+
+	// Findings holds the findings recorded with RecordFinding, in order
+	Findings []Finding `json:"findings,omitempty"`
+
+	// Items holds the outcome of each item of a batch, in order
+	Items []ItemResult `json:"items,omitempty"`
+}
+
+// ItemResult is the outcome of one item of a batch, such as one target of
+// ForEachTarget
+type ItemResult struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Status   int           `json:"status"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Levels of Finding
+const (
+	FindingError   = "error"
+	FindingWarning = "warning"
+	FindingNote    = "note"
+)
+
+// Finding is a problem found by an analysis command, such as a linter or
+// scanner, optionally at a location within a file
+type Finding struct {
+	// RuleID identifies the check which produced the finding
+	RuleID string `json:"rule_id,omitempty"`
+
+	// Level is FindingError, FindingWarning or FindingNote
+	Level   string `json:"level"`
+	Message string `json:"message"`
+
+	// Path is the file the finding is in, relative to the directory being
+	// analyzed, or empty if it isn't in a particular file
+	Path string `json:"path,omitempty"`
+
+	// Line and Column are where the finding begins, counting from 1, or 0
+	// if unknown
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+const (
+	runLogDir       = "runs"
+	runLogKeep      = 20
+	runOutputLimit  = 16 * 1024
+	runLogTruncated = "\n[output truncated]\n"
+)
+
+// WithRunLog keeps a structured record of the most recent runs of app in its
+// state directory, and installs a `last [n]` subcommand which displays the
+// nth most recent run. Commands may add to the record with RecordEvent; plans
+// executed with Plan are recorded automatically.
+func WithRunLog(app string) Option {
+	return func(c *config) {
+		c.runLog = app
+		c.subcommands["last"] = &LastCommand{App: app}
+	}
+}
+
+type runRecorder struct {
+	mu     sync.Mutex
+	record RunRecord
+	output limitedBuffer
+}
+
+// RecordEvent adds an event to the record of the current run. It does nothing
+// if the run isn't being recorded.
+func RecordEvent(ctx context.Context, kind, message string) {
+	if r, ok := ctx.Value("run-record").(*runRecorder); ok {
+		r.mu.Lock()
+		r.record.Events = append(r.record.Events,
+			RunEvent{Time: time.Now(), Kind: kind, Message: message})
+		r.mu.Unlock()
+	}
+}
+
+// Emit adds a structured value, such as a slice of the items a command
+// listed, to the record of the current run, for output formats which present
+// data rather than text, e.g. `--output html`. It does nothing if the run isn't
+// being recorded, so commands should still print their results.
+func Emit(ctx context.Context, value interface{}) {
+	if r, ok := ctx.Value("run-record").(*runRecorder); ok {
+		r.mu.Lock()
+		r.record.Data = append(r.record.Data, value)
+		r.mu.Unlock()
+	}
+}
+
+// RecordFinding adds a finding to the record of the current run, for output
+// formats such as `--output sarif`. It does nothing if the run isn't being
+// recorded, so commands should still print what they found.
+func RecordFinding(ctx context.Context, f Finding) {
+	if r, ok := ctx.Value("run-record").(*runRecorder); ok {
+		r.mu.Lock()
+		r.record.Findings = append(r.record.Findings, f)
+		r.mu.Unlock()
+	}
+}
+
+// RecordItem adds the outcome of one item of a batch to the record of the
+// current run, for reports such as `--report junit:results.xml`. It does
+// nothing if the run isn't being recorded. ForEachTarget records each target
+// itself.
+func RecordItem(ctx context.Context, item ItemResult) {
+	if r, ok := ctx.Value("run-record").(*runRecorder); ok {
+		r.mu.Lock()
+		r.record.Items = append(r.record.Items, item)
+		r.mu.Unlock()
+	}
+}
+
+// startRecording begins recording a run, capturing the command's output. The
+// returned function completes the record and returns it.
+func startRecording(ctx context.Context, sys System, name string, args []string) (context.Context, func(int, error) RunRecord) {
+	r := &runRecorder{record: RunRecord{
+		Command: name,
+		Args:    args,
+		Start:   time.Now(),
+	}}
+	r.output.limit = runOutputLimit
+	if id, ok := ctx.Value("trace-id").(string); ok {
+		r.record.TraceID = id
+	}
+
+	var restore func()
+	if s, ok := baseOf(sys); ok {
+		out := s.Out
+		s.Out = teeOutput(out, &r.output)
+		restore = func() { s.Out = out }
+	}
+
+	return context.WithValue(ctx, "run-record", r), func(status int, err error) RunRecord {
+		if restore != nil {
+			restore()
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.record.Duration = time.Since(r.record.Start)
+		r.record.Status = status
+		if err != nil {
+			r.record.Error = err.Error()
+		}
+		r.record.Output = r.output.String()
+		return r.record
+	}
+}
+
+// teeOutput returns a writer which writes to both out and record. If out is a
+// terminal the writer is one too, so that colors and the terminal's size are
+// still used while the output is recorded.
+func teeOutput(out, record io.Writer) io.Writer {
+	w := io.MultiWriter(out, record)
+	if f, ok := out.(interface{ Fd() uintptr }); ok {
+		return &terminalTee{w, f}
+	}
+	return w
+}
+
+// terminalTee is a writer copying output to a terminal, which it reports as
+// its own
+type terminalTee struct {
+	io.Writer
+	terminal interface{ Fd() uintptr }
+}
+
+// Fd returns the terminal output is copied to
+func (t *terminalTee) Fd() uintptr {
+	return t.terminal.Fd()
+}
+
+// saveRunRecord writes record to its own file within the run log, so that
+// concurrent runs don't contend for a shared file, then removes the oldest
+// records beyond the number kept
+func saveRunRecord(sys System, app string, record RunRecord) error {
+	dir, err := runLogPath(sys, app)
+	if err != nil {
+		return err
+	}
+	if err := sys.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%020d-%.8s.json", record.Start.UnixNano(), record.TraceID)
+	if err := sys.WriteFile(filepath.Join(dir, name), b, 0600); err != nil {
+		return err
+	}
+
+	names, err := runRecordNames(sys, dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > runLogKeep {
+		if err := sys.Remove(filepath.Join(dir, names[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// loadRunRecords returns the recorded runs of app, oldest first
+func loadRunRecords(sys System, app string) ([]RunRecord, error) {
+	dir, err := runLogPath(sys, app)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := runRecordNames(sys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []RunRecord
+	for _, name := range names {
+		b, err := sys.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			// removed by a concurrent run
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var r RunRecord
+		if err := json.Unmarshal(b, &r); err != nil {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func runLogPath(sys System, app string) (string, error) {
+	dir, err := stateDir(sys, app)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, runLogDir), nil
+}
+
+// runRecordNames returns the names of the files within the run log, oldest
+// first
+func runRecordNames(sys System, dir string) ([]string, error) {
+	infos, err := sys.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, info := range infos {
+		if !info.IsDir() && filepath.Ext(info.Name()) == ".json" {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LastCommand is a subcommand which displays the record of a recent run
+type LastCommand struct {
+	DefaultHelp
+
+	App string
+}
+
+// Synopsis describes the last command
+func (c *LastCommand) Synopsis() string {
+	return "Show what a recent run did"
+}
+
+// Command displays the nth most recent run, where n is the first argument
+// and defaults to 1
+func (c *LastCommand) Command(ctx context.Context, args []string, sys System) error {
+	n := 1
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return &ExitError{Status: ExitUsage, Message: Localize(sys, "Invalid run number: %s", args[0])}
+		}
+	}
+
+	records, err := loadRunRecords(sys, c.App)
+	if err != nil {
+		return err
+	}
+	if n > len(records) {
+		return &ExitError{Status: ExitFailure, Message: Localize(sys, "Only %d run(s) recorded", len(records))}
+	}
+
+	r := records[len(records)-n]
+	sys.Printf("Command:  %s\n", strings.TrimSpace(r.Command+" "+strings.Join(r.Args, " ")))
+	sys.Printf("Started:  %s\n", r.Start.Format(time.RFC3339))
+	sys.Printf("Duration: %s\n", r.Duration.Round(time.Millisecond))
+	sys.Printf("Status:   %d\n", r.Status)
+	if len(r.Error) > 0 {
+		sys.Printf("Error:    %s\n", r.Error)
+	}
+	if len(r.TraceID) > 0 {
+		sys.Printf("Trace ID: %s\n", r.TraceID)
+	}
+
+	if len(r.Events) > 0 {
+		sys.Println("\nEvents:")
+		for _, e := range r.Events {
+			sys.Printf("  %s  %-9s  %s\n", e.Time.Format("15:04:05"), e.Kind, e.Message)
+		}
+	}
+
+	if len(r.Output) > 0 {
+		sys.Printf("\nOutput:\n%s", r.Output)
+		if !strings.HasSuffix(r.Output, "\n") {
+			sys.Println()
+		}
+	}
+	return nil
+}
+
+// limitedBuffer keeps at most limit bytes written to it
+type limitedBuffer struct {
+	bytes.Buffer
+
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + runLogTruncated
+	}
+	return b.Buffer.String()
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRunLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	environment := map[string]string{"XDG_STATE_HOME": dir}
+
+	result, _ := runMainWithEnv(t, &testPlanCommand{}, []string{"testplan"}, environment,
+		WithRunLog("testplan"))
+	if result != 1 {
+		t.Errorf("expected a 1 status, received %d\n", result)
+	}
+
+	system, _ := NewTestSystem(t, []string{"testplan"}, environment)
+	records, err := loadRunRecords(system, "testplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 recorded run, found %d\n", len(records))
+	}
+
+	r := records[0]
+	if r.Status != 1 || len(r.Error) == 0 {
+		t.Errorf("expected a failed run to be recorded, received %+v\n", r)
+	}
+	if len(r.Events) != 6 {
+		t.Errorf("expected 3 plan and 3 operation events, received %+v\n", r.Events)
+	}
+}
+
+func TestRunLogKeepsRecentRuns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	system, _ := NewTestSystem(t, []string{"testplan"}, map[string]string{"XDG_STATE_HOME": dir})
+	start := time.Now()
+	for i := 0; i < runLogKeep+5; i++ {
+		record := RunRecord{Command: strconv.Itoa(i), Start: start.Add(time.Duration(i))}
+		if err := saveRunRecord(system, "testplan", record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := loadRunRecords(system, "testplan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != runLogKeep {
+		t.Fatalf("expected %d recorded runs, found %d\n", runLogKeep, len(records))
+	}
+	if records[0].Command != "5" || records[len(records)-1].Command != strconv.Itoa(runLogKeep+4) {
+		t.Errorf("expected the most recent runs to be kept, oldest first\n")
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// uiIdleTimeout is how long ServeUI waits for a request before it stops
+var uiIdleTimeout = 10 * time.Minute
+
+// ServeUI serves handler on a free port of localhost and opens it in the
+// user's browser, for commands whose output is best shown as a web page, such
+// as a coverage report or a dashboard. The URL is printed too, in case no
+// browser can be opened. Requests naming another host are refused, so that
+// other web pages the user has open can't reach the handler.
+//
+// ServeUI returns nil once ctx is done, the user interrupts it, or no request
+// has been made for a while, giving requests in progress a few seconds to
+// finish.
+func ServeUI(ctx context.Context, sys System, handler http.Handler) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	host := listener.Addr().String()
+	address := "http://" + host + "/"
+
+	var active int32
+	activity := make(chan struct{}, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != host {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		atomic.AddInt32(&active, 1)
+		defer func() {
+			atomic.AddInt32(&active, -1)
+			select {
+			case activity <- struct{}{}:
+			default:
+			}
+		}()
+		handler.ServeHTTP(w, r)
+	})}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	interrupts := sys.Notify(ctx, os.Interrupt)
+	if err := sys.OpenBrowser(address); err != nil {
+		sys.Logf(tr(sys, "Unable to open a browser: %s\n"), err)
+	}
+	if _, err := sys.Println(Localize(sys, "Serving %s; press Ctrl-C to stop", address)); err != nil {
+		server.Close()
+		return err
+	}
+
+	timeout := uiIdleTimeout
+	idle := time.NewTimer(timeout)
+	defer idle.Stop()
+	for {
+		select {
+		case err := <-served:
+			return err
+		case <-activity:
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(timeout)
+			continue
+		case <-idle.C:
+			if atomic.LoadInt32(&active) > 0 {
+				idle.Reset(timeout)
+				continue
+			}
+			sys.Logf(tr(sys, "Stopping after %s without a request\n"), timeout)
+		case <-ctx.Done():
+		case <-interrupts:
+		}
+		return stopServer(server)
+	}
+}
+
+// stopServer shuts server down, closing the connections of requests which
+// don't finish in time, such as event streams
+func stopServer(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return server.Close()
+	}
+	return nil
+}
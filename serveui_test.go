@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeUI(t *testing.T) {
+	system, _ := NewTestSystem(t, nil, nil)
+	done := make(chan error, 1)
+	go func() {
+		done <- ServeUI(context.Background(), system, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "coverage: 87%")
+		}))
+	}()
+	system.Console.ExpectString("press Ctrl-C to stop")
+
+	urls := system.OpenedURLs()
+	if len(urls) != 1 || !strings.HasPrefix(urls[0], "http://127.0.0.1:") {
+		t.Fatalf("expected a local URL to be opened, received %v\n", urls)
+	}
+	resp, err := http.Get(urls[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "coverage: 87%" {
+		t.Errorf("expected the handler's page, received %q\n", body)
+	}
+
+	req, _ := http.NewRequest("GET", urls[0], nil)
+	req.Host = "attacker.example.com"
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected another host to be forbidden, received %v (%v)\n", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	system.Signal(os.Interrupt)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeUI did not stop when interrupted")
+	}
+}
+
+func TestServeUIIdle(t *testing.T) {
+	timeout := uiIdleTimeout
+	uiIdleTimeout = 20 * time.Millisecond
+	defer func() { uiIdleTimeout = timeout }()
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	err := ServeUI(context.Background(), system, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectMatch(t, *output.Stdout, `Open http://127\.0\.0\.1:\d+/ in your browser`)
+	ExpectMatch(t, *output.Stderr, `Stopping after 20ms without a request`)
+}
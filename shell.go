@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"path"
+	"strings"
+)
+
+// Shell identifies a command-line shell
+type Shell string
+
+// Shells recognized by System.Shell and ShellQuote
+const (
+	ShellSh         Shell = "sh"
+	ShellBash       Shell = "bash"
+	ShellZsh        Shell = "zsh"
+	ShellFish       Shell = "fish"
+	ShellPowerShell Shell = "powershell"
+	ShellCmd        Shell = "cmd"
+)
+
+// detectShell determines the user's shell from the environment. On Unix the
+// SHELL variable names the login shell. On Windows, PowerShell adds the
+// user's Documents folder to PSModulePath, which distinguishes it from cmd.
+func detectShell(getenv func(string) string) Shell {
+	if sh := getenv("SHELL"); len(sh) > 0 {
+		name := path.Base(strings.Replace(sh, "\\", "/", -1))
+		name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+		switch Shell(name) {
+		case ShellBash, ShellZsh, ShellFish, ShellCmd:
+			return Shell(name)
+		case "pwsh", ShellPowerShell:
+			return ShellPowerShell
+		}
+		return ShellSh
+	}
+
+	if len(getenv("ComSpec")) > 0 {
+		if strings.Contains(strings.ToLower(getenv("PSModulePath")), `\documents\`) {
+			return ShellPowerShell
+		}
+		return ShellCmd
+	}
+
+	return ShellSh
+}
+
+// ShellQuote joins args into a single command line, quoting each argument as
+// required for it to be pasted into the given shell
+func ShellQuote(args []string, shell Shell) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		switch shell {
+		case ShellFish:
+			quoted[i] = quoteFish(arg)
+		case ShellPowerShell:
+			quoted[i] = quotePowerShell(arg)
+		case ShellCmd:
+			quoted[i] = quoteCmd(arg)
+		default:
+			quoted[i] = quotePOSIX(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func isShellSafe(s string, extra string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_-./:+@", r):
+		case strings.ContainsRune(extra, r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// quotePOSIX quotes s for sh and its descendants. A leading `=` is quoted
+// because zsh expands `=name` to the path of the command name.
+func quotePOSIX(s string) string {
+	if isShellSafe(s, ",=%") && s[0] != '=' {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func quoteFish(s string) string {
+	if isShellSafe(s, ",=%") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+func quotePowerShell(s string) string {
+	if isShellSafe(s, `\=`) && s[0] != '-' && s[0] != '@' {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// cmdMetacharacters are the characters cmd interprets on a command line
+const cmdMetacharacters = `%!^&|<>()"`
+
+// quoteCmd quotes s following the conventions used by the Microsoft C runtime
+// to split a command line. If s contains any of cmd's metacharacters, every
+// metacharacter in the result, including the enclosing quotes, is escaped
+// with a caret so that cmd passes it through regardless of its quoting state.
+func quoteCmd(s string) string {
+	if isShellSafe(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, r := range s {
+		switch r {
+		case '\\':
+			slashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes*2+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+		}
+		slashes = 0
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes*2))
+	b.WriteByte('"')
+
+	if !strings.ContainsAny(s, cmdMetacharacters) {
+		return b.String()
+	}
+
+	var escaped strings.Builder
+	for _, r := range b.String() {
+		if strings.ContainsRune(cmdMetacharacters, r) {
+			escaped.WriteByte('^')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
@@ -0,0 +1,63 @@
+package cli
+
+import "testing"
+
+func TestShellQuote(t *testing.T) {
+	args := []string{"echo", "it's", "a b", "$HOME", ""}
+	tests := map[Shell]string{
+		ShellBash:       `echo 'it'\''s' 'a b' '$HOME' ''`,
+		ShellFish:       `echo 'it\'s' 'a b' '$HOME' ''`,
+		ShellPowerShell: `echo 'it''s' 'a b' '$HOME' ''`,
+		ShellCmd:        `echo "it's" "a b" "$HOME" ""`,
+	}
+
+	for shell, expected := range tests {
+		if quoted := ShellQuote(args, shell); quoted != expected {
+			t.Errorf("%s: expected %s, received %s\n", shell, expected, quoted)
+		}
+	}
+
+	for _, test := range []struct {
+		shell    Shell
+		arg      string
+		expected string
+	}{
+		{ShellBash, "a,b", `a,b`},
+		{ShellZsh, "=ls", `'=ls'`},
+		{ShellZsh, "key=value", `key=value`},
+		{ShellPowerShell, "a,b", `'a,b'`},
+		{ShellCmd, "a,b", `"a,b"`},
+		{ShellCmd, "%PATH%", `^"^%PATH^%^"`},
+		{ShellCmd, `a"b & c`, `^"a\^"b ^& c^"`},
+		{ShellCmd, "x|y^z", `^"x^|y^^z^"`},
+	} {
+		if quoted := ShellQuote([]string{test.arg}, test.shell); quoted != test.expected {
+			t.Errorf("%s: expected %s for %q, received %s\n",
+				test.shell, test.expected, test.arg, quoted)
+		}
+	}
+}
+
+func TestShell(t *testing.T) {
+	tests := []struct {
+		environment map[string]string
+		expected    Shell
+	}{
+		{map[string]string{"SHELL": "/usr/bin/zsh"}, ShellZsh},
+		{map[string]string{"SHELL": "/usr/local/bin/fish"}, ShellFish},
+		{map[string]string{"SHELL": "/bin/dash"}, ShellSh},
+		{map[string]string{"ComSpec": `C:\Windows\system32\cmd.exe`}, ShellCmd},
+		{map[string]string{
+			"ComSpec":      `C:\Windows\system32\cmd.exe`,
+			"PSModulePath": `C:\Users\me\Documents\WindowsPowerShell\Modules`,
+		}, ShellPowerShell},
+	}
+
+	for _, test := range tests {
+		system, _ := NewTestSystem(t, []string{"test"}, test.environment)
+		if shell := system.Shell(); shell != test.expected {
+			t.Errorf("expected %s for %v, received %s\n",
+				test.expected, test.environment, shell)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// signalNotifier tracks the channels returned by Notify
+type signalNotifier struct {
+	mu            sync.Mutex
+	subscriptions []signalSubscription
+}
+
+// signalSubscription is a channel and the signals it is notified of; all
+// signals if there are none
+type signalSubscription struct {
+	ch      chan os.Signal
+	signals []os.Signal
+}
+
+// Notify returns a channel which receives the given signals, or every signal
+// if none are given, as signal.Notify would, until ctx is done. The signals
+// are then restored to their previous behavior. As with signal.Notify, a
+// signal is dropped if the channel's buffer is full.
+func (s *BaseSystem) Notify(ctx context.Context, signals ...os.Signal) <-chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	s.subscribe(ctx, ch, signals)
+	signal.Notify(ch, signals...)
+	go func() {
+		<-ctx.Done()
+		signal.Stop(ch)
+	}()
+	return ch
+}
+
+// subscribe makes ch receive the given signals when they are delivered with
+// signalled, without catching those sent to the process, until ctx is done
+func (s *BaseSystem) subscribe(ctx context.Context, ch chan os.Signal, signals []os.Signal) {
+	n := &s.signals
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subscriptions = append(n.subscriptions, signalSubscription{ch, signals})
+
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		for i, sub := range n.subscriptions {
+			if sub.ch == ch {
+				n.subscriptions = append(n.subscriptions[:i], n.subscriptions[i+1:]...)
+				break
+			}
+		}
+	}()
+}
+
+// signalled delivers sig to each channel subscribed to it, reporting whether
+// there were any
+func (s *BaseSystem) signalled(sig os.Signal) bool {
+	n := &s.signals
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	delivered := false
+	for _, sub := range n.subscriptions {
+		if !notifies(sub.signals, sig) {
+			continue
+		}
+		delivered = true
+		select {
+		case sub.ch <- sig:
+		default:
+		}
+	}
+	return delivered
+}
+
+// notifies reports whether a subscription to signals receives sig
+func notifies(signals []os.Signal, sig os.Signal) bool {
+	if len(signals) == 0 {
+		return true
+	}
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
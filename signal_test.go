@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testReloadCommand struct {
+	listening chan struct{}
+	reloaded  chan struct{}
+}
+
+func (c *testReloadCommand) Help() {}
+
+func (c *testReloadCommand) Command(ctx context.Context, args []string, s System) error {
+	signals := s.Notify(ctx, syscall.SIGHUP, os.Interrupt)
+	close(c.listening)
+	for sig := range signals {
+		if sig == os.Interrupt {
+			return nil
+		}
+		s.Println("reloading")
+		c.reloaded <- struct{}{}
+	}
+	return nil
+}
+
+func TestSignal(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"testreload"}, nil, 5, 40)
+	if system.Signal(syscall.SIGHUP) {
+		t.Error("expected a signal to be dropped before the command listens for it")
+	}
+
+	cmd := &testReloadCommand{listening: make(chan struct{}), reloaded: make(chan struct{}, 1)}
+	done := make(chan int)
+	go func() {
+		done <- Main(context.Background(), cmd, system)
+	}()
+	<-cmd.listening
+
+	system.Signal(syscall.SIGHUP)
+	<-cmd.reloaded
+	ExpectMatch(t, *output.STDOUT, `reloading`)
+	if system.Signal(syscall.SIGTERM) {
+		t.Error("expected a signal the command isn't listening for to be dropped")
+	}
+	system.Signal(os.Interrupt)
+
+	if result := <-done; result != 0 {
+		t.Errorf("command did not return a 0 status\n%s", output.STDERR)
+	}
+}
+
+func TestMemorySystemSignal(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"testreload"}})
+	cmd := &testReloadCommand{listening: make(chan struct{}), reloaded: make(chan struct{}, 1)}
+	done := make(chan int)
+	go func() {
+		done <- Main(context.Background(), cmd, system)
+	}()
+	<-cmd.listening
+
+	if !system.Signal(os.Interrupt) {
+		t.Error("expected the command to be listening for interrupts")
+	}
+	if result := <-done; result != 0 {
+		t.Errorf("command did not return a 0 status\n%s", output.Stderr)
+	}
+}
+
+func TestNotifyStops(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	system.Notify(ctx, syscall.SIGHUP)
+	if !system.Signal(syscall.SIGHUP) {
+		t.Fatal("expected the signal to be delivered")
+	}
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for system.Signal(syscall.SIGHUP) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the subscription to end with its context")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
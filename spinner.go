@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Spinner shows that an operation of unknown length, such as a request to a
+// server, is under way
+type Spinner interface {
+	// Start begins showing the spinner; starting it again does nothing
+	Start()
+
+	// Stop stops the spinner and clears it from the terminal
+	Stop()
+
+	// UpdateLabel replaces the label shown beside the spinner
+	UpdateLabel(label string)
+}
+
+// spinnerRedraw is how often a spinner on a terminal is animated
+var spinnerRedraw = 100 * time.Millisecond
+
+// spinnerFrames are the frames a spinner is animated with, where unicode may
+// be used and where it may not
+var spinnerFrames = [2][]string{
+	{"|", "/", "-", "\\"},
+	{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+}
+
+// Spinner returns a Spinner labelled label, which is shown with diagnostics
+// once started: animated in place on a terminal, and otherwise as a line such
+// as `Connecting...` when started or relabelled, repeated with the time spent
+// every few seconds, so that logs of CI jobs show that the operation is alive.
+func (s *BaseSystem) Spinner(label string) Spinner {
+	frames := spinnerFrames[0]
+	if s.unicode() {
+		frames = spinnerFrames[1]
+	}
+	return &spinner{
+		s:        s,
+		label:    label,
+		terminal: isTerminal(s.stderr()),
+		frames:   frames,
+	}
+}
+
+type spinner struct {
+	s        *BaseSystem
+	terminal bool
+	frames   []string
+
+	mu      sync.Mutex
+	label   string
+	frame   int
+	start   time.Time
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (p *spinner) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.start = time.Now()
+	p.stop, p.stopped = make(chan struct{}), make(chan struct{})
+	p.draw()
+
+	interval := progressInterval
+	if p.terminal {
+		interval = spinnerRedraw
+	}
+	go p.run(interval, p.stop, p.stopped)
+}
+
+// run redraws the spinner every interval until stop is closed
+func (p *spinner) run(interval time.Duration, stop, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame = (p.frame + 1) % len(p.frames)
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+func (p *spinner) Stop() {
+	p.mu.Lock()
+	stop, stopped := p.stop, p.stopped
+	p.stop = nil
+	p.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-stopped
+	if p.terminal {
+		p.s.Eprint("\r\x1b[K")
+	}
+}
+
+func (p *spinner) UpdateLabel(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if label == p.label {
+		return
+	}
+	p.label = label
+	if p.stop != nil {
+		p.start = time.Now()
+		p.draw()
+	}
+}
+
+// draw shows the current frame on a terminal, or otherwise prints the label
+// and, once the operation has taken a while, the time spent on it
+func (p *spinner) draw() {
+	if p.terminal {
+		p.s.Eprint("\r\x1b[K" + p.frames[p.frame] + " " + p.label)
+		return
+	}
+	elapsed := time.Since(p.start).Round(time.Second)
+	if elapsed < time.Second {
+		p.s.Eprintln(p.label + "...")
+	} else {
+		p.s.Eprintln(fmt.Sprintf("%s... (%s)", p.label, elapsed))
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpinnerPlain(t *testing.T) {
+	interval := progressInterval
+	progressInterval = time.Hour
+	defer func() { progressInterval = interval }()
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	p := system.Spinner("Connecting")
+	p.UpdateLabel("Connecting to api.example.com")
+	p.Start()
+	p.Start()
+	p.UpdateLabel("Fetching widgets")
+	p.UpdateLabel("Fetching widgets")
+	p.Stop()
+	p.Stop()
+	p.UpdateLabel("Done")
+
+	expected := "Connecting to api.example.com...\nFetching widgets...\n"
+	if actual := output.Stderr.String(); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+}
+
+func TestSpinnerTerminal(t *testing.T) {
+	redraw := spinnerRedraw
+	spinnerRedraw = time.Millisecond
+	defer func() { spinnerRedraw = redraw }()
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	s, _ := baseOf(system)
+	p := &spinner{s: s, label: "Waiting", terminal: true, frames: spinnerFrames[0]}
+	p.Start()
+	time.Sleep(20 * time.Millisecond)
+	p.Stop()
+
+	drawn := output.Stderr.String()
+	if !strings.HasPrefix(drawn, "\r\x1b[K| Waiting\r\x1b[K/ Waiting") {
+		t.Errorf("expected the spinner to be animated, received %q\n", drawn)
+	}
+	if !strings.HasSuffix(drawn, "Waiting\r\x1b[K") {
+		t.Errorf("expected the spinner to be cleared, received %q\n", drawn)
+	}
+}
+
+func TestSpinnerTestSystem(t *testing.T) {
+	system, _ := NewTestSystem(t, nil, nil)
+	p := system.Spinner("Connecting")
+	p.Start()
+	p.UpdateLabel("Fetching")
+	p.Stop()
+
+	spinners := system.Spinners()
+	if len(spinners) != 1 {
+		t.Fatalf("expected one spinner, received %d\n", len(spinners))
+	}
+	s := spinners[0]
+	if !s.Started || !s.Stopped || len(s.Labels) != 2 || s.Labels[1] != "Fetching" {
+		t.Errorf("expected the spinner's use to be recorded, received %+v\n", s)
+	}
+}
@@ -0,0 +1,532 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// streamHeartbeat is how often a websocket is pinged. A connection which
+	// receives nothing for twice as long is considered lost.
+	streamHeartbeat = 30 * time.Second
+
+	// streamBackoff is the wait before reconnecting, which doubles with each
+	// failed attempt up to streamMaxBackoff
+	streamBackoff    = time.Second
+	streamMaxBackoff = 30 * time.Second
+)
+
+// streamMaxMessage is the largest websocket message accepted
+const streamMaxMessage = 16 << 20
+
+// websocketGUID is appended to the key of a websocket handshake to compute
+// the server's answer
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// errStreamEnded is returned when the server ends a stream rather than it
+// being lost
+var errStreamEnded = errors.New("stream ended")
+
+// errStreamIdle is returned when nothing was received within two heartbeats
+var errStreamIdle = errors.New("no heartbeat received")
+
+// StreamMessage is a message received by StreamConnect
+type StreamMessage struct {
+	// Event is the type of a server-sent event, `message` unless the server
+	// named another
+	Event string
+
+	// ID is the ID of the last server-sent event which had one
+	ID string
+
+	Data []byte
+
+	// Binary is set for binary websocket messages
+	Binary bool
+}
+
+// StreamHandler handles a message received by StreamConnect. Returning an
+// error ends the stream.
+type StreamHandler func(ctx context.Context, msg StreamMessage) error
+
+// StreamConnect connects to a stream of messages, calling handler with each,
+// for commands which attach to or tail a service. `ws` and `wss` URLs are
+// websockets; `http` and `https` URLs are streams of server-sent events.
+// Requests are made with the System's HTTPClient.
+//
+// A lost connection is reestablished, waiting a second and then twice as
+// long after each failed attempt, up to 30 seconds. Server-sent events
+// resume from the last event ID, and may change the wait with `retry`.
+// Websockets are pinged every 30 seconds, and a connection which receives
+// nothing for a minute is considered lost.
+//
+// StreamConnect returns nil when ctx is done, closing the connection
+// cleanly, or when the server ends the stream, by closing a websocket
+// normally or answering a request for events with 204 No Content. It
+// returns the handler's error, and an APIError if the server refuses the
+// connection with a status which retrying won't change.
+func StreamConnect(ctx context.Context, sys System, rawurl string, handler StreamHandler) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+
+	var lastID string
+	retry := streamBackoff
+	wait := retry
+	for {
+		conn, err := dialStream(ctx, sys, u, lastID)
+		received := false
+		if err == nil {
+			received, err = serveStream(ctx, conn, handler, &lastID)
+			conn.close()
+			if es, ok := conn.(*eventStream); ok && es.retry > 0 {
+				retry = es.retry
+			}
+		}
+
+		var fatal fatalStreamError
+		switch {
+		case ctx.Err() != nil, err == errStreamEnded:
+			return nil
+		case errors.As(err, &fatal):
+			return fatal.err
+		}
+
+		if received {
+			wait = retry
+		}
+		if conn == nil {
+			sys.Logf(tr(sys, "Unable to connect: %s; retrying in %s\n"), err, wait)
+		} else {
+			sys.Logf(tr(sys, "Connection lost: %s; reconnecting in %s\n"), err, wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > streamMaxBackoff {
+			wait = streamMaxBackoff
+		}
+	}
+}
+
+// fatalStreamError is an error which reconnecting won't resolve
+type fatalStreamError struct {
+	err error
+}
+
+func (e fatalStreamError) Error() string {
+	return e.err.Error()
+}
+
+// streamConn is a connection to a stream
+type streamConn interface {
+	// read returns the next message, calling active whenever anything is
+	// received
+	read(active func()) (StreamMessage, error)
+
+	// ping sends a heartbeat, if the protocol has one
+	ping() error
+
+	// close closes the connection, cleanly if it may be. It may be called
+	// more than once.
+	close()
+}
+
+// serveStream passes the messages of conn to handler until the connection
+// is lost, ctx is done or handler fails, reporting whether any message was
+// received
+func serveStream(ctx context.Context, conn streamConn, handler StreamHandler, lastID *string) (bool, error) {
+	last := time.Now().UnixNano()
+	active := func() { atomic.StoreInt64(&last, time.Now().UnixNano()) }
+	var idle int32
+	heartbeat := streamHeartbeat
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if time.Since(time.Unix(0, atomic.LoadInt64(&last))) > 2*heartbeat {
+					atomic.StoreInt32(&idle, 1)
+					conn.close()
+					return
+				}
+				conn.ping()
+			}
+		}
+	}()
+
+	received := false
+	for {
+		msg, err := conn.read(active)
+		if err != nil {
+			if atomic.LoadInt32(&idle) == 1 {
+				err = errStreamIdle
+			}
+			return received, err
+		}
+		received = true
+		if len(msg.ID) > 0 {
+			*lastID = msg.ID
+		}
+		if err := handler(ctx, msg); err != nil {
+			return received, fatalStreamError{err}
+		}
+	}
+}
+
+// dialStream connects to the stream at u, resuming after the event lastID
+func dialStream(ctx context.Context, sys System, u *url.URL, lastID string) (streamConn, error) {
+	target := *u
+	var key string
+	switch u.Scheme {
+	case "ws", "wss":
+		target.Scheme = "http" + strings.TrimPrefix(u.Scheme, "ws")
+		b := make([]byte, 16)
+		if _, err := io.ReadFull(sys.Rand(), b); err != nil {
+			return nil, err
+		}
+		key = base64.StdEncoding.EncodeToString(b)
+	case "http", "https":
+	default:
+		return nil, fatalStreamError{fmt.Errorf(tr(sys, "Unsupported stream URL: %s"), u)}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, fatalStreamError{err}
+	}
+	req = req.WithContext(ctx)
+	if len(key) > 0 {
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", key)
+	} else {
+		req.Header.Set("Accept", "text/event-stream")
+		req.Header.Set("Cache-Control", "no-cache")
+		if len(lastID) > 0 {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+	}
+
+	resp, err := sys.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	expected := http.StatusOK
+	if len(key) > 0 {
+		expected = http.StatusSwitchingProtocols
+	}
+	if resp.StatusCode != expected {
+		return nil, streamRefused(sys, resp)
+	}
+
+	if len(key) == 0 {
+		return &eventStream{body: resp.Body, r: bufio.NewReader(resp.Body), id: lastID}, nil
+	}
+	rw, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok || resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		resp.Body.Close()
+		return nil, fatalStreamError{errors.New(tr(sys, "The server did not accept the websocket handshake"))}
+	}
+	return &websocket{rw: rw, r: bufio.NewReader(rw), rand: sys.Rand()}, nil
+}
+
+// streamRefused returns the error for a response refusing a connection
+func streamRefused(sys System, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return errStreamEnded
+	}
+	err := NewAPIError(sys, resp)
+	if resp.StatusCode < 500 && err.ExitStatus() != ExitTemporaryFailure {
+		return fatalStreamError{err}
+	}
+	return err
+}
+
+// websocketAccept returns the answer to the key of a websocket handshake
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// websocket is a client's websocket connection, as described by RFC 6455
+type websocket struct {
+	rw   io.ReadWriteCloser
+	r    *bufio.Reader
+	rand io.Reader
+
+	// mu serializes frames written by the reader and the heartbeat
+	mu     sync.Mutex
+	closed bool
+}
+
+// Websocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// websocket close codes
+const (
+	closeNormal   = 1000
+	closeNoStatus = 1005
+)
+
+func (ws *websocket) read(active func()) (StreamMessage, error) {
+	var msg StreamMessage
+	started := false
+	for {
+		op, fin, payload, err := ws.readFrame()
+		if err != nil {
+			return msg, err
+		}
+		active()
+
+		switch op {
+		case opPing:
+			if err := ws.write(opPong, payload); err != nil {
+				return msg, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			code, reason := closeNoStatus, ""
+			if len(payload) >= 2 {
+				code, reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			ws.close()
+			if code == closeNormal {
+				return msg, errStreamEnded
+			}
+			return msg, fmt.Errorf("websocket closed with %d %s", code, reason)
+		case opText, opBinary:
+			if started {
+				return msg, errors.New("websocket message interrupted")
+			}
+			started = true
+			msg.Binary = op == opBinary
+			msg.Data = payload
+		case opContinuation:
+			if !started {
+				return msg, errors.New("unexpected websocket continuation")
+			}
+			if len(msg.Data)+len(payload) > streamMaxMessage {
+				return msg, errors.New("websocket message too large")
+			}
+			msg.Data = append(msg.Data, payload...)
+		default:
+			return msg, fmt.Errorf("unknown websocket opcode %d", op)
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// readFrame reads a frame, unmasking its payload
+func (ws *websocket) readFrame() (op byte, fin bool, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(ws.r, h[:]); err != nil {
+		return 0, false, nil, err
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(ws.r, b[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(ws.r, b[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if n > streamMaxMessage {
+		return 0, false, nil, errors.New("websocket message too large")
+	}
+
+	var mask [4]byte
+	masked := h[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+			return 0, false, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(ws.r, payload); err != nil {
+		return 0, false, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return op, fin, payload, nil
+}
+
+// write writes a frame, masked as frames sent by a client must be
+func (ws *websocket) write(op byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return io.ErrClosedPipe
+	}
+	return ws.writeFrame(op, payload)
+}
+
+func (ws *websocket) writeFrame(op byte, payload []byte) error {
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.rand, mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := ws.rw.Write(frame)
+	return err
+}
+
+func (ws *websocket) ping() error {
+	return ws.write(opPing, nil)
+}
+
+// close sends a normal close frame and closes the connection
+func (ws *websocket) close() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return
+	}
+	ws.closed = true
+
+	var code [2]byte
+	binary.BigEndian.PutUint16(code[:], closeNormal)
+	ws.writeFrame(opClose, code[:])
+	ws.rw.Close()
+}
+
+// eventStream is a stream of server-sent events, as described by the HTML
+// standard
+type eventStream struct {
+	body io.ReadCloser
+	r    *bufio.Reader
+	once sync.Once
+
+	// id is the last event ID, and retry the reconnection time the server
+	// asked for
+	id    string
+	retry time.Duration
+}
+
+func (es *eventStream) read(active func()) (StreamMessage, error) {
+	var event string
+	var data []byte
+	for {
+		line, err := es.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return StreamMessage{}, err
+		}
+		active()
+
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			if data == nil {
+				event = ""
+				continue
+			}
+			if len(event) == 0 {
+				event = "message"
+			}
+			return StreamMessage{Event: event, ID: es.id, Data: data}, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data != nil {
+				data = append(data, '\n')
+			}
+			if data == nil {
+				data = []byte{}
+			}
+			data = append(data, value...)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				es.id = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				es.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+func (es *eventStream) ping() error {
+	return nil
+}
+
+func (es *eventStream) close() {
+	es.once.Do(func() { es.body.Close() })
+}
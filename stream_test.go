@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// setStreamTimings shortens the heartbeat and backoff of streams for a test
+func setStreamTimings(t *testing.T, heartbeat, backoff time.Duration) {
+	h, b, m := streamHeartbeat, streamBackoff, streamMaxBackoff
+	streamHeartbeat, streamBackoff, streamMaxBackoff = heartbeat, backoff, 4*backoff
+	t.Cleanup(func() {
+		streamHeartbeat, streamBackoff, streamMaxBackoff = h, b, m
+	})
+}
+
+func TestStreamEvents(t *testing.T) {
+	setStreamTimings(t, time.Minute, 10*time.Millisecond)
+
+	var connections int32
+	var resumed string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": connected\n\nid: 1\nevent: log\ndata: first\ndata: line\n\n")
+		case 2:
+			resumed = r.Header.Get("Last-Event-ID")
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: second\r\n\r\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	var received []string
+	err := StreamConnect(context.Background(), system, server.URL, func(ctx context.Context, msg StreamMessage) error {
+		received = append(received, fmt.Sprintf("%s %s %q", msg.Event, msg.ID, msg.Data))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`log 1 "first\nline"`, `message 1 "second"`}
+	if strings.Join(received, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected messages:\n%s\nreceived:\n%s\n", strings.Join(expected, "\n"), strings.Join(received, "\n"))
+	}
+	if resumed != "1" {
+		t.Errorf("expected to resume after event 1, received %q\n", resumed)
+	}
+	ExpectMatch(t, *output.Stderr, `Connection lost: unexpected EOF; reconnecting in 10ms`)
+}
+
+// writeServerFrame writes an unmasked websocket frame, as a server does
+func writeServerFrame(w *bufio.ReadWriter, op byte, fin bool, payload []byte) {
+	b := op
+	if fin {
+		b |= 0x80
+	}
+	w.Write([]byte{b, byte(len(payload))})
+	w.Write(payload)
+	w.Flush()
+}
+
+func TestStreamWebsocket(t *testing.T) {
+	setStreamTimings(t, time.Minute, 10*time.Millisecond)
+
+	pong := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Accept: %s\r\n\r\n", websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+
+		client := &websocket{r: rw.Reader}
+		writeServerFrame(rw, opPing, true, []byte("beat"))
+		if op, _, payload, err := client.readFrame(); err != nil || op != opPong {
+			t.Errorf("expected a pong, received opcode %d (%v)\n", op, err)
+		} else {
+			pong <- string(payload)
+		}
+
+		writeServerFrame(rw, opText, false, []byte("hello, "))
+		writeServerFrame(rw, opContinuation, true, []byte("world"))
+		writeServerFrame(rw, opBinary, true, []byte{0, 1})
+		code := make([]byte, 2)
+		binary.BigEndian.PutUint16(code, closeNormal)
+		writeServerFrame(rw, opClose, true, code)
+
+		if op, _, _, err := client.readFrame(); err != nil || op != opClose {
+			t.Errorf("expected the close to be answered, received opcode %d (%v)\n", op, err)
+		}
+	}))
+	defer server.Close()
+
+	system, _ := NewMemorySystem(MemorySystemOptions{})
+	var received []string
+	err := StreamConnect(context.Background(), system, "ws"+strings.TrimPrefix(server.URL, "http"),
+		func(ctx context.Context, msg StreamMessage) error {
+			received = append(received, fmt.Sprintf("%t %q", msg.Binary, msg.Data))
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := <-pong; p != "beat" {
+		t.Errorf("expected the ping's payload to be returned, received %q\n", p)
+	}
+	expected := []string{`false "hello, world"`, `true "\x00\x01"`}
+	if strings.Join(received, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected messages:\n%s\nreceived:\n%s\n", strings.Join(expected, "\n"), strings.Join(received, "\n"))
+	}
+}
+
+func TestStreamShutdown(t *testing.T) {
+	setStreamTimings(t, 20*time.Millisecond, 10*time.Millisecond)
+
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %d\n\n", atomic.AddInt32(&connections, 1))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	system, output := NewMemorySystem(MemorySystemOptions{})
+	done := make(chan error, 1)
+	go func() {
+		done <- StreamConnect(ctx, system, server.URL, func(ctx context.Context, msg StreamMessage) error {
+			// the first connection is idle after its event, so is lost
+			if string(msg.Data) == "2" {
+				cancel()
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil once cancelled, received %s\n", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamConnect did not return once cancelled")
+	}
+	ExpectMatch(t, *output.Stderr, `Connection lost: no heartbeat received`)
+}
+
+func TestStreamRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such stream", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	system, _ := NewMemorySystem(MemorySystemOptions{})
+	err := StreamConnect(context.Background(), system, server.URL, func(ctx context.Context, msg StreamMessage) error {
+		return nil
+	})
+	if e, ok := err.(*APIError); !ok || e.StatusCode != http.StatusNotFound || e.Message != "no such stream" {
+		t.Errorf("expected a 404 APIError, received %v\n", err)
+	}
+
+	stop := errors.New("stop")
+	err = StreamConnect(context.Background(), system, "ftp://example.com", func(ctx context.Context, msg StreamMessage) error {
+		return stop
+	})
+	if err == nil || !strings.Contains(err.Error(), "Unsupported stream URL") {
+		t.Errorf("expected an unsupported URL, received %v\n", err)
+	}
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"reflect"
+)
+
+// streams records whether a System's output and diagnostics share a
+// destination, as they do when a program is run with `2>&1` or by a CI
+// system. Writes through the System are always made one at a time, so that
+// progress printed by one goroutine isn't shredded by messages logged by
+// another; when the destination is shared, writes to the streams returned by
+// Stdout and Stderr are too.
+type streams struct {
+	// out and err are the streams the destination was last detected for
+	out, err io.Writer
+	shared   bool
+}
+
+// flusher is a buffered stream
+type flusher interface {
+	Flush() error
+}
+
+// coordinate prepares to write to w, which is Out or the diagnostics stream,
+// returning a function which must be called once it has been written. Output
+// buffered for the other stream is flushed first, as C's stdio flushes
+// standard output before writing to standard error, so that everything
+// appears in the order it was written.
+func (s *BaseSystem) coordinate(w io.Writer) (done func()) {
+	s.writes.Lock()
+	for _, other := range []io.Writer{s.Out, s.stderr()} {
+		if f, ok := other.(flusher); ok && !identical(other, w) {
+			f.Flush()
+		}
+	}
+	return s.writes.Unlock
+}
+
+// sharesOutput reports whether Out and the diagnostics stream write to the
+// same destination, detecting it only when either has changed
+func (s *BaseSystem) sharesOutput() bool {
+	s.writes.Lock()
+	defer s.writes.Unlock()
+
+	out, err := s.Out, s.stderr()
+	if !identical(out, s.streams.out) || !identical(err, s.streams.err) {
+		s.streams = streams{out: out, err: err, shared: sameDestination(out, err)}
+	}
+	return s.streams.shared
+}
+
+// coordinatedWriter is a stream of a System whose writes are coordinated
+// with those made through the System
+type coordinatedWriter struct {
+	s *BaseSystem
+	w io.Writer
+}
+
+func (w *coordinatedWriter) Write(p []byte) (int, error) {
+	defer w.s.coordinate(w.w)()
+	return w.w.Write(p)
+}
+
+// coordinated returns w, wrapped so that writes to it are coordinated if it
+// shares a destination with the System's other stream. Files are returned
+// as they are, since each write to a file is already made whole.
+func (s *BaseSystem) coordinated(w io.Writer) io.Writer {
+	if _, ok := w.(*os.File); ok || w == nil || !s.sharesOutput() {
+		return w
+	}
+	return &coordinatedWriter{s, w}
+}
+
+// sameDestination reports whether a and b write to the same place: they are
+// the same writer, or files which refer to the same file, pipe or terminal
+func sameDestination(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if identical(a, b) {
+		return true
+	}
+
+	fa, ok := a.(*os.File)
+	fb, ok2 := b.(*os.File)
+	if !ok || !ok2 {
+		return false
+	}
+	ia, err := fa.Stat()
+	if err != nil {
+		return false
+	}
+	ib, err := fb.Stat()
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
+
+// identical reports whether a and b are the same writer, without panicking
+// for writers which can't be compared
+func identical(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSharedOutputIsCoordinated(t *testing.T) {
+	var b bytes.Buffer
+	s := &BaseSystem{Out: &b, Err: &b, Logger: log.New(&b, "", 0)}
+	if !s.sharesOutput() {
+		t.Fatal("expected output and diagnostics to share a destination")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				switch i % 4 {
+				case 0:
+					s.Printf("progress %d\n", j)
+				case 1:
+					s.Logf("logged %d\n", j)
+				case 2:
+					fmt.Fprintf(s.Stdout(), "streamed %d\n", j)
+				case 3:
+					fmt.Fprintf(s.Stderr(), "diagnosed %d\n", j)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 200 {
+		t.Fatalf("expected 200 lines, received %d", len(lines))
+	}
+	for _, line := range lines {
+		var word string
+		var n int
+		if _, err := fmt.Sscanf(line, "%s %d", &word, &n); err != nil {
+			t.Errorf("shredded line %q", line)
+		}
+	}
+}
+
+func TestBufferedOutputFlushedBeforeDiagnostics(t *testing.T) {
+	var b bytes.Buffer
+	out := bufio.NewWriter(&b)
+	s := &BaseSystem{Out: out, Err: &b, Logger: log.New(&b, "", 0)}
+
+	s.Println("first")
+	s.Log("second")
+	s.Println("third")
+	s.Eprintln("fourth")
+	out.Flush()
+
+	if expected := "first\nsecond\nthird\nfourth\n"; b.String() != expected {
+		t.Errorf("expected %q, received %q", expected, b.String())
+	}
+}
+
+func TestSameDestination(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a device file")
+	}
+
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if !sameDestination(f, g) {
+		t.Errorf("expected two files opened from %s to share a destination", os.DevNull)
+	}
+	if sameDestination(&bytes.Buffer{}, &bytes.Buffer{}) {
+		t.Error("expected distinct buffers not to share a destination")
+	}
+}
@@ -1,15 +1,23 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 
-	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/term"
 )
 
 // System is passed to commands as an argument when the command is run. It
@@ -18,30 +26,296 @@ import (
 type System interface {
 	Environ() []string
 	Getenv(string) string
+
+	// LookupEnv, Setenv and Unsetenv read and change the System's
+	// environment, which a System may keep apart from the process's so that
+	// commands which change it may be tested
+	LookupEnv(string) (string, bool)
+	Setenv(key, value string) error
+	Unsetenv(string) error
 	Args() []string
+	Shell() Shell
+
+	// Getwd and Chdir get and change the working directory which relative
+	// paths are resolved against. A System may have a working directory of
+	// its own rather than sharing the process's, so commands should resolve
+	// paths with Abs rather than the filepath package.
+	Getwd() (string, error)
+	Chdir(string) error
+
+	// Abs returns a path made absolute by resolving it against the working
+	// directory, and Rel a path relative to it, for display
+	Abs(string) (string, error)
+	Rel(string) (string, error)
+
+	ReadFile(string) ([]byte, error)
+	WriteFile(string, []byte, os.FileMode) error
+	MkdirAll(string, os.FileMode) error
+	ReadDir(string) ([]os.FileInfo, error)
+	Remove(string) error
+
+	// TempDir returns the directory temporary files are created in, and
+	// CreateTemp creates a new file within it, named by pattern as
+	// ioutil.TempFile names files, which the caller must remove
+	TempDir() string
+	CreateTemp(pattern string) (*os.File, error)
+
+	// ConfigDir, CacheDir and DataDir return the directories app keeps its
+	// configuration, cached data and other data in, following the XDG base
+	// directory specification or the platform's conventions. They are
+	// resolved through the System's environment so that tests may redirect
+	// them.
+	ConfigDir(app string) (string, error)
+	CacheDir(app string) (string, error)
+	DataDir(app string) (string, error)
 
 	Print(...interface{}) (int, error)
 	Printf(string, ...interface{}) (int, error)
 	Println(...interface{}) (int, error)
 
+	// Eprint, Eprintf and Eprintln write diagnostics to STDERR, unlike Log
+	// without a timestamp, so that they may be separated from output
+	Eprint(...interface{}) (int, error)
+	Eprintf(string, ...interface{}) (int, error)
+	Eprintln(...interface{}) (int, error)
+
 	Scan(...interface{}) (int, error)
 	Scanf(string, ...interface{}) (int, error)
 
+	// Stdin, Stdout and Stderr return the underlying streams, for commands
+	// which copy or encode large amounts of data, or attach them to a
+	// subprocess. When a stream is a file, such as a terminal, the file
+	// itself is returned.
+	Stdin() io.Reader
+	Stdout() io.Writer
+	Stderr() io.Writer
+
 	Log(...interface{})
 	Logf(string, ...interface{})
 
 	ReadPassword() (string, error)
+
+	// ReadLine prints prompt and reads a line of input, which the user may
+	// edit if input is a terminal
+	ReadLine(prompt string) (string, error)
+
+	// ReadLineNoEcho reads a line of input without echoing it, for values
+	// such as one-time codes, and SetEcho turns echoing on or off
+	ReadLineNoEcho() (string, error)
+	SetEcho(on bool) error
+
+	// AssumesYes reports whether confirmation prompts should be answered
+	// affirmatively without reading input
+	AssumesYes() bool
+
+	// Interactive reports whether input is attached to a terminal
+	Interactive() bool
+
+	// TerminalWidth returns the number of columns output should be wrapped
+	// to
+	TerminalWidth() int
+
+	// TermSize returns the size of the terminal output is attached to, in
+	// columns and rows, or ErrNotTerminal if it isn't attached to one
+	TermSize() (width, height int, err error)
+
+	// NotifyResize returns a channel which receives a value each time the
+	// terminal is resized, so that tables and progress bars may be redrawn to
+	// fit. The channel is closed once ctx is done.
+	NotifyResize(ctx context.Context) <-chan struct{}
+
+	// Notify returns a channel which receives the given signals, such as
+	// SIGHUP to reload configuration, until ctx is done, so that commands
+	// handle signals without importing os/signal and may be tested by sending
+	// them to a TestSystem
+	Notify(ctx context.Context, signals ...os.Signal) <-chan os.Signal
+
+	// Color and Colorf return text in a color or style, which is left out
+	// when output isn't a terminal, NO_COLOR is set or `--no-color` is
+	// given, so that output piped elsewhere stays plain
+	Color(c Color, a ...interface{}) string
+	Colorf(c Color, format string, a ...interface{}) string
+
+	// RenderMarkdown renders markdown, such as a changelog, for display in
+	// the terminal output is attached to
+	RenderMarkdown(src string) string
+
+	ExternalDiff(old, new []byte) error
+
+	// Edit opens content in the user's editor and returns it once edited,
+	// for commands which edit resources as `kubectl edit` does
+	Edit(initial []byte, pattern string) ([]byte, error)
+
+	// OpenBrowser opens a web page in the user's browser, such as the page
+	// of an OAuth device flow or a dashboard
+	OpenBrowser(url string) error
+
+	// ReadClipboard and WriteClipboard read and write the text on the
+	// user's clipboard, for commands which copy a token or a link
+	ReadClipboard() (string, error)
+	WriteClipboard(text string) error
+
+	// QRCode prints data, such as a pairing URL, as a QR code to be scanned
+	// from the terminal, or as text where a code can't be shown
+	QRCode(data string) error
+
+	// Progress returns a ProgressBar reporting the progress of a long
+	// operation towards total
+	Progress(total int64, label string) ProgressBar
+
+	// Spinner returns a Spinner showing that an operation of unknown length
+	// is under way
+	Spinner(label string) Spinner
+
+	// Exec runs a program and waits for it to exit, so that commands which
+	// shell out to tools such as git may be tested with fakes of them
+	Exec(ctx context.Context, name string, args []string, opts ...ExecOption) (Result, error)
+
+	// HTTPClient returns the client commands make HTTP requests with, so
+	// that requests may be traced with `--curl` and sent to a test server
+	HTTPClient() *http.Client
+
+	// Username, HomeDir and Hostname describe the user running the command
+	// and their host, and UID and GID their numeric IDs, -1 on Windows, for
+	// use in default paths and settings
+	Username() (string, error)
+	HomeDir() (string, error)
+	Hostname() (string, error)
+	UID() int
+	GID() int
+
+	// Rand returns the source commands should read random bytes from, for
+	// tokens, nonces and IDs, and RandomID returns a random hexadecimal ID.
+	// A TestSystem's source is seeded, so that they are deterministic.
+	Rand() io.Reader
+	RandomID() string
+
+	// Exit ends the command with a status, as os.Exit would, but returns it
+	// through Main so that deferred functions and rollbacks run and tests
+	// may assert on it. It must be called from the goroutine running the
+	// command.
+	Exit(status int)
 }
 
 type BaseSystem struct {
 	In          io.Reader
 	Out         io.Writer
+	Err         io.Writer
 	Logger      *log.Logger
 	Environment map[string]string
 	Arguments   []string
+
+	// Dir, if set, is the System's working directory, which unlike the
+	// process's is changed by Chdir without affecting other Systems
+	Dir string
+
+	// User, if set, is reported as the user running the command and their
+	// host, rather than the process's
+	User *UserInfo
+
+	// AssumeYes answers confirmation prompts affirmatively without reading
+	// input
+	AssumeYes bool
+
+	// SortLocale overrides the locale used to sort output for humans
+	SortLocale string
+
+	// StrictInput makes prompts accept only ASCII answers, ignoring the
+	// locale
+	StrictInput bool
+
+	// MaskPasswords makes ReadPassword echo an asterisk for each character
+	// typed
+	MaskPasswords bool
+
+	// KeepHistory makes ReadLine remember the lines read, so that the user
+	// may recall them with the up and down arrows
+	KeepHistory bool
+
+	// Conflicts decides what Render does with files which already exist,
+	// rather than asking
+	Conflicts Conflict
+
+	// Transport, if set, carries the requests made with HTTPClient rather
+	// than http.DefaultTransport
+	Transport http.RoundTripper
+
+	// EchoCurl makes HTTPClient print each request as a curl command
+	EchoCurl bool
+
+	// DebugHTTP makes HTTPClient log each request and its response
+	DebugHTTP bool
+
+	// NoColor turns off the colors and styles of Color and RenderMarkdown
+	NoColor bool
+
+	// ConfigDirOverride, StateDirOverride, CacheDirOverride and
+	// DataDirOverride, if set, replace the directories derived from the
+	// environment for the configuration, state, cache and data of the
+	// application named DirOverrideApp, or of every application if it is
+	// empty
+	ConfigDirOverride string
+	StateDirOverride  string
+	CacheDirOverride  string
+	DataDirOverride   string
+	DirOverrideApp    string
+
+	// Random, if set, is read by Rand rather than crypto/rand.Reader
+	Random io.Reader
+
+	// Width, if set, pins the width returned by TerminalWidth and TermSize,
+	// and Height the height returned by TermSize
+	Width  int
+	Height int
+
+	// size guards Width and Height, which a TestSystem may change while a
+	// command is running
+	size   sync.Mutex
+	resize resizeNotifier
+
+	// env guards Environment, which Setenv may change while programs are
+	// being started
+	env sync.RWMutex
+
+	// random guards Random, which may not be safe to read concurrently
+	random sync.Mutex
+
+	// signals are the subscriptions made with Notify
+	signals signalNotifier
+
+	// pending is a read of In abandoned when its context was done
+	pending pendingRead
+
+	// lineEditor edits the lines read by ReadLine, kept between calls when
+	// KeepHistory is set
+	lineEditor *term.Terminal
+
+	// writes serializes writes to Out, Err and the Logger
+	writes  sync.Mutex
+	streams streams
+}
+
+// defaultTerminalWidth is assumed when output isn't attached to a terminal
+const defaultTerminalWidth = 80
+
+// base allows the library to reach the BaseSystem embedded in a System
+func (s *BaseSystem) base() *BaseSystem {
+	return s
+}
+
+// baseOf returns the BaseSystem embedded in sys, if there is one
+func baseOf(sys System) (*BaseSystem, bool) {
+	b, ok := sys.(interface{ base() *BaseSystem })
+	if !ok {
+		return nil, false
+	}
+	return b.base(), true
 }
 
 func (s *BaseSystem) Environ() []string {
+	s.env.RLock()
+	defer s.env.RUnlock()
+
 	environ := make([]string, len(s.Environment))
 
 	i := 0
@@ -56,28 +330,235 @@ func (s *BaseSystem) Environ() []string {
 }
 
 func (s *BaseSystem) Getenv(k string) string {
-	if v, ok := s.Environment[k]; ok {
-		return v
+	v, _ := s.LookupEnv(k)
+	return v
+}
+
+// LookupEnv returns the value of a variable and whether it is set, to
+// distinguish a variable which is set but empty from one which is unset
+func (s *BaseSystem) LookupEnv(k string) (string, bool) {
+	s.env.RLock()
+	defer s.env.RUnlock()
+	v, ok := s.Environment[s.envName(k)]
+	return v, ok
+}
+
+// envName returns the name under which k is kept in the environment. Names
+// are case-insensitive on Windows, so `Path` is found when asking for PATH.
+func (s *BaseSystem) envName(k string) string {
+	if runtime.GOOS != "windows" {
+		return k
+	}
+	if _, ok := s.Environment[k]; ok {
+		return k
+	}
+	for name := range s.Environment {
+		if strings.EqualFold(name, k) {
+			return name
+		}
+	}
+	return k
+}
+
+// Setenv sets a variable in the System's environment, which is inherited by
+// programs run with Exec
+func (s *BaseSystem) Setenv(k, v string) error {
+	if len(k) == 0 || strings.ContainsAny(k, "=\x00") {
+		return fmt.Errorf("Invalid environment variable name: %q", k)
+	}
+	s.env.Lock()
+	defer s.env.Unlock()
+	if s.Environment == nil {
+		s.Environment = map[string]string{}
 	}
-	return ""
+	s.Environment[s.envName(k)] = v
+	return nil
+}
+
+// Unsetenv removes a variable from the System's environment
+func (s *BaseSystem) Unsetenv(k string) error {
+	s.env.Lock()
+	defer s.env.Unlock()
+	delete(s.Environment, s.envName(k))
+	return nil
 }
 
 func (s *BaseSystem) Args() []string {
 	return s.Arguments
 }
 
+// programName returns the name a program was run as given the first of its
+// arguments, without its directory or, on Windows, its `.exe` extension
+func programName(arg0 string) string {
+	name := filepath.Base(arg0)
+	if ext := filepath.Ext(name); runtime.GOOS == "windows" && strings.EqualFold(ext, ".exe") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+func (s *BaseSystem) Shell() Shell {
+	return detectShell(s.Getenv)
+}
+
+func (s *BaseSystem) Getwd() (string, error) {
+	if len(s.Dir) > 0 {
+		return s.Dir, nil
+	}
+	return os.Getwd()
+}
+
+// Chdir changes the working directory, which is the process's unless Dir is
+// set
+func (s *BaseSystem) Chdir(dir string) error {
+	if len(s.Dir) == 0 {
+		return os.Chdir(dir)
+	}
+
+	dir = s.resolve(dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &os.PathError{Op: "chdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+	s.Dir = filepath.Clean(dir)
+	return nil
+}
+
+func (s *BaseSystem) Abs(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	wd, err := s.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wd, path), nil
+}
+
+func (s *BaseSystem) Rel(path string) (string, error) {
+	wd, err := s.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if path, err = s.Abs(path); err != nil {
+		return "", err
+	}
+	return filepath.Rel(wd, path)
+}
+
+// resolve returns name resolved against Dir, if it is set and name is
+// relative
+func (s *BaseSystem) resolve(name string) string {
+	if len(s.Dir) == 0 || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(s.Dir, name)
+}
+
+func (s *BaseSystem) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(s.resolve(name))
+}
+
+// WriteFile writes data to the named file, replacing it atomically so that
+// concurrent readers never observe a partially written file
+func (s *BaseSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	name = s.resolve(name)
+	f, err := ioutil.TempFile(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (s *BaseSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(s.resolve(path), perm)
+}
+
+func (s *BaseSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(s.resolve(name))
+}
+
+func (s *BaseSystem) Remove(name string) error {
+	return os.Remove(s.resolve(name))
+}
+
+// TempDir returns the directory named by TMPDIR, TEMP or TMP in the System's
+// environment, or else the process's temporary directory
+func (s *BaseSystem) TempDir() string {
+	return tempRoot(s)
+}
+
+func (s *BaseSystem) CreateTemp(pattern string) (*os.File, error) {
+	return ioutil.TempFile(s.TempDir(), pattern)
+}
+
 func (s *BaseSystem) Print(a ...interface{}) (int, error) {
+	defer s.coordinate(s.Out)()
 	return fmt.Fprint(s.Out, a...)
 }
 
 func (s *BaseSystem) Printf(format string, a ...interface{}) (int, error) {
+	defer s.coordinate(s.Out)()
 	return fmt.Fprintf(s.Out, format, a...)
 }
 
 func (s *BaseSystem) Println(a ...interface{}) (int, error) {
+	defer s.coordinate(s.Out)()
 	return fmt.Fprintln(s.Out, a...)
 }
 
+// stderr returns Err, or the Logger's writer if Err isn't set
+func (s *BaseSystem) stderr() io.Writer {
+	if s.Err != nil {
+		return s.Err
+	}
+	return s.Logger.Writer()
+}
+
+func (s *BaseSystem) Stdin() io.Reader {
+	return s.In
+}
+
+func (s *BaseSystem) Stdout() io.Writer {
+	return s.coordinated(s.Out)
+}
+
+func (s *BaseSystem) Stderr() io.Writer {
+	return s.coordinated(s.stderr())
+}
+
+func (s *BaseSystem) Eprint(a ...interface{}) (int, error) {
+	defer s.coordinate(s.stderr())()
+	return fmt.Fprint(s.stderr(), a...)
+}
+
+func (s *BaseSystem) Eprintf(format string, a ...interface{}) (int, error) {
+	defer s.coordinate(s.stderr())()
+	return fmt.Fprintf(s.stderr(), format, a...)
+}
+
+func (s *BaseSystem) Eprintln(a ...interface{}) (int, error) {
+	defer s.coordinate(s.stderr())()
+	return fmt.Fprintln(s.stderr(), a...)
+}
+
 func (s *BaseSystem) Scan(a ...interface{}) (int, error) {
 	return fmt.Fscan(s.In, a...)
 }
@@ -91,13 +572,143 @@ func (s *BaseSystem) Scanln(a ...interface{}) (int, error) {
 }
 
 func (s *BaseSystem) Log(a ...interface{}) {
+	defer s.coordinate(s.Logger.Writer())()
 	s.Logger.Println(a...)
 }
 
 func (s *BaseSystem) Logf(format string, a ...interface{}) {
+	defer s.coordinate(s.Logger.Writer())()
 	s.Logger.Printf(format, a...)
 }
 
+func (s *BaseSystem) AssumesYes() bool {
+	return s.AssumeYes
+}
+
+func (s *BaseSystem) Interactive() bool {
+	return isTerminal(s.In)
+}
+
+// TerminalWidth returns Width if it is set, then the width of the terminal
+// output is attached to, then the value of COLUMNS, and otherwise 80
+func (s *BaseSystem) TerminalWidth() int {
+	if width, _ := s.pinnedSize(); width > 0 {
+		return width
+	}
+	if f, ok := s.Out.(interface{ Fd() uintptr }); ok && isTerminal(s.Out) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width
+		}
+	}
+	if width, err := strconv.Atoi(s.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return defaultTerminalWidth
+}
+
+// ErrNotTerminal is returned by TermSize when output isn't attached to a
+// terminal
+var ErrNotTerminal = errors.New("output is not a terminal")
+
+// TermSize returns Width and Height if they are set, and otherwise the size
+// of the terminal output is attached to
+func (s *BaseSystem) TermSize() (int, int, error) {
+	width, height := s.pinnedSize()
+	if width > 0 && height > 0 {
+		return width, height, nil
+	}
+
+	f, ok := s.Out.(interface{ Fd() uintptr })
+	if !ok || !isTerminal(s.Out) {
+		return 0, 0, ErrNotTerminal
+	}
+	w, h, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0, 0, err
+	}
+	if width > 0 {
+		w = width
+	}
+	if height > 0 {
+		h = height
+	}
+	return w, h, nil
+}
+
+// pinnedSize returns Width and Height
+func (s *BaseSystem) pinnedSize() (width, height int) {
+	s.size.Lock()
+	defer s.size.Unlock()
+	return s.Width, s.Height
+}
+
+// NotifyResize returns a channel which receives a value each time the
+// terminal is resized. Notifications are coalesced, so a listener which is
+// slow to receive one isn't sent another until it has.
+func (s *BaseSystem) NotifyResize(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	r := &s.resize
+	r.mu.Lock()
+	if r.listeners == nil {
+		r.listeners = map[chan struct{}]bool{}
+	}
+	if len(r.listeners) == 0 && isTerminal(s.Out) {
+		r.stop = watchResize(s, s.resized)
+	}
+	r.listeners[ch] = true
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, ch)
+		close(ch)
+		if len(r.listeners) == 0 && r.stop != nil {
+			r.stop()
+			r.stop = nil
+		}
+	}()
+	return ch
+}
+
+// resizeNotifier tracks the listeners of NotifyResize
+type resizeNotifier struct {
+	mu        sync.Mutex
+	listeners map[chan struct{}]bool
+
+	// stop stops watching the terminal for changes in size
+	stop func()
+}
+
+// resized notifies each listener of NotifyResize
+func (s *BaseSystem) resized() {
+	s.resize.mu.Lock()
+	defer s.resize.mu.Unlock()
+	for ch := range s.resize.listeners {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// exitRequest is the value System.Exit panics with, which Main recovers
+type exitRequest struct {
+	status int
+}
+
+func (s *BaseSystem) Exit(status int) {
+	panic(exitRequest{status})
+}
+
+// isTerminal reports whether r is attached to a terminal
+func isTerminal(r interface{}) bool {
+	f, ok := r.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
 type UnixSystem struct {
 	*BaseSystem
 }
@@ -106,7 +717,7 @@ func NewUnixSystem() *UnixSystem {
 	env := os.Environ()
 	environment := make(map[string]string, len(env))
 	for _, e := range env {
-		split := strings.Split(e, "=")
+		split := strings.SplitN(e, "=", 2)
 		environment[split[0]] = split[1]
 	}
 
@@ -115,16 +726,40 @@ func NewUnixSystem() *UnixSystem {
 	return &UnixSystem{&BaseSystem{
 		In:          os.Stdin,
 		Out:         os.Stdout,
+		Err:         os.Stderr,
 		Logger:      log.New(os.Stderr, "", log.LstdFlags),
 		Environment: environment,
 		Arguments:   arguments,
 	}}
 }
 
+// Setenv sets a variable in the environment of both the System and the
+// process
+func (s *UnixSystem) Setenv(k, v string) error {
+	if err := s.BaseSystem.Setenv(k, v); err != nil {
+		return err
+	}
+	return os.Setenv(k, v)
+}
+
+// Unsetenv removes a variable from the environment of both the System and
+// the process
+func (s *UnixSystem) Unsetenv(k string) error {
+	if err := s.BaseSystem.Unsetenv(k); err != nil {
+		return err
+	}
+	return os.Unsetenv(k)
+}
+
+// ReadPassword reads a line from the terminal without echoing it, or
+// echoing asterisks if MaskPasswords is set
 func (s *UnixSystem) ReadPassword() (string, error) {
-	cloaked, err := terminal.ReadPassword(int(syscall.Stdin))
+	if s.MaskPasswords {
+		return readMaskedTerminal(int(syscall.Stdin), os.Stdin, s.Out)
+	}
+	cloaked, err := term.ReadPassword(int(syscall.Stdin))
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	return string(cloaked), nil
 }
@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+// NewSystem returns a System for the current process suited to the
+// platform: a WindowsSystem on Windows and a UnixSystem elsewhere
+func NewSystem() System {
+	return NewUnixSystem()
+}
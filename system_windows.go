@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"log"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// enableVirtualTerminalProcessing is the console mode in which Windows
+// interprets the escape sequences used to color output
+const enableVirtualTerminalProcessing = 0x4
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// WindowsSystem is the System of a process running on Windows
+type WindowsSystem struct {
+	*BaseSystem
+}
+
+// NewWindowsSystem returns a System for the current process. The console is
+// switched to interpret escape sequences, so that colored output is
+// displayed rather than printed as is, and the per-drive working directory
+// entries which Windows hides in the environment, e.g. `=C:=C:\work`, are
+// left out.
+func NewWindowsSystem() *WindowsSystem {
+	env := os.Environ()
+	environment := make(map[string]string, len(env))
+	for _, e := range env {
+		split := strings.SplitN(e, "=", 2)
+		if len(split) < 2 || len(split[0]) == 0 {
+			continue
+		}
+		environment[split[0]] = split[1]
+	}
+
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		enableColor(f)
+	}
+
+	return &WindowsSystem{&BaseSystem{
+		In:          os.Stdin,
+		Out:         os.Stdout,
+		Err:         os.Stderr,
+		Logger:      log.New(os.Stderr, "", log.LstdFlags),
+		Environment: environment,
+		Arguments:   os.Args,
+	}}
+}
+
+// NewSystem returns a System for the current process suited to the
+// platform: a WindowsSystem on Windows and a UnixSystem elsewhere
+func NewSystem() System {
+	return NewWindowsSystem()
+}
+
+// enableColor turns on escape sequence processing for f if it is a console.
+// Older consoles which don't support it are left as they are.
+func enableColor(f *os.File) {
+	var mode uint32
+	handle := syscall.Handle(f.Fd())
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return
+	}
+	if mode&enableVirtualTerminalProcessing == 0 {
+		setConsoleMode.Call(uintptr(handle), uintptr(mode|enableVirtualTerminalProcessing))
+	}
+}
+
+// Setenv sets a variable in the environment of both the System and the
+// process
+func (s *WindowsSystem) Setenv(k, v string) error {
+	if err := s.BaseSystem.Setenv(k, v); err != nil {
+		return err
+	}
+	return os.Setenv(k, v)
+}
+
+// Unsetenv removes a variable from the environment of both the System and
+// the process
+func (s *WindowsSystem) Unsetenv(k string) error {
+	if err := s.BaseSystem.Unsetenv(k); err != nil {
+		return err
+	}
+	return os.Unsetenv(k)
+}
+
+// ReadPassword reads a line from the console without echoing it, or
+// echoing asterisks if MaskPasswords is set, and without the carriage
+// return which ends lines typed on Windows
+func (s *WindowsSystem) ReadPassword() (string, error) {
+	if s.MaskPasswords {
+		return readMaskedTerminal(int(os.Stdin.Fd()), os.Stdin, s.Out)
+	}
+	cloaked, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(cloaked), "\r"), nil
+}
@@ -0,0 +1,23 @@
+package cli
+
+import "testing"
+
+func TestWindowsEnvironmentCase(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Environment: map[string]string{
+		"Path": `C:\Windows`,
+	}})
+
+	if path := system.Getenv("PATH"); path != `C:\Windows` {
+		t.Errorf("expected Path to be found as PATH, received %q\n", path)
+	}
+	system.Setenv("PATH", `C:\bin`)
+	if environ := system.Environ(); len(environ) != 1 || environ[0] != `Path=C:\bin` {
+		t.Errorf("expected Path to be replaced, received %q\n", environ)
+	}
+}
+
+func TestWindowsProgramName(t *testing.T) {
+	if name := programName(`C:\Tools\widgets.EXE`); name != "widgets" {
+		t.Errorf("expected the extension to be removed, received %q\n", name)
+	}
+}
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Alignment is how the values of a table's column are aligned
+type Alignment int
+
+// Alignments of a column
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+	AlignCenter
+)
+
+// minColumnWidth is the narrowest a column is made to fit a table to the
+// terminal
+const minColumnWidth = 4
+
+// Column describes a column of a Table
+type Column struct {
+	Header string
+	Align  Alignment
+
+	// MaxWidth, if set, truncates values longer than it
+	MaxWidth int
+}
+
+// Table lays out rows of values in aligned columns, for commands which list
+// resources. When output is a terminal, the widest columns are truncated so
+// that the table fits it.
+type Table struct {
+	Columns []Column
+
+	// Border draws lines around the table and between its cells
+	Border bool
+
+	// Width, if set, is the width the table is fit to in place of the
+	// terminal's
+	Width int
+
+	rows [][]string
+}
+
+// NewTable returns a Table with columns of the given headers, aligned left
+func NewTable(headers ...string) *Table {
+	t := &Table{Columns: make([]Column, len(headers))}
+	for i, h := range headers {
+		t.Columns[i].Header = h
+	}
+	return t
+}
+
+// Append adds a row of values, each formatted as by Sprint. A row with more
+// values than the table has columns adds columns without headers.
+func (t *Table) Append(values ...interface{}) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = strings.Join(strings.Fields(fmt.Sprint(v)), " ")
+	}
+	t.rows = append(t.rows, row)
+}
+
+// Print prints the table to the output of sys
+func (t *Table) Print(sys System) error {
+	width := t.Width
+	if width == 0 {
+		if w, _, err := sys.TermSize(); err == nil {
+			width = w
+		}
+	}
+	unicode := true
+	if s, ok := baseOf(sys); ok {
+		unicode = s.unicode()
+	}
+	_, err := sys.Print(t.render(width, unicode))
+	return err
+}
+
+// render lays the table out within width columns, if width is set, drawing
+// borders and truncating with unicode if it may be used
+func (t *Table) render(width int, unicode bool) string {
+	columns := append([]Column{}, t.Columns...)
+	for _, row := range t.rows {
+		for len(columns) < len(row) {
+			columns = append(columns, Column{})
+		}
+	}
+	headers := false
+	for _, c := range t.Columns {
+		headers = headers || len(c.Header) > 0
+	}
+
+	widths := make([]int, len(columns))
+	measure := func(row []string) {
+		for i, value := range row {
+			if n := visibleLength(value); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if headers {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = c.Header
+		}
+		measure(row)
+	}
+	for _, row := range t.rows {
+		measure(row)
+	}
+	for i, c := range columns {
+		if c.MaxWidth > 0 && widths[i] > c.MaxWidth {
+			widths[i] = c.MaxWidth
+		}
+	}
+	if width > 0 {
+		fitColumns(widths, width-tableOverhead(len(columns), t.Border))
+	}
+
+	ellipsis := "…"
+	lines := tableLines{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"}
+	if !unicode {
+		ellipsis = "..."
+		lines = tableLines{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+	}
+
+	var b strings.Builder
+	rule := func(left, middle, right string) {
+		if !t.Border {
+			return
+		}
+		b.WriteString(left)
+		for i, w := range widths {
+			if i > 0 {
+				b.WriteString(middle)
+			}
+			b.WriteString(strings.Repeat(lines.horizontal, w+2))
+		}
+		b.WriteString(right + "\n")
+	}
+	row := func(values []string) {
+		var line strings.Builder
+		if t.Border {
+			line.WriteString(lines.vertical + " ")
+		}
+		for i, w := range widths {
+			if i > 0 {
+				if t.Border {
+					line.WriteString(" " + lines.vertical + " ")
+				} else {
+					line.WriteString("  ")
+				}
+			}
+			var value string
+			if i < len(values) {
+				value = truncate(values[i], w, ellipsis)
+			}
+			line.WriteString(align(value, w, columns[i].Align))
+		}
+		if t.Border {
+			line.WriteString(" " + lines.vertical)
+		}
+		b.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+
+	rule(lines.topLeft, lines.topMiddle, lines.topRight)
+	if headers {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = c.Header
+		}
+		row(values)
+		rule(lines.left, lines.middle, lines.right)
+	}
+	for _, values := range t.rows {
+		row(values)
+	}
+	rule(lines.bottomLeft, lines.bottomMiddle, lines.bottomRight)
+	return b.String()
+}
+
+// tableLines are the characters a table's border is drawn with
+type tableLines struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	left, middle, right                   string
+	bottomLeft, bottomMiddle, bottomRight string
+}
+
+// tableOverhead returns the width taken by the space between the values of
+// a table's columns
+func tableOverhead(columns int, border bool) int {
+	if columns == 0 {
+		return 0
+	}
+	if border {
+		return 3*columns + 1
+	}
+	return 2 * (columns - 1)
+}
+
+// fitColumns narrows the widest of widths, one column at a time, until their
+// sum fits available or none can be narrowed further
+func fitColumns(widths []int, available int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	for total > available {
+		widest := -1
+		for i, w := range widths {
+			if w > minColumnWidth && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// truncate shortens value to width visible runes, ending it with ellipsis.
+// Terminal control sequences, such as colors, are removed from values which
+// are shortened.
+func truncate(value string, width int, ellipsis string) string {
+	if visibleLength(value) <= width {
+		return value
+	}
+	value = escapeSequence.ReplaceAllString(value, "")
+	keep := width - utf8.RuneCountInString(ellipsis)
+	if keep <= 0 {
+		return string([]rune(value)[:width])
+	}
+	return string([]rune(value)[:keep]) + ellipsis
+}
+
+// align pads value to width visible runes
+func align(value string, width int, alignment Alignment) string {
+	pad := width - visibleLength(value)
+	if pad <= 0 {
+		return value
+	}
+	switch alignment {
+	case AlignRight:
+		return strings.Repeat(" ", pad) + value
+	case AlignCenter:
+		return strings.Repeat(" ", pad/2) + value + strings.Repeat(" ", pad-pad/2)
+	default:
+		return value + strings.Repeat(" ", pad)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	table := NewTable("NAME", "STATUS", "AGE")
+	table.Columns[2].Align = AlignRight
+	table.Append("web", "running", 12)
+	table.Append("a-worker-with-a-long-name", "stopped\n(exit 1)", 3)
+
+	expected := "" +
+		"NAME                       STATUS            AGE\n" +
+		"web                        running            12\n" +
+		"a-worker-with-a-long-name  stopped (exit 1)    3\n"
+	if actual := table.render(0, true); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+
+	expected = "" +
+		"NAME          STATUS         AGE\n" +
+		"web           running         12\n" +
+		"a-worker-wi…  stopped (exi…    3\n"
+	if actual := table.render(32, true); actual != expected {
+		t.Errorf("expected to fit 32 columns:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+}
+
+func TestTableBorder(t *testing.T) {
+	table := &Table{
+		Columns: []Column{{Header: "Key"}, {Header: "Value", MaxWidth: 8, Align: AlignCenter}},
+		Border:  true,
+	}
+	table.Append("token", "abcdefghijklmnop")
+	table.Append("id", "7", "extra")
+
+	expected := "" +
+		"+-------+----------+-------+\n" +
+		"| Key   |  Value   |       |\n" +
+		"+-------+----------+-------+\n" +
+		"| token | abcde... |       |\n" +
+		"| id    |    7     | extra |\n" +
+		"+-------+----------+-------+\n"
+	if actual := table.render(0, false); actual != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+}
+
+func TestTablePrint(t *testing.T) {
+	system, output := NewMemorySystem(MemorySystemOptions{Environment: map[string]string{"LANG": "en_US.UTF-8"}})
+	table := NewTable("ID", "DESCRIPTION")
+	table.Append(1, "a description longer than any terminal is wide, which isn't truncated when piped")
+	if err := table.Print(system); err != nil {
+		t.Fatal(err)
+	}
+	ExpectMatch(t, *output.Stdout, `^ID  DESCRIPTION\n1   a description .* when piped\n$`)
+
+	screen, _ := NewScreenTestSystem(t, nil, map[string]string{"LANG": "en_US.UTF-8"}, 8, 30)
+	table.Border = true
+	if err := table.Print(screen); err != nil {
+		t.Fatal(err)
+	}
+	if line := screen.Screen().Line(3); line != "│ 1  │ a description longer… │" {
+		t.Errorf("expected the table to fit the terminal, received %q\n", line)
+	}
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// stringsFlag is a flag.Value which collects every occurrence of a repeated
+// flag
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// Targets selects which of a set of configured targets (clusters, accounts,
+// directories, ...) a command should run against. Commands should call its
+// Flags method from their own to define the `--target` and `--all-targets`
+// flags, then pass it to ForEachTarget.
+type Targets struct {
+	// Available lists every configured target
+	Available []string
+
+	// Default is used when no target is selected on the command line
+	Default string
+
+	selected stringsFlag
+	all      bool
+}
+
+// Flags defines the `--target` and `--all-targets` flags
+func (t *Targets) Flags(f *flag.FlagSet) {
+	f.Var(&t.selected, "target", "run against the named target; may be repeated")
+	f.BoolVar(&t.all, "all-targets", false, "run against every configured target")
+}
+
+// Selected returns the targets chosen on the command line
+func (t *Targets) Selected() ([]string, error) {
+	if t.all {
+		if len(t.selected) > 0 {
+			return nil, fmt.Errorf("--target and --all-targets may not be combined")
+		}
+		if len(t.Available) == 0 {
+			return nil, fmt.Errorf("No targets are configured")
+		}
+		return t.Available, nil
+	}
+
+	if len(t.selected) > 0 {
+		for _, name := range t.selected {
+			if len(t.Available) > 0 && !containsString(t.Available, name) {
+				return nil, fmt.Errorf("Unknown target: %s", name)
+			}
+		}
+		return t.selected, nil
+	}
+
+	if len(t.Default) > 0 {
+		return []string{t.Default}, nil
+	}
+	if len(t.Available) == 1 {
+		return t.Available, nil
+	}
+	return nil, fmt.Errorf("No target selected; use --target or --all-targets")
+}
+
+// TargetFunc is the body of a command run against a single target
+type TargetFunc func(ctx context.Context, target string, sys System) error
+
+// ForEachTarget runs fn against each selected target in turn. When more than
+// one target is selected, everything fn prints or logs is prefixed with the
+// target's name. A failure against one target doesn't prevent the others from
+// running; the returned error reports how many targets failed and carries the
+// highest exit status among them. The outcome for each target is recorded
+// with RecordItem.
+func ForEachTarget(ctx context.Context, sys System, targets *Targets, fn TargetFunc) error {
+	names, err := targets.Selected()
+	if err != nil {
+		return &ExitError{Status: ExitUsage, Message: err.Error()}
+	}
+
+	status, failed := 0, 0
+	for _, name := range names {
+		s := sys
+		if len(names) > 1 {
+			s = PrefixSystem(sys, fmt.Sprintf("[%s] ", name))
+		}
+
+		start := time.Now()
+		err := fn(context.WithValue(ctx, "target", name), name, s)
+		item := ItemResult{Name: name, Duration: time.Since(start)}
+		if err != nil {
+			failed++
+			s.Log(err.Error())
+			item.Status, item.Error = exitStatus(err, false), err.Error()
+			if item.Status > status {
+				status = item.Status
+			}
+		}
+		RecordItem(ctx, item)
+	}
+
+	if failed > 0 {
+		return &ExitError{
+			Status:  status,
+			Message: Localize(sys, "%d of %d targets failed", failed, len(names)),
+		}
+	}
+	return nil
+}
+
+// PrefixSystem returns a System which prefixes each line printed or logged
+// through it
+func PrefixSystem(sys System, prefix string) System {
+	return &prefixSystem{System: sys, prefix: prefix, lineStart: true, errLineStart: true}
+}
+
+type prefixSystem struct {
+	System
+
+	prefix       string
+	lineStart    bool
+	errLineStart bool
+}
+
+// prefixLines prefixes each line of text which begins a line of output. At
+// is updated to record whether the next text written will begin a line.
+func (s *prefixSystem) prefixLines(text string, at *bool) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		if *at {
+			b.WriteString(s.prefix)
+		}
+
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			b.WriteString(text)
+			*at = false
+			break
+		}
+
+		b.WriteString(text[:i+1])
+		text = text[i+1:]
+		*at = true
+	}
+	return b.String()
+}
+
+func (s *prefixSystem) write(text string) (int, error) {
+	return s.System.Print(s.prefixLines(text, &s.lineStart))
+}
+
+func (s *prefixSystem) writeErr(text string) (int, error) {
+	return s.System.Eprint(s.prefixLines(text, &s.errLineStart))
+}
+
+// Stdout returns a writer which prefixes each line written to it
+func (s *prefixSystem) Stdout() io.Writer {
+	return prefixWriter(s.write)
+}
+
+// Stderr returns a writer which prefixes each line written to it
+func (s *prefixSystem) Stderr() io.Writer {
+	return prefixWriter(s.writeErr)
+}
+
+// prefixWriter adapts one of a prefixSystem's write methods to io.Writer
+type prefixWriter func(string) (int, error)
+
+func (w prefixWriter) Write(p []byte) (int, error) {
+	if _, err := w(string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *prefixSystem) Print(a ...interface{}) (int, error) {
+	return s.write(fmt.Sprint(a...))
+}
+
+func (s *prefixSystem) Printf(format string, a ...interface{}) (int, error) {
+	return s.write(fmt.Sprintf(format, a...))
+}
+
+func (s *prefixSystem) Println(a ...interface{}) (int, error) {
+	return s.write(fmt.Sprintln(a...))
+}
+
+func (s *prefixSystem) Eprint(a ...interface{}) (int, error) {
+	return s.writeErr(fmt.Sprint(a...))
+}
+
+func (s *prefixSystem) Eprintf(format string, a ...interface{}) (int, error) {
+	return s.writeErr(fmt.Sprintf(format, a...))
+}
+
+func (s *prefixSystem) Eprintln(a ...interface{}) (int, error) {
+	return s.writeErr(fmt.Sprintln(a...))
+}
+
+func (s *prefixSystem) Log(a ...interface{}) {
+	s.System.Log(s.prefix + strings.TrimSuffix(fmt.Sprintln(a...), "\n"))
+}
+
+func (s *prefixSystem) Logf(format string, a ...interface{}) {
+	s.System.Log(s.prefix + strings.TrimSuffix(fmt.Sprintf(format, a...), "\n"))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"testing"
+)
+
+type testTargetsCommand struct {
+	Targets
+}
+
+func (c *testTargetsCommand) Help() {}
+
+func (c *testTargetsCommand) Flags(f *flag.FlagSet) {
+	c.Targets.Flags(f)
+}
+
+func (c *testTargetsCommand) Command(ctx context.Context, args []string, s System) error {
+	return ForEachTarget(ctx, s, &c.Targets, func(ctx context.Context, target string, s System) error {
+		s.Printf("deploying\n")
+		if target == "staging" {
+			return &ExitError{Status: 4, Message: "unreachable"}
+		}
+		return nil
+	})
+}
+
+func TestForEachTarget(t *testing.T) {
+	cmd := &testTargetsCommand{Targets{Available: []string{"production", "staging"}}}
+	result, output := runMain(t, cmd, []string{"deploy", "--all-targets"})
+
+	if result != 4 {
+		t.Errorf("expected the highest target exit status, received %d\n", result)
+	}
+	ExpectMatch(t, *output.STDOUT, `\[production\] deploying`)
+	ExpectMatch(t, *output.STDOUT, `\[staging\] deploying`)
+	ExpectMatch(t, *output.STDERR, `\[staging\] unreachable`)
+	ExpectMatch(t, *output.STDERR, `1 of 2 targets failed`)
+
+	cmd = &testTargetsCommand{Targets{Available: []string{"production", "staging"}}}
+	result, output = runMain(t, cmd, []string{"deploy", "--target", "production"})
+
+	if result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+	ExpectMatch(t, *output.STDOUT, `^deploying`)
+}
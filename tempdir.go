@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// WithKeepTemp adds a `--keep-temp` flag which preserves the directory
+// created by TempDir once the command has returned, and logs its path, so
+// that its contents may be inspected when debugging
+func WithKeepTemp() Option {
+	return func(c *config) {
+		c.keepTemp = true
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("keep-temp", false, "keep the command's temporary files for debugging")
+		})
+	}
+}
+
+// scratchDir is the temporary directory of a run, created when it is first
+// asked for
+type scratchDir struct {
+	sys    System
+	prefix string
+	keep   bool
+
+	once sync.Once
+	path string
+	err  error
+}
+
+// TempDir returns a scratch directory for the command running on sys. The
+// directory is created the first time it is asked for within the System's
+// TempDir, so that a TestSystem catches it if it leaks, and is removed along
+// with its contents when Main returns unless `--keep-temp` is given. If ctx
+// did not come from Main, each call creates a new directory, which the
+// caller must remove.
+func TempDir(ctx context.Context, sys System) (string, error) {
+	d, ok := ctx.Value("temp-dir").(*scratchDir)
+	if !ok {
+		return ioutil.TempDir(sys.TempDir(), "go-cli-")
+	}
+
+	d.once.Do(func() {
+		d.path, d.err = ioutil.TempDir(d.sys.TempDir(), d.prefix+"-")
+		if d.err != nil {
+			return
+		}
+		OnExit(ctx, func() {
+			if d.keep {
+				d.sys.Logf(tr(d.sys, "Kept temporary files in %s\n"), d.path)
+				return
+			}
+			if err := os.RemoveAll(d.path); err != nil {
+				d.sys.Logf(tr(d.sys, "Unable to remove temporary files: %s\n"), err)
+			}
+		})
+	})
+	return d.path, d.err
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type testTempCommand struct {
+	dir string
+}
+
+func (c *testTempCommand) Help() {}
+
+func (c *testTempCommand) Command(ctx context.Context, args []string, s System) error {
+	dir, err := TempDir(ctx, s)
+	if err != nil {
+		return err
+	}
+	if again, err := TempDir(ctx, s); err != nil || again != dir {
+		return &ExitError{Status: ExitFailure, Message: "TempDir returned another directory: " + again}
+	}
+	c.dir = dir
+	return ioutil.WriteFile(filepath.Join(dir, "scratch"), []byte("scratch"), 0600)
+}
+
+func TestTempDir(t *testing.T) {
+	sandbox := Sandbox(t)
+	system, output := sandbox.System([]string{"testtemp"})
+	cmd := &testTempCommand{}
+
+	if result := Main(context.Background(), cmd, system, WithKeepTemp()); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if !strings.HasPrefix(cmd.dir, sandbox.TempDir) {
+		t.Errorf("expected a directory within %s, received %s\n", sandbox.TempDir, cmd.dir)
+	}
+	if _, err := os.Stat(cmd.dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, received %v\n", cmd.dir, err)
+	}
+}
+
+func TestKeepTemp(t *testing.T) {
+	sandbox := Sandbox(t)
+	system, output := sandbox.System([]string{"testtemp", "--keep-temp"})
+	cmd := &testTempCommand{}
+
+	if result := Main(context.Background(), cmd, system, WithKeepTemp()); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if _, err := os.Stat(filepath.Join(cmd.dir, "scratch")); err != nil {
+		t.Errorf("expected the temporary files to be kept: %s\n", err)
+	}
+	ExpectMatch(t, *output.STDERR, `Kept temporary files in `+regexp.QuoteMeta(cmd.dir))
+	os.RemoveAll(cmd.dir)
+}
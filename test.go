@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"testing"
 )
 
@@ -24,6 +25,44 @@ func ExpectError(t *testing.T, err error) {
 	}
 }
 
+// ExpectExitCode asserts that Main returned the expected status, such as
+// ExitUsage
+func ExpectExitCode(t *testing.T, result int, expected int) {
+	t.Helper()
+	if result != expected {
+		t.Errorf("Expected exit status %s; received %s",
+			exitStatusName(expected), exitStatusName(result))
+	}
+}
+
+// ExpectCancelled asserts that Main returned ExitCancelled because the
+// command was interrupted
+func ExpectCancelled(t *testing.T, result int) {
+	t.Helper()
+	ExpectExitCode(t, result, ExitCancelled)
+}
+
+// exitStatusName describes an exit status by its category
+func exitStatusName(status int) string {
+	switch status {
+	case ExitOK:
+		return "0 (ok)"
+	case ExitFailure:
+		return "1 (failure)"
+	case ExitUsage:
+		return "2 (usage)"
+	case ExitUnavailable:
+		return "69 (unavailable)"
+	case ExitTemporaryFailure:
+		return "75 (temporary failure)"
+	case ExitNoPermission:
+		return "77 (no permission)"
+	case ExitCancelled:
+		return "130 (cancelled)"
+	}
+	return strconv.Itoa(status)
+}
+
 func ExpectOutput(t *testing.T, stdout bytes.Buffer) {
 	if len(stdout.Bytes()) < 1 {
 		t.Errorf("Expected command to write output to STDOUT")
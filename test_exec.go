@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ExecFake stands in for a program run by a TestSystem's Exec. It writes what
+// the program would to call.Stdout and call.Stderr and returns the status the
+// program would exit with.
+type ExecFake func(ctx context.Context, call ExecCall) int
+
+// execFakes holds the fakes of a TestSystem and the calls they received
+type execFakes struct {
+	sync.Mutex
+	programs map[string]ExecFake
+	calls    []ExecCall
+
+	// opened are the URLs given to OpenBrowser
+	opened []string
+
+	// editor stands in for the user's editor
+	editor func(content []byte) ([]byte, error)
+
+	// clipboard stands in for the user's clipboard
+	clipboard string
+
+	// progress records the bars returned by Progress
+	progress []*testProgress
+
+	// spinners records the spinners returned by Spinner
+	spinners []*testSpinner
+}
+
+// FakeExec makes Exec call fake rather than run the named program. A fake
+// named "*" stands in for every program without a fake of its own, so that a
+// test can ensure nothing real is run. Programs without a fake are run as
+// usual.
+func (ts *TestSystem) FakeExec(name string, fake ExecFake) {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	if ts.fakes.programs == nil {
+		ts.fakes.programs = map[string]ExecFake{}
+	}
+	ts.fakes.programs[name] = fake
+}
+
+// FakeOutput returns an ExecFake which prints stdout and exits with status
+func FakeOutput(stdout string, status int) ExecFake {
+	return func(ctx context.Context, call ExecCall) int {
+		call.Stdout.Write([]byte(stdout))
+		return status
+	}
+}
+
+// ExecCalls returns the calls made of Exec, in order, whether or not they
+// were faked
+func (ts *TestSystem) ExecCalls() []ExecCall {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	return append([]ExecCall(nil), ts.fakes.calls...)
+}
+
+// FakeEditor makes Edit call edit with the content to be edited rather than
+// run an editor, returning what edit returns as though the user had made the
+// edits
+func (ts *TestSystem) FakeEditor(edit func(content []byte) ([]byte, error)) {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	ts.fakes.editor = edit
+}
+
+// Edit calls the fake editor if there is one, or else runs the editor named
+// by the test environment with Exec, so that it may be faked as a program
+func (ts *TestSystem) Edit(initial []byte, pattern string) ([]byte, error) {
+	ts.fakes.Lock()
+	edit := ts.fakes.editor
+	ts.fakes.Unlock()
+	if edit != nil {
+		return edit(append([]byte(nil), initial...))
+	}
+	return ts.edit(initial, pattern, ts.Exec)
+}
+
+// OpenBrowser records the URL rather than opening it, so that tests of
+// commands which send the user to a web page don't open a browser. The URLs
+// opened are returned by OpenedURLs.
+func (ts *TestSystem) OpenBrowser(address string) error {
+	if err := checkBrowserURL(address); err != nil {
+		return err
+	}
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	ts.fakes.opened = append(ts.fakes.opened, address)
+	return nil
+}
+
+// OpenedURLs returns the URLs given to OpenBrowser, in order
+func (ts *TestSystem) OpenedURLs() []string {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	return append([]string(nil), ts.fakes.opened...)
+}
+
+// ReadClipboard returns the text of a clipboard kept in memory, so that
+// tests don't read the user's clipboard
+func (ts *TestSystem) ReadClipboard() (string, error) {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	return ts.fakes.clipboard, nil
+}
+
+// WriteClipboard puts text on a clipboard kept in memory, so that tests
+// don't overwrite the user's clipboard
+func (ts *TestSystem) WriteClipboard(text string) error {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	ts.fakes.clipboard = text
+	return nil
+}
+
+// Exec runs the fake of a program if there is one, or else the program itself
+func (ts *TestSystem) Exec(ctx context.Context, name string, args []string, opts ...ExecOption) (Result, error) {
+	return ts.exec(ctx, name, args, opts, func(ctx context.Context, call *ExecCall) (int, error) {
+		ts.fakes.Lock()
+		ts.fakes.calls = append(ts.fakes.calls, *call)
+		fake, ok := ts.fakes.programs[strings.TrimSuffix(filepath.Base(call.Name), ".exe")]
+		if !ok {
+			fake, ok = ts.fakes.programs["*"]
+		}
+		ts.fakes.Unlock()
+
+		if !ok {
+			return ts.runProcess(ctx, call)
+		}
+		if call.Stdin == nil {
+			call.Stdin = strings.NewReader("")
+		}
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+		return fake(ctx, *call), nil
+	})
+}
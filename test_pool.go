@@ -0,0 +1,227 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	"github.com/Netflix/go-expect"
+	"golang.org/x/term"
+)
+
+// ConsolePool reuses the pseudoterminals behind TestSystems. Opening a PTY
+// costs far more than the typical CLI test, and suites which run thousands
+// of tests with -parallel can otherwise exhaust file descriptors. Idle
+// consoles are kept in a channel, so leasing and returning them never takes a
+// lock.
+type ConsolePool struct {
+	idle chan *pooledConsole
+
+	created   int64
+	reused    int64
+	discarded int64
+	markers   int64
+}
+
+// PoolStats describes the activity of a ConsolePool
+type PoolStats struct {
+	// Created is the number of consoles opened by the pool
+	Created int64
+
+	// Reused is the number of times an idle console was leased
+	Reused int64
+
+	// Discarded is the number of consoles closed rather than returned to the
+	// pool, either because it was full or the console was no longer usable
+	Discarded int64
+
+	// Idle is the number of consoles waiting to be leased
+	Idle int
+}
+
+// DefaultConsolePool is the pool used by NewTestSystem
+var DefaultConsolePool = NewConsolePool(64)
+
+// NewConsolePool returns a pool which keeps up to size idle consoles
+func NewConsolePool(size int) *ConsolePool {
+	return &ConsolePool{idle: make(chan *pooledConsole, size)}
+}
+
+// Stats returns the pool's activity so far
+func (p *ConsolePool) Stats() PoolStats {
+	return PoolStats{
+		Created:   atomic.LoadInt64(&p.created),
+		Reused:    atomic.LoadInt64(&p.reused),
+		Discarded: atomic.LoadInt64(&p.discarded),
+		Idle:      len(p.idle),
+	}
+}
+
+type pooledConsole struct {
+	*expect.Console
+
+	pool  *ConsolePool
+	out   *switchWriter
+	state *term.State
+}
+
+// get leases a console whose output is copied to out
+func (p *ConsolePool) get(out io.Writer) (*pooledConsole, error) {
+	select {
+	case c := <-p.idle:
+		atomic.AddInt64(&p.reused, 1)
+		c.out.set(out)
+		return c, nil
+	default:
+	}
+
+	w := &switchWriter{}
+	w.set(out)
+	console, err := expect.NewConsole(
+		expect.WithDefaultTimeout(5*time.Second),
+		expect.WithStdout(w),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := term.GetState(int(console.Tty().Fd()))
+	if err != nil {
+		console.Close()
+		return nil, err
+	}
+
+	atomic.AddInt64(&p.created, 1)
+	return &pooledConsole{console, p, w, state}, nil
+}
+
+// put returns a console to the pool once any input and output left over by
+// the test which leased it has been discarded. Consoles which can't be
+// cleaned up, e.g. because the test closed the Tty, are closed instead.
+func (p *ConsolePool) put(c *pooledConsole) {
+	c.out.set(ioutil.Discard)
+	if err := p.reset(c); err == nil {
+		select {
+		case p.idle <- c:
+			return
+		default:
+		}
+	}
+
+	c.Close()
+	atomic.AddInt64(&p.discarded, 1)
+}
+
+func (p *ConsolePool) reset(c *pooledConsole) error {
+	if err := term.Restore(int(c.Tty().Fd()), c.state); err != nil {
+		return err
+	}
+
+	// consume input the command never read
+	marker := p.marker()
+	if _, err := c.SendLine(marker); err != nil {
+		return err
+	}
+	read := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(c.Tty())
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				read <- err
+				return
+			}
+			if line == marker+"\n" {
+				read <- nil
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-read:
+		if err != nil {
+			return err
+		}
+	case <-time.After(time.Second):
+		return fmt.Errorf("timed out discarding input")
+	}
+
+	// consume output, including the echoed input, which wasn't read
+	return c.flush(p.marker())
+}
+
+// flush writes marker to the console's Tty and reads up to it, so that
+// everything written before it has passed through the console's output
+func (c *pooledConsole) flush(marker string) error {
+	if _, err := c.Tty().Write([]byte(marker)); err != nil {
+		return err
+	}
+	_, err := c.ExpectString(marker)
+	return err
+}
+
+// capture reads the console's output in the background until the returned
+// function is called, which waits for everything written so far to be
+// captured in out. Unlike closing the Tty and calling ExpectEOF, it leaves
+// the console usable, so that it can be reused by another test.
+func (c *pooledConsole) capture(out *bytes.Buffer) func() {
+	marker := c.pool.marker()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.ExpectString(marker)
+	}()
+
+	return func() {
+		c.Tty().Write([]byte(marker))
+		<-done
+
+		if b := out.Bytes(); bytes.HasSuffix(b, []byte(marker)) {
+			out.Truncate(len(b) - len(marker))
+		}
+	}
+}
+
+func (c *pooledConsole) setEcho(on bool) error {
+	return setEcho(int(c.Tty().Fd()), on)
+}
+
+func (c *pooledConsole) readPassword(masked bool) (string, error) {
+	if masked {
+		return readMaskedTerminal(int(c.Tty().Fd()), c.Tty(), c.Tty())
+	}
+	cloaked, err := term.ReadPassword(int(c.Tty().Fd()))
+	if err != nil {
+		return "", err
+	}
+	return string(cloaked), nil
+}
+
+func (p *ConsolePool) marker() string {
+	return fmt.Sprintf("\x1b]go-cli-%d\x07", atomic.AddInt64(&p.markers, 1))
+}
+
+// switchWriter forwards writes to a writer which may be replaced while it is
+// in use
+type switchWriter struct {
+	w atomic.Value
+}
+
+type writerBox struct {
+	io.Writer
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.w.Store(writerBox{w})
+}
+
+func (s *switchWriter) Write(b []byte) (int, error) {
+	return s.w.Load().(writerBox).Write(b)
+}
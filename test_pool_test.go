@@ -0,0 +1,41 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"testing"
+)
+
+func TestConsolePoolReuse(t *testing.T) {
+	before := DefaultConsolePool.Stats()
+
+	t.Run("leaves input unread", func(t *testing.T) {
+		system, _ := NewTestSystem(t, []string{"test"}, nil)
+		system.Console.SendLine("leftover")
+		system.Print("unread output")
+	})
+
+	t.Run("reuses the console", func(t *testing.T) {
+		system, output := NewTestSystem(t, []string{"test"}, nil)
+		if stats := DefaultConsolePool.Stats(); stats.Reused <= before.Reused {
+			t.Fatalf("expected a console to be reused, stats %+v\n", stats)
+		}
+
+		wait := system.Capture()
+		system.Console.SendLine("fresh")
+		var line string
+		if _, err := system.Scan(&line); err != nil {
+			t.Fatal(err)
+		}
+		system.Print("done")
+		wait()
+
+		if line != "fresh" {
+			t.Errorf("expected to read fresh input, received %q\n", line)
+		}
+		if got := output.STDOUT.String(); got != "fresh\r\ndone" {
+			t.Errorf("expected only this test's output, received %q\n", got)
+		}
+	})
+}
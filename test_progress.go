@@ -0,0 +1,74 @@
+package cli
+
+import "sync"
+
+// ProgressRecord is the state of a ProgressBar returned by a TestSystem
+type ProgressRecord struct {
+	Label string
+	Total int64
+
+	// Updates are the values the progress was set to, in order
+	Updates []int64
+
+	Finished bool
+}
+
+// testProgress is a ProgressBar which records its updates rather than
+// drawing them, so that tests needn't match output redrawn over time
+type testProgress struct {
+	mu     sync.Mutex
+	record ProgressRecord
+}
+
+// Progress returns a ProgressBar which records its updates, returned by
+// ProgressBars, rather than drawing them
+func (ts *TestSystem) Progress(total int64, label string) ProgressBar {
+	p := &testProgress{record: ProgressRecord{Label: label, Total: total}}
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	ts.fakes.progress = append(ts.fakes.progress, p)
+	return p
+}
+
+// ProgressBars returns the state of the bars returned by Progress, in the
+// order they were created
+func (ts *TestSystem) ProgressBars() []ProgressRecord {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	records := make([]ProgressRecord, len(ts.fakes.progress))
+	for i, p := range ts.fakes.progress {
+		p.mu.Lock()
+		records[i] = p.record
+		records[i].Updates = append([]int64(nil), p.record.Updates...)
+		p.mu.Unlock()
+	}
+	return records
+}
+
+func (p *testProgress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var current int64
+	if updates := p.record.Updates; len(updates) > 0 {
+		current = updates[len(updates)-1]
+	}
+	p.set(current + n)
+}
+
+func (p *testProgress) Set(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.set(n)
+}
+
+func (p *testProgress) set(n int64) {
+	if !p.record.Finished {
+		p.record.Updates = append(p.record.Updates, n)
+	}
+}
+
+func (p *testProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.record.Finished = true
+}
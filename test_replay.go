@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ReplayOption configures how ReplayCast plays back a recorded session
+type ReplayOption func(*replayConfig)
+
+type replayConfig struct {
+	speed float64
+}
+
+// ReplaySpeed scales the recorded timing of input events. A factor of 2 plays
+// the session back twice as fast; a factor of zero or less sends every input
+// event without waiting.
+func ReplaySpeed(factor float64) ReplayOption {
+	return func(c *replayConfig) {
+		c.speed = factor
+	}
+}
+
+type castHeader struct {
+	Version int               `json:"version"`
+	Command string            `json:"command"`
+	Env     map[string]string `json:"env"`
+}
+
+type castEvent struct {
+	Time float64
+	Type string
+	Data string
+}
+
+func (e *castEvent) UnmarshalJSON(b []byte) error {
+	var fields []interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	if len(fields) != 3 {
+		return fmt.Errorf("expected 3 fields in event, found %d", len(fields))
+	}
+
+	var ok bool
+	if e.Time, ok = fields[0].(float64); !ok {
+		return fmt.Errorf("invalid event time: %v", fields[0])
+	}
+	if e.Type, ok = fields[1].(string); !ok {
+		return fmt.Errorf("invalid event type: %v", fields[1])
+	}
+	if e.Data, ok = fields[2].(string); !ok {
+		return fmt.Errorf("invalid event data: %v", fields[2])
+	}
+	return nil
+}
+
+// ReplayCast runs a recorded session against root as a regression test. The
+// session is read from an asciicast v2 file; the header's `command` and `env`
+// become the TestSystem's arguments and environment, input ("i") events are
+// sent to the console at their recorded offsets, and the output written by
+// the command is compared against the recorded output ("o") events.
+func ReplayCast(t *testing.T, root Command, path string, opts ...ReplayOption) {
+	t.Helper()
+
+	config := &replayConfig{speed: 1}
+	for _, o := range opts {
+		o(config)
+	}
+
+	header, events, err := readCast(path)
+	if err != nil {
+		t.Fatalf("Unable to read session %s: %s", path, err)
+	}
+
+	arguments := strings.Fields(header.Command)
+	if len(arguments) == 0 {
+		t.Fatalf("Session %s does not record a command", path)
+	}
+
+	var expected strings.Builder
+	for _, e := range events {
+		if e.Type == "o" {
+			expected.WriteString(e.Data)
+		}
+	}
+
+	system, output := NewTestSystem(t, arguments, header.Env)
+
+	wait := system.Capture()
+
+	stop := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		start := time.Now()
+		for _, e := range events {
+			if e.Type != "i" {
+				continue
+			}
+
+			if config.speed > 0 {
+				offset := time.Duration(e.Time / config.speed * float64(time.Second))
+				select {
+				case <-time.After(time.Until(start.Add(offset))):
+				case <-stop:
+					return
+				}
+			}
+
+			if _, err := system.Expecter().Send(e.Data); err != nil {
+				return
+			}
+		}
+	}()
+
+	Main(context.Background(), root, system)
+
+	close(stop)
+	<-sent
+	wait()
+
+	want := normalizeNewlines(expected.String())
+	got := normalizeNewlines(output.STDOUT.String())
+	if want != got {
+		t.Errorf("Output does not match session %s\n%s", path, diffLines(want, got))
+	}
+}
+
+func readCast(path string) (*castHeader, []castEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("missing header")
+	}
+
+	var header castHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil, nil, fmt.Errorf("invalid header: %s", err)
+	}
+	if header.Version != 2 {
+		return nil, nil, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	var events []castEvent
+	for line := 2; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var e castEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		events = append(events, e)
+	}
+
+	return &header, events, scanner.Err()
+}
+
+func normalizeNewlines(s string) string {
+	return strings.Replace(s, "\r\n", "\n", -1)
+}
+
+func diffLines(want, got string) string {
+	w := strings.Split(want, "\n")
+	g := strings.Split(got, "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			return fmt.Sprintf("First difference at line %d\nExpected: %q\nReceived: %q",
+				i+1, wl, gl)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"context"
+	"testing"
+)
+
+type testGreetCommand struct{}
+
+func (testGreetCommand) Help() {}
+
+func (testGreetCommand) Command(ctx context.Context, args []string, s System) error {
+	var name string
+	s.Print("What is your name? ")
+	if _, err := s.Scan(&name); err != nil {
+		return err
+	}
+	s.Printf("Hello, %s\n", name)
+	return nil
+}
+
+func TestReplayCast(t *testing.T) {
+	ReplayCast(t, testGreetCommand{}, "testdata/greeting.cast")
+}
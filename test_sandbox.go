@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestSandbox is an isolated home for integration tests of commands which
+// read and write "the user's machine". Each sandbox has its own home,
+// XDG base directories, temporary and runtime directories, and a PATH
+// containing only its Bin directory, all within a temporary directory which
+// is removed when the test completes. Nothing is read from or written to the
+// process's environment, so sandboxed tests may run in parallel.
+type TestSandbox struct {
+	// Root is the directory containing the sandbox
+	Root string
+
+	Home       string
+	ConfigHome string
+	StateHome  string
+	CacheHome  string
+	DataHome   string
+	RuntimeDir string
+	TempDir    string
+
+	// Bin is the only directory on the sandbox's PATH
+	Bin string
+
+	// Work is the working directory of each System created from the sandbox
+	Work string
+
+	// Environment is given to each System created from the sandbox
+	Environment map[string]string
+
+	t *testing.T
+}
+
+// Sandbox creates a TestSandbox which is removed when the test completes
+func Sandbox(t *testing.T) *TestSandbox {
+	t.Helper()
+
+	root, err := ioutil.TempDir("", "go-cli-sandbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(root)
+	})
+
+	// resolve symbolic links, such as /tmp on macOS, so that paths reported
+	// by commands can be compared with the sandbox's
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+
+	s := &TestSandbox{
+		Root:       root,
+		Home:       filepath.Join(root, "home"),
+		ConfigHome: filepath.Join(root, "home", ".config"),
+		StateHome:  filepath.Join(root, "home", ".local", "state"),
+		CacheHome:  filepath.Join(root, "home", ".cache"),
+		DataHome:   filepath.Join(root, "home", ".local", "share"),
+		RuntimeDir: filepath.Join(root, "run"),
+		TempDir:    filepath.Join(root, "tmp"),
+		Bin:        filepath.Join(root, "bin"),
+		Work:       filepath.Join(root, "work"),
+		t:          t,
+	}
+	for _, dir := range []string{
+		s.ConfigHome, s.StateHome, s.CacheHome, s.DataHome, s.RuntimeDir, s.TempDir, s.Bin, s.Work,
+	} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.Environment = map[string]string{
+		"HOME":            s.Home,
+		"USERPROFILE":     s.Home,
+		"XDG_CONFIG_HOME": s.ConfigHome,
+		"XDG_STATE_HOME":  s.StateHome,
+		"XDG_CACHE_HOME":  s.CacheHome,
+		"XDG_DATA_HOME":   s.DataHome,
+		"XDG_RUNTIME_DIR": s.RuntimeDir,
+		"TMPDIR":          s.TempDir,
+		"TEMP":            s.TempDir,
+		"TMP":             s.TempDir,
+		"PATH":            s.Bin,
+		"LANG":            "C",
+	}
+	return s
+}
+
+// Setenv sets a variable in the sandbox's environment
+func (s *TestSandbox) Setenv(key, value string) {
+	s.Environment[key] = value
+}
+
+// WriteFile writes a file within the sandbox's home directory, creating its
+// parent directories, and returns its absolute path. Name is relative to
+// the home directory and uses forward slashes, e.g. `.config/app/config.json`.
+func (s *TestSandbox) WriteFile(name string, data []byte) string {
+	s.t.Helper()
+
+	path := filepath.Join(s.Home, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		s.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		s.t.Fatal(err)
+	}
+	return path
+}
+
+// System returns a TestSystem whose environment is a copy of the sandbox's,
+// working in the sandbox's Work directory
+func (s *TestSandbox) System(arguments []string) (*TestSystem, *TestOutput) {
+	s.t.Helper()
+
+	environment := make(map[string]string, len(s.Environment))
+	for k, v := range s.Environment {
+		environment[k] = v
+	}
+	system, output := NewTestSystem(s.t, arguments, environment)
+	system.Dir = s.Work
+	return system, output
+}
+
+// StubResponse is what a stub binary does when it is run
+type StubResponse struct {
+	Stdout string
+	Stderr string
+	Status int
+}
+
+// StubCall records a single invocation of a stub binary
+type StubCall struct {
+	// Args are the arguments the stub was run with, excluding its name
+	Args []string
+
+	// Env is the environment the stub was run with. Values containing
+	// newlines are recorded faithfully only if no line after the first
+	// contains an equals sign.
+	Env map[string]string
+
+	// Stdin is everything written to the stub's standard input, or empty if
+	// it was attached to a terminal
+	Stdin string
+}
+
+// Stub is an executable in a sandbox's Bin directory which records how it
+// is run
+type Stub struct {
+	Name string
+	Path string
+
+	dir string
+	t   *testing.T
+}
+
+// Stub writes an executable named name to the sandbox's Bin directory, so
+// that commands which shell out to it, e.g. to git or kubectl, run the stub
+// instead. The stub records its arguments, environment and input, then
+// writes the response's output and exits with its status. Stubs are POSIX
+// shell scripts; the test is skipped on Windows.
+func (s *TestSandbox) Stub(name string, response StubResponse) *Stub {
+	s.t.Helper()
+
+	if runtime.GOOS == "windows" {
+		s.t.Skip("stub binaries require a POSIX shell")
+	}
+
+	stub := &Stub{
+		Name: name,
+		Path: filepath.Join(s.Bin, name),
+		dir:  filepath.Join(s.Root, "stubs", name),
+		t:    s.t,
+	}
+	if err := os.MkdirAll(stub.dir, 0700); err != nil {
+		s.t.Fatal(err)
+	}
+
+	// the sandbox's PATH contains only stubs, so the utilities the stub
+	// relies on are found on the test's PATH instead
+	tools := map[string]string{}
+	for _, tool := range []string{"mkdir", "env", "cat"} {
+		path, err := exec.LookPath(tool)
+		if err != nil {
+			s.t.Fatalf("Unable to create stub %s: %s", name, err)
+		}
+		tools[tool] = quotePOSIX(path)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+dir=%s
+n=0
+while ! %s "$dir/$n" 2>/dev/null; do
+	n=$((n + 1))
+done
+for arg in "$@"; do
+	printf '%%s\000' "$arg"
+done >"$dir/$n/args"
+%s >"$dir/$n/env"
+if [ -t 0 ]; then
+	: >"$dir/$n/stdin"
+else
+	%s >"$dir/$n/stdin"
+fi
+printf '%%s' %s
+printf '%%s' %s >&2
+exit %d
+`, quotePOSIX(stub.dir), tools["mkdir"], tools["env"], tools["cat"],
+		quotePOSIXWord(response.Stdout), quotePOSIXWord(response.Stderr), response.Status)
+
+	if err := ioutil.WriteFile(stub.Path, []byte(script), 0700); err != nil {
+		s.t.Fatal(err)
+	}
+	return stub
+}
+
+// Calls returns the invocations of the stub so far, in the order they were
+// made
+func (st *Stub) Calls() []StubCall {
+	st.t.Helper()
+
+	infos, err := ioutil.ReadDir(st.dir)
+	if err != nil {
+		st.t.Fatal(err)
+	}
+	var indexes []int
+	for _, info := range infos {
+		if n, err := strconv.Atoi(info.Name()); err == nil {
+			indexes = append(indexes, n)
+		}
+	}
+	sort.Ints(indexes)
+
+	calls := make([]StubCall, len(indexes))
+	for i, n := range indexes {
+		dir := filepath.Join(st.dir, strconv.Itoa(n))
+		read := func(name string) string {
+			b, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				st.t.Fatalf("Unable to read call %d of stub %s: %s", n, st.Name, err)
+			}
+			return string(b)
+		}
+
+		if args := read("args"); len(args) > 0 {
+			calls[i].Args = strings.Split(strings.TrimSuffix(args, "\x00"), "\x00")
+		}
+		calls[i].Env = parseEnv(read("env"))
+		calls[i].Stdin = read("stdin")
+	}
+	return calls
+}
+
+// parseEnv parses the output of env(1)
+func parseEnv(s string) map[string]string {
+	env := map[string]string{}
+	var last string
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		if i := strings.IndexByte(line, '='); i > 0 {
+			last = line[:i]
+			env[last] = line[i+1:]
+			continue
+		}
+		if len(last) > 0 {
+			env[last] += "\n" + line
+		}
+	}
+	return env
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSandboxIsolation(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sandbox := Sandbox(t)
+			path := sandbox.WriteFile(".config/testmemo/name", []byte(name))
+			if !strings.HasPrefix(path, sandbox.ConfigHome) {
+				t.Errorf("expected %s within %s\n", path, sandbox.ConfigHome)
+			}
+
+			system, output := sandbox.System([]string{"testmemo"})
+			if got := system.Getenv("PATH"); got != sandbox.Bin {
+				t.Errorf("expected only the sandbox on PATH, received %q\n", got)
+			}
+
+			wait := system.Capture()
+			result := Main(context.Background(), &testMemoCommand{}, system, WithCache("testmemo"))
+			wait()
+			if result != 0 {
+				t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+			}
+
+			entries, err := ioutil.ReadDir(filepath.Join(sandbox.CacheHome, "testmemo", "memo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected the cache to be written to the sandbox, found %d entries\n", len(entries))
+			}
+		})
+	}
+}
+
+func TestSandboxWorkingDirectory(t *testing.T) {
+	sandbox := Sandbox(t)
+	system, _ := sandbox.System([]string{"test"})
+
+	if wd, err := system.Getwd(); err != nil || wd != sandbox.Work {
+		t.Fatalf("expected to work in %s, received %s (%v)\n", sandbox.Work, wd, err)
+	}
+	if err := system.MkdirAll(filepath.Join("src", "widgets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.Chdir("src"); err != nil {
+		t.Fatal(err)
+	}
+	if err := system.WriteFile(filepath.Join("widgets", "main.go"), []byte("package main\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(sandbox.Work, "src", "widgets", "main.go")
+	if _, err := ioutil.ReadFile(path); err != nil {
+		t.Errorf("expected relative paths to be resolved within the sandbox: %s\n", err)
+	}
+	if abs, err := system.Abs(filepath.Join("widgets", "main.go")); err != nil || abs != path {
+		t.Errorf("expected %s, received %s (%v)\n", path, abs, err)
+	}
+	if rel, err := system.Rel(filepath.Join(sandbox.Work, "README.md")); err != nil || rel != filepath.Join("..", "README.md") {
+		t.Errorf("expected a path relative to the working directory, received %s (%v)\n", rel, err)
+	}
+	if err := system.Chdir(filepath.Join("widgets", "main.go")); err == nil {
+		t.Errorf("expected changing into a file to fail\n")
+	}
+	if err := system.Chdir("missing"); err == nil {
+		t.Errorf("expected changing into a missing directory to fail\n")
+	}
+	if wd, _ := os.Getwd(); strings.HasPrefix(wd, sandbox.Root) {
+		t.Errorf("expected the process's working directory to be unchanged\n")
+	}
+}
+
+func TestSandboxStub(t *testing.T) {
+	sandbox := Sandbox(t)
+	git := sandbox.Stub("git", StubResponse{Stdout: "abc123\n", Stderr: "warning", Status: 3})
+	sandbox.Setenv("GIT_DIR", "/repo/.git")
+
+	var environment []string
+	for k, v := range sandbox.Environment {
+		environment = append(environment, k+"="+v)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(git.Path, "commit", "-m", "it's a\nmessage")
+	cmd.Env = environment
+	cmd.Stdin = strings.NewReader("input")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 3 {
+		t.Errorf("expected the scripted status, received %v\n", err)
+	}
+	if stdout.String() != "abc123\n" || stderr.String() != "warning" {
+		t.Errorf("expected the scripted output, received %q and %q\n", stdout.String(), stderr.String())
+	}
+
+	calls := git.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected one call, received %d\n", len(calls))
+	}
+	if expected := []string{"commit", "-m", "it's a\nmessage"}; !reflect.DeepEqual(calls[0].Args, expected) {
+		t.Errorf("expected arguments %q, received %q\n", expected, calls[0].Args)
+	}
+	if calls[0].Env["GIT_DIR"] != "/repo/.git" {
+		t.Errorf("expected the environment to be recorded, received %v\n", calls[0].Env)
+	}
+	if calls[0].Stdin != "input" {
+		t.Errorf("expected input to be recorded, received %q\n", calls[0].Stdin)
+	}
+}
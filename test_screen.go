@@ -0,0 +1,685 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// Expecter is the part of an expect console which tests use to drive a
+// command: sending it input and waiting for its output. It is satisfied by
+// *expect.Console as well as by the in-memory terminal behind
+// NewScreenTestSystem.
+type Expecter interface {
+	Send(string) (int, error)
+	SendLine(string) (int, error)
+	ExpectString(string) (string, error)
+}
+
+// testConsole is the terminal a TestSystem is attached to
+type testConsole interface {
+	Expecter
+
+	// capture starts copying output to out, if it isn't already, and returns
+	// a function which waits for everything written so far to arrive there
+	capture(out *bytes.Buffer) (wait func())
+
+	// readPassword reads a line of input without echoing it, or echoing
+	// asterisks if masked
+	readPassword(masked bool) (string, error)
+
+	// setEcho turns the echoing of input on or off
+	setEcho(on bool) error
+}
+
+// Screen is an in-memory emulation of a VT100 terminal, for asserting on what
+// a command leaves on the screen rather than on the bytes it wrote. It
+// understands cursor movement, erasing and scrolling; other control
+// sequences, such as colors, are consumed and ignored. As with a terminal
+// attached to a pseudoterminal, a newline written to the Screen also returns
+// the cursor to the first column.
+type Screen struct {
+	mu sync.Mutex
+
+	rows, cols int
+	cells      [][]rune
+
+	row, col           int
+	savedRow, savedCol int
+
+	// wrapping is set once a rune is written to the last column; the cursor
+	// moves to the next line only when another rune follows
+	wrapping bool
+
+	state   screenState
+	params  []byte
+	partial []byte
+}
+
+type screenState int
+
+const (
+	stateGround screenState = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+// NewScreen returns a blank Screen of the given size
+func NewScreen(rows, cols int) *Screen {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	s := &Screen{rows: rows, cols: cols}
+	s.reset()
+	return s
+}
+
+// Size returns the number of rows and columns on the Screen
+func (s *Screen) Size() (rows, cols int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rows, s.cols
+}
+
+// Resize changes the number of rows and columns on the Screen, keeping what
+// fits of its contents, as a terminal emulator does when its window is resized
+func (s *Screen) Resize(rows, cols int) {
+	if rows < 1 {
+		rows = 1
+	}
+	if cols < 1 {
+		cols = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cells := make([][]rune, rows)
+	for i := range cells {
+		cells[i] = make([]rune, cols)
+		blank(cells[i])
+		if i < s.rows {
+			copy(cells[i], s.cells[i])
+		}
+	}
+	s.rows, s.cols, s.cells = rows, cols, cells
+	s.row, s.col = clamp(s.row, 0, rows-1), clamp(s.col, 0, cols-1)
+	s.savedRow, s.savedCol = clamp(s.savedRow, 0, rows-1), clamp(s.savedCol, 0, cols-1)
+	s.wrapping = false
+}
+
+// Cursor returns the zero-based position of the cursor
+func (s *Screen) Cursor() (row, col int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.row, s.col
+}
+
+// Cell returns the rune at a zero-based position, which is a space if
+// nothing has been written there or the position is off the Screen
+func (s *Screen) Cell(row, col int) rune {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if row < 0 || row >= s.rows || col < 0 || col >= s.cols {
+		return ' '
+	}
+	return s.cells[row][col]
+}
+
+// Line returns the contents of a zero-based row without trailing spaces
+func (s *Screen) Line(row int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if row < 0 || row >= s.rows {
+		return ""
+	}
+	return strings.TrimRight(string(s.cells[row]), " ")
+}
+
+// String returns the contents of the Screen, one line per row, without
+// trailing spaces or blank rows
+func (s *Screen) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, s.rows)
+	for i, row := range s.cells {
+		lines[i] = strings.TrimRight(string(row), " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Write interprets b as output sent to the terminal
+func (s *Screen) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(b)
+	if len(s.partial) > 0 {
+		b = append(s.partial, b...)
+		s.partial = nil
+	}
+
+	for len(b) > 0 {
+		if b[0] < utf8.RuneSelf {
+			s.process(rune(b[0]))
+			b = b[1:]
+			continue
+		}
+		if !utf8.FullRune(b) {
+			s.partial = append([]byte(nil), b...)
+			break
+		}
+		r, size := utf8.DecodeRune(b)
+		s.process(r)
+		b = b[size:]
+	}
+	return n, nil
+}
+
+func (s *Screen) process(r rune) {
+	switch s.state {
+	case stateGround:
+		s.ground(r)
+
+	case stateEscape:
+		s.state = stateGround
+		switch r {
+		case '[':
+			s.state = stateCSI
+			s.params = s.params[:0]
+		case ']':
+			s.state = stateOSC
+		case '(', ')':
+			s.state = stateCharset
+		case '7':
+			s.savedRow, s.savedCol = s.row, s.col
+		case '8':
+			s.moveTo(s.savedRow, s.savedCol)
+		case 'D':
+			s.lineFeed()
+		case 'E':
+			s.col = 0
+			s.lineFeed()
+		case 'M':
+			s.reverseIndex()
+		case 'c':
+			s.reset()
+		}
+
+	case stateCharset:
+		s.state = stateGround
+
+	case stateCSI:
+		switch {
+		case r >= 0x30 && r <= 0x3f:
+			s.params = append(s.params, byte(r))
+		case r >= 0x20 && r <= 0x2f:
+			// intermediate bytes don't occur in the sequences emulated
+		case r >= 0x40 && r <= 0x7e:
+			s.state = stateGround
+			s.csi(r)
+		default:
+			s.state = stateGround
+		}
+
+	case stateOSC:
+		switch r {
+		case '\a':
+			s.state = stateGround
+		case 0x1b:
+			s.state = stateOSCEscape
+		}
+
+	case stateOSCEscape:
+		s.state = stateGround
+	}
+}
+
+func (s *Screen) ground(r rune) {
+	switch r {
+	case 0x1b:
+		s.state = stateEscape
+	case '\r':
+		s.col = 0
+		s.wrapping = false
+	case '\n', '\v', '\f':
+		s.col = 0
+		s.lineFeed()
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+		s.wrapping = false
+	case '\t':
+		s.moveTo(s.row, (s.col/8+1)*8)
+	default:
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		if s.wrapping {
+			s.col = 0
+			s.lineFeed()
+		}
+		s.cells[s.row][s.col] = r
+		if s.col == s.cols-1 {
+			s.wrapping = true
+		} else {
+			s.col++
+		}
+	}
+}
+
+// csi performs a control sequence introduced by `ESC [`
+func (s *Screen) csi(final rune) {
+	params := string(s.params)
+	if strings.HasPrefix(params, "?") || strings.HasPrefix(params, ">") {
+		// private modes, such as hiding the cursor, don't affect the
+		// contents of the screen
+		return
+	}
+
+	var args []int
+	for _, p := range strings.Split(params, ";") {
+		n, _ := strconv.Atoi(p)
+		args = append(args, n)
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] > 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'A':
+		s.moveTo(s.row-arg(0, 1), s.col)
+	case 'B':
+		s.moveTo(s.row+arg(0, 1), s.col)
+	case 'C':
+		s.moveTo(s.row, s.col+arg(0, 1))
+	case 'D':
+		s.moveTo(s.row, s.col-arg(0, 1))
+	case 'E':
+		s.moveTo(s.row+arg(0, 1), 0)
+	case 'F':
+		s.moveTo(s.row-arg(0, 1), 0)
+	case 'G':
+		s.moveTo(s.row, arg(0, 1)-1)
+	case 'd':
+		s.moveTo(arg(0, 1)-1, s.col)
+	case 'H', 'f':
+		s.moveTo(arg(0, 1)-1, arg(1, 1)-1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			s.clear(s.row, s.col, s.rows-1, s.cols-1)
+		case 1:
+			s.clear(0, 0, s.row, s.col)
+		case 2, 3:
+			s.clear(0, 0, s.rows-1, s.cols-1)
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			s.clear(s.row, s.col, s.row, s.cols-1)
+		case 1:
+			s.clear(s.row, 0, s.row, s.col)
+		case 2:
+			s.clear(s.row, 0, s.row, s.cols-1)
+		}
+	case 'X':
+		end := s.col + arg(0, 1) - 1
+		if end >= s.cols {
+			end = s.cols - 1
+		}
+		s.clear(s.row, s.col, s.row, end)
+	case 'P':
+		line := s.cells[s.row]
+		n := clamp(arg(0, 1), 0, s.cols-s.col)
+		copy(line[s.col:], line[s.col+n:])
+		blank(line[s.cols-n:])
+	case '@':
+		line := s.cells[s.row]
+		n := clamp(arg(0, 1), 0, s.cols-s.col)
+		copy(line[s.col+n:], line[s.col:])
+		blank(line[s.col : s.col+n])
+	case 'L':
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollDown(s.row)
+		}
+	case 'M':
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollUp(s.row)
+		}
+	case 'S':
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollUp(0)
+		}
+	case 'T':
+		for i := 0; i < arg(0, 1); i++ {
+			s.scrollDown(0)
+		}
+	case 's':
+		s.savedRow, s.savedCol = s.row, s.col
+	case 'u':
+		s.moveTo(s.savedRow, s.savedCol)
+	}
+}
+
+// moveTo moves the cursor, keeping it on the screen
+func (s *Screen) moveTo(row, col int) {
+	s.row = clamp(row, 0, s.rows-1)
+	s.col = clamp(col, 0, s.cols-1)
+	s.wrapping = false
+}
+
+// lineFeed moves the cursor down a row, scrolling at the bottom of the screen
+func (s *Screen) lineFeed() {
+	s.wrapping = false
+	if s.row == s.rows-1 {
+		s.scrollUp(0)
+		return
+	}
+	s.row++
+}
+
+// reverseIndex moves the cursor up a row, scrolling at the top of the screen
+func (s *Screen) reverseIndex() {
+	s.wrapping = false
+	if s.row == 0 {
+		s.scrollDown(0)
+		return
+	}
+	s.row--
+}
+
+// scrollUp removes the row at top, moving the rows below it up and leaving
+// a blank row at the bottom
+func (s *Screen) scrollUp(top int) {
+	line := s.cells[top]
+	copy(s.cells[top:], s.cells[top+1:])
+	blank(line)
+	s.cells[s.rows-1] = line
+}
+
+// scrollDown inserts a blank row at top, moving the rows below it down and
+// discarding the bottom row
+func (s *Screen) scrollDown(top int) {
+	line := s.cells[s.rows-1]
+	copy(s.cells[top+1:], s.cells[top:s.rows-1])
+	blank(line)
+	s.cells[top] = line
+}
+
+// clear blanks the cells from one position to another, inclusive, in reading
+// order
+func (s *Screen) clear(fromRow, fromCol, toRow, toCol int) {
+	for row := fromRow; row <= toRow; row++ {
+		start, end := 0, s.cols-1
+		if row == fromRow {
+			start = fromCol
+		}
+		if row == toRow {
+			end = toCol
+		}
+		blank(s.cells[row][start : end+1])
+	}
+}
+
+func (s *Screen) reset() {
+	s.cells = make([][]rune, s.rows)
+	for i := range s.cells {
+		s.cells[i] = make([]rune, s.cols)
+		blank(s.cells[i])
+	}
+	s.row, s.col = 0, 0
+	s.savedRow, s.savedCol = 0, 0
+	s.wrapping = false
+	s.state = stateGround
+}
+
+func blank(cells []rune) {
+	for i := range cells {
+		cells[i] = ' '
+	}
+}
+
+func clamp(n, low, high int) int {
+	if n < low {
+		return low
+	}
+	if n > high {
+		return high
+	}
+	return n
+}
+
+// ExpectScreen compares what is on the screen of a System from
+// NewScreenTestSystem with a golden file, which holds Screen.String followed
+// by a newline. Setting UPDATE_GOLDEN=1 in the environment writes the golden
+// file from the screen instead.
+func ExpectScreen(t *testing.T, ts *TestSystem, path string) {
+	t.Helper()
+
+	screen := ts.Screen()
+	if screen == nil {
+		t.Fatalf("ExpectScreen requires a System from NewScreenTestSystem")
+	}
+	got := screen.String() + "\n"
+
+	if len(os.Getenv("UPDATE_GOLDEN")) > 0 {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Unable to update %s: %s", path, err)
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read %s: %s; set UPDATE_GOLDEN=1 to create it", path, err)
+	}
+	if want := normalizeNewlines(string(b)); want != got {
+		t.Errorf("Screen does not match %s\n%s\nScreen:\n%s", path, diffLines(want, got), got)
+	}
+}
+
+// screenConsole is a terminal held entirely in memory: output is rendered on
+// a Screen and input is queued until the command reads it. Input is echoed
+// as the command reads it, except by readPassword, so that the Screen
+// doesn't depend on how sending input races with reading it.
+type screenConsole struct {
+	screen  *Screen
+	timeout time.Duration
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	out    io.Writer
+	output []byte
+	read   int
+	closed bool
+
+	input *memoryInput
+
+	// noEcho stops input from being echoed as it is read
+	noEcho bool
+}
+
+func newScreenConsole(rows, cols int, out io.Writer) *screenConsole {
+	c := &screenConsole{
+		screen:  NewScreen(rows, cols),
+		timeout: 5 * time.Second,
+		out:     out,
+		input:   newMemoryInput(),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Write renders output on the Screen and makes it available to ExpectString
+func (c *screenConsole) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	c.screen.Write(b)
+	c.output = append(c.output, b...)
+	c.cond.Broadcast()
+	return c.out.Write(b)
+}
+
+// Read returns input sent to the console, echoing it unless echo has been
+// turned off
+func (c *screenConsole) Read(b []byte) (int, error) {
+	n, err := c.input.Read(b)
+	c.mu.Lock()
+	echo := !c.noEcho
+	c.mu.Unlock()
+	if n > 0 && echo {
+		c.Write(b[:n])
+	}
+	return n, err
+}
+
+func (c *screenConsole) setEcho(on bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noEcho = !on
+	return nil
+}
+
+func (c *screenConsole) Send(s string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return c.input.Write([]byte(s))
+}
+
+func (c *screenConsole) SendLine(s string) (int, error) {
+	return c.Send(s + "\n")
+}
+
+// ExpectString waits until s has been written since the previous match and
+// returns the output up to the end of it
+func (c *screenConsole) ExpectString(s string) (string, error) {
+	deadline := time.Now().Add(c.timeout)
+	timer := time.AfterFunc(c.timeout, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if i := bytes.Index(c.output[c.read:], []byte(s)); i >= 0 {
+			end := c.read + i + len(s)
+			matched := string(c.output[c.read:end])
+			c.read = end
+			return matched, nil
+		}
+		if c.closed {
+			return string(c.output[c.read:]), io.EOF
+		}
+		if !time.Now().Before(deadline) {
+			return string(c.output[c.read:]), fmt.Errorf("timed out waiting for %q", s)
+		}
+		c.cond.Wait()
+	}
+}
+
+// capture needs no waiting, since output reaches the buffer as it is written
+func (c *screenConsole) capture(out *bytes.Buffer) func() {
+	return func() {}
+}
+
+func (c *screenConsole) readPassword(masked bool) (string, error) {
+	if masked {
+		return readMasked(c.input, c)
+	}
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		if _, err := c.input.Read(b); err != nil {
+			return string(line), err
+		}
+		if b[0] == '\n' {
+			return strings.TrimSuffix(string(line), "\r"), nil
+		}
+		line = append(line, b[0])
+	}
+}
+
+// Close ends input and wakes anything waiting for output
+func (c *screenConsole) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.cond.Broadcast()
+	return c.input.Close()
+}
+
+// memoryInput is an unbounded queue of input. Unlike an io.Pipe, writing to
+// it doesn't wait for a reader, so tests may send input before the command
+// asks for it.
+type memoryInput struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newMemoryInput() *memoryInput {
+	m := &memoryInput{}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+func (m *memoryInput) Read(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.buf.Len() == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if m.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return m.buf.Read(b)
+}
+
+func (m *memoryInput) Write(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return 0, io.ErrClosedPipe
+	}
+	m.cond.Broadcast()
+	return m.buf.Write(b)
+}
+
+func (m *memoryInput) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestScreen(t *testing.T) {
+	cases := []struct {
+		name     string
+		output   string
+		expected string
+		row, col int
+	}{
+		{"text", "hello\nworld", "hello\nworld", 1, 5},
+		{"carriage return", "50%\r100%", "100%", 0, 4},
+		{"backspace", "ab\bc", "ac", 0, 2},
+		{"erase line", "wait 50%\r\x1b[Kdone", "done", 0, 4},
+		{"cursor up", "one\ntwo\n\x1b[2Aun", "une\ntwo", 0, 2},
+		{"cursor position", "\x1b[2;3Hx", "\n  x", 1, 3},
+		{"erase display", "one\ntwo\x1b[2J\x1b[H", "", 0, 0},
+		{"wrap", "abcdefghij", "abcdefgh\nij", 1, 2},
+		{"pending wrap", "abcdefgh\r", "abcdefgh", 0, 0},
+		{"scroll", "1\n2\n3\n4", "2\n3\n4", 2, 1},
+		{"colors", "\x1b[1;31mred\x1b[0m", "red", 0, 3},
+		{"title", "\x1b]0;title\ahi", "hi", 0, 2},
+		{"unicode", "héllo ✓", "héllo ✓", 0, 7},
+		{"save and restore", "ab\x1b7\ncd\x1b8e", "abe\ncd", 0, 3},
+		{"delete characters", "abcdef\r\x1b[2P", "cdef", 0, 0},
+	}
+	for _, c := range cases {
+		screen := NewScreen(3, 8)
+		fmt.Fprint(screen, c.output)
+
+		if actual := screen.String(); actual != c.expected {
+			t.Errorf("%s: expected screen %q, received %q\n", c.name, c.expected, actual)
+		}
+		if row, col := screen.Cursor(); row != c.row || col != c.col {
+			t.Errorf("%s: expected cursor at %d,%d, received %d,%d\n", c.name, c.row, c.col, row, col)
+		}
+	}
+}
+
+func TestScreenSplitRune(t *testing.T) {
+	screen := NewScreen(1, 8)
+	b := []byte("✓")
+	screen.Write(b[:1])
+	screen.Write(b[1:])
+	if actual := screen.Cell(0, 0); actual != '✓' {
+		t.Errorf("expected a rune split across writes to be decoded, received %q\n", actual)
+	}
+}
+
+type testProgressCommand struct{}
+
+func (c *testProgressCommand) Help() {}
+
+func (c *testProgressCommand) Command(ctx context.Context, args []string, s System) error {
+	for i := 1; i <= 3; i++ {
+		s.Printf("\r\x1b[Kstep %d of 3", i)
+	}
+	s.Println()
+
+	if err := ConfirmByTyping(s, "widgets"); err != nil {
+		return err
+	}
+	s.Println("deleted")
+	return nil
+}
+
+func TestScreenTestSystem(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"progress"}, nil, 5, 80)
+	go func() {
+		system.Expecter().ExpectString("to confirm: ")
+		system.Expecter().SendLine("widgets")
+	}()
+
+	if result := Main(context.Background(), &testProgressCommand{}, system); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+
+	expected := "step 3 of 3\n" +
+		`This action cannot be undone. Type "widgets" to confirm: widgets` + "\n" +
+		"deleted"
+	if actual := system.Screen().String(); actual != expected {
+		t.Errorf("expected screen:\n%s\nreceived:\n%s\n", expected, actual)
+	}
+	if row, col := system.Screen().Cursor(); row != 3 || col != 0 {
+		t.Errorf("expected cursor at 3,0, received %d,%d\n", row, col)
+	}
+	ExpectMatch(t, *output.STDOUT, `step 1 of 3`)
+	ExpectScreen(t, system, "testdata/progress.golden")
+}
+
+func TestScreenTestSystemPassword(t *testing.T) {
+	system, _ := NewScreenTestSystem(t, []string{"test"}, nil, 2, 20)
+	system.Print("Password: ")
+	system.Expecter().SendLine("hunter2")
+
+	password, err := system.ReadPassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "hunter2" {
+		t.Errorf("expected the password sent, received %q\n", password)
+	}
+	if actual := system.Screen().String(); actual != "Password:" {
+		t.Errorf("expected the password not to be echoed, received %q\n", actual)
+	}
+}
+
+func TestScreenResize(t *testing.T) {
+	screen := NewScreen(3, 8)
+	fmt.Fprint(screen, "abcdefgh\nij")
+
+	screen.Resize(2, 4)
+	if rows, cols := screen.Size(); rows != 2 || cols != 4 {
+		t.Errorf("expected a 2x4 screen, received %dx%d\n", rows, cols)
+	}
+	if actual := screen.String(); actual != "abcd\nij" {
+		t.Errorf("expected the contents to be cropped, received %q\n", actual)
+	}
+	if row, col := screen.Cursor(); row != 1 || col != 2 {
+		t.Errorf("expected the cursor at 1,2, received %d,%d\n", row, col)
+	}
+}
+
+type testResizeCommand struct{}
+
+func (c *testResizeCommand) Help() {}
+
+func (c *testResizeCommand) Command(ctx context.Context, args []string, s System) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resized := s.NotifyResize(ctx)
+
+	width, height, err := s.TermSize()
+	if err != nil {
+		return err
+	}
+	s.Printf("%dx%d\n", width, height)
+
+	<-resized
+	if width, height, err = s.TermSize(); err != nil {
+		return err
+	}
+	s.Printf("%dx%d\n", width, height)
+	return nil
+}
+
+func TestTermSize(t *testing.T) {
+	system, output := NewScreenTestSystem(t, []string{"resize"}, nil, 5, 40)
+	go func() {
+		system.Expecter().ExpectString("40x5")
+		system.Resize(60, 10)
+	}()
+
+	if result := Main(context.Background(), &testResizeCommand{}, system); result != 0 {
+		t.Fatalf("command did not return a 0 status\n%s", output.STDERR)
+	}
+	if actual := system.Screen().String(); actual != "40x5\n60x10" {
+		t.Errorf("expected the new size to be printed, received %q\n", actual)
+	}
+	if rows, cols := system.Screen().Size(); rows != 10 || cols != 60 {
+		t.Errorf("expected the screen to be resized to 10x60, received %dx%d\n", rows, cols)
+	}
+	if system.TerminalWidth() != 60 {
+		t.Errorf("expected TerminalWidth to follow the size, received %d\n", system.TerminalWidth())
+	}
+
+	memory, _ := NewMemorySystem(MemorySystemOptions{})
+	if _, _, err := memory.TermSize(); err != ErrNotTerminal {
+		t.Errorf("expected ErrNotTerminal without a terminal, received %v\n", err)
+	}
+}
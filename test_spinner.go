@@ -0,0 +1,66 @@
+package cli
+
+import "sync"
+
+// SpinnerRecord is the state of a Spinner returned by a TestSystem
+type SpinnerRecord struct {
+	// Labels are the labels the spinner was given, in order
+	Labels []string
+
+	Started bool
+	Stopped bool
+}
+
+// testSpinner is a Spinner which records its use rather than animating, so
+// that tests needn't match output redrawn over time
+type testSpinner struct {
+	mu     sync.Mutex
+	record SpinnerRecord
+}
+
+// Spinner returns a Spinner which records its use, returned by Spinners,
+// rather than animating
+func (ts *TestSystem) Spinner(label string) Spinner {
+	p := &testSpinner{record: SpinnerRecord{Labels: []string{label}}}
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	ts.fakes.spinners = append(ts.fakes.spinners, p)
+	return p
+}
+
+// Spinners returns the state of the spinners returned by Spinner, in the
+// order they were created
+func (ts *TestSystem) Spinners() []SpinnerRecord {
+	ts.fakes.Lock()
+	defer ts.fakes.Unlock()
+	records := make([]SpinnerRecord, len(ts.fakes.spinners))
+	for i, p := range ts.fakes.spinners {
+		p.mu.Lock()
+		records[i] = p.record
+		records[i].Labels = append([]string(nil), p.record.Labels...)
+		p.mu.Unlock()
+	}
+	return records
+}
+
+func (p *testSpinner) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.record.Started = true
+}
+
+func (p *testSpinner) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.record.Started {
+		p.record.Stopped = true
+	}
+}
+
+func (p *testSpinner) UpdateLabel(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if label != p.record.Labels[len(p.record.Labels)-1] {
+		p.record.Labels = append(p.record.Labels, label)
+	}
+}
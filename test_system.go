@@ -2,17 +2,25 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
 	"testing"
-	"time"
-
-	"github.com/Netflix/go-expect"
-	"golang.org/x/crypto/ssh/terminal"
 )
 
 type TestSystem struct {
 	*BaseSystem
-	Console *expect.Console
+	testPTY
+
+	console testConsole
+	screen  *Screen
+	output  *TestOutput
+	fakes   execFakes
+	temp    *testTemp
 }
 
 type TestOutput struct {
@@ -20,40 +28,180 @@ type TestOutput struct {
 	STDERR *bytes.Buffer
 }
 
-func NewTestSystem(
-	t *testing.T, arguments []string, environment map[string]string,
+// NewScreenTestSystem returns a System attached to an in-memory terminal of
+// the given size rather than a pseudoterminal, so it works where PTYs aren't
+// available, such as on Windows. Output is rendered on the Screen returned by
+// the System's Screen method, which tests may use to assert on what the user
+// would see. STDOUT captures the bytes written, which unlike with
+// NewTestSystem aren't translated by a terminal driver.
+func NewScreenTestSystem(
+	t *testing.T, arguments []string, environment map[string]string, rows, cols int,
 ) (*TestSystem, *TestOutput) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
 
-	console, err := expect.NewTestConsole(t,
-		expect.WithDefaultTimeout(5*time.Second),
-		expect.WithStdout(stdout),
-	)
-	if err != nil {
-		t.Fatal(err)
-	}
+	console := newScreenConsole(rows, cols, io.MultiWriter(stdout, newTestLog(t)))
+	t.Cleanup(func() {
+		console.Close()
+	})
 
 	if environment == nil {
 		environment = map[string]string{}
 	}
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
 
+	output := &TestOutput{stdout, stderr}
 	return &TestSystem{
 		BaseSystem: &BaseSystem{
-			In:          console.Tty(),
-			Out:         console.Tty(),
+			In:          console,
+			Out:         console,
+			Err:         stderr,
 			Logger:      log.New(stderr, "", log.LstdFlags),
 			Environment: environment,
 			Arguments:   arguments,
+			Dir:         dir,
+			User:        testUser(environment),
+			Width:       cols,
+			Height:      rows,
+			Random:      rand.New(rand.NewSource(1)),
 		},
-		Console: console,
-	}, &TestOutput{stdout, stderr}
+		console: console,
+		screen:  console.screen,
+		output:  output,
+		temp:    newTestTemp(t),
+	}, output
+}
+
+// testUser returns the fake user a TestSystem reports
+func testUser(environment map[string]string) *UserInfo {
+	home := environment["HOME"]
+	if len(home) == 0 {
+		home = environment["USERPROFILE"]
+	}
+	return &UserInfo{
+		Username: "tester",
+		HomeDir:  home,
+		Hostname: "testhost",
+		UID:      1000,
+		GID:      1000,
+	}
+}
+
+// Resize changes the size of the System's terminal, as a user resizing their
+// window would, and notifies commands listening with NotifyResize. The
+// Screen of a System from NewScreenTestSystem is resized to match.
+func (ts *TestSystem) Resize(width, height int) {
+	ts.size.Lock()
+	ts.Width, ts.Height = width, height
+	ts.size.Unlock()
+
+	if ts.screen != nil {
+		ts.screen.Resize(height, width)
+	}
+	ts.resized()
+}
+
+// Seed reseeds the System's random source, which is seeded with 1 to begin
+// with, so that a test may check the IDs a command generates from another
+// seed
+func (ts *TestSystem) Seed(seed int64) {
+	ts.random.Lock()
+	ts.Random = rand.New(rand.NewSource(seed))
+	ts.random.Unlock()
+}
+
+// Notify returns a channel which receives the given signals when they are
+// sent with Signal, until ctx is done. Signals sent to the test process
+// aren't caught.
+func (ts *TestSystem) Notify(ctx context.Context, signals ...os.Signal) <-chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	ts.subscribe(ctx, ch, signals)
+	return ch
+}
+
+// Signal sends sig to the command, as the user or a supervisor would, by
+// delivering it to the channels returned by Notify. It reports whether the
+// command was listening for sig.
+func (ts *TestSystem) Signal(sig os.Signal) bool {
+	return ts.signalled(sig)
 }
 
+// Expecter returns the terminal the System is attached to, for sending it
+// input and waiting for output, whether it is the pseudoterminal of a System
+// from NewTestSystem or the emulated terminal of one from NewScreenTestSystem
+func (ts *TestSystem) Expecter() Expecter {
+	return ts.console
+}
+
+// Screen returns the emulated terminal of a System from NewScreenTestSystem,
+// or nil if the System is attached to a pseudoterminal
+func (ts *TestSystem) Screen() *Screen {
+	return ts.screen
+}
+
+// Interactive reports that input is attached to a terminal, which for an
+// emulated terminal is always the case
+func (ts *TestSystem) Interactive() bool {
+	return ts.screen != nil || ts.BaseSystem.Interactive()
+}
+
+// Capture collects the console's output until the returned function is
+// called, which waits for everything written so far to be captured. Unlike
+// closing the Tty and calling ExpectEOF, it leaves the console usable, so
+// that it can be reused by another test.
+func (ts *TestSystem) Capture() (wait func()) {
+	return ts.console.capture(ts.output.STDOUT)
+}
+
+// SetEcho turns the console's echoing of input on or off
+func (ts *TestSystem) SetEcho(on bool) error {
+	return ts.console.setEcho(on)
+}
+
+// ReadLineNoEcho reads a line from the console without echoing it
+func (ts *TestSystem) ReadLineNoEcho() (string, error) {
+	return readLineNoEcho(ts.BaseSystem, ts.SetEcho)
+}
+
+// ReadPassword reads a line from the console without echoing it, or
+// echoing asterisks if MaskPasswords is set
 func (ts *TestSystem) ReadPassword() (string, error) {
-	cloaked, err := terminal.ReadPassword(int(ts.Console.Tty().Fd()))
-	if err != nil {
-		return "", err
+	return ts.console.readPassword(ts.MaskPasswords)
+}
+
+// ExternalDiff presents differences using $DIFFTOOL from the test environment
+// or the built-in unified diff, ignoring the user's git configuration. The
+// tool is run with Exec, so it may be faked.
+func (ts *TestSystem) ExternalDiff(old, new []byte) error {
+	return ts.externalDiff(old, new, false, ts.Exec, ts.TempDir())
+}
+
+// testLog writes the console's output to the test log a line at a time
+type testLog struct {
+	t    *testing.T
+	mu   sync.Mutex
+	line []byte
+}
+
+func newTestLog(t *testing.T) *testLog {
+	return &testLog{t: t}
+}
+
+func (l *testLog) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.line = append(l.line, b...)
+	for {
+		i := bytes.IndexByte(l.line, '\n')
+		if i < 0 {
+			break
+		}
+		l.t.Log(strings.TrimSuffix(string(l.line[:i]), "\r"))
+		l.line = l.line[i+1:]
 	}
-	return string(cloaked), nil
+	return len(b), nil
 }
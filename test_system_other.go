@@ -0,0 +1,71 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/Netflix/go-expect"
+)
+
+// testPTY holds the pseudoterminal of a TestSystem
+type testPTY struct {
+	// Console is the pseudoterminal of a System from NewTestSystem, through
+	// which tests drive the command. It is nil for a System from
+	// NewScreenTestSystem; Expecter returns the terminal of either.
+	Console *expect.Console
+}
+
+// NewTestSystem returns a System attached to a pseudoterminal, along with
+// the buffers its output is captured in. The pseudoterminal is leased from
+// DefaultConsolePool and returned to it when the test completes. The System
+// starts in the test's working directory, and changing it with Chdir doesn't
+// affect other tests. It reports a fake user, whose home is HOME from the
+// environment; set User to report another.
+func NewTestSystem(
+	t *testing.T, arguments []string, environment map[string]string,
+) (*TestSystem, *TestOutput) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	console, err := DefaultConsolePool.get(io.MultiWriter(stdout, newTestLog(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		DefaultConsolePool.put(console)
+	})
+
+	if environment == nil {
+		environment = map[string]string{}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := &TestOutput{stdout, stderr}
+	return &TestSystem{
+		BaseSystem: &BaseSystem{
+			In:          console.Tty(),
+			Out:         console.Tty(),
+			Err:         stderr,
+			Logger:      log.New(stderr, "", log.LstdFlags),
+			Environment: environment,
+			Arguments:   arguments,
+			Dir:         dir,
+			User:        testUser(environment),
+			Random:      rand.New(rand.NewSource(1)),
+		},
+		testPTY: testPTY{Console: console.Console},
+		console: console,
+		output:  output,
+		temp:    newTestTemp(t),
+	}, output
+}
@@ -0,0 +1,22 @@
+package cli
+
+import "testing"
+
+// testPTY holds the terminal of a TestSystem. Pseudoterminals aren't
+// available on Windows, so Console is the in-memory terminal behind
+// NewScreenTestSystem, rather than an *expect.Console as elsewhere.
+type testPTY struct {
+	Console Expecter
+}
+
+// NewTestSystem returns a System attached to an in-memory terminal of 24
+// rows by 80 columns, along with the buffers its output is captured in,
+// since pseudoterminals aren't available on Windows. It is otherwise
+// equivalent to NewScreenTestSystem.
+func NewTestSystem(
+	t *testing.T, arguments []string, environment map[string]string,
+) (*TestSystem, *TestOutput) {
+	ts, output := NewScreenTestSystem(t, arguments, environment, 24, 80)
+	ts.Console = ts.console
+	return ts, output
+}
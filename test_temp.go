@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TempDir returns a directory of the test's own within the System's
+// temporary directory, in which CreateTemp creates files. The directory is
+// removed when the test completes, and the test fails if the command left
+// anything within it, so that leaked temporary files are caught.
+func (s *TestSystem) TempDir() string {
+	return s.temp.dir(tempRoot(s))
+}
+
+func (s *TestSystem) CreateTemp(pattern string) (*os.File, error) {
+	return ioutil.TempFile(s.TempDir(), pattern)
+}
+
+// testTemp is the temporary directory of a TestSystem, created when it is
+// first asked for
+type testTemp struct {
+	t    testReporter
+	once sync.Once
+	path string
+}
+
+// testReporter is the part of testing.T a testTemp reports through
+type testReporter interface {
+	Errorf(format string, args ...interface{})
+	Cleanup(func())
+}
+
+func newTestTemp(t testReporter) *testTemp {
+	d := &testTemp{t: t}
+	t.Cleanup(d.check)
+	return d
+}
+
+// dir returns the directory, creating it within root the first time it is
+// asked for
+func (d *testTemp) dir(root string) string {
+	d.once.Do(func() {
+		path, err := ioutil.TempDir(root, "test-")
+		if err != nil {
+			d.t.Errorf("unable to create a temporary directory: %s\n", err)
+			return
+		}
+		d.path = path
+	})
+	if len(d.path) == 0 {
+		return root
+	}
+	return d.path
+}
+
+// check fails the test if anything was left within the directory, then
+// removes it
+func (d *testTemp) check() {
+	if len(d.path) == 0 {
+		return
+	}
+	defer os.RemoveAll(d.path)
+
+	infos, err := ioutil.ReadDir(d.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			d.t.Errorf("unable to check for leaked temporary files: %s\n", err)
+		}
+		return
+	}
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	if len(names) > 0 {
+		d.t.Errorf("temporary files were not removed: %s\n", strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeReporter records what a testTemp reports, so that a leak may be
+// detected without failing the test
+type fakeReporter struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (r *fakeReporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeReporter) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func TestTestSystemTempDir(t *testing.T) {
+	sandbox := Sandbox(t)
+	system, _ := sandbox.System([]string{"testtemp"})
+
+	dir := system.TempDir()
+	if filepath.Dir(dir) != sandbox.TempDir {
+		t.Errorf("expected a directory within %s, received %s\n", sandbox.TempDir, dir)
+	}
+	f, err := system.CreateTemp("scratch-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if filepath.Dir(f.Name()) != dir || !strings.HasSuffix(f.Name(), ".txt") {
+		t.Errorf("expected a .txt file within %s, received %s\n", dir, f.Name())
+	}
+	os.Remove(f.Name())
+}
+
+func TestTestSystemTempLeak(t *testing.T) {
+	sandbox := Sandbox(t)
+	reporter := &fakeReporter{}
+	temp := newTestTemp(reporter)
+
+	dir := temp.dir(sandbox.TempDir)
+	f, err := os.Create(filepath.Join(dir, "leaked"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	for _, fn := range reporter.cleanups {
+		fn()
+	}
+	if len(reporter.errors) != 1 || !strings.Contains(reporter.errors[0], "leaked") {
+		t.Errorf("expected the leaked file to be reported, received %q\n", reporter.errors)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, received %v\n", dir, err)
+	}
+}
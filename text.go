@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Wrap wraps each line of text at spaces so that, prefixed with indent, it
+// fits within width columns, formatting prose as help is formatted. Blank
+// lines separate paragraphs, and lines which are already indented, such as
+// examples, are left as they are. Words too long to fit are placed on their
+// own line. Pass the System's TerminalWidth to fit the user's terminal.
+func Wrap(text string, width int, indent string) string {
+	width -= utf8.RuneCountInString(indent)
+	if width < minWrapWidth {
+		width = minWrapWidth
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) == 0 || line[0] == ' ' || line[0] == '\t' {
+			lines = append(lines, line)
+			continue
+		}
+		lines = append(lines, wrapText(line, width)...)
+	}
+	return Indent(strings.Join(lines, "\n"), indent)
+}
+
+// wrapText breaks text into lines of at most width visible runes at spaces.
+// Words longer than width are placed on their own line.
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	length := 0
+	for _, word := range strings.Fields(text) {
+		n := visibleLength(word)
+		if length > 0 && length+1+n > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			length = 0
+		}
+		if length > 0 {
+			line.WriteByte(' ')
+			length++
+		}
+		line.WriteString(word)
+		length += n
+	}
+	if length > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// visibleLength returns the number of runes of s which are displayed,
+// ignoring terminal control sequences such as colors
+func visibleLength(s string) int {
+	if strings.IndexByte(s, 0x1b) >= 0 {
+		s = escapeSequence.ReplaceAllString(s, "")
+	}
+	return utf8.RuneCountInString(s)
+}
+
+// Indent prefixes each line of text which isn't blank with prefix
+func Indent(text, prefix string) string {
+	if len(prefix) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if len(strings.TrimSpace(line)) > 0 {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Dedent removes the leading whitespace common to every line of text which
+// isn't blank, so that text written in an indented raw string literal may be
+// printed as it reads in the source. Blank lines are emptied.
+func Dedent(text string) string {
+	lines := strings.Split(text, "\n")
+
+	var margin string
+	first := true
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if len(trimmed) == 0 {
+			continue
+		}
+		leading := line[:len(line)-len(trimmed)]
+		if first {
+			margin, first = leading, false
+			continue
+		}
+		n := 0
+		for n < len(margin) && n < len(leading) && margin[n] == leading[n] {
+			n++
+		}
+		margin = margin[:n]
+	}
+
+	for i, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = line[len(margin):]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,36 @@
+package cli
+
+import "testing"
+
+func TestWrap(t *testing.T) {
+	text := "Widgets are synced from the upstream catalog every hour.\n\n" +
+		"  widgets sync --all\n" +
+		"Run with --dry-run to preview."
+	expected := "  Widgets are synced from the\n" +
+		"  upstream catalog every hour.\n" +
+		"\n" +
+		"    widgets sync --all\n" +
+		"  Run with --dry-run to\n" +
+		"  preview."
+	if wrapped := Wrap(text, 30, "  "); wrapped != expected {
+		t.Errorf("expected:\n%s\nreceived:\n%s", expected, wrapped)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	if indented := Indent("one\n\ntwo\n", "> "); indented != "> one\n\n> two\n" {
+		t.Errorf("unexpected indentation: %q", indented)
+	}
+}
+
+func TestDedent(t *testing.T) {
+	text := `
+		Next steps:
+		  1. Commit the generated files
+		  2. Run widgets sync
+	`
+	expected := "\nNext steps:\n  1. Commit the generated files\n  2. Run widgets sync\n"
+	if dedented := Dedent(text); dedented != expected {
+		t.Errorf("expected %q, received %q", expected, dedented)
+	}
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// usageFile is the name of the file in the state directory which counts the
+// uses of each command
+const usageFile = "usage.json"
+
+// WithUsageTelemetry counts the runs of each command of app, and the flags
+// each run was given, in its state directory, and installs a hidden
+// `usage-report` subcommand summarizing the counts, so that the maintainers of
+// a large CLI may find the commands and flags nobody uses before deprecating
+// them. Only the names of commands and flags are counted, never arguments or
+// values, and nothing leaves the machine. Counting is disabled while
+// DO_NOT_TRACK is set.
+func WithUsageTelemetry(app string) Option {
+	return func(c *config) {
+		c.usage = app
+		c.subcommands["usage-report"] = &usageReportCommand{app: app}
+	}
+}
+
+// usageCounts are the uses of each command, keyed by path, which is empty for
+// the root command
+type usageCounts struct {
+	Since    time.Time                 `json:"since"`
+	Commands map[string]int            `json:"commands"`
+	Flags    map[string]map[string]int `json:"flags,omitempty"`
+}
+
+// recordUsage counts a run of the command at path, given the flags set in f
+// other than those of the framework
+func recordUsage(sys System, app, path string, f *flag.FlagSet, framework frameworkFlags) error {
+	if len(sys.Getenv("DO_NOT_TRACK")) > 0 {
+		return nil
+	}
+	counts, err := loadUsage(sys, app)
+	if err != nil {
+		return err
+	}
+	if counts.Since.IsZero() {
+		counts.Since = time.Now().UTC().Truncate(time.Second)
+	}
+	counts.Commands[path]++
+	f.Visit(func(fl *flag.Flag) {
+		if _, ok := framework[fl.Name]; ok {
+			return
+		}
+		if counts.Flags[path] == nil {
+			counts.Flags[path] = map[string]int{}
+		}
+		counts.Flags[path][fl.Name]++
+	})
+
+	dir, err := stateDir(sys, app)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(counts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := sys.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return sys.WriteFile(filepath.Join(dir, usageFile), append(data, '\n'), 0644)
+}
+
+// loadUsage returns the uses counted so far, which are none if the file of
+// counts doesn't exist
+func loadUsage(sys System, app string) (*usageCounts, error) {
+	counts := &usageCounts{Commands: map[string]int{}, Flags: map[string]map[string]int{}}
+	dir, err := stateDir(sys, app)
+	if err != nil {
+		return nil, err
+	}
+	data, err := sys.ReadFile(filepath.Join(dir, usageFile))
+	if os.IsNotExist(err) {
+		return counts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, counts); err != nil {
+		return nil, err
+	}
+	if counts.Commands == nil {
+		counts.Commands = map[string]int{}
+	}
+	if counts.Flags == nil {
+		counts.Flags = map[string]map[string]int{}
+	}
+	return counts, nil
+}
+
+// usageReportCommand prints the uses counted by WithUsageTelemetry
+type usageReportCommand struct {
+	DefaultHelp
+
+	app    string
+	root   Command
+	cfg    *config
+	unused bool
+}
+
+// Synopsis describes the usage report command
+func (c *usageReportCommand) Synopsis() string {
+	return "Summarize which commands and flags are used"
+}
+
+// Hidden keeps the usage report, which is for maintainers, out of help
+func (c *usageReportCommand) Hidden() bool {
+	return true
+}
+
+// Flags defines the flags of the usage report command
+func (c *usageReportCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.unused, "unused", false, "list only the commands and flags which haven't been used")
+}
+
+// bindTree is called by Main with the command tree being run
+func (c *usageReportCommand) bindTree(root Command, cfg *config) {
+	c.root, c.cfg = root, cfg
+}
+
+// Command prints a row for each runnable command and each of its flags with
+// the number of times it was used, including those never used
+func (c *usageReportCommand) Command(ctx context.Context, args []string, sys System) error {
+	counts, err := loadUsage(sys, c.app)
+	if err != nil {
+		return err
+	}
+
+	program := "program"
+	if arguments := sys.Args(); len(arguments) > 0 {
+		program = programName(arguments[0])
+	}
+	var entries []Entry
+	if _, ok := c.root.(Action); ok {
+		entries = append(entries, Entry{Command: c.root, Runnable: true})
+	}
+	if b, ok := unwrap(c.root).(HasSubcommands); ok {
+		entries = append(entries, b.Subcommands().Entries("")...)
+	}
+
+	table := NewTable("COMMAND", "FLAG", "USES")
+	table.Columns[2].Align = AlignRight
+	for _, e := range entries {
+		if !e.Runnable {
+			continue
+		}
+		command := strings.TrimSpace(program + " " + e.Path)
+		if uses := counts.Commands[e.Path]; !c.unused || uses == 0 {
+			table.Append(command, "", strconv.Itoa(uses))
+		}
+
+		var names []string
+		if b, ok := unwrap(e.Command).(HasFlags); ok {
+			f := flag.NewFlagSet(e.Path, flag.ContinueOnError)
+			b.Flags(f)
+			f.VisitAll(func(fl *flag.Flag) {
+				names = append(names, fl.Name)
+			})
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if uses := counts.Flags[e.Path][name]; !c.unused || uses == 0 {
+				table.Append(command, "--"+name, strconv.Itoa(uses))
+			}
+		}
+	}
+
+	if !counts.Since.IsZero() {
+		sys.Println(Localize(sys, "Uses counted since %s", counts.Since.Format("2006-01-02")))
+	} else {
+		sys.Println(Localize(sys, "No uses have been counted"))
+	}
+	return table.Print(sys)
+}
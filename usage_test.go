@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type testUsageCommand struct {
+	DefaultHelp
+	force, dryRun bool
+}
+
+func (c *testUsageCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.force, "force", false, "deploy even if checks fail")
+	f.BoolVar(&c.dryRun, "dry-run", false, "show what would be deployed")
+}
+
+func (c *testUsageCommand) Command(ctx context.Context, args []string, sys System) error {
+	return nil
+}
+
+type testUsageRoot struct {
+	DefaultHelp
+}
+
+func (c *testUsageRoot) Subcommands() CLI {
+	return CLI{
+		"deploy": &testUsageCommand{},
+		"status": &testRegisteredCommand{},
+	}
+}
+
+func TestUsageTelemetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(env map[string]string, args ...string) string {
+		if env == nil {
+			env = map[string]string{}
+		}
+		env["XDG_STATE_HOME"] = dir
+		system, output := NewMemorySystem(MemorySystemOptions{
+			Arguments:   append([]string{"site"}, args...),
+			Environment: env,
+		})
+		ExpectExitCode(t, Main(context.Background(), &testUsageRoot{}, system, WithUsageTelemetry("site"), WithNoColor()), ExitOK)
+		return output.Stdout.String()
+	}
+
+	report := run(nil, "usage-report")
+	if !strings.Contains(report, "No uses have been counted") {
+		t.Errorf("expected no uses to be counted\n%s", report)
+	}
+
+	run(nil, "deploy", "--force", "--no-color")
+	run(nil, "deploy", "--force", "production")
+	run(nil, "deploy")
+	run(map[string]string{"DO_NOT_TRACK": "1"}, "deploy", "--dry-run")
+
+	report = run(nil, "usage-report")
+	for _, re := range []string{
+		`^Uses counted since \d{4}-\d\d-\d\d\n`,
+		`(?m)^COMMAND +FLAG +USES$`,
+		`(?m)^site deploy +3$`,
+		`(?m)^site deploy +--dry-run +0$`,
+		`(?m)^site deploy +--force +2$`,
+		`(?m)^site status +0$`,
+	} {
+		if !regexp.MustCompile(re).MatchString(report) {
+			t.Errorf("expected report to match %s\n%s", re, report)
+		}
+	}
+	if strings.Contains(report, "no-color") || strings.Contains(report, "usage-report") {
+		t.Errorf("expected framework flags and commands to be left out\n%s", report)
+	}
+
+	report = run(nil, "usage-report", "--unused")
+	if strings.Contains(report, "--force") || !strings.Contains(report, "--dry-run") || !strings.Contains(report, "site status") {
+		t.Errorf("expected only unused commands and flags\n%s", report)
+	}
+
+	system, output := NewMemorySystem(MemorySystemOptions{Arguments: []string{"site", "help"}})
+	Main(context.Background(), &testUsageRoot{}, system, WithUsageTelemetry("site"))
+	if strings.Contains(output.Stdout.String()+output.Stderr.String(), "usage-report") {
+		t.Error("expected usage-report to be hidden from help")
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"runtime"
+)
+
+// UserInfo describes the user running a command and the host they are
+// running it on, for Systems which report them rather than the process's
+type UserInfo struct {
+	Username string
+	HomeDir  string
+	Hostname string
+	UID      int
+	GID      int
+}
+
+// errNoHome is returned by HomeDir when the home directory isn't known
+var errNoHome = errors.New("Unable to determine home directory")
+
+// Username returns the login name of the user running the command
+func (s *BaseSystem) Username() (string, error) {
+	if s.User != nil {
+		return s.User.Username, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// HomeDir returns the home directory of the user running the command: HOME,
+// or USERPROFILE on Windows, from the System's environment, and otherwise
+// the directory the operating system records for them
+func (s *BaseSystem) HomeDir() (string, error) {
+	if s.User != nil {
+		if len(s.User.HomeDir) == 0 {
+			return "", errNoHome
+		}
+		return s.User.HomeDir, nil
+	}
+
+	variable := "HOME"
+	if runtime.GOOS == "windows" {
+		variable = "USERPROFILE"
+	}
+	if home := s.Getenv(variable); len(home) > 0 {
+		return home, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	if len(u.HomeDir) == 0 {
+		return "", errNoHome
+	}
+	return u.HomeDir, nil
+}
+
+// Hostname returns the name of the host the command is running on
+func (s *BaseSystem) Hostname() (string, error) {
+	if s.User != nil {
+		return s.User.Hostname, nil
+	}
+	return os.Hostname()
+}
+
+// UID returns the numeric user ID of the user running the command, or -1 on
+// Windows
+func (s *BaseSystem) UID() int {
+	if s.User != nil {
+		return s.User.UID
+	}
+	return os.Getuid()
+}
+
+// GID returns the numeric group ID of the user running the command, or -1 on
+// Windows
+func (s *BaseSystem) GID() int {
+	if s.User != nil {
+		return s.User.GID
+	}
+	return os.Getgid()
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTestSystemUser(t *testing.T) {
+	sandbox := Sandbox(t)
+	system, _ := sandbox.System([]string{"test"})
+
+	if home, err := system.HomeDir(); err != nil || home != sandbox.Home {
+		t.Errorf("expected the sandbox's home %s, received %s (%v)\n", sandbox.Home, home, err)
+	}
+	if name, _ := system.Username(); name != "tester" {
+		t.Errorf("expected the fake user, received %s\n", name)
+	}
+	if host, _ := system.Hostname(); host != "testhost" {
+		t.Errorf("expected the fake host, received %s\n", host)
+	}
+
+	system.User = &UserInfo{Username: "root", Hostname: "build", UID: 0, GID: 0}
+	if name, _ := system.Username(); name != "root" || system.UID() != 0 {
+		t.Errorf("expected the user to be faked as root, received %s (%d)\n", name, system.UID())
+	}
+	if _, err := system.HomeDir(); err == nil {
+		t.Error("expected an error for a user without a home directory")
+	}
+}
+
+func TestProcessUser(t *testing.T) {
+	system, _ := NewMemorySystem(MemorySystemOptions{Environment: map[string]string{
+		"HOME":        "/home/someone",
+		"USERPROFILE": "/home/someone",
+	}})
+
+	if home, err := system.HomeDir(); err != nil || home != "/home/someone" {
+		t.Errorf("expected HOME from the environment, received %s (%v)\n", home, err)
+	}
+	if system.UID() != os.Getuid() || system.GID() != os.Getgid() {
+		t.Errorf("expected the process's IDs, received %d and %d\n", system.UID(), system.GID())
+	}
+	expected, _ := os.Hostname()
+	if host, _ := system.Hostname(); host != expected {
+		t.Errorf("expected host %s, received %s\n", expected, host)
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// VersionInfo describes the build of a CLI application. Fields are typically
+// set at build time using ldflags, e.g.
+//
+//	go build -ldflags "-X main.version=1.2.3 -X main.commit=$(git rev-parse HEAD)"
+type VersionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+// String returns a human-readable representation of the VersionInfo
+func (v VersionInfo) String() string {
+	var details []string
+	if len(v.Commit) > 0 {
+		details = append(details, "commit "+v.Commit)
+	}
+	if len(v.Date) > 0 {
+		details = append(details, "built "+v.Date)
+	}
+
+	if len(details) == 0 {
+		return v.Version
+	}
+	return fmt.Sprintf("%s (%s)", v.Version, strings.Join(details, ", "))
+}
+
+// ReadBuildInfo fills in any empty fields of the VersionInfo using the build
+// information embedded in the binary by the Go toolchain
+func (v VersionInfo) ReadBuildInfo() VersionInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+
+	if len(v.Version) == 0 {
+		v.Version = info.Main.Version
+	}
+
+	return readVCSInfo(v, info)
+}
+
+// WithVersion installs a `version` subcommand and a `--version` flag which
+// print the given VersionInfo. Empty fields are filled in from the build
+// information embedded in the binary.
+func WithVersion(v VersionInfo) Option {
+	return func(c *config) {
+		info := v.ReadBuildInfo()
+		c.version = &info
+		c.subcommands["version"] = &VersionCommand{Info: info}
+		c.flags = append(c.flags, func(f *flag.FlagSet) {
+			f.Bool("version", false, "print version information and exit")
+		})
+	}
+}
+
+// VersionCommand is a subcommand which prints version information
+type VersionCommand struct {
+	DefaultHelp
+
+	Info VersionInfo
+
+	json        bool
+	checkCompat bool
+	compat      MinimumVersion
+}
+
+// Synopsis describes the version command
+func (c *VersionCommand) Synopsis() string {
+	return "Print version information"
+}
+
+// Flags defines the flags accepted by the version command
+func (c *VersionCommand) Flags(f *flag.FlagSet) {
+	f.BoolVar(&c.json, "json", false, "print version information as JSON")
+	if c.compat != nil {
+		f.BoolVar(&c.checkCompat, "check-compat", false, "check that the server supports this version")
+	}
+}
+
+// Command prints version information and, given `--check-compat`, checks
+// that the server supports this version
+func (c *VersionCommand) Command(ctx context.Context, args []string, sys System) error {
+	if err := printVersion(sys, c.Info, c.json); err != nil {
+		return err
+	}
+	if c.checkCompat {
+		return checkCompat(ctx, sys, c.Info.Version, c.compat, c.json)
+	}
+	return nil
+}
+
+func printVersion(sys System, info VersionInfo, asJSON bool) error {
+	if asJSON {
+		b, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+		_, err = sys.Println(string(b))
+		return err
+	}
+
+	var name string
+	if arguments := sys.Args(); len(arguments) > 0 {
+		name = programName(arguments[0])
+	}
+	_, err := sys.Printf("%s version %s\n", name, info)
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !go1.18
+// +build !go1.18
+
+package cli
+
+import "runtime/debug"
+
+// readVCSInfo returns v unchanged, since toolchains before Go 1.18 don't
+// embed version control information
+func readVCSInfo(v VersionInfo, info *debug.BuildInfo) VersionInfo {
+	return v
+}
@@ -0,0 +1,24 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+import "runtime/debug"
+
+// readVCSInfo fills in the commit and date of v, if empty, from the version
+// control information the toolchain embeds from Go 1.18
+func readVCSInfo(v VersionInfo, info *debug.BuildInfo) VersionInfo {
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if len(v.Commit) == 0 {
+				v.Commit = s.Value
+			}
+		case "vcs.time":
+			if len(v.Date) == 0 {
+				v.Date = s.Value
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,329 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchOption configures WatchPaths
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	debounce time.Duration
+	interval time.Duration
+
+	// ready is called once the initial snapshot has been taken
+	ready func()
+}
+
+// WatchDebounce sets how long the watched files must be left unchanged before
+// fn is called, so that a burst of writes (e.g. from an editor or a checkout)
+// produces a single call. The default is 100ms.
+func WatchDebounce(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.debounce = d
+	}
+}
+
+// WatchInterval sets how often files are checked on platforms without a
+// native notification backend. The default is 500ms.
+func WatchInterval(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.interval = d
+	}
+}
+
+// WatchFunc is called by WatchPaths with the paths of the files which were
+// created, modified, or removed, in lexical order
+type WatchFunc func(changed []string) error
+
+// watcher wakes the watch loop when files beneath the added directories may
+// have changed
+type watcher interface {
+	add(dir string) error
+	events() <-chan struct{}
+	close() error
+
+	// dropped returns the directories which are no longer watched, because
+	// they were removed, since it was last called
+	dropped() []string
+}
+
+// WatchPaths calls fn whenever files matching patterns change, until ctx is
+// done. Patterns are file path globs in which `**` matches any number of
+// directories; a pattern naming a directory matches everything beneath it,
+// and a pattern prefixed with `!` excludes the files and directories it
+// matches. Version control directories are always ignored.
+//
+// Changes are detected with inotify on Linux and by polling elsewhere. Errors
+// returned by fn are logged and watching continues, so that a failing rebuild
+// doesn't end the loop.
+func WatchPaths(ctx context.Context, sys System, patterns []string, fn WatchFunc, opts ...WatchOption) error {
+	config := &watchConfig{debounce: 100 * time.Millisecond, interval: 500 * time.Millisecond}
+	for _, o := range opts {
+		o(config)
+	}
+	if config.interval <= config.debounce {
+		config.interval = 2 * config.debounce
+	}
+
+	rules := newWatchRules(sys, patterns)
+
+	w, err := newWatcher(config.interval)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	// directories which are removed are forgotten, so that they are watched
+	// again if they are recreated
+	watched := make(map[string]bool)
+	scan := func() map[string]fileState {
+		for _, dir := range w.dropped() {
+			delete(watched, dir)
+		}
+		files, dirs := rules.scan()
+		current := make(map[string]bool, len(dirs))
+		for _, dir := range dirs {
+			if watched[dir] {
+				current[dir] = true
+			} else if err := w.add(dir); err == nil {
+				current[dir] = true
+			}
+		}
+		watched = current
+		return files
+	}
+
+	previous := scan()
+	if config.ready != nil {
+		config.ready()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.events():
+		}
+
+		timer := time.NewTimer(config.debounce)
+	settle:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-w.events():
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(config.debounce)
+			case <-timer.C:
+				break settle
+			}
+		}
+
+		current := scan()
+		changed := diffFileStates(previous, current)
+		previous = current
+
+		if len(changed) > 0 {
+			if err := fn(changed); err != nil {
+				sys.Log(err)
+			}
+		}
+	}
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    os.FileMode
+}
+
+func diffFileStates(previous, current map[string]fileState) []string {
+	var changed []string
+	for path, state := range current {
+		if p, ok := previous[path]; !ok || p != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+var watchIgnoredDirs = []string{".git", ".hg", ".svn"}
+
+type watchRules struct {
+	sys     System
+	include []string
+	exclude []string
+}
+
+func newWatchRules(sys System, patterns []string) *watchRules {
+	r := &watchRules{sys: sys}
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			r.exclude = append(r.exclude, filepath.ToSlash(filepath.Clean(p[1:])))
+		} else if len(p) > 0 {
+			r.include = append(r.include, filepath.ToSlash(filepath.Clean(p)))
+		}
+	}
+	return r
+}
+
+// scan returns the state of every matching file and the directories which
+// must be watched to notice changes to them. Roots are resolved against the
+// System's working directory, so files keep the form of the pattern which
+// matched them while the directories are absolute.
+func (r *watchRules) scan() (map[string]fileState, []string) {
+	files := make(map[string]fileState)
+	seen := make(map[string]bool)
+	var dirs []string
+
+	for _, pattern := range r.include {
+		root := filepath.FromSlash(globRoot(pattern))
+		abs, err := r.sys.Abs(root)
+		if err != nil {
+			continue
+		}
+		filepath.Walk(abs, func(full string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(abs, full)
+			if err != nil {
+				return nil
+			}
+			path := filepath.Join(root, rel)
+			name := filepath.ToSlash(path)
+
+			if info.IsDir() {
+				if containsString(watchIgnoredDirs, info.Name()) || r.excluded(name) {
+					return filepath.SkipDir
+				}
+				if !seen[full] {
+					seen[full] = true
+					dirs = append(dirs, full)
+				}
+				return nil
+			}
+
+			if !r.excluded(name) && matchWatchPattern(pattern, name) {
+				files[path] = fileState{info.ModTime(), info.Size(), info.Mode()}
+			}
+			return nil
+		})
+	}
+
+	return files, dirs
+}
+
+func (r *watchRules) excluded(name string) bool {
+	for _, pattern := range r.exclude {
+		if matchWatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRoot returns the longest leading portion of pattern which contains no
+// glob metacharacters
+func globRoot(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	for i, s := range segments {
+		if strings.ContainsAny(s, "*?[\\") {
+			if i == 0 {
+				return "."
+			}
+			root := strings.Join(segments[:i], "/")
+			if len(root) == 0 {
+				return "/"
+			}
+			return root
+		}
+	}
+	return pattern
+}
+
+// matchWatchPattern reports whether the slash-separated name matches pattern,
+// in which `**` matches any number of path segments. A pattern without
+// metacharacters also matches everything beneath it.
+func matchWatchPattern(pattern, name string) bool {
+	if !strings.ContainsAny(pattern, "*?[\\") {
+		return name == pattern || strings.HasPrefix(name, strings.TrimSuffix(pattern, "/")+"/")
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := filepath.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// pollWatcher wakes the watch loop at a fixed interval
+type pollWatcher struct {
+	ticker *time.Ticker
+	c      chan struct{}
+	done   chan struct{}
+}
+
+func newPollWatcher(interval time.Duration) *pollWatcher {
+	w := &pollWatcher{
+		ticker: time.NewTicker(interval),
+		c:      make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		for {
+			select {
+			case <-w.ticker.C:
+				select {
+				case w.c <- struct{}{}:
+				case <-w.done:
+					return
+				}
+			case <-w.done:
+				return
+			}
+		}
+	}()
+	return w
+}
+
+func (w *pollWatcher) add(dir string) error    { return nil }
+func (w *pollWatcher) events() <-chan struct{} { return w.c }
+func (w *pollWatcher) dropped() []string       { return nil }
+
+func (w *pollWatcher) close() error {
+	w.ticker.Stop()
+	close(w.done)
+	return nil
+}
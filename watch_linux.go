@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_ATTRIB | syscall.IN_DELETE_SELF
+
+// inotifyWatcher wakes the watch loop whenever inotify reports activity in a
+// watched directory
+type inotifyWatcher struct {
+	fd   int
+	file *os.File
+	c    chan struct{}
+
+	// mu guards dirs, the directory of each watch descriptor, and removed,
+	// the directories whose watches the kernel dropped because they were
+	// deleted or unmounted
+	mu      sync.Mutex
+	dirs    map[int32]string
+	removed []string
+}
+
+func newWatcher(interval time.Duration) (watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return newPollWatcher(interval), nil
+	}
+
+	w := &inotifyWatcher{
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		c:    make(chan struct{}, 1),
+		dirs: map[int32]string{},
+	}
+	go w.read()
+	return w, nil
+}
+
+func (w *inotifyWatcher) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		w.forgetIgnored(buf[:n])
+		select {
+		case w.c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// forgetIgnored records the directories of the IN_IGNORED events in buf,
+// whose watches have been removed
+func (w *inotifyWatcher) forgetIgnored(buf []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(buf) >= syscall.SizeofInotifyEvent {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[0]))
+		if event.Mask&syscall.IN_IGNORED != 0 {
+			if dir, ok := w.dirs[event.Wd]; ok {
+				delete(w.dirs, event.Wd)
+				w.removed = append(w.removed, dir)
+			}
+		}
+		buf = buf[syscall.SizeofInotifyEvent+int(event.Len):]
+	}
+}
+
+func (w *inotifyWatcher) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirs[int32(wd)] = dir
+	return nil
+}
+
+func (w *inotifyWatcher) dropped() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	removed := w.removed
+	w.removed = nil
+	return removed
+}
+
+func (w *inotifyWatcher) events() <-chan struct{} { return w.c }
+func (w *inotifyWatcher) close() error            { return w.file.Close() }
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+import "time"
+
+func newWatcher(interval time.Duration) (watcher, error) {
+	return newPollWatcher(interval), nil
+}
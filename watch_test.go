@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatchWatchPattern(t *testing.T) {
+	for _, c := range []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"*.go", "cli.go", true},
+		{"*.go", "cmd/cli.go", false},
+		{"**/*.go", "cli.go", true},
+		{"**/*.go", "cmd/app/main.go", true},
+		{"cmd/**", "cmd/app/main.go", true},
+		{"cmd", "cmd/app/main.go", true},
+		{"cmd", "cmdline/main.go", false},
+		{"src/**/test/*.txt", "src/a/b/test/x.txt", true},
+		{"src/**/test/*.txt", "src/a/b/x.txt", false},
+	} {
+		if got := matchWatchPattern(c.pattern, c.name); got != c.match {
+			t.Errorf("matchWatchPattern(%q, %q) = %v, expected %v\n",
+				c.pattern, c.name, got, c.match)
+		}
+	}
+}
+
+type testWatchCommand struct {
+	dir     string
+	changed []string
+}
+
+func (c *testWatchCommand) Help() {}
+
+func (c *testWatchCommand) Command(ctx context.Context, args []string, s System) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	patterns := []string{filepath.Join(c.dir, "**", "*.txt"), "!" + filepath.Join(c.dir, "build")}
+	write := func(cfg *watchConfig) {
+		cfg.ready = func() {
+			for _, name := range []string{"ignored.log", filepath.Join("build", "out.txt"), "notes.txt"} {
+				ioutil.WriteFile(filepath.Join(c.dir, name), []byte("x"), 0600)
+			}
+		}
+	}
+
+	return WatchPaths(ctx, s, patterns, func(changed []string) error {
+		c.changed = changed
+		cancel()
+		return nil
+	}, WatchDebounce(20*time.Millisecond), WatchInterval(50*time.Millisecond), write)
+}
+
+func TestWatchPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "build"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &testWatchCommand{dir: dir}
+	if result, _ := runMain(t, cmd, []string{"testwatch"}); result != 0 {
+		t.Errorf("command did not return a 0 status\n")
+	}
+
+	expected := []string{filepath.Join(dir, "notes.txt")}
+	if !reflect.DeepEqual(cmd.changed, expected) {
+		t.Errorf("expected changes %v, received %v\n", expected, cmd.changed)
+	}
+}
+
+func TestWatchRelativePatterns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	system, _ := NewTestSystem(t, nil, nil)
+	if err := system.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ready := func(cfg *watchConfig) {
+		cfg.ready = func() {
+			ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0600)
+		}
+	}
+
+	var changed []string
+	err = WatchPaths(ctx, system, []string{"*.txt"}, func(c []string) error {
+		changed = c
+		cancel()
+		return nil
+	}, WatchDebounce(20*time.Millisecond), WatchInterval(50*time.Millisecond), ready)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"notes.txt"}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changes %v, received %v\n", expected, changed)
+	}
+}
+
+func TestWatchRecreatedDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	system, _ := NewTestSystem(t, nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	recreate := func(cfg *watchConfig) {
+		cfg.ready = func() {
+			go func() {
+				os.Remove(sub)
+				time.Sleep(200 * time.Millisecond)
+				os.Mkdir(sub, 0700)
+				time.Sleep(200 * time.Millisecond)
+				ioutil.WriteFile(filepath.Join(sub, "notes.txt"), []byte("x"), 0600)
+			}()
+		}
+	}
+
+	var changed []string
+	err = WatchPaths(ctx, system, []string{filepath.Join(dir, "**", "*.txt")}, func(c []string) error {
+		changed = c
+		cancel()
+		return nil
+	}, WatchDebounce(20*time.Millisecond), WatchInterval(50*time.Millisecond), recreate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{filepath.Join(sub, "notes.txt")}; !reflect.DeepEqual(changed, expected) {
+		t.Errorf("expected changes %v, received %v\n", expected, changed)
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// yamlNode is a value decoded from JSON which keeps the order of its keys,
+// so that the fields of a struct are written in the order they're declared
+type yamlNode struct {
+	keys   []string
+	values []*yamlNode
+	object bool
+	array  bool
+	scalar interface{}
+}
+
+// writeYAML writes value as YAML, encoding it as it would be encoded as JSON
+// so that its fields are named by their `json` tags
+func writeYAML(w io.Writer, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	node, err := decodeYAMLNode(d)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, strings.Join(node.lines(), "\n")+"\n")
+	return err
+}
+
+func decodeYAMLNode(d *json.Decoder) (*yamlNode, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case json.Delim('{'):
+		n := &yamlNode{object: true}
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeYAMLNode(d)
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, key.(string))
+			n.values = append(n.values, value)
+		}
+		_, err := d.Token()
+		return n, err
+	case json.Delim('['):
+		n := &yamlNode{array: true}
+		for d.More() {
+			value, err := decodeYAMLNode(d)
+			if err != nil {
+				return nil, err
+			}
+			n.values = append(n.values, value)
+		}
+		_, err := d.Token()
+		return n, err
+	}
+	return &yamlNode{scalar: t}, nil
+}
+
+// collection reports whether n is written as a block of lines
+func (n *yamlNode) collection() bool {
+	return (n.object || n.array) && len(n.values) > 0
+}
+
+// lines returns the lines of n written as YAML
+func (n *yamlNode) lines() []string {
+	var lines []string
+	switch {
+	case n.object && len(n.values) == 0:
+		return []string{"{}"}
+	case n.array && len(n.values) == 0:
+		return []string{"[]"}
+	case n.object:
+		for i, key := range n.keys {
+			value := n.values[i]
+			if !value.collection() {
+				lines = append(lines, yamlString(key)+": "+value.lines()[0])
+				continue
+			}
+			lines = append(lines, yamlString(key)+":")
+			for _, line := range value.lines() {
+				lines = append(lines, "  "+line)
+			}
+		}
+	case n.array:
+		for _, value := range n.values {
+			for i, line := range value.lines() {
+				if i == 0 {
+					lines = append(lines, "- "+line)
+				} else {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+	default:
+		switch v := n.scalar.(type) {
+		case nil:
+			return []string{"null"}
+		case bool:
+			if v {
+				return []string{"true"}
+			}
+			return []string{"false"}
+		case json.Number:
+			return []string{v.String()}
+		case string:
+			return []string{yamlString(v)}
+		}
+	}
+	return lines
+}
+
+// yamlReserved are the plain scalars YAML reads as something other than a
+// string
+var yamlReserved = map[string]bool{
+	"null": true, "~": true, "true": true, "false": true,
+	"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true,
+}
+
+// yamlString returns s as a YAML scalar, quoted unless it reads as the same
+// string when plain
+func yamlString(s string) string {
+	if yamlPlain(s) {
+		return s
+	}
+	var b strings.Builder
+	e := json.NewEncoder(&b)
+	e.SetEscapeHTML(false)
+	e.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// yamlPlain reports whether s may be written without quotes: it isn't empty,
+// reserved or a number, doesn't begin with an indicator and contains no
+// characters which end or comment a plain scalar
+func yamlPlain(s string) bool {
+	if len(s) == 0 || yamlReserved[strings.ToLower(s)] {
+		return false
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` .+") || unicode.IsDigit(rune(s[0])) {
+		return false
+	}
+	if strings.HasSuffix(s, " ") || strings.HasSuffix(s, ":") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}